@@ -300,6 +300,44 @@ func (c *chain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock,
 	return block, nil
 }
 
+// get the latest account block of the address which is confirmed by the snapshot block of snapshotHeight or before
+func (c *chain) GetLatestAccountBlockAtHeight(addr types.Address, snapshotHeight uint64) (*ledger.AccountBlock, error) {
+	latestHeight, err := c.GetLatestAccountHeight(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// the confirmed heights of account blocks are ascending, search the highest one which is not higher than snapshotHeight
+	low, high := uint64(1), latestHeight
+	var result *ledger.AccountBlock
+	for low <= high {
+		mid := low + (high-low)/2
+
+		block, err := c.GetAccountBlockByHeight(addr, mid)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errors.New(fmt.Sprintf("block is nil, addr is %s, height is %d", addr, mid))
+		}
+
+		confirmedHeight, err := c.indexDB.GetConfirmHeightByHash(&block.Hash)
+		if err != nil {
+			cErr := errors.New(fmt.Sprintf("c.indexDB.GetConfirmHeightByHash failed, hash is %s. Error: %s", block.Hash, err))
+			c.log.Error(cErr.Error(), "method", "GetLatestAccountBlockAtHeight")
+			return nil, cErr
+		}
+
+		if confirmedHeight > 0 && confirmedHeight <= snapshotHeight {
+			result = block
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	return result, nil
+}
+
 func (c *chain) GetLatestAccountHeight(addr types.Address) (uint64, error) {
 	if block := c.cache.GetLatestAccountBlock(addr); block != nil {
 		return block.Height, nil
@@ -65,19 +65,9 @@ func (bDB *BlockDB) Close() error {
 
 func (bDB *BlockDB) Write(ss *ledger.SnapshotChunk) ([]*chain_file_manager.Location, *chain_file_manager.Location, error) {
 
-	accountBlocksLocation := make([]*chain_file_manager.Location, 0, len(ss.AccountBlocks))
-
-	for _, accountBlock := range ss.AccountBlocks {
-		buf, err := accountBlock.Serialize()
-		if err != nil {
-			return nil, nil, errors.New(fmt.Sprintf("ss.AccountBlocks.Serialize failed, error is %s, accountBlock is %+v", err.Error(), accountBlock))
-		}
-
-		if location, err := bDB.fm.Write(makeWriteBytes(bDB.snappyWriteBuffer, BlockTypeAccountBlock, buf)); err != nil {
-			return nil, nil, errors.New(fmt.Sprintf("bDB.fm.Write failed, error is %s, accountBlock is %+v", err.Error(), accountBlock))
-		} else {
-			accountBlocksLocation = append(accountBlocksLocation, location)
-		}
+	accountBlocksLocation, err := bDB.WriteAccountBlocks(ss.AccountBlocks)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	buf, err := ss.SnapshotBlock.Serialize()
@@ -95,6 +85,25 @@ func (bDB *BlockDB) Write(ss *ledger.SnapshotChunk) ([]*chain_file_manager.Locat
 	return accountBlocksLocation, snapshotBlockLocation, nil
 }
 
+// WriteAccountBlocks writes account blocks without snapshot block, the blocks belong to the snapshot block written next.
+func (bDB *BlockDB) WriteAccountBlocks(accountBlocks []*ledger.AccountBlock) ([]*chain_file_manager.Location, error) {
+	accountBlocksLocation := make([]*chain_file_manager.Location, 0, len(accountBlocks))
+
+	for _, accountBlock := range accountBlocks {
+		buf, err := accountBlock.Serialize()
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("ss.AccountBlocks.Serialize failed, error is %s, accountBlock is %+v", err.Error(), accountBlock))
+		}
+
+		if location, err := bDB.fm.Write(makeWriteBytes(bDB.snappyWriteBuffer, BlockTypeAccountBlock, buf)); err != nil {
+			return nil, errors.New(fmt.Sprintf("bDB.fm.Write failed, error is %s, accountBlock is %+v", err.Error(), accountBlock))
+		} else {
+			accountBlocksLocation = append(accountBlocksLocation, location)
+		}
+	}
+	return accountBlocksLocation, nil
+}
+
 func (bDB *BlockDB) Read(location *chain_file_manager.Location) ([]byte, error) {
 	buf, _, err := bDB.fm.Read(location)
 	if err != nil {
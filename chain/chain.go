@@ -81,6 +81,9 @@ type chain struct {
 
 	forkActiveCheckPoint fork.ForkPointItem
 	forkActiveCache      fork.ForkPointList
+
+	stateManifest   *interfaces.StateManifest
+	stateManifestMu sync.Mutex
 }
 
 /*
@@ -162,6 +165,13 @@ func (c *chain) Init() error {
 		return err
 	}
 
+	// align the plugins which are written outside the flusher
+	if c.chainCfg.OpenPlugins {
+		if err := c.plugins.Reconcile(); err != nil {
+			return err
+		}
+	}
+
 	// init fork active
 	if err := c.initActiveFork(); err != nil {
 		return err
@@ -307,7 +317,7 @@ func (c *chain) newDbAndRecover() error {
 	// init plugins
 	if c.chainCfg.OpenPlugins {
 		var err error
-		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c); err != nil {
+		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c, c.chainCfg, c.genesisCfg); err != nil {
 			cErr := errors.New(fmt.Sprintf("chain_plugins.NewPlugins failed. Error: %s", err))
 			c.log.Error(cErr.Error(), "method", "newDbAndRecover")
 			return cErr
@@ -520,7 +530,7 @@ func (c *chain) ResetLog(dir string, lvl string) {
 	path := filepath.Join(dir, "chain_logs", time.Now().Format("2006-01-02T15-04"))
 	filename := filepath.Join(path, "chain.log")
 
-	h := log15.LvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.LogfmtFormat()))
+	h := log15.ModuleLvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.FileFormat()))
 
 	c.log.SetHandler(
 		h,
@@ -0,0 +1,97 @@
+package chain_index
+
+import (
+	"github.com/vitelabs/go-vite/chain/file_manager"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// IterateAccountsFrom iterates the addresses of all accounts in the order of address, from the address start on.
+func (iDB *IndexDB) IterateAccountsFrom(start *types.Address, iterateFunc func(addr types.Address) bool) error {
+	iter := iDB.store.NewIterator(util.BytesPrefix([]byte{chain_utils.AccountAddressKeyPrefix}))
+	defer iter.Release()
+
+	var ok bool
+	if start != nil {
+		ok = iter.Seek(chain_utils.CreateAccountAddressKey(start))
+	} else {
+		ok = iter.Next()
+	}
+
+	for ; ok; ok = iter.Next() {
+		addr, err := types.BytesToAddress(iter.Key()[1:])
+		if err != nil {
+			return err
+		}
+		if !iterateFunc(addr) {
+			break
+		}
+	}
+	return iter.Error()
+}
+
+// GetConfirmedAccountHeight returns the height of the latest account block of addr confirmed
+// at or before the snapshot height, 0 means none.
+func (iDB *IndexDB) GetConfirmedAccountHeight(addr *types.Address, snapshotHeight uint64) (uint64, error) {
+	startKey := chain_utils.CreateConfirmHeightKey(addr, 1)
+	endKey := chain_utils.CreateConfirmHeightKey(addr, helper.MaxUint64)
+
+	iter := iDB.store.NewIterator(&util.Range{Start: startKey, Limit: endKey})
+	defer iter.Release()
+
+	height := uint64(0)
+	for iter.Next() {
+		if chain_utils.BytesToUint64(iter.Value()) > snapshotHeight {
+			break
+		}
+		key := iter.Key()
+		height = chain_utils.BytesToUint64(key[len(key)-8:])
+	}
+
+	return height, iter.Error()
+}
+
+// InsertStateAccountBlocks writes the indexes of account blocks restored from a state snapshot,
+// the blocks are confirmed by the snapshot heights of confirmHeights. Unlike InsertAccountBlock,
+// no on road or receive info is written, because the blocks around them are missing.
+func (iDB *IndexDB) InsertStateAccountBlocks(blocks []*ledger.AccountBlock, confirmHeights []uint64, locations []*chain_file_manager.Location) error {
+	batch := iDB.store.NewBatch()
+
+	created := make(map[types.Address]struct{})
+	for index, block := range blocks {
+		if _, ok := created[block.AccountAddress]; !ok {
+			if ok, err := iDB.HasAccount(block.AccountAddress); err != nil {
+				return err
+			} else if !ok {
+				iDB.createAccount(batch, &block.AccountAddress)
+			}
+			created[block.AccountAddress] = struct{}{}
+		}
+
+		addrHeightValue := append(block.AccountAddress.Bytes(), chain_utils.Uint64ToBytes(block.Height)...)
+		iDB.insertAbHashHeight(batch, block, addrHeightValue)
+		for _, sendBlock := range block.SendBlockList {
+			iDB.insertAbHashHeight(batch, sendBlock, addrHeightValue)
+		}
+
+		iDB.insertAbHeightLocation(batch, block, locations[index])
+
+		batch.Put(chain_utils.CreateConfirmHeightKey(&block.AccountAddress, block.Height), chain_utils.Uint64ToBytes(confirmHeights[index]))
+	}
+
+	iDB.store.WriteDirectly(batch)
+	return nil
+}
+
+// InsertStateSnapshotBlock writes the indexes of the snapshot block of a state snapshot, the blocks before it are missing.
+func (iDB *IndexDB) InsertStateSnapshotBlock(snapshotBlock *ledger.SnapshotBlock, location *chain_file_manager.Location) {
+	batch := iDB.store.NewBatch()
+
+	iDB.insertSbHashHeight(batch, snapshotBlock.Hash, snapshotBlock.Height)
+	iDB.insertSbHeightLocation(batch, snapshotBlock, location)
+
+	iDB.store.WriteDirectly(batch)
+}
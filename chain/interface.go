@@ -127,6 +127,9 @@ type Chain interface {
 
 	GetLatestAccountHeight(addr types.Address) (uint64, error)
 
+	// get the latest account block which is confirmed at the snapshot height
+	GetLatestAccountBlockAtHeight(addr types.Address, snapshotHeight uint64) (*ledger.AccountBlock, error)
+
 	// ====== Query snapshot block ======
 	IsGenesisSnapshotBlock(hash types.Hash) bool
 
@@ -218,6 +221,12 @@ type Chain interface {
 	// get confirmed snapshot Balance, if history is too old, failed
 	GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error)
 
+	// check the state history at the snapshot height is retained
+	CheckHistoryHeight(snapshotHeight uint64) error
+
+	// get Balance at the snapshot height
+	GetBalanceAtHeight(addr types.Address, tokenId types.TokenTypeId, snapshotHeight uint64) (*big.Int, error)
+
 	// get contract code
 	GetContractCode(contractAddr types.Address) ([]byte, error)
 
@@ -237,6 +246,10 @@ type Chain interface {
 
 	GetValue(address types.Address, key []byte) ([]byte, error)
 
+	GetStorageIteratorAtHeight(address types.Address, prefix []byte, snapshotHeight uint64) (interfaces.StorageIterator, error)
+
+	GetValueAtHeight(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error)
+
 	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
 
 	// ====== Query built-in contract storage ======
@@ -276,6 +289,15 @@ type Chain interface {
 
 	GetSyncCache() interfaces.SyncCache
 
+	// ====== Sync state ======
+	GetStateManifest(height uint64) (*interfaces.StateManifest, error)
+
+	GetStateChunk(height uint64, index int) ([]interfaces.StateEntry, error)
+
+	InsertStateChunk(manifest *interfaces.StateManifest, index int, entries []interfaces.StateEntry) error
+
+	InsertStateSnapshotBlock(manifest *interfaces.StateManifest, history []*ledger.SnapshotBlock) error
+
 	// ====== OnRoad ======
 	LoadOnRoad(gid types.Gid) (map[types.Address]map[types.Address][]ledger.HashHeight, error)
 
@@ -6,6 +6,16 @@ const (
 	OnRoadInfoKeyPrefix = byte(1)
 
 	DiffTokenHash = byte(2)
+
+	DexTradeKeyPrefix = byte(3)
+
+	DexKlineKeyPrefix = byte(4)
+
+	DexDepthKeyPrefix = byte(5)
+
+	DexOrderKeyPrefix = byte(6)
+
+	DexUndoKeyPrefix = byte(7)
 )
 
 func CreateOnRoadInfoKey(addr *types.Address, tId *types.TokenTypeId) []byte {
@@ -0,0 +1,690 @@
+package chain_plugins
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/go-errors/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/vm/contracts/dex/proto"
+)
+
+const (
+	// DefaultDexQueryLimit is used when a query of the dex market doesn't give a limit
+	DefaultDexQueryLimit = 100
+	// MaxDexQueryLimit is the max count of records returned by a query of the dex market
+	MaxDexQueryLimit = 1000
+
+	// the undo records of the latest dexUndoRetainHeight snapshot blocks are kept, the same as the redo logs
+	// of the state, the ledger cannot be rolled back deeper without them
+	dexUndoRetainHeight = 1200
+)
+
+var (
+	dLog = log15.New("plugin", "dex_market")
+
+	// DexKlineIntervals are the candle periods kept for every market.
+	// The index of an interval is part of the storage key, so new intervals must be appended.
+	DexKlineIntervals = []DexKlineInterval{
+		{"1m", 60},
+		{"5m", 5 * 60},
+		{"15m", 15 * 60},
+		{"30m", 30 * 60},
+		{"1h", 3600},
+		{"4h", 4 * 3600},
+		{"1d", 24 * 3600},
+	}
+
+	UnknownDexKlineIntervalErr = errors.New("unknown kline interval")
+
+	dexNewOrderTopic    = dex.NewOrderEvent{}.GetTopicId()
+	dexOrderUpdateTopic = dex.OrderUpdateEvent{}.GetTopicId()
+	dexTxTopic          = dex.TransactionEvent{}.GetTopicId()
+)
+
+type DexKlineInterval struct {
+	Name    string
+	Seconds int64
+}
+
+type DexTrade struct {
+	TradeId      string     `json:"tradeId"`
+	MarketId     int32      `json:"marketId"`
+	Price        string     `json:"price"`
+	Quantity     string     `json:"quantity"`
+	Amount       string     `json:"amount"`
+	TakerSide    bool       `json:"takerSide"`
+	TakerOrderId string     `json:"takerOrderId"`
+	MakerOrderId string     `json:"makerOrderId"`
+	Timestamp    int64      `json:"timestamp"`
+	BlockHash    types.Hash `json:"blockHash"`
+}
+
+type DexKline struct {
+	Timestamp  int64  `json:"timestamp"`
+	Open       string `json:"open"`
+	High       string `json:"high"`
+	Low        string `json:"low"`
+	Close      string `json:"close"`
+	Volume     string `json:"volume"`
+	Amount     string `json:"amount"`
+	TradeCount uint64 `json:"tradeCount"`
+}
+
+type DexDepthLevel struct {
+	Price    string `json:"price"`
+	Quantity string `json:"quantity"`
+}
+
+type DexDepth struct {
+	Asks []*DexDepthLevel `json:"asks"`
+	Bids []*DexDepthLevel `json:"bids"`
+}
+
+// dexOrderState is what the depth needs to know about a resting order
+type dexOrderState struct {
+	Quantity         []byte
+	ExecutedQuantity []byte
+}
+
+// dexUndoEntry is the value of a key before a block of the dex trade contract was indexed, Value is nil
+// if the key didn't exist.
+type dexUndoEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// DexMarket indexes the trades, the klines and the order book depth of the dex markets from the vm logs
+// of the confirmed blocks of the dex trade contract.
+//
+// Every indexed block keeps an undo record with the previous values of the keys it changed, so a snapshot
+// rollback restores the data without replaying the markets. The records older than dexUndoRetainHeight
+// snapshot blocks are pruned.
+type DexMarket struct {
+	chain Chain
+	store *chain_db.Store
+	mu    sync.RWMutex
+}
+
+func newDexMarket(store *chain_db.Store, chain Chain) Plugin {
+	return &DexMarket{
+		chain: chain,
+		store: store,
+	}
+}
+
+func (dm *DexMarket) SetStore(store *chain_db.Store) {
+	dm.store = store
+}
+
+func (dm *DexMarket) InsertAccountBlock(*leveldb.Batch, *ledger.AccountBlock) error {
+	return nil
+}
+
+func (dm *DexMarket) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	if err := dm.pruneUndo(batch, snapshotBlock.Height); err != nil {
+		return errors.New(fmt.Sprintf("prune dex undo records failed, snapshot block %d. Error: %s", snapshotBlock.Height, err))
+	}
+
+	blocks := filterDexTradeBlocks(confirmedBlocks)
+	if len(blocks) <= 0 {
+		return nil
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height < blocks[j].Height
+	})
+
+	w := newDexMarketWriter(dm.store, batch)
+	for _, block := range blocks {
+		if err := dm.insertBlock(w, snapshotBlock.Height, block); err != nil {
+			return errors.New(fmt.Sprintf("index dex block %s failed, snapshot block %d. Error: %s", block.Hash, snapshotBlock.Height, err))
+		}
+	}
+	return nil
+}
+
+func (dm *DexMarket) DeleteAccountBlocks(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (dm *DexMarket) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	blocks := make([]*ledger.AccountBlock, 0)
+	snapshotHeights := make(map[types.Hash]uint64)
+	for _, chunk := range chunks {
+		// only the confirmed blocks are indexed
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		for _, block := range filterDexTradeBlocks(chunk.AccountBlocks) {
+			blocks = append(blocks, block)
+			snapshotHeights[block.Hash] = chunk.SnapshotBlock.Height
+		}
+	}
+	if len(blocks) <= 0 {
+		return nil
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height > blocks[j].Height
+	})
+
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+
+	for _, block := range blocks {
+		key := createDexUndoKey(snapshotHeights[block.Hash], block.Hash)
+		value, err := dm.store.Get(key)
+		if err != nil {
+			return err
+		}
+		if len(value) <= 0 {
+			continue
+		}
+		var undo []*dexUndoEntry
+		if err := json.Unmarshal(value, &undo); err != nil {
+			return errors.New(fmt.Sprintf("decode undo record of dex block %s failed. Error: %s", block.Hash, err))
+		}
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undo[i].Value == nil {
+				batch.Delete(undo[i].Key)
+			} else {
+				batch.Put(undo[i].Key, undo[i].Value)
+			}
+		}
+		batch.Delete(key)
+	}
+	return nil
+}
+
+// pruneUndo deletes the undo records except the ones of the latest dexUndoRetainHeight snapshot blocks
+func (dm *DexMarket) pruneUndo(batch *leveldb.Batch, snapshotHeight uint64) error {
+	if snapshotHeight <= dexUndoRetainHeight {
+		return nil
+	}
+	iter := dm.store.NewIterator(&util.Range{
+		Start: []byte{DexUndoKeyPrefix},
+		Limit: createDexUndoPrefixKey(snapshotHeight - dexUndoRetainHeight + 1),
+	})
+	defer iter.Release()
+
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	return iter.Error()
+}
+
+func (dm *DexMarket) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetTrades returns the latest trades of the market in [startTime, endTime), the newest first.
+func (dm *DexMarket) GetTrades(marketId int32, startTime, endTime int64, limit int) ([]*DexTrade, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	limit = normalizeDexQueryLimit(limit)
+	iter := dm.store.NewIterator(&util.Range{
+		Start: createDexTradePrefixKey(marketId, startTime),
+		Limit: createDexTradePrefixKey(marketId, endTime),
+	})
+	defer iter.Release()
+
+	trades := make([]*DexTrade, 0)
+	for ok := iter.Last(); ok && len(trades) < limit; ok = iter.Prev() {
+		trade := &DexTrade{}
+		if err := json.Unmarshal(iter.Value(), trade); err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// GetKlines returns the latest candles of the market in [startTime, endTime), in ascending order of time.
+func (dm *DexMarket) GetKlines(marketId int32, interval string, startTime, endTime int64, limit int) ([]*DexKline, error) {
+	index, ok := dexKlineIntervalIndex(interval)
+	if !ok {
+		return nil, UnknownDexKlineIntervalErr
+	}
+
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	limit = normalizeDexQueryLimit(limit)
+	seconds := DexKlineIntervals[index].Seconds
+	iter := dm.store.NewIterator(&util.Range{
+		Start: createDexKlineKey(marketId, index, startTime-startTime%seconds),
+		Limit: createDexKlineKey(marketId, index, endTime),
+	})
+	defer iter.Release()
+
+	klines := make([]*DexKline, 0)
+	for ok := iter.Last(); ok && len(klines) < limit; ok = iter.Prev() {
+		kline := &DexKline{}
+		if err := json.Unmarshal(iter.Value(), kline); err != nil {
+			return nil, err
+		}
+		klines = append(klines, kline)
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(klines)-1; i < j; i, j = i+1, j-1 {
+		klines[i], klines[j] = klines[j], klines[i]
+	}
+	return klines, nil
+}
+
+// GetDepth returns the best price levels of both sides of the market, asks are sorted by price
+// ascending and bids by price descending.
+func (dm *DexMarket) GetDepth(marketId int32, limit int) (*DexDepth, error) {
+	dm.mu.RLock()
+	defer dm.mu.RUnlock()
+
+	limit = normalizeDexQueryLimit(limit)
+	asks, err := dm.getDepthLevels(marketId, true, limit)
+	if err != nil {
+		return nil, err
+	}
+	bids, err := dm.getDepthLevels(marketId, false, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &DexDepth{Asks: asks, Bids: bids}, nil
+}
+
+func (dm *DexMarket) getDepthLevels(marketId int32, side bool, limit int) ([]*DexDepthLevel, error) {
+	iter := dm.store.NewIterator(util.BytesPrefix(createDexDepthPrefixKey(marketId, side)))
+	defer iter.Release()
+
+	// the best ask is the lowest price, the best bid is the highest one
+	next, ok := iter.Next, iter.Next()
+	if !side {
+		next, ok = iter.Prev, iter.Last()
+	}
+	levels := make([]*DexDepthLevel, 0)
+	for ; ok && len(levels) < limit; ok = next() {
+		key := iter.Key()
+		levels = append(levels, &DexDepthLevel{
+			Price:    dex.BytesToPrice(key[len(key)-dex.PriceBytesLength:]),
+			Quantity: new(big.Int).SetBytes(iter.Value()).String(),
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+func (dm *DexMarket) insertBlock(w *dexMarketWriter, snapshotHeight uint64, block *ledger.AccountBlock) error {
+	logList, err := dm.chain.GetVmLogList(block.LogHash)
+	if err != nil {
+		return err
+	}
+	for index, vmLog := range logList {
+		if len(vmLog.Topics) <= 0 {
+			continue
+		}
+		switch vmLog.Topics[0] {
+		case dexNewOrderTopic:
+			event, ok := dex.NewOrderEvent{}.FromBytes(vmLog.Data).(dex.NewOrderEvent)
+			if !ok || event.Order == nil {
+				dLog.Error(fmt.Sprintf("decode new order event failed, block %s, log %d", block.Hash, index), "method", "insertBlock")
+				continue
+			}
+			err = dm.insertOrder(w, event.Order)
+		case dexOrderUpdateTopic:
+			event, ok := dex.OrderUpdateEvent{}.FromBytes(vmLog.Data).(dex.OrderUpdateEvent)
+			if !ok {
+				dLog.Error(fmt.Sprintf("decode order update event failed, block %s, log %d", block.Hash, index), "method", "insertBlock")
+				continue
+			}
+			err = dm.updateOrder(w, &event.OrderUpdateInfo)
+		case dexTxTopic:
+			event, ok := dex.TransactionEvent{}.FromBytes(vmLog.Data).(dex.TransactionEvent)
+			if !ok {
+				dLog.Error(fmt.Sprintf("decode tx event failed, block %s, log %d", block.Hash, index), "method", "insertBlock")
+				continue
+			}
+			err = dm.insertTrade(w, block, uint32(index), &event.Transaction)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.finishBlock(snapshotHeight, block.Hash)
+}
+
+// insertOrder puts the remaining quantity of a new order on the book if the order rests after matching.
+func (dm *DexMarket) insertOrder(w *dexMarketWriter, order *dexproto.Order) error {
+	if !isDexRestingStatus(order.Status) {
+		return nil
+	}
+	marketId, side, price, _, err := dex.DeComposeOrderId(order.Id)
+	if err != nil {
+		return err
+	}
+	remaining := new(big.Int).Sub(new(big.Int).SetBytes(order.Quantity), new(big.Int).SetBytes(order.ExecutedQuantity))
+	if remaining.Sign() <= 0 {
+		return nil
+	}
+	if err := w.putJSON(createDexOrderKey(order.Id), &dexOrderState{
+		Quantity:         order.Quantity,
+		ExecutedQuantity: order.ExecutedQuantity,
+	}); err != nil {
+		return err
+	}
+	return dm.addDepth(w, marketId, side, price, remaining)
+}
+
+// updateOrder moves the depth of a resting order by the change of its remaining quantity.
+func (dm *DexMarket) updateOrder(w *dexMarketWriter, info *dexproto.OrderUpdateInfo) error {
+	key := createDexOrderKey(info.Id)
+	value, err := w.get(key)
+	if err != nil {
+		return err
+	}
+	if len(value) <= 0 {
+		// the order has never rested on the book
+		return nil
+	}
+	state := &dexOrderState{}
+	if err := json.Unmarshal(value, state); err != nil {
+		return err
+	}
+	marketId, side, price, _, err := dex.DeComposeOrderId(info.Id)
+	if err != nil {
+		return err
+	}
+
+	quantity := new(big.Int).SetBytes(state.Quantity)
+	delta := new(big.Int).Sub(quantity, new(big.Int).SetBytes(state.ExecutedQuantity))
+	delta.Neg(delta)
+	if isDexRestingStatus(info.Status) {
+		delta.Add(delta, new(big.Int).Sub(quantity, new(big.Int).SetBytes(info.ExecutedQuantity)))
+		state.ExecutedQuantity = info.ExecutedQuantity
+		err = w.putJSON(key, state)
+	} else {
+		err = w.delete(key)
+	}
+	if err != nil {
+		return err
+	}
+	return dm.addDepth(w, marketId, side, price, delta)
+}
+
+func (dm *DexMarket) addDepth(w *dexMarketWriter, marketId int32, side bool, price []byte, delta *big.Int) error {
+	if delta.Sign() == 0 {
+		return nil
+	}
+	key := createDexDepthKey(marketId, side, price)
+	value, err := w.get(key)
+	if err != nil {
+		return err
+	}
+	quantity := new(big.Int).Add(new(big.Int).SetBytes(value), delta)
+	if quantity.Sign() <= 0 {
+		return w.delete(key)
+	}
+	return w.put(key, quantity.Bytes())
+}
+
+func (dm *DexMarket) insertTrade(w *dexMarketWriter, block *ledger.AccountBlock, index uint32, tx *dexproto.Transaction) error {
+	marketId, _, _, _, err := dex.DeComposeOrderId(tx.TakerId)
+	if err != nil {
+		return err
+	}
+	quantity := new(big.Int).SetBytes(tx.Quantity)
+	amount := new(big.Int).SetBytes(tx.Amount)
+	price := dex.BytesToPrice(tx.Price)
+
+	trade := &DexTrade{
+		TradeId:      hex.EncodeToString(tx.Id),
+		MarketId:     marketId,
+		Price:        price,
+		Quantity:     quantity.String(),
+		Amount:       amount.String(),
+		TakerSide:    tx.TakerSide,
+		TakerOrderId: hex.EncodeToString(tx.TakerId),
+		MakerOrderId: hex.EncodeToString(tx.MakerId),
+		Timestamp:    tx.Timestamp,
+		BlockHash:    block.Hash,
+	}
+	if err := w.putJSON(createDexTradeKey(marketId, tx.Timestamp, block.Height, index), trade); err != nil {
+		return err
+	}
+
+	for i, interval := range DexKlineIntervals {
+		if err := dm.updateKline(w, marketId, i, tx.Timestamp-tx.Timestamp%interval.Seconds, tx.Price, quantity, amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dm *DexMarket) updateKline(w *dexMarketWriter, marketId int32, interval int, start int64, priceBytes []byte, quantity, amount *big.Int) error {
+	key := createDexKlineKey(marketId, interval, start)
+	value, err := w.get(key)
+	if err != nil {
+		return err
+	}
+	price := dex.BytesToPrice(priceBytes)
+	kline := &DexKline{
+		Timestamp: start,
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Volume:    "0",
+		Amount:    "0",
+	}
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, kline); err != nil {
+			return err
+		}
+	}
+	if bytes.Compare(priceBytes, dex.PriceToBytes(kline.High)) > 0 {
+		kline.High = price
+	}
+	if bytes.Compare(priceBytes, dex.PriceToBytes(kline.Low)) < 0 {
+		kline.Low = price
+	}
+	kline.Close = price
+	volume, _ := new(big.Int).SetString(kline.Volume, 10)
+	kline.Volume = volume.Add(volume, quantity).String()
+	total, _ := new(big.Int).SetString(kline.Amount, 10)
+	kline.Amount = total.Add(total, amount).String()
+	kline.TradeCount++
+	return w.putJSON(key, kline)
+}
+
+// dexMarketWriter writes the changes of the blocks confirmed by a snapshot block into one batch,
+// the changes are visible to the following blocks before the batch is written to the store.
+type dexMarketWriter struct {
+	store *chain_db.Store
+	batch *leveldb.Batch
+
+	cache map[string][]byte
+
+	undo    []*dexUndoEntry
+	touched map[string]struct{}
+}
+
+func newDexMarketWriter(store *chain_db.Store, batch *leveldb.Batch) *dexMarketWriter {
+	return &dexMarketWriter{
+		store:   store,
+		batch:   batch,
+		cache:   make(map[string][]byte),
+		touched: make(map[string]struct{}),
+	}
+}
+
+func (w *dexMarketWriter) get(key []byte) ([]byte, error) {
+	if value, ok := w.cache[string(key)]; ok {
+		return value, nil
+	}
+	return w.store.Get(key)
+}
+
+func (w *dexMarketWriter) put(key, value []byte) error {
+	return w.set(key, value)
+}
+
+func (w *dexMarketWriter) putJSON(key []byte, v interface{}) error {
+	value, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return w.set(key, value)
+}
+
+func (w *dexMarketWriter) delete(key []byte) error {
+	return w.set(key, nil)
+}
+
+func (w *dexMarketWriter) set(key, value []byte) error {
+	if _, ok := w.touched[string(key)]; !ok {
+		prev, err := w.get(key)
+		if err != nil {
+			return err
+		}
+		w.touched[string(key)] = struct{}{}
+		w.undo = append(w.undo, &dexUndoEntry{Key: key, Value: prev})
+	}
+
+	w.cache[string(key)] = value
+	if value == nil {
+		w.batch.Delete(key)
+	} else {
+		w.batch.Put(key, value)
+	}
+	return nil
+}
+
+func (w *dexMarketWriter) finishBlock(snapshotHeight uint64, blockHash types.Hash) error {
+	if len(w.undo) > 0 {
+		value, err := json.Marshal(w.undo)
+		if err != nil {
+			return err
+		}
+		w.batch.Put(createDexUndoKey(snapshotHeight, blockHash), value)
+	}
+	w.undo = nil
+	w.touched = make(map[string]struct{})
+	return nil
+}
+
+func filterDexTradeBlocks(blocks []*ledger.AccountBlock) []*ledger.AccountBlock {
+	result := make([]*ledger.AccountBlock, 0)
+	for _, block := range blocks {
+		if block.AccountAddress == types.AddressDexTrade && block.LogHash != nil {
+			result = append(result, block)
+		}
+	}
+	return result
+}
+
+func isDexRestingStatus(status int32) bool {
+	return status == dex.Pending || status == dex.PartialExecuted
+}
+
+func dexKlineIntervalIndex(name string) (int, bool) {
+	for i, interval := range DexKlineIntervals {
+		if interval.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func normalizeDexQueryLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultDexQueryLimit
+	}
+	if limit > MaxDexQueryLimit {
+		return MaxDexQueryLimit
+	}
+	return limit
+}
+
+func dexMarketIdToBytes(marketId int32) []byte {
+	bs := make([]byte, 4)
+	binary.BigEndian.PutUint32(bs, uint32(marketId))
+	return bs
+}
+
+func createDexTradePrefixKey(marketId int32, timestamp int64) []byte {
+	key := make([]byte, 0, 1+4+8)
+	key = append(key, DexTradeKeyPrefix)
+	key = append(key, dexMarketIdToBytes(marketId)...)
+	key = append(key, chain_utils.Uint64ToBytes(uint64(timestamp))...)
+	return key
+}
+
+func createDexTradeKey(marketId int32, timestamp int64, height uint64, index uint32) []byte {
+	key := make([]byte, 0, 1+4+8+8+4)
+	key = append(key, createDexTradePrefixKey(marketId, timestamp)...)
+	key = append(key, chain_utils.Uint64ToBytes(height)...)
+	key = append(key, dexMarketIdToBytes(int32(index))...)
+	return key
+}
+
+func createDexKlineKey(marketId int32, interval int, start int64) []byte {
+	key := make([]byte, 0, 1+4+1+8)
+	key = append(key, DexKlineKeyPrefix)
+	key = append(key, dexMarketIdToBytes(marketId)...)
+	key = append(key, byte(interval))
+	key = append(key, chain_utils.Uint64ToBytes(uint64(start))...)
+	return key
+}
+
+func createDexDepthPrefixKey(marketId int32, side bool) []byte {
+	key := make([]byte, 0, 1+4+1+dex.PriceBytesLength)
+	key = append(key, DexDepthKeyPrefix)
+	key = append(key, dexMarketIdToBytes(marketId)...)
+	if side {
+		key = append(key, 1)
+	} else {
+		key = append(key, 0)
+	}
+	return key
+}
+
+func createDexDepthKey(marketId int32, side bool, price []byte) []byte {
+	return append(createDexDepthPrefixKey(marketId, side), price...)
+}
+
+func createDexOrderKey(orderId []byte) []byte {
+	key := make([]byte, 0, 1+len(orderId))
+	key = append(key, DexOrderKeyPrefix)
+	key = append(key, orderId...)
+	return key
+}
+
+func createDexUndoPrefixKey(snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+8+types.HashSize)
+	key = append(key, DexUndoKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func createDexUndoKey(snapshotHeight uint64, blockHash types.Hash) []byte {
+	return append(createDexUndoPrefixKey(snapshotHeight), blockHash.Bytes()...)
+}
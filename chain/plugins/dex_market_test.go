@@ -0,0 +1,290 @@
+package chain_plugins
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/flusher"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/vm/contracts/dex/proto"
+)
+
+type dexTestChain struct {
+	logs map[types.Hash]ledger.VmLogList
+}
+
+func (c *dexTestChain) Flusher() *chain_flusher.Flusher               { return nil }
+func (c *dexTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock { return nil }
+func (c *dexTestChain) GetSnapshotBlocksByHeight(uint64, bool, uint64) ([]*ledger.SnapshotBlock, error) {
+	return nil, nil
+}
+func (c *dexTestChain) GetSubLedgerAfterHeight(uint64) ([]*ledger.SnapshotChunk, error) {
+	return nil, nil
+}
+func (c *dexTestChain) GetSubLedger(uint64, uint64) ([]*ledger.SnapshotChunk, error) {
+	return nil, nil
+}
+func (c *dexTestChain) GetAccountBlockByHash(types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+func (c *dexTestChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return c.logs[*logListHash], nil
+}
+func (c *dexTestChain) IsAccountBlockExisted(types.Hash) (bool, error)  { return false, nil }
+func (c *dexTestChain) IsGenesisAccountBlock(types.Hash) bool           { return false }
+func (c *dexTestChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock { return nil }
+func (c *dexTestChain) LoadAllOnRoad() (map[types.Address][]types.Hash, error) {
+	return nil, nil
+}
+
+// dexTestOrderId composes an order id the way dex.ComposeOrderId does
+func dexTestOrderId(marketId int32, side bool, price string, timestamp int64, serialNo uint32) []byte {
+	id := make([]byte, dex.OrderIdBytesLength)
+	bs := make([]byte, 8)
+	binary.BigEndian.PutUint32(bs, uint32(marketId))
+	copy(id[:3], bs[1:4])
+	priceBytes := dex.PriceToBytes(price)
+	if side {
+		id[3] = 1
+	} else {
+		dex.BitwiseNotBytes(priceBytes)
+	}
+	copy(id[4:14], priceBytes)
+	binary.BigEndian.PutUint64(bs, uint64(timestamp))
+	copy(id[14:19], bs[3:])
+	binary.BigEndian.PutUint32(bs, serialNo)
+	copy(id[19:], bs[1:4])
+	return id
+}
+
+func dexTestLog(t *testing.T, topic types.Hash, msg proto.Message) *ledger.VmLog {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ledger.VmLog{Topics: []types.Hash{topic}, Data: data}
+}
+
+type dexMarketTester struct {
+	t      *testing.T
+	chain  *dexTestChain
+	store  *chain_db.Store
+	dm     *DexMarket
+	height uint64
+}
+
+func (tester *dexMarketTester) newBlock(logs ...*ledger.VmLog) *ledger.AccountBlock {
+	tester.height++
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: types.AddressDexTrade,
+		Height:         tester.height,
+	}
+	binary.BigEndian.PutUint64(block.Hash[:8], tester.height)
+	logHash := block.Hash
+	logHash[31] = 1
+	block.LogHash = &logHash
+	tester.chain.logs[logHash] = logs
+	return block
+}
+
+func (tester *dexMarketTester) snapshot(height uint64, blocks ...*ledger.AccountBlock) *ledger.SnapshotChunk {
+	for _, block := range blocks {
+		tester.store.WriteAccountBlock(tester.store.NewBatch(), block)
+	}
+	sb := &ledger.SnapshotBlock{Height: height}
+	batch := tester.store.NewBatch()
+	if err := tester.dm.InsertSnapshotBlock(batch, sb, blocks); err != nil {
+		tester.t.Fatal(err)
+	}
+	tester.store.WriteSnapshot(batch, blocks)
+	return &ledger.SnapshotChunk{SnapshotBlock: sb, AccountBlocks: blocks}
+}
+
+func (tester *dexMarketTester) rollback(chunks ...*ledger.SnapshotChunk) {
+	batch := tester.store.NewBatch()
+	if err := tester.dm.DeleteSnapshotBlocks(batch, chunks); err != nil {
+		tester.t.Fatal(err)
+	}
+	tester.store.RollbackSnapshot(batch)
+}
+
+func (tester *dexMarketTester) checkDepth(asks, bids []DexDepthLevel) {
+	depth, err := tester.dm.GetDepth(1, 0)
+	if err != nil {
+		tester.t.Fatal(err)
+	}
+	check := func(name string, levels []*DexDepthLevel, expected []DexDepthLevel) {
+		if len(levels) != len(expected) {
+			tester.t.Fatalf("expected %d %s, got %d", len(expected), name, len(levels))
+		}
+		for i, level := range levels {
+			if *level != expected[i] {
+				tester.t.Fatalf("expected %s %d %+v, got %+v", name, i, expected[i], *level)
+			}
+		}
+	}
+	check("asks", depth.Asks, asks)
+	check("bids", depth.Bids, bids)
+}
+
+func (tester *dexMarketTester) checkTrades(count int) {
+	trades, err := tester.dm.GetTrades(1, 0, 1<<40, 0)
+	if err != nil {
+		tester.t.Fatal(err)
+	}
+	if len(trades) != count {
+		tester.t.Fatalf("expected %d trades, got %d", count, len(trades))
+	}
+}
+
+func (tester *dexMarketTester) checkKline(interval string, expected *DexKline) {
+	klines, err := tester.dm.GetKlines(1, interval, 0, 1<<40, 0)
+	if err != nil {
+		tester.t.Fatal(err)
+	}
+	if expected == nil {
+		if len(klines) != 0 {
+			tester.t.Fatalf("expected no klines, got %d", len(klines))
+		}
+		return
+	}
+	if len(klines) != 1 || *klines[0] != *expected {
+		tester.t.Fatalf("expected kline %+v, got %d klines", *expected, len(klines))
+	}
+}
+
+func TestDexMarket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex_market")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "dexMarketTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	chain := &dexTestChain{logs: make(map[types.Hash]ledger.VmLogList)}
+	tester := &dexMarketTester{t: t, chain: chain, store: store, dm: newDexMarket(store, chain).(*DexMarket)}
+
+	sellId := dexTestOrderId(1, true, "1.5", 1000, 1)
+	buyId := dexTestOrderId(1, false, "1.2", 1000, 2)
+	takerId := dexTestOrderId(1, false, "1.5", 1010, 3)
+	taker2Id := dexTestOrderId(1, false, "1.6", 1020, 4)
+
+	// two resting orders
+	chunk1 := tester.snapshot(1,
+		tester.newBlock(dexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+			Id: sellId, Status: dex.Pending, Quantity: big.NewInt(100).Bytes(),
+		}})),
+		tester.newBlock(dexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+			Id: buyId, Status: dex.Pending, Quantity: big.NewInt(50).Bytes(),
+		}})))
+	tester.checkDepth([]DexDepthLevel{{"1.5", "100"}}, []DexDepthLevel{{"1.2", "50"}})
+	tester.checkTrades(0)
+
+	// a taker fully executed by the sell order
+	chunk2 := tester.snapshot(2, tester.newBlock(
+		dexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+			Id: takerId, Status: dex.FullyExecuted, Quantity: big.NewInt(40).Bytes(), ExecutedQuantity: big.NewInt(40).Bytes(),
+		}}),
+		dexTestLog(t, dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{
+			Id: sellId, Status: dex.PartialExecuted, ExecutedQuantity: big.NewInt(40).Bytes(),
+		}),
+		dexTestLog(t, dexTxTopic, &dexproto.Transaction{
+			Id: []byte{1}, TakerId: takerId, MakerId: sellId, Price: dex.PriceToBytes("1.5"),
+			Quantity: big.NewInt(40).Bytes(), Amount: big.NewInt(60).Bytes(), Timestamp: 1010,
+		})))
+	tester.checkDepth([]DexDepthLevel{{"1.5", "60"}}, []DexDepthLevel{{"1.2", "50"}})
+	tester.checkTrades(1)
+	firstKline := &DexKline{Timestamp: 960, Open: "1.5", High: "1.5", Low: "1.5", Close: "1.5", Volume: "40", Amount: "60", TradeCount: 1}
+	tester.checkKline("1m", firstKline)
+
+	// a taker takes the rest of the sell order and rests on the bid side
+	chunk3 := tester.snapshot(3, tester.newBlock(
+		dexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+			Id: taker2Id, Status: dex.PartialExecuted, Quantity: big.NewInt(100).Bytes(), ExecutedQuantity: big.NewInt(60).Bytes(),
+		}}),
+		dexTestLog(t, dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{
+			Id: sellId, Status: dex.FullyExecuted, ExecutedQuantity: big.NewInt(100).Bytes(),
+		}),
+		dexTestLog(t, dexTxTopic, &dexproto.Transaction{
+			Id: []byte{2}, TakerId: taker2Id, MakerId: sellId, Price: dex.PriceToBytes("1.5"),
+			Quantity: big.NewInt(60).Bytes(), Amount: big.NewInt(90).Bytes(), Timestamp: 1015,
+		}),
+		dexTestLog(t, dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{
+			Id: buyId, Status: dex.Cancelled, ExecutedQuantity: big.NewInt(0).Bytes(),
+		})))
+	tester.checkDepth(nil, []DexDepthLevel{{"1.6", "40"}})
+	tester.checkTrades(2)
+	tester.checkKline("1m", &DexKline{Timestamp: 960, Open: "1.5", High: "1.5", Low: "1.5", Close: "1.5", Volume: "100", Amount: "150", TradeCount: 2})
+	if _, err := tester.dm.GetKlines(1, "2m", 0, 1<<40, 0); err != UnknownDexKlineIntervalErr {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tester.rollback(chunk3)
+	tester.checkDepth([]DexDepthLevel{{"1.5", "60"}}, []DexDepthLevel{{"1.2", "50"}})
+	tester.checkTrades(1)
+	tester.checkKline("1m", firstKline)
+	tester.checkKline("1d", &DexKline{Timestamp: 0, Open: "1.5", High: "1.5", Low: "1.5", Close: "1.5", Volume: "40", Amount: "60", TradeCount: 1})
+
+	tester.rollback(chunk1, chunk2)
+	tester.checkDepth(nil, nil)
+	tester.checkTrades(0)
+	tester.checkKline("1m", nil)
+}
+
+func TestDexMarket_PruneUndo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex_market")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store, err := chain_db.NewStore(dir, "dexMarketTest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	chain := &dexTestChain{logs: make(map[types.Hash]ledger.VmLogList)}
+	tester := &dexMarketTester{t: t, chain: chain, store: store, dm: newDexMarket(store, chain).(*DexMarket)}
+
+	newOrder := func(serialNo uint32) *ledger.VmLog {
+		return dexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+			Id: dexTestOrderId(1, true, "1.5", 1000, serialNo), Status: dex.Pending, Quantity: big.NewInt(100).Bytes(),
+		}})
+	}
+	block1 := tester.newBlock(newOrder(1))
+	tester.snapshot(1, block1)
+	block2 := tester.newBlock(newOrder(2))
+	tester.snapshot(2, block2)
+
+	hasUndo := func(snapshotHeight uint64, block *ledger.AccountBlock) bool {
+		value, err := store.Get(createDexUndoKey(snapshotHeight, block.Hash))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(value) > 0
+	}
+	if !hasUndo(1, block1) || !hasUndo(2, block2) {
+		t.Fatal("undo records should be kept")
+	}
+
+	// the snapshot block without dex blocks prunes the records too
+	tester.snapshot(1 + dexUndoRetainHeight)
+	if hasUndo(1, block1) {
+		t.Fatal("undo record of snapshot 1 should be pruned")
+	}
+	if !hasUndo(2, block2) {
+		t.Fatal("undo record of snapshot 2 should be kept")
+	}
+}
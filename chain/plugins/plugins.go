@@ -1,12 +1,15 @@
 package chain_plugins
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vm_db"
+	"math/big"
 	"os"
 	"path"
 	"sync"
@@ -27,12 +30,13 @@ type Plugins struct {
 	chain   Chain
 	store   *chain_db.Store
 	plugins map[string]Plugin
+	sqlDB   *sql.DB
 
 	writeStatus uint32
 	mu          sync.RWMutex
 }
 
-func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
+func NewPlugins(chainDir string, chain Chain, chainCfg *config.Chain, genesisCfg *config.Genesis) (*Plugins, error) {
 	var err error
 
 	dataDir := path.Join(chainDir, "plugins")
@@ -45,6 +49,32 @@ func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
 	plugins := map[string]Plugin{
 		"filterToken": newFilterToken(store, chain),
 		"onRoadInfo":  newOnRoadInfo(store, chain),
+		"dexMarket":   newDexMarket(store, chain),
+	}
+
+	// open sql indexer
+	var sqlDB *sql.DB
+	if chainCfg != nil && chainCfg.SqlIndexerDriver != "" {
+		dsn := chainCfg.SqlIndexerDSN
+		if dsn == "" && chainCfg.SqlIndexerDriver == SqliteDriver {
+			dsn = "file:" + path.Join(chainDir, sqlIndexerFile) + "?_busy_timeout=10000"
+		}
+		if sqlDB, err = sql.Open(chainCfg.SqlIndexerDriver, dsn); err != nil {
+			store.Close()
+			return nil, errors.New(fmt.Sprintf("sql.Open failed, driver is %s. Error: %s", chainCfg.SqlIndexerDriver, err))
+		}
+
+		var genesisBalances map[string]map[string]*big.Int
+		if genesisCfg != nil {
+			genesisBalances = genesisCfg.AccountBalanceMap
+		}
+		sqlIndexer, err := newSqlIndexer(sqlDB, chain, genesisBalances)
+		if err != nil {
+			sqlDB.Close()
+			store.Close()
+			return nil, err
+		}
+		plugins["sqlIndexer"] = sqlIndexer
 	}
 
 	return &Plugins{
@@ -52,6 +82,7 @@ func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
 		chain:       chain,
 		store:       store,
 		plugins:     plugins,
+		sqlDB:       sqlDB,
 		writeStatus: start,
 		log:         log15.New("module", "chain_plugins"),
 	}, nil
@@ -98,6 +129,13 @@ func (p *Plugins) RebuildData() error {
 		plugin.SetStore(store)
 	}
 
+	// the sql index lives outside the data dir, clear it before replaying
+	if sqlIndexer, ok := p.plugins["sqlIndexer"].(*SqlIndexer); ok {
+		if err := sqlIndexer.Reset(); err != nil {
+			return err
+		}
+	}
+
 	// replace flusher store
 	flusher := p.chain.Flusher()
 	flusher.ReplaceStore(p.store.Id(), store)
@@ -171,10 +209,29 @@ func (p *Plugins) RebuildData() error {
 	return nil
 }
 
+// Reconcile aligns the plugins which are not flushed with the chain, it's called after the chain
+// loads its cache.
+func (p *Plugins) Reconcile() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if sqlIndexer, ok := p.plugins["sqlIndexer"].(*SqlIndexer); ok {
+		if err := sqlIndexer.Reconcile(); err != nil {
+			return errors.New(fmt.Sprintf("reconcile sql indexer failed. Error: %s", err))
+		}
+	}
+	return nil
+}
+
 func (p *Plugins) Close() error {
 	if err := p.store.Close(); err != nil {
 		return err
 	}
+	if p.sqlDB != nil {
+		if err := p.sqlDB.Close(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,600 @@
+package chain_plugins
+
+import (
+	"database/sql"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+
+	// the sqlite driver built by cgo, registered as "sqlite3"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	// SqliteDriver is the name of the sqlite driver built into gvite
+	SqliteDriver = "sqlite3"
+
+	sqlIndexerFile = "sql_indexer.db"
+)
+
+// sqlIndexerSchema is written with the common subset of SQL understood by
+// sqlite and mysql, the placeholders of all statements are "?".
+var sqlIndexerSchema = []string{
+	`CREATE TABLE IF NOT EXISTS snapshot_blocks (
+		hash      CHAR(64) PRIMARY KEY,
+		height    BIGINT NOT NULL,
+		prev_hash CHAR(64) NOT NULL,
+		producer  CHAR(55) NOT NULL,
+		timestamp BIGINT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_snapshot_blocks_height ON snapshot_blocks (height)`,
+
+	`CREATE TABLE IF NOT EXISTS account_blocks (
+		hash            CHAR(64) PRIMARY KEY,
+		block_type      SMALLINT NOT NULL,
+		address         CHAR(55) NOT NULL,
+		height          BIGINT NOT NULL,
+		prev_hash       CHAR(64) NOT NULL,
+		from_block_hash CHAR(64) NOT NULL,
+		to_address      CHAR(55) NOT NULL,
+		token_id        CHAR(28) NOT NULL,
+		amount          TEXT NOT NULL,
+		fee             TEXT NOT NULL,
+		quota_used      BIGINT NOT NULL,
+		snapshot_hash   CHAR(64),
+		snapshot_height BIGINT
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_account_blocks_address ON account_blocks (address, height)`,
+	`CREATE INDEX IF NOT EXISTS idx_account_blocks_snapshot ON account_blocks (snapshot_height)`,
+
+	`CREATE TABLE IF NOT EXISTS transfers (
+		hash         CHAR(64) PRIMARY KEY,
+		block_hash   CHAR(64) NOT NULL,
+		from_address CHAR(55) NOT NULL,
+		to_address   CHAR(55) NOT NULL,
+		token_id     CHAR(28) NOT NULL,
+		amount       TEXT NOT NULL,
+		fee          TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_block ON transfers (block_hash)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_from ON transfers (from_address)`,
+	`CREATE INDEX IF NOT EXISTS idx_transfers_to ON transfers (to_address)`,
+
+	`CREATE TABLE IF NOT EXISTS vm_logs (
+		block_hash CHAR(64) NOT NULL,
+		log_index  INTEGER NOT NULL,
+		address    CHAR(55) NOT NULL,
+		topics     TEXT NOT NULL,
+		data       TEXT NOT NULL,
+		PRIMARY KEY (block_hash, log_index)
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_vm_logs_address ON vm_logs (address)`,
+
+	`CREATE TABLE IF NOT EXISTS balance_changes (
+		block_hash CHAR(64) NOT NULL,
+		address    CHAR(55) NOT NULL,
+		token_id   CHAR(28) NOT NULL,
+		delta      TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_balance_changes_block ON balance_changes (block_hash)`,
+
+	`CREATE TABLE IF NOT EXISTS token_balances (
+		address  CHAR(55) NOT NULL,
+		token_id CHAR(28) NOT NULL,
+		balance  TEXT NOT NULL,
+		PRIMARY KEY (address, token_id)
+	)`,
+
+	// the latest snapshot block written into the index, it's committed with the block itself
+	`CREATE TABLE IF NOT EXISTS indexer_status (
+		id              INTEGER PRIMARY KEY,
+		snapshot_height BIGINT NOT NULL,
+		snapshot_hash   CHAR(64) NOT NULL
+	)`,
+}
+
+var sqlIndexerTables = []string{
+	"snapshot_blocks", "account_blocks", "transfers", "vm_logs", "balance_changes", "token_balances", "indexer_status",
+}
+
+// SqlIndexer mirrors the ledger into a relational database, so that explorers can query
+// blocks, transfers, vm logs and token balances with SQL.
+//
+// Account blocks are written when they are inserted into the chain, and get the hash and height
+// of the snapshot block once they are confirmed. VM logs are only indexed for confirmed blocks.
+// Every balance change is recorded per block, so rollbacks revert balances exactly. Balances
+// allocated by the genesis config are recorded as the changes of the genesis receive blocks.
+//
+// The index is committed before the chain flushes its own stores, so it's reconciled with the
+// ledger by the latest indexed snapshot block when the chain starts.
+type SqlIndexer struct {
+	chain Chain
+	db    *sql.DB
+
+	genesisBalances map[string]map[string]*big.Int // address - tokenId - balanceAmount
+
+	log log15.Logger
+}
+
+func newSqlIndexer(db *sql.DB, chain Chain, genesisBalances map[string]map[string]*big.Int) (*SqlIndexer, error) {
+	si := &SqlIndexer{
+		chain:           chain,
+		db:              db,
+		genesisBalances: genesisBalances,
+		log:             log15.New("plugin", "sql_indexer"),
+	}
+
+	for _, stmt := range sqlIndexerSchema {
+		if _, err := db.Exec(stmt); err != nil {
+			return nil, errors.New(fmt.Sprintf("create sql indexer schema failed. Error: %s", err))
+		}
+	}
+	return si, nil
+}
+
+// DB returns the database which the indexer writes into.
+func (si *SqlIndexer) DB() *sql.DB {
+	return si.db
+}
+
+func (si *SqlIndexer) SetStore(store *chain_db.Store) {}
+
+func (si *SqlIndexer) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return si.withTx(func(tx *sql.Tx) error {
+		// make the insertion idempotent, the chain may write the same block again when it recovers
+		if err := si.deleteAccountBlock(tx, accountBlock.Hash); err != nil {
+			return err
+		}
+		return si.insertAccountBlock(tx, accountBlock)
+	})
+}
+
+func (si *SqlIndexer) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	return si.withTx(func(tx *sql.Tx) error {
+		var timestamp int64
+		if snapshotBlock.Timestamp != nil {
+			timestamp = snapshotBlock.Timestamp.Unix()
+		}
+
+		if _, err := tx.Exec(`DELETE FROM snapshot_blocks WHERE hash = ?`, snapshotBlock.Hash.String()); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO snapshot_blocks (hash, height, prev_hash, producer, timestamp) VALUES (?, ?, ?, ?, ?)`,
+			snapshotBlock.Hash.String(), snapshotBlock.Height, snapshotBlock.PrevHash.String(), snapshotBlock.Producer().String(), timestamp); err != nil {
+			return err
+		}
+		if err := si.setIndexedSnapshot(tx, snapshotBlock.Height, snapshotBlock.Hash); err != nil {
+			return err
+		}
+
+		for _, accountBlock := range confirmedBlocks {
+			if _, err := tx.Exec(`UPDATE account_blocks SET snapshot_hash = ?, snapshot_height = ? WHERE hash = ?`,
+				snapshotBlock.Hash.String(), snapshotBlock.Height, accountBlock.Hash.String()); err != nil {
+				return err
+			}
+
+			if err := si.insertVmLogs(tx, accountBlock); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (si *SqlIndexer) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	return si.withTx(func(tx *sql.Tx) error {
+		return si.deleteAccountBlocks(tx, accountBlocks)
+	})
+}
+
+func (si *SqlIndexer) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	return si.withTx(func(tx *sql.Tx) error {
+		var lowest *ledger.SnapshotBlock
+		for _, chunk := range chunks {
+			if chunk.SnapshotBlock != nil {
+				if _, err := tx.Exec(`DELETE FROM snapshot_blocks WHERE hash = ?`, chunk.SnapshotBlock.Hash.String()); err != nil {
+					return err
+				}
+				if lowest == nil || chunk.SnapshotBlock.Height < lowest.Height {
+					lowest = chunk.SnapshotBlock
+				}
+			}
+
+			if err := si.deleteAccountBlocks(tx, chunk.AccountBlocks); err != nil {
+				return err
+			}
+		}
+
+		if lowest != nil {
+			return si.setIndexedSnapshot(tx, lowest.Height-1, lowest.PrevHash)
+		}
+		return nil
+	})
+}
+
+func (si *SqlIndexer) RemoveNewUnconfirmed(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	return si.DeleteAccountBlocks(batch, accountBlocks)
+}
+
+// Reconcile aligns the index with the ledger. After a crash the index may contain blocks which
+// the ledger never flushed, and it lags behind when the indexer is opened on an existing ledger.
+// The blocks above the highest snapshot block shared with the ledger and all unconfirmed blocks
+// are reverted, then the missing part of the ledger is replayed.
+func (si *SqlIndexer) Reconcile() error {
+	latestSb := si.chain.GetLatestSnapshotBlock()
+	if latestSb == nil {
+		return errors.New("latest snapshot block is nil")
+	}
+
+	height, _, err := si.indexedSnapshot()
+	if err != nil {
+		return err
+	}
+	if height > latestSb.Height {
+		height = latestSb.Height
+	}
+
+	// find the highest snapshot block shared by the index and the ledger
+	var hash types.Hash
+	for ; height > 0; height-- {
+		sbs, err := si.chain.GetSnapshotBlocksByHeight(height, true, 1)
+		if err != nil {
+			return errors.New(fmt.Sprintf("si.chain.GetSnapshotBlocksByHeight failed, height is %d. Error: %s", height, err))
+		}
+		if len(sbs) <= 0 {
+			continue
+		}
+
+		var indexedHash string
+		err = si.db.QueryRow(`SELECT hash FROM snapshot_blocks WHERE height = ?`, height).Scan(&indexedHash)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if indexedHash == sbs[0].Hash.String() {
+			hash = sbs[0].Hash
+			break
+		}
+	}
+
+	if height <= 0 {
+		if err := si.Reset(); err != nil {
+			return err
+		}
+	} else if err := si.withTx(func(tx *sql.Tx) error {
+		return si.revertAbove(tx, height, hash)
+	}); err != nil {
+		return err
+	}
+
+	if height < latestSb.Height {
+		si.log.Info(fmt.Sprintf("replay the ledger from %d to %d", height+1, latestSb.Height), "method", "Reconcile")
+	}
+	for height < latestSb.Height {
+		targetHeight := height + roundSize
+		if targetHeight > latestSb.Height {
+			targetHeight = latestSb.Height
+		}
+
+		chunks, err := si.chain.GetSubLedger(height, targetHeight)
+		if err != nil {
+			return errors.New(fmt.Sprintf("si.chain.GetSubLedger failed, height is %d - %d. Error: %s", height, targetHeight, err))
+		}
+		for _, chunk := range chunks {
+			if chunk.SnapshotBlock == nil || chunk.SnapshotBlock.Height <= height {
+				continue
+			}
+			for _, accountBlock := range chunk.AccountBlocks {
+				if err := si.InsertAccountBlock(nil, accountBlock); err != nil {
+					return err
+				}
+			}
+			if err := si.InsertSnapshotBlock(nil, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+				return err
+			}
+		}
+		height = targetHeight
+	}
+
+	for _, accountBlock := range si.chain.GetAllUnconfirmedBlocks() {
+		if err := si.InsertAccountBlock(nil, accountBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset removes all the indexed data.
+func (si *SqlIndexer) Reset() error {
+	return si.withTx(func(tx *sql.Tx) error {
+		for _, table := range sqlIndexerTables {
+			if _, err := tx.Exec(`DELETE FROM ` + table); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (si *SqlIndexer) indexedSnapshot() (uint64, types.Hash, error) {
+	var height uint64
+	var hashStr string
+	err := si.db.QueryRow(`SELECT snapshot_height, snapshot_hash FROM indexer_status WHERE id = 1`).Scan(&height, &hashStr)
+	switch {
+	case err == sql.ErrNoRows:
+		return 0, types.Hash{}, nil
+	case err != nil:
+		return 0, types.Hash{}, err
+	}
+
+	hash, err := types.HexToHash(hashStr)
+	if err != nil {
+		return 0, types.Hash{}, err
+	}
+	return height, hash, nil
+}
+
+func (si *SqlIndexer) setIndexedSnapshot(tx *sql.Tx, height uint64, hash types.Hash) error {
+	if _, err := tx.Exec(`DELETE FROM indexer_status`); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`INSERT INTO indexer_status (id, snapshot_height, snapshot_hash) VALUES (1, ?, ?)`, height, hash.String())
+	return err
+}
+
+// revertAbove reverts the snapshot blocks higher than the height, the account blocks confirmed by them
+// and all unconfirmed account blocks.
+func (si *SqlIndexer) revertAbove(tx *sql.Tx, height uint64, hash types.Hash) error {
+	rows, err := tx.Query(`SELECT hash FROM account_blocks WHERE snapshot_height IS NULL OR snapshot_height > ?`, height)
+	if err != nil {
+		return err
+	}
+	var hashList []types.Hash
+	for rows.Next() {
+		var hashStr string
+		if err := rows.Scan(&hashStr); err != nil {
+			rows.Close()
+			return err
+		}
+		blockHash, err := types.HexToHash(hashStr)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		hashList = append(hashList, blockHash)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(hashList) > 0 {
+		si.log.Info(fmt.Sprintf("revert %d account blocks above snapshot height %d", len(hashList), height), "method", "revertAbove")
+	}
+	for _, blockHash := range hashList {
+		if err := si.deleteAccountBlock(tx, blockHash); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM snapshot_blocks WHERE height > ?`, height); err != nil {
+		return err
+	}
+	return si.setIndexedSnapshot(tx, height, hash)
+}
+
+func (si *SqlIndexer) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := si.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		si.log.Error(fmt.Sprintf("write sql indexer failed. Error: %s", err))
+		return err
+	}
+	return tx.Commit()
+}
+
+func (si *SqlIndexer) insertAccountBlock(tx *sql.Tx, accountBlock *ledger.AccountBlock) error {
+	if _, err := tx.Exec(`INSERT INTO account_blocks (hash, block_type, address, height, prev_hash, from_block_hash, to_address, token_id, amount, fee, quota_used) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		accountBlock.Hash.String(), accountBlock.BlockType, accountBlock.AccountAddress.String(), accountBlock.Height,
+		accountBlock.PrevHash.String(), accountBlock.FromBlockHash.String(), accountBlock.ToAddress.String(),
+		accountBlock.TokenId.String(), bigString(accountBlock.Amount), bigString(accountBlock.Fee), accountBlock.QuotaUsed); err != nil {
+		return err
+	}
+
+	if accountBlock.IsSendBlock() {
+		return si.insertSendBlock(tx, accountBlock.Hash, accountBlock)
+	}
+
+	if accountBlock.BlockType == ledger.BlockTypeGenesisReceive {
+		if err := si.insertGenesisBalances(tx, accountBlock); err != nil {
+			return err
+		}
+	} else {
+		sendBlock, err := si.chain.GetAccountBlockByHash(accountBlock.FromBlockHash)
+		if err != nil {
+			return errors.New(fmt.Sprintf("si.chain.GetAccountBlockByHash failed. Error: %s", err))
+		}
+		if sendBlock == nil {
+			return errors.New(fmt.Sprintf("send block %s is nil", accountBlock.FromBlockHash))
+		}
+		if err := si.changeBalance(tx, accountBlock.Hash, accountBlock.AccountAddress, sendBlock.TokenId, sendBlock.Amount); err != nil {
+			return err
+		}
+	}
+
+	for _, sendBlock := range accountBlock.SendBlockList {
+		if err := si.insertSendBlock(tx, accountBlock.Hash, sendBlock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertGenesisBalances records the balances allocated by the genesis config, every address
+// in the genesis config has exactly one genesis receive block.
+func (si *SqlIndexer) insertGenesisBalances(tx *sql.Tx, accountBlock *ledger.AccountBlock) error {
+	for tokenIdStr, balance := range si.genesisBalances[accountBlock.AccountAddress.String()] {
+		tokenId, err := types.HexToTokenTypeId(tokenIdStr)
+		if err != nil {
+			return errors.New(fmt.Sprintf("invalid genesis token id %s. Error: %s", tokenIdStr, err))
+		}
+		if err := si.changeBalance(tx, accountBlock.Hash, accountBlock.AccountAddress, tokenId, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SqlIndexer) insertSendBlock(tx *sql.Tx, blockHash types.Hash, sendBlock *ledger.AccountBlock) error {
+	if _, err := tx.Exec(`INSERT INTO transfers (hash, block_hash, from_address, to_address, token_id, amount, fee) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sendBlock.Hash.String(), blockHash.String(), sendBlock.AccountAddress.String(), sendBlock.ToAddress.String(),
+		sendBlock.TokenId.String(), bigString(sendBlock.Amount), bigString(sendBlock.Fee)); err != nil {
+		return err
+	}
+
+	if sendBlock.Amount != nil {
+		if err := si.changeBalance(tx, blockHash, sendBlock.AccountAddress, sendBlock.TokenId, new(big.Int).Neg(sendBlock.Amount)); err != nil {
+			return err
+		}
+	}
+	if sendBlock.Fee != nil {
+		if err := si.changeBalance(tx, blockHash, sendBlock.AccountAddress, ledger.ViteTokenId, new(big.Int).Neg(sendBlock.Fee)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SqlIndexer) insertVmLogs(tx *sql.Tx, accountBlock *ledger.AccountBlock) error {
+	if accountBlock.LogHash == nil {
+		return nil
+	}
+
+	logList, err := si.chain.GetVmLogList(accountBlock.LogHash)
+	if err != nil {
+		return errors.New(fmt.Sprintf("si.chain.GetVmLogList failed. Error: %s", err))
+	}
+
+	if _, err := tx.Exec(`DELETE FROM vm_logs WHERE block_hash = ?`, accountBlock.Hash.String()); err != nil {
+		return err
+	}
+	for index, vmLog := range logList {
+		topics := make([]string, 0, len(vmLog.Topics))
+		for _, topic := range vmLog.Topics {
+			topics = append(topics, topic.String())
+		}
+		if _, err := tx.Exec(`INSERT INTO vm_logs (block_hash, log_index, address, topics, data) VALUES (?, ?, ?, ?, ?)`,
+			accountBlock.Hash.String(), index, accountBlock.AccountAddress.String(), strings.Join(topics, ","), fmt.Sprintf("%x", vmLog.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SqlIndexer) deleteAccountBlocks(tx *sql.Tx, accountBlocks []*ledger.AccountBlock) error {
+	// revert from the highest block, the order of balance changes doesn't matter but it keeps the log readable
+	for i := len(accountBlocks) - 1; i >= 0; i-- {
+		if err := si.deleteAccountBlock(tx, accountBlocks[i].Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SqlIndexer) deleteAccountBlock(tx *sql.Tx, blockHash types.Hash) error {
+	rows, err := tx.Query(`SELECT address, token_id, delta FROM balance_changes WHERE block_hash = ?`, blockHash.String())
+	if err != nil {
+		return err
+	}
+
+	type change struct {
+		addr, tokenId string
+		delta         *big.Int
+	}
+	var changes []change
+	for rows.Next() {
+		var c change
+		var delta string
+		if err := rows.Scan(&c.addr, &c.tokenId, &delta); err != nil {
+			rows.Close()
+			return err
+		}
+		var ok bool
+		if c.delta, ok = new(big.Int).SetString(delta, 10); !ok {
+			rows.Close()
+			return errors.New(fmt.Sprintf("invalid balance delta %s of block %s", delta, blockHash))
+		}
+		changes = append(changes, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, c := range changes {
+		if err := si.addBalance(tx, c.addr, c.tokenId, c.delta.Neg(c.delta)); err != nil {
+			return err
+		}
+	}
+
+	for _, stmt := range []string{
+		`DELETE FROM balance_changes WHERE block_hash = ?`,
+		`DELETE FROM vm_logs WHERE block_hash = ?`,
+		`DELETE FROM transfers WHERE block_hash = ?`,
+		`DELETE FROM account_blocks WHERE hash = ?`,
+	} {
+		if _, err := tx.Exec(stmt, blockHash.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (si *SqlIndexer) changeBalance(tx *sql.Tx, blockHash types.Hash, addr types.Address, tokenId types.TokenTypeId, delta *big.Int) error {
+	if delta == nil || delta.Sign() == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO balance_changes (block_hash, address, token_id, delta) VALUES (?, ?, ?, ?)`,
+		blockHash.String(), addr.String(), tokenId.String(), delta.String()); err != nil {
+		return err
+	}
+	return si.addBalance(tx, addr.String(), tokenId.String(), delta)
+}
+
+func (si *SqlIndexer) addBalance(tx *sql.Tx, addr, tokenId string, delta *big.Int) error {
+	var balanceStr string
+	err := tx.QueryRow(`SELECT balance FROM token_balances WHERE address = ? AND token_id = ?`, addr, tokenId).Scan(&balanceStr)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`INSERT INTO token_balances (address, token_id, balance) VALUES (?, ?, ?)`, addr, tokenId, delta.String())
+		return err
+	case err != nil:
+		return err
+	}
+
+	balance, ok := new(big.Int).SetString(balanceStr, 10)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid balance %s of %s %s", balanceStr, addr, tokenId))
+	}
+	balance.Add(balance, delta)
+
+	_, err = tx.Exec(`UPDATE token_balances SET balance = ? WHERE address = ? AND token_id = ?`, balance.String(), addr, tokenId)
+	return err
+}
+
+func bigString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
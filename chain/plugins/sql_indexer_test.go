@@ -0,0 +1,341 @@
+package chain_plugins
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/flusher"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	sqlTestUser1     = sqlTestAddress(1, false)
+	sqlTestUser2     = sqlTestAddress(2, false)
+	sqlTestContract1 = sqlTestAddress(1, true)
+)
+
+func sqlTestAddress(n byte, isContract bool) types.Address {
+	b := make([]byte, types.AddressSize)
+	b[types.AddressCoreSize-1] = n
+	if isContract {
+		b[types.AddressCoreSize] = types.ContractAddrByte
+	}
+	addr, err := types.BytesToAddress(b)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+type sqlTestChain struct {
+	snapshotBlocks []*ledger.SnapshotBlock
+	confirmed      map[uint64][]*ledger.AccountBlock
+	unconfirmed    []*ledger.AccountBlock
+	blocks         map[types.Hash]*ledger.AccountBlock
+	logs           map[types.Hash]ledger.VmLogList
+}
+
+func newSqlTestChain() *sqlTestChain {
+	return &sqlTestChain{
+		confirmed: make(map[uint64][]*ledger.AccountBlock),
+		blocks:    make(map[types.Hash]*ledger.AccountBlock),
+		logs:      make(map[types.Hash]ledger.VmLogList),
+	}
+}
+
+func (c *sqlTestChain) Flusher() *chain_flusher.Flusher { return nil }
+func (c *sqlTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	if len(c.snapshotBlocks) <= 0 {
+		return nil
+	}
+	return c.snapshotBlocks[len(c.snapshotBlocks)-1]
+}
+func (c *sqlTestChain) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	if height <= 0 || height > uint64(len(c.snapshotBlocks)) {
+		return nil, nil
+	}
+	return []*ledger.SnapshotBlock{c.snapshotBlocks[height-1]}, nil
+}
+func (c *sqlTestChain) GetSubLedgerAfterHeight(uint64) ([]*ledger.SnapshotChunk, error) {
+	return nil, nil
+}
+func (c *sqlTestChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	var chunks []*ledger.SnapshotChunk
+	for _, sb := range c.snapshotBlocks {
+		if sb.Height >= startHeight && sb.Height <= endHeight {
+			chunks = append(chunks, &ledger.SnapshotChunk{SnapshotBlock: sb, AccountBlocks: c.confirmed[sb.Height]})
+		}
+	}
+	return chunks, nil
+}
+func (c *sqlTestChain) GetAccountBlockByHash(hash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[hash], nil
+}
+func (c *sqlTestChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return c.logs[*logListHash], nil
+}
+func (c *sqlTestChain) IsAccountBlockExisted(types.Hash) (bool, error)  { return false, nil }
+func (c *sqlTestChain) IsGenesisAccountBlock(types.Hash) bool           { return false }
+func (c *sqlTestChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock { return c.unconfirmed }
+func (c *sqlTestChain) LoadAllOnRoad() (map[types.Address][]types.Hash, error) {
+	return nil, nil
+}
+
+func (c *sqlTestChain) addSnapshotBlock(blocks ...*ledger.AccountBlock) *ledger.SnapshotBlock {
+	sb := &ledger.SnapshotBlock{
+		Height:    uint64(len(c.snapshotBlocks)) + 1,
+		Timestamp: &time.Time{},
+	}
+	if latest := c.GetLatestSnapshotBlock(); latest != nil {
+		sb.PrevHash = latest.Hash
+	}
+	sb.Hash = types.DataHash(big.NewInt(int64(sb.Height)).Bytes())
+	c.snapshotBlocks = append(c.snapshotBlocks, sb)
+	c.confirmed[sb.Height] = blocks
+	return sb
+}
+
+func (c *sqlTestChain) newSendBlock(from, to types.Address, height uint64, amount int64) *ledger.AccountBlock {
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: from,
+		ToAddress:      to,
+		Height:         height,
+		TokenId:        ledger.ViteTokenId,
+		Amount:         big.NewInt(amount),
+		Fee:            big.NewInt(1),
+	}
+	block.Hash = types.DataHash([]byte{byte(height), from.Bytes()[types.AddressCoreSize-1], 's'})
+	c.blocks[block.Hash] = block
+	return block
+}
+
+func (c *sqlTestChain) newReceiveBlock(sendBlock *ledger.AccountBlock, height uint64, logs ledger.VmLogList) *ledger.AccountBlock {
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: sendBlock.ToAddress,
+		FromBlockHash:  sendBlock.Hash,
+		Height:         height,
+	}
+	block.Hash = types.DataHash([]byte{byte(height), sendBlock.ToAddress.Bytes()[types.AddressCoreSize-1], 'r'})
+	if len(logs) > 0 {
+		logHash := types.DataHash(block.Hash.Bytes())
+		block.LogHash = &logHash
+		c.logs[logHash] = logs
+	}
+	c.blocks[block.Hash] = block
+	return block
+}
+
+func newTestSqlIndexer(t *testing.T, chain Chain, genesisBalances map[string]map[string]*big.Int) (*SqlIndexer, func()) {
+	dir, err := ioutil.TempDir("", "sql_indexer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open(SqliteDriver, "file:"+path.Join(dir, sqlIndexerFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeFn := func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+
+	si, err := newSqlIndexer(db, chain, genesisBalances)
+	if err != nil {
+		closeFn()
+		t.Fatal(err)
+	}
+	return si, closeFn
+}
+
+func sqlTestCount(t *testing.T, si *SqlIndexer, table string) int {
+	var count int
+	if err := si.DB().QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func sqlTestBalance(t *testing.T, si *SqlIndexer, addr types.Address) string {
+	var balance string
+	err := si.DB().QueryRow(`SELECT balance FROM token_balances WHERE address = ? AND token_id = ?`, addr.String(), ledger.ViteTokenId.String()).Scan(&balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestSqlIndexer_InsertAndRollback(t *testing.T) {
+	chain := newSqlTestChain()
+	si, closeFn := newTestSqlIndexer(t, chain, nil)
+	defer closeFn()
+
+	send := chain.newSendBlock(sqlTestUser1, sqlTestContract1, 1, 100)
+	receive := chain.newReceiveBlock(send, 1, ledger.VmLogList{{Topics: []types.Hash{{1}}, Data: []byte{2}}})
+	sb := chain.addSnapshotBlock(send, receive)
+
+	for _, block := range []*ledger.AccountBlock{send, receive} {
+		if err := si.InsertAccountBlock(nil, block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count := sqlTestCount(t, si, "vm_logs"); count != 0 {
+		t.Fatalf("vm logs of unconfirmed blocks are indexed, count is %d", count)
+	}
+	if err := si.InsertSnapshotBlock(nil, sb, []*ledger.AccountBlock{send, receive}); err != nil {
+		t.Fatal(err)
+	}
+
+	for table, expected := range map[string]int{"snapshot_blocks": 1, "account_blocks": 2, "transfers": 1, "vm_logs": 1} {
+		if count := sqlTestCount(t, si, table); count != expected {
+			t.Fatalf("%s count is %d, expected %d", table, count, expected)
+		}
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "-101" {
+		t.Fatalf("balance of sender is %s", balance)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestContract1); balance != "100" {
+		t.Fatalf("balance of receiver is %s", balance)
+	}
+	if height, hash, err := si.indexedSnapshot(); err != nil || height != 1 || hash != sb.Hash {
+		t.Fatalf("indexed snapshot is %d %s, err is %v", height, hash, err)
+	}
+
+	// inserting the same block again doesn't change the balance
+	if err := si.InsertAccountBlock(nil, send); err != nil {
+		t.Fatal(err)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "-101" {
+		t.Fatalf("balance of sender is %s after inserting again", balance)
+	}
+
+	if err := si.DeleteSnapshotBlocks(nil, []*ledger.SnapshotChunk{{SnapshotBlock: sb, AccountBlocks: []*ledger.AccountBlock{send, receive}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"snapshot_blocks", "account_blocks", "transfers", "vm_logs", "balance_changes"} {
+		if count := sqlTestCount(t, si, table); count != 0 {
+			t.Fatalf("%s count is %d after rollback", table, count)
+		}
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "0" {
+		t.Fatalf("balance of sender is %s after rollback", balance)
+	}
+	if height, hash, err := si.indexedSnapshot(); err != nil || height != 0 || hash != sb.PrevHash {
+		t.Fatalf("indexed snapshot is %d %s after rollback, err is %v", height, hash, err)
+	}
+}
+
+func TestSqlIndexer_Reconcile(t *testing.T) {
+	chain := newSqlTestChain()
+	si, closeFn := newTestSqlIndexer(t, chain, nil)
+	defer closeFn()
+
+	send1 := chain.newSendBlock(sqlTestUser1, sqlTestContract1, 1, 100)
+	sb1 := chain.addSnapshotBlock(send1)
+	send2 := chain.newSendBlock(sqlTestUser1, sqlTestContract1, 2, 10)
+	sb2 := chain.addSnapshotBlock(send2)
+	unconfirmed := chain.newSendBlock(sqlTestUser1, sqlTestContract1, 3, 1)
+	chain.unconfirmed = []*ledger.AccountBlock{unconfirmed}
+
+	// the index lags behind the ledger
+	if err := si.InsertAccountBlock(nil, send1); err != nil {
+		t.Fatal(err)
+	}
+	if err := si.InsertSnapshotBlock(nil, sb1, []*ledger.AccountBlock{send1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := si.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if height, hash, err := si.indexedSnapshot(); err != nil || height != 2 || hash != sb2.Hash {
+		t.Fatalf("indexed snapshot is %d %s, err is %v", height, hash, err)
+	}
+	if count := sqlTestCount(t, si, "account_blocks"); count != 3 {
+		t.Fatalf("account blocks count is %d", count)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "-114" {
+		t.Fatalf("balance of sender is %s", balance)
+	}
+
+	// the index is ahead of the ledger, the ledger lost the second snapshot block and the unconfirmed block
+	chain.snapshotBlocks = chain.snapshotBlocks[:1]
+	chain.unconfirmed = nil
+	if err := si.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if height, hash, err := si.indexedSnapshot(); err != nil || height != 1 || hash != sb1.Hash {
+		t.Fatalf("indexed snapshot is %d %s, err is %v", height, hash, err)
+	}
+	if count := sqlTestCount(t, si, "snapshot_blocks"); count != 1 {
+		t.Fatalf("snapshot blocks count is %d", count)
+	}
+	if count := sqlTestCount(t, si, "account_blocks"); count != 1 {
+		t.Fatalf("account blocks count is %d", count)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "-101" {
+		t.Fatalf("balance of sender is %s", balance)
+	}
+
+	// the ledger forked at the first snapshot block
+	chain.snapshotBlocks = nil
+	send3 := chain.newSendBlock(sqlTestUser2, sqlTestContract1, 1, 5)
+	chain.addSnapshotBlock(send3)
+	chain.snapshotBlocks[0].Hash = types.DataHash([]byte("fork"))
+	if err := si.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if count := sqlTestCount(t, si, "account_blocks"); count != 1 {
+		t.Fatalf("account blocks count is %d after fork", count)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser2); balance != "-6" {
+		t.Fatalf("balance of sender is %s after fork", balance)
+	}
+	if count := sqlTestCount(t, si, "token_balances"); count != 1 {
+		t.Fatalf("token balances count is %d after fork", count)
+	}
+}
+
+func TestSqlIndexer_GenesisBalances(t *testing.T) {
+	chain := newSqlTestChain()
+	si, closeFn := newTestSqlIndexer(t, chain, map[string]map[string]*big.Int{
+		sqlTestUser1.String(): {ledger.ViteTokenId.String(): big.NewInt(1000)},
+	})
+	defer closeFn()
+
+	genesis := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeGenesisReceive,
+		AccountAddress: sqlTestUser1,
+		Height:         1,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+	}
+	genesis.Hash = types.DataHash([]byte{1, 'g'})
+	chain.addSnapshotBlock(genesis)
+	send := chain.newSendBlock(sqlTestUser1, sqlTestContract1, 2, 100)
+	chain.addSnapshotBlock(send)
+
+	if err := si.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "899" {
+		t.Fatalf("balance of the genesis account is %s", balance)
+	}
+
+	// the index is rebuilt from the genesis snapshot block
+	if err := si.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := si.Reconcile(); err != nil {
+		t.Fatal(err)
+	}
+	if balance := sqlTestBalance(t, si, sqlTestUser1); balance != "899" {
+		t.Fatalf("balance of the genesis account is %s after rebuild", balance)
+	}
+}
@@ -41,6 +41,62 @@ func (c *chain) GetConfirmedBalanceList(addrList []types.Address, tokenId types.
 	return balanceMap, nil
 }
 
+// get Balance at the snapshot height
+func (c *chain) GetBalanceAtHeight(addr types.Address, tokenId types.TokenTypeId, snapshotHeight uint64) (*big.Int, error) {
+	if err := c.CheckHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	result, err := c.stateDB.GetSnapshotBalance(snapshotHeight, addr, tokenId)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotBalance failed, Addr is %s, tokenId is %s, snapshotHeight is %d. Error: %s", addr, tokenId, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetBalanceAtHeight")
+		return nil, cErr
+	}
+	return result, nil
+}
+
+// get contract storage value at the snapshot height
+func (c *chain) GetValueAtHeight(addr types.Address, key []byte, snapshotHeight uint64) ([]byte, error) {
+	if err := c.CheckHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	value, err := c.stateDB.GetSnapshotValue(snapshotHeight, addr, key)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotValue failed, Addr is %s, key is %v, snapshotHeight is %d. Error: %s", addr, key, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetValueAtHeight")
+		return nil, cErr
+	}
+	return value, nil
+}
+
+// get contract storage iterator at the snapshot height
+func (c *chain) GetStorageIteratorAtHeight(addr types.Address, prefix []byte, snapshotHeight uint64) (interfaces.StorageIterator, error) {
+	if err := c.CheckHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	iter, err := c.stateDB.NewSnapshotStorageIteratorByHeight(snapshotHeight, addr, prefix)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.NewSnapshotStorageIteratorByHeight failed, Addr is %s, prefix is %v, snapshotHeight is %d. Error: %s", addr, prefix, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetStorageIteratorAtHeight")
+		return nil, cErr
+	}
+	return iter, nil
+}
+
+// check the state history at the snapshot height is retained
+func (c *chain) CheckHistoryHeight(snapshotHeight uint64) error {
+	if snapshotHeight <= 0 {
+		return errors.New("snapshot height is 0")
+	}
+	if latestHeight := c.GetLatestSnapshotBlock().Height; snapshotHeight > latestHeight {
+		return errors.New(fmt.Sprintf("snapshot height %d is higher than the latest snapshot height %d", snapshotHeight, latestHeight))
+	}
+	return nil
+}
+
 // get contract code
 func (c *chain) GetContractCode(contractAddress types.Address) ([]byte, error) {
 	code, err := c.stateDB.GetCode(contractAddress)
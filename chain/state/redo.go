@@ -110,6 +110,11 @@ func (redo *Redo) initCache() error {
 	return nil
 }
 
+// SetRetainHeight sets how many snapshot blocks the redo logs are kept for, 0 means the redo logs are never deleted
+func (redo *Redo) SetRetainHeight(retainHeight uint64) {
+	redo.retainHeight = retainHeight
+}
+
 func (redo *Redo) Close() error {
 	if err := redo.store.Close(); err != nil {
 		return err
@@ -161,7 +166,7 @@ func (redo *Redo) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock, confi
 	batch.Put(chain_utils.CreateRedoSnapshot(snapshotBlock.Height), value)
 
 	// rollback stale data
-	if snapshotBlock.Height > redo.retainHeight {
+	if redo.retainHeight > 0 && snapshotBlock.Height > redo.retainHeight {
 		batch.Delete(chain_utils.CreateRedoSnapshot(snapshotBlock.Height - redo.retainHeight))
 		//redo.log.Info(fmt.Sprintf("delete %d", snapshotBlock.Height-redo.retainHeight), "method", "InsertSnapshotBlock")
 	}
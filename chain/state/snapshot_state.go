@@ -0,0 +1,223 @@
+package chain_state
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// the prefixes of the state at a snapshot block, in the order of iteration
+var snapshotStatePrefixes = []byte{
+	chain_utils.StorageKeyPrefix,
+	chain_utils.BalanceKeyPrefix,
+	chain_utils.CodeKeyPrefix,
+	chain_utils.ContractMetaKeyPrefix,
+	chain_utils.GidContractKeyPrefix,
+}
+
+var errStopIteration = errors.New("stop iteration")
+
+// IterateSnapshotState iterates the state at the snapshot height in the key order, from the key start on.
+// Storage and balances are restored from the history, code and contracts are filtered by isContractAvailable,
+// because they have no history. Deleted storage is iterated with empty value, so that the state can be
+// installed over another one.
+func (sDB *StateDB) IterateSnapshotState(height uint64, start []byte, isContractAvailable func(addr types.Address) (bool, error),
+	fn func(entry interfaces.StateEntry) bool) error {
+
+	for _, prefix := range snapshotStatePrefixes {
+		var sectionStart []byte
+		if len(start) > 0 {
+			if start[0] > prefix {
+				continue
+			}
+			if start[0] == prefix {
+				sectionStart = start
+			}
+		}
+
+		var err error
+		switch prefix {
+		case chain_utils.StorageKeyPrefix:
+			err = sDB.iterateHistory(chain_utils.StorageHistoryKeyPrefix, prefix, height, sectionStart, fn)
+		case chain_utils.BalanceKeyPrefix:
+			err = sDB.iterateHistory(chain_utils.BalanceHistoryKeyPrefix, prefix, height, sectionStart, fn)
+		default:
+			err = sDB.iterateContractState(prefix, sectionStart, isContractAvailable, fn)
+		}
+
+		if err == errStopIteration {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iterateHistory iterates the history keys like prefix + key + height, and returns the latest value
+// not higher than the height of every key, with latestPrefix + key as the key.
+func (sDB *StateDB) iterateHistory(historyPrefix, latestPrefix byte, height uint64, start []byte, fn func(entry interfaces.StateEntry) bool) error {
+	iter := sDB.store.NewIterator(util.BytesPrefix([]byte{historyPrefix}))
+	defer iter.Release()
+
+	var ok bool
+	if len(start) > 0 {
+		seekKey := make([]byte, len(start))
+		copy(seekKey, start)
+		seekKey[0] = historyPrefix
+		ok = iter.Seek(seekKey)
+	} else {
+		ok = iter.Next()
+	}
+
+	var current interfaces.StateEntry
+	emit := func() bool {
+		if current.Key == nil || current.Value == nil {
+			return true
+		}
+		return fn(current)
+	}
+
+	for ; ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) < 9 {
+			return errors.New(fmt.Sprintf("invalid history key %v", key))
+		}
+		latestKey := key[:len(key)-8]
+		if current.Key != nil && !bytes.Equal(current.Key[1:], latestKey[1:]) {
+			if !emit() {
+				return errStopIteration
+			}
+			current = interfaces.StateEntry{}
+		}
+		if current.Key == nil {
+			current.Key = make([]byte, len(latestKey))
+			copy(current.Key, latestKey)
+			current.Key[0] = latestPrefix
+		}
+
+		if binary.BigEndian.Uint64(key[len(key)-8:]) <= height {
+			value := iter.Value()
+			current.Value = make([]byte, len(value))
+			copy(current.Value, value)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	if !emit() {
+		return errStopIteration
+	}
+	return nil
+}
+
+// iterateContractState iterates code, contract meta and gid contract keys of the available contracts.
+func (sDB *StateDB) iterateContractState(prefix byte, start []byte, isContractAvailable func(addr types.Address) (bool, error),
+	fn func(entry interfaces.StateEntry) bool) error {
+
+	iter := sDB.store.NewIterator(util.BytesPrefix([]byte{prefix}))
+	defer iter.Release()
+
+	var ok bool
+	if len(start) > 0 {
+		ok = iter.Seek(start)
+	} else {
+		ok = iter.Next()
+	}
+
+	for ; ok; ok = iter.Next() {
+		key := iter.Key()
+		if len(key) < 1+types.AddressSize {
+			return errors.New(fmt.Sprintf("invalid contract key %v", key))
+		}
+		// the address is at the end of all contract keys
+		addr, err := types.BytesToAddress(key[len(key)-types.AddressSize:])
+		if err != nil {
+			return err
+		}
+		available, err := isContractAvailable(addr)
+		if err != nil {
+			return err
+		}
+		if !available {
+			continue
+		}
+
+		entry := interfaces.StateEntry{
+			Key:   make([]byte, len(key)),
+			Value: make([]byte, len(iter.Value())),
+		}
+		copy(entry.Key, key)
+		copy(entry.Value, iter.Value())
+		if !fn(entry) {
+			return errStopIteration
+		}
+	}
+
+	return iter.Error()
+}
+
+// WriteSnapshotState writes state entries of the snapshot height, storage and balances are written into
+// both the latest state and the history of the height.
+func (sDB *StateDB) WriteSnapshotState(height uint64, entries []interfaces.StateEntry) error {
+	batch := sDB.store.NewBatch()
+
+	var heightBytes [8]byte
+	binary.BigEndian.PutUint64(heightBytes[:], height)
+
+	for _, entry := range entries {
+		if len(entry.Key) < 1+types.AddressSize {
+			return errors.New(fmt.Sprintf("invalid state key %v", entry.Key))
+		}
+
+		switch entry.Key[0] {
+		case chain_utils.StorageKeyPrefix:
+			if len(entry.Key) != 1+types.AddressSize+types.HashSize+1 {
+				return errors.New(fmt.Sprintf("invalid storage key %v", entry.Key))
+			}
+			if len(entry.Value) > 0 {
+				batch.Put(entry.Key, entry.Value)
+			} else {
+				batch.Delete(entry.Key)
+			}
+
+			historyKey := append([]byte{chain_utils.StorageHistoryKeyPrefix}, entry.Key[1:]...)
+			sDB.writeHistoryKey(batch, append(historyKey, heightBytes[:]...), entry.Value)
+
+		case chain_utils.BalanceKeyPrefix:
+			if len(entry.Key) != 1+types.AddressSize+types.TokenTypeIdSize {
+				return errors.New(fmt.Sprintf("invalid balance key %v", entry.Key))
+			}
+			sDB.writeBalance(batch, entry.Key, entry.Value)
+
+			historyKey := append([]byte{chain_utils.BalanceHistoryKeyPrefix}, entry.Key[1:]...)
+			batch.Put(append(historyKey, heightBytes[:]...), entry.Value)
+
+		case chain_utils.ContractMetaKeyPrefix:
+			sDB.writeContractMeta(batch, entry.Key, entry.Value)
+
+		case chain_utils.CodeKeyPrefix, chain_utils.GidContractKeyPrefix:
+			batch.Put(entry.Key, entry.Value)
+
+		default:
+			return errors.New(fmt.Sprintf("unknown state key prefix %d", entry.Key[0]))
+		}
+	}
+
+	sDB.store.WriteDirectly(batch)
+	return nil
+}
+
+// InsertStateSnapshotBlock starts the redo log after the snapshot block of an installed state snapshot.
+func (sDB *StateDB) InsertStateSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) {
+	sDB.redo.InsertSnapshotBlock(snapshotBlock, nil)
+}
@@ -0,0 +1,138 @@
+package chain_state
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	chain_db "github.com/vitelabs/go-vite/chain/db"
+	chain_utils "github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func newTestStateDB(t *testing.T, ctrl *gomock.Controller, dir string) *StateDB {
+	mockChain := NewMockChain(ctrl)
+	mockChain.EXPECT().QueryLatestSnapshotBlock().Return(nil, nil).AnyTimes()
+
+	store, err := chain_db.NewStore(path.Join(dir, "state"), "stateDb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	redoStore, err := chain_db.NewStore(path.Join(dir, "state_redo"), "stateDbRedo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sDB, err := NewStateDBWithStore(mockChain, &config.Chain{}, store, redoStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sDB
+}
+
+func collectSnapshotState(t *testing.T, sDB *StateDB, height uint64, start []byte, filter func(addr types.Address) (bool, error)) []interfaces.StateEntry {
+	var entries []interfaces.StateEntry
+	if err := sDB.IterateSnapshotState(height, start, filter, func(entry interfaces.StateEntry) bool {
+		entries = append(entries, entry)
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return entries
+}
+
+func checkStateEntries(t *testing.T, entries, expected []interfaces.StateEntry) {
+	if len(entries) != len(expected) {
+		t.Fatalf("%d entries, should be %d", len(entries), len(expected))
+	}
+	for i := range entries {
+		if !bytes.Equal(entries[i].Key, expected[i].Key) || !bytes.Equal(entries[i].Value, expected[i].Value) {
+			t.Fatalf("entry %d is %v, should be %v", i, entries[i], expected[i])
+		}
+	}
+}
+
+func TestStateDB_SnapshotState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "snapshot_state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sDB := newTestStateDB(t, ctrl, path.Join(dir, "source"))
+
+	addr := types.Address{1}
+	contract := types.Address{2}
+	newContract := types.Address{3}
+	filter := func(addr types.Address) (bool, error) {
+		return addr != newContract, nil
+	}
+
+	batch := sDB.store.NewBatch()
+	// k1 is changed at 2 and 5, k2 is set after 5, k3 is deleted at 4
+	batch.Put(chain_utils.CreateHistoryStorageValueKey(&contract, []byte("k1"), 2), []byte("v1"))
+	batch.Put(chain_utils.CreateHistoryStorageValueKey(&contract, []byte("k1"), 5), []byte("v2"))
+	batch.Put(chain_utils.CreateHistoryStorageValueKey(&contract, []byte("k2"), 6), []byte("v3"))
+	batch.Put(chain_utils.CreateHistoryStorageValueKey(&contract, []byte("k3"), 3), []byte("v4"))
+	batch.Put(chain_utils.CreateHistoryStorageValueKey(&contract, []byte("k3"), 4), []byte{})
+	batch.Put(chain_utils.CreateHistoryBalanceKey(addr, ledger.ViteTokenId, 2), []byte{100})
+	batch.Put(chain_utils.CreateHistoryBalanceKey(addr, ledger.ViteTokenId, 7), []byte{200})
+	batch.Put(chain_utils.CreateCodeKey(contract), []byte("code"))
+	batch.Put(chain_utils.CreateCodeKey(newContract), []byte("new code"))
+	batch.Put(chain_utils.CreateContractMetaKey(contract), []byte("meta"))
+	batch.Put(chain_utils.CreateContractMetaKey(newContract), []byte("new meta"))
+	sDB.store.WriteDirectly(batch)
+
+	expected := []interfaces.StateEntry{
+		{Key: chain_utils.CreateStorageValueKey(&contract, []byte("k1")), Value: []byte("v2")},
+		{Key: chain_utils.CreateStorageValueKey(&contract, []byte("k3")), Value: []byte{}},
+		{Key: chain_utils.CreateBalanceKey(addr, ledger.ViteTokenId), Value: []byte{100}},
+		{Key: chain_utils.CreateCodeKey(contract), Value: []byte("code")},
+		{Key: chain_utils.CreateContractMetaKey(contract), Value: []byte("meta")},
+	}
+
+	entries := collectSnapshotState(t, sDB, 5, nil, filter)
+	checkStateEntries(t, entries, expected)
+
+	for i := range expected {
+		checkStateEntries(t, collectSnapshotState(t, sDB, 5, expected[i].Key, filter), expected[i:])
+	}
+
+	count := 0
+	if err := sDB.IterateSnapshotState(5, nil, filter, func(entry interfaces.StateEntry) bool {
+		count++
+		return count < 2
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("iteration should stop at 2, iterated %d", count)
+	}
+
+	// install into another state db
+	target := newTestStateDB(t, ctrl, path.Join(dir, "target"))
+	if err := target.WriteSnapshotState(5, entries); err != nil {
+		t.Fatal(err)
+	}
+	checkStateEntries(t, collectSnapshotState(t, target, 5, nil, filter), expected)
+
+	value, err := target.store.Get(chain_utils.CreateStorageValueKey(&contract, []byte("k1")))
+	if err != nil || !bytes.Equal(value, []byte("v2")) {
+		t.Fatalf("latest storage is %v, error: %v", value, err)
+	}
+	if ok, err := target.store.Has(chain_utils.CreateStorageValueKey(&contract, []byte("k3"))); err != nil || ok {
+		t.Fatalf("deleted storage should not be written, error: %v", err)
+	}
+
+	if err := target.WriteSnapshotState(5, []interfaces.StateEntry{{Key: []byte{0xff, 1}}}); err == nil {
+		t.Fatal("invalid key should be rejected")
+	}
+}
@@ -80,6 +80,11 @@ func NewStateDBWithStore(chain Chain, chainCfg *config.Chain, store *chain_db.St
 		redo:                storageRedo,
 	}
 
+	// keep the redo logs of all snapshot blocks
+	if chainCfg.ArchiveMode {
+		storageRedo.SetRetainHeight(0)
+	}
+
 	if err := stateDb.newCache(); err != nil {
 		return nil, err
 	}
@@ -331,6 +336,25 @@ func (sDB *StateDB) GetSnapshotValue(snapshotBlockHeight uint64, addr types.Addr
 	return nil, nil
 }
 
+// GetSnapshotBalance returns the balance of the address when the snapshot block of snapshotBlockHeight was inserted
+func (sDB *StateDB) GetSnapshotBalance(snapshotBlockHeight uint64, addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	startHistoryBalanceKey := chain_utils.CreateHistoryBalanceKey(addr, tokenId, 0)
+	endHistoryBalanceKey := chain_utils.CreateHistoryBalanceKey(addr, tokenId, snapshotBlockHeight+1)
+
+	iter := sDB.store.NewIterator(&util.Range{Start: startHistoryBalanceKey, Limit: endHistoryBalanceKey})
+	defer iter.Release()
+
+	if iter.Last() {
+		return big.NewInt(0).SetBytes(iter.Value()), nil
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+
+	return big.NewInt(0), nil
+}
+
 func (sDB *StateDB) SetCacheLevelForConsensus(level uint32) {
 	atomic.StoreUint32(&sDB.consensusCacheLevel, level)
 }
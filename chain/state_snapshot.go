@@ -0,0 +1,426 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// StateChunkSize is the count of state entries in a chunk of the state manifest
+const StateChunkSize = 1000
+
+// GetStateManifest computes the state manifest at the snapshot height, it iterates the whole state,
+// the latest manifest is cached for GetStateChunk. The state history at the height must be retained.
+func (c *chain) GetStateManifest(height uint64) (*interfaces.StateManifest, error) {
+	c.stateManifestMu.Lock()
+	defer c.stateManifestMu.Unlock()
+
+	if c.stateManifest != nil && c.stateManifest.Block.Height == height {
+		return c.stateManifest, nil
+	}
+
+	if err := c.CheckHistoryHeight(height); err != nil {
+		return nil, err
+	}
+
+	snapshotBlock, err := c.GetSnapshotBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotBlock == nil {
+		return nil, errors.New(fmt.Sprintf("snapshot block %d is not existed", height))
+	}
+
+	manifest := &interfaces.StateManifest{
+		Block: snapshotBlock,
+	}
+
+	entries := make([]interfaces.StateEntry, 0, StateChunkSize)
+	addChunk := func() {
+		manifest.Chunks = append(manifest.Chunks, interfaces.StateChunkInfo{
+			StartKey: entries[0].Key,
+			Count:    uint64(len(entries)),
+			Hash:     interfaces.ComputeStateChunkHash(entries),
+		})
+		entries = entries[:0]
+	}
+
+	if err := c.iterateSnapshotState(height, nil, func(entry interfaces.StateEntry) bool {
+		entries = append(entries, entry)
+		if len(entries) >= StateChunkSize {
+			addChunk()
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		addChunk()
+	}
+
+	manifest.Root = manifest.ComputeRoot()
+	c.stateManifest = manifest
+
+	return manifest, nil
+}
+
+// GetStateChunk returns the entries of the chunk of the state manifest at the snapshot height,
+// the manifest must have been computed by GetStateManifest.
+func (c *chain) GetStateChunk(height uint64, index int) ([]interfaces.StateEntry, error) {
+	c.stateManifestMu.Lock()
+	manifest := c.stateManifest
+	c.stateManifestMu.Unlock()
+
+	if manifest == nil || manifest.Block.Height != height {
+		return nil, errors.New(fmt.Sprintf("state manifest %d is not computed", height))
+	}
+	if index < 0 || index >= len(manifest.Chunks) {
+		return nil, errors.New(fmt.Sprintf("state chunk index %d is out of range %d", index, len(manifest.Chunks)))
+	}
+
+	info := manifest.Chunks[index]
+	entries := make([]interfaces.StateEntry, 0, info.Count)
+	if err := c.iterateSnapshotState(height, info.StartKey, func(entry interfaces.StateEntry) bool {
+		entries = append(entries, entry)
+		return uint64(len(entries)) < info.Count
+	}); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// InsertStateChunk verifies the chunk against the manifest and writes it, the ledger must have only the genesis snapshot block.
+func (c *chain) InsertStateChunk(manifest *interfaces.StateManifest, index int, entries []interfaces.StateEntry) error {
+	if err := c.checkStateChunk(manifest, index, entries); err != nil {
+		return err
+	}
+
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+
+	height := manifest.Block.Height
+
+	stateEntries := make([]interfaces.StateEntry, 0, len(entries))
+	var blocks []*ledger.AccountBlock
+	var confirmHeights []uint64
+	for _, entry := range entries {
+		if entry.Key[0] != interfaces.StateBlockKeyPrefix {
+			stateEntries = append(stateEntries, entry)
+			continue
+		}
+
+		if len(entry.Value) <= 8 {
+			return errors.New(fmt.Sprintf("invalid state block entry %v", entry.Key))
+		}
+		confirmHeight := binary.BigEndian.Uint64(entry.Value[:8])
+		if confirmHeight > height {
+			return errors.New(fmt.Sprintf("state block is confirmed at %d, higher than %d", confirmHeight, height))
+		}
+
+		block := &ledger.AccountBlock{}
+		if err := block.Deserialize(entry.Value[8:]); err != nil {
+			return err
+		}
+		if !bytes.Equal(entry.Key, createStateBlockKey(block.AccountAddress, block.Height)) {
+			return errors.New(fmt.Sprintf("state block %s doesn't match the key %v", block.Hash, entry.Key))
+		}
+		if block.ComputeHash() != block.Hash {
+			return errors.New(fmt.Sprintf("state block hash %s is wrong", block.Hash))
+		}
+
+		blocks = append(blocks, block)
+		confirmHeights = append(confirmHeights, confirmHeight)
+	}
+
+	if len(stateEntries) > 0 {
+		if err := c.stateDB.WriteSnapshotState(height, stateEntries); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.stateDB.WriteSnapshotState failed, height is %d. Error: %s", height, err))
+			c.log.Error(cErr.Error(), "method", "InsertStateChunk")
+			return cErr
+		}
+	}
+
+	if len(blocks) > 0 {
+		locations, err := c.blockDB.WriteAccountBlocks(blocks)
+		if err != nil {
+			cErr := errors.New(fmt.Sprintf("c.blockDB.WriteAccountBlocks failed, height is %d. Error: %s", height, err))
+			c.log.Error(cErr.Error(), "method", "InsertStateChunk")
+			return cErr
+		}
+		if err := c.indexDB.InsertStateAccountBlocks(blocks, confirmHeights, locations); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.indexDB.InsertStateAccountBlocks failed, height is %d. Error: %s", height, err))
+			c.log.Error(cErr.Error(), "method", "InsertStateChunk")
+			return cErr
+		}
+	}
+
+	return nil
+}
+
+// InsertStateSnapshotBlock inserts the snapshot block of the manifest after all chunks are inserted,
+// the snapshot blocks and account blocks after it can be inserted then. The history is the snapshot blocks
+// before it in order, they are read by the random seed and the consensus, their account blocks are missing.
+func (c *chain) InsertStateSnapshotBlock(manifest *interfaces.StateManifest, history []*ledger.SnapshotBlock) error {
+	if err := c.checkStateManifest(manifest); err != nil {
+		return err
+	}
+	if err := c.checkStateHistory(manifest.Block, history); err != nil {
+		return err
+	}
+
+	c.flushMu.RLock()
+	snapshotBlock := manifest.Block
+
+	blocks := make([]*ledger.SnapshotBlock, 0, len(history)+1)
+	blocks = append(append(blocks, history...), snapshotBlock)
+	for _, block := range blocks {
+		_, location, err := c.blockDB.Write(&ledger.SnapshotChunk{SnapshotBlock: block})
+		if err != nil {
+			c.flushMu.RUnlock()
+			cErr := errors.New(fmt.Sprintf("c.blockDB.Write failed, snapshotBlock is %+v. Error: %s", block, err.Error()))
+			c.log.Error(cErr.Error(), "method", "InsertStateSnapshotBlock")
+			return cErr
+		}
+
+		c.indexDB.InsertStateSnapshotBlock(block, location)
+	}
+
+	c.cache.InsertSnapshotBlock(snapshotBlock, nil)
+
+	c.stateDB.InsertStateSnapshotBlock(snapshotBlock)
+	c.flushMu.RUnlock()
+
+	c.flusher.Flush()
+
+	c.log.Info(fmt.Sprintf("insert state snapshot %s %d with %d history blocks, root is %s", snapshotBlock.Hash, snapshotBlock.Height, len(history), manifest.Root))
+	return nil
+}
+
+// checkStateHistory checks the history blocks are linked to the snapshot block of the manifest,
+// and the genesis snapshot block if they start from it.
+func (c *chain) checkStateHistory(snapshotBlock *ledger.SnapshotBlock, history []*ledger.SnapshotBlock) error {
+	next := snapshotBlock
+	for i := len(history) - 1; i >= 0; i-- {
+		block := history[i]
+		if block == nil || block.Height != next.Height-1 || block.Hash != next.PrevHash {
+			return errors.New(fmt.Sprintf("state history block before %d is not linked", next.Height))
+		}
+		if block.ComputeHash() != block.Hash {
+			return errors.New(fmt.Sprintf("state history block %s hash is wrong", block.Hash))
+		}
+		next = block
+	}
+	if next.Height <= c.genesisSnapshotBlock.Height {
+		return errors.New(fmt.Sprintf("state history block %d is not higher than the genesis", next.Height))
+	}
+	if next.Height == c.genesisSnapshotBlock.Height+1 && next.PrevHash != c.genesisSnapshotBlock.Hash {
+		return errors.New("state history is not linked to the genesis snapshot block")
+	}
+	return nil
+}
+
+func (c *chain) checkStateManifest(manifest *interfaces.StateManifest) error {
+	if manifest == nil || manifest.Block == nil {
+		return errors.New("state manifest is nil")
+	}
+	if root := manifest.ComputeRoot(); root != manifest.Root {
+		return errors.New(fmt.Sprintf("state manifest root is %s, should be %s", manifest.Root, root))
+	}
+	if latest := c.GetLatestSnapshotBlock(); latest.Height != c.genesisSnapshotBlock.Height {
+		return errors.New(fmt.Sprintf("state snapshot can only be inserted into a new ledger, latest snapshot height is %d", latest.Height))
+	}
+	if manifest.Block.Height <= c.genesisSnapshotBlock.Height {
+		return errors.New(fmt.Sprintf("state snapshot height %d is too low", manifest.Block.Height))
+	}
+	return nil
+}
+
+func (c *chain) checkStateChunk(manifest *interfaces.StateManifest, index int, entries []interfaces.StateEntry) error {
+	if err := c.checkStateManifest(manifest); err != nil {
+		return err
+	}
+	if index < 0 || index >= len(manifest.Chunks) {
+		return errors.New(fmt.Sprintf("state chunk index %d is out of range %d", index, len(manifest.Chunks)))
+	}
+
+	info := manifest.Chunks[index]
+	if uint64(len(entries)) != info.Count {
+		return errors.New(fmt.Sprintf("state chunk %d has %d entries, should be %d", index, len(entries), info.Count))
+	}
+	if len(entries) <= 0 || !bytes.Equal(entries[0].Key, info.StartKey) {
+		return errors.New(fmt.Sprintf("state chunk %d start key is wrong", index))
+	}
+	for i, entry := range entries {
+		if len(entry.Key) <= 0 {
+			return errors.New(fmt.Sprintf("state chunk %d has empty key", index))
+		}
+		if i > 0 && bytes.Compare(entries[i-1].Key, entry.Key) >= 0 {
+			return errors.New(fmt.Sprintf("state chunk %d is not in order", index))
+		}
+	}
+	if hash := interfaces.ComputeStateChunkHash(entries); hash != info.Hash {
+		return errors.New(fmt.Sprintf("state chunk %d hash is %s, should be %s", index, hash, info.Hash))
+	}
+	return nil
+}
+
+// iterateSnapshotState iterates the state db at the snapshot height, then the account blocks needed by the blocks after it.
+func (c *chain) iterateSnapshotState(height uint64, start []byte, fn func(entry interfaces.StateEntry) bool) error {
+	isContractAvailable := func(addr types.Address) (bool, error) {
+		meta, err := c.stateDB.GetContractMeta(addr)
+		if err != nil {
+			return false, err
+		}
+		return c.isContractAvailableInSnapshot(meta, height)
+	}
+
+	stopped := false
+	if len(start) <= 0 || start[0] != interfaces.StateBlockKeyPrefix {
+		if err := c.stateDB.IterateSnapshotState(height, start, isContractAvailable, func(entry interfaces.StateEntry) bool {
+			if !fn(entry) {
+				stopped = true
+				return false
+			}
+			return true
+		}); err != nil {
+			return err
+		}
+		if stopped {
+			return nil
+		}
+		start = nil
+	}
+
+	return c.iterateStateBlocks(height, start, fn)
+}
+
+// iterateStateBlocks iterates the latest account block of every account confirmed at the snapshot height,
+// and the blocks creating the contracts, which are needed to verify the blocks after the snapshot block.
+func (c *chain) iterateStateBlocks(height uint64, start []byte, fn func(entry interfaces.StateEntry) bool) error {
+	var startAddr *types.Address
+	if len(start) > 0 {
+		if len(start) != 1+types.AddressSize+8 {
+			return errors.New(fmt.Sprintf("invalid state block key %v", start))
+		}
+		addr, err := types.BytesToAddress(start[1 : 1+types.AddressSize])
+		if err != nil {
+			return err
+		}
+		startAddr = &addr
+	}
+
+	// the heights of the blocks creating contracts
+	createHeights := make(map[types.Address][]uint64)
+	var iterErr error
+	c.stateDB.IterateContracts(func(addr types.Address, meta *ledger.ContractMeta, err error) bool {
+		if err != nil {
+			iterErr = err
+			return false
+		}
+		if available, err := c.isContractAvailableInSnapshot(meta, height); err != nil {
+			iterErr = err
+			return false
+		} else if !available || meta.CreateBlockHash.IsZero() {
+			return true
+		}
+		creator, createHeight, err := c.indexDB.GetAddrHeightByHash(&meta.CreateBlockHash)
+		if err != nil {
+			iterErr = err
+			return false
+		}
+		if creator != nil {
+			createHeights[*creator] = append(createHeights[*creator], createHeight)
+		}
+		return true
+	})
+	if iterErr != nil {
+		return iterErr
+	}
+
+	if err := c.indexDB.IterateAccountsFrom(startAddr, func(addr types.Address) bool {
+		latestHeight, err := c.indexDB.GetConfirmedAccountHeight(&addr, height)
+		if err != nil {
+			iterErr = err
+			return false
+		}
+		if latestHeight <= 0 {
+			return true
+		}
+
+		heights := append([]uint64{latestHeight}, createHeights[addr]...)
+		sort.Slice(heights, func(i, j int) bool { return heights[i] < heights[j] })
+
+		for i, abHeight := range heights {
+			if i > 0 && heights[i-1] == abHeight {
+				continue
+			}
+			key := createStateBlockKey(addr, abHeight)
+			if len(start) > 0 && bytes.Compare(key, start) < 0 {
+				continue
+			}
+
+			value, err := c.getStateBlockValue(addr, abHeight)
+			if err != nil {
+				iterErr = err
+				return false
+			}
+			if !fn(interfaces.StateEntry{Key: key, Value: value}) {
+				return false
+			}
+		}
+		return true
+	}); err != nil {
+		return err
+	}
+	return iterErr
+}
+
+func (c *chain) getStateBlockValue(addr types.Address, height uint64) ([]byte, error) {
+	block, err := c.GetAccountBlockByHeight(addr, height)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New(fmt.Sprintf("account block %s %d is not existed", addr, height))
+	}
+	confirmHeight, err := c.indexDB.GetConfirmHeightByHash(&block.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := block.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, 8, 8+len(buf))
+	binary.BigEndian.PutUint64(value, confirmHeight)
+	return append(value, buf...), nil
+}
+
+func (c *chain) isContractAvailableInSnapshot(meta *ledger.ContractMeta, height uint64) (bool, error) {
+	// the contracts created in genesis
+	if meta == nil || meta.CreateBlockHash.IsZero() {
+		return true, nil
+	}
+	confirmHeight, err := c.indexDB.GetConfirmHeightByHash(&meta.CreateBlockHash)
+	if err != nil {
+		return false, err
+	}
+	return confirmHeight > 0 && confirmHeight <= height, nil
+}
+
+func createStateBlockKey(addr types.Address, height uint64) []byte {
+	key := make([]byte, 1+types.AddressSize+8)
+	key[0] = interfaces.StateBlockKeyPrefix
+	copy(key[1:1+types.AddressSize], addr.Bytes())
+	binary.BigEndian.PutUint64(key[1+types.AddressSize:], height)
+	return key
+}
@@ -0,0 +1,360 @@
+package sync_cache
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/golang/snappy"
+	"github.com/vitelabs/go-vite/chain/block"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// An export file is a versioned variant of the cache segment:
+//
+//	header:  magic(7) | version(1) | from(8) | to(8) | prevHash(32) | hash(32)
+//	records: size(4) | code(1) | snappy(block), the same as the cache segment
+//	trailer: size(4) = 0 | sha256 of header and records(32)
+//
+// Records are the account blocks of a snapshot chunk followed by its snapshot block.
+const (
+	ExportFileVersion = byte(1)
+	ExportFileSuffix  = ".vledger"
+
+	exportFileMagic      = "VLEDGER"
+	exportFileHeaderSize = len(exportFileMagic) + 1 + 8 + 8 + types.HashSize + types.HashSize
+
+	// maxExportRecordSize bounds a record before and after decompression, it is far larger than any block,
+	// so that a corrupted or crafted size can't make the reader allocate without limit.
+	maxExportRecordSize = 16 * 1024 * 1024
+)
+
+var errExportFileChecksum = errors.New("export file checksum mismatch")
+
+// ExportFileName returns the name of an export file, which can be parsed back by newSegmentByFilename.
+func ExportFileName(segment interfaces.Segment) string {
+	return filePrefix + strconv.FormatUint(segment.From, 10) + "_" + segment.PrevHash.String() + "_" +
+		strconv.FormatUint(segment.To, 10) + "_" + segment.Hash.String() + ExportFileSuffix
+}
+
+// SegmentByExportFileName parses the segment from the name of an export file.
+func SegmentByExportFileName(filename string) (interfaces.Segment, error) {
+	return newSegmentByFilename(path.Base(filename))
+}
+
+// ExportWriter writes snapshot chunks of segment [From, To] into an export file.
+type ExportWriter struct {
+	fd      *os.File
+	w       *bufio.Writer
+	hasher  hash.Hash
+	segment interfaces.Segment
+
+	buf        []byte
+	nextHeight uint64
+	prevHash   types.Hash
+}
+
+func NewExportWriter(filename string, segment interfaces.Segment) (*ExportWriter, error) {
+	if segment.From == 0 || segment.From > segment.To {
+		return nil, fmt.Errorf("invalid segment %d-%d", segment.From, segment.To)
+	}
+	fd, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export file %s: %v", filename, err)
+	}
+
+	w := &ExportWriter{
+		fd:         fd,
+		w:          bufio.NewWriter(fd),
+		hasher:     sha256.New(),
+		segment:    segment,
+		nextHeight: segment.From,
+		prevHash:   segment.PrevHash,
+	}
+
+	header := make([]byte, 0, exportFileHeaderSize)
+	header = append(header, exportFileMagic...)
+	header = append(header, ExportFileVersion)
+	header = appendUint64(header, segment.From)
+	header = appendUint64(header, segment.To)
+	header = append(header, segment.PrevHash.Bytes()...)
+	header = append(header, segment.Hash.Bytes()...)
+	if err = w.write(header); err != nil {
+		fd.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// WriteChunk writes the account blocks and the snapshot block of chunk, chunks must be written in height order.
+func (w *ExportWriter) WriteChunk(chunk *ledger.SnapshotChunk) error {
+	sb := chunk.SnapshotBlock
+	if sb == nil {
+		return errors.New("snapshot block of chunk is nil")
+	}
+	if sb.Height != w.nextHeight || sb.PrevHash != w.prevHash {
+		return fmt.Errorf("snapshot block %d %s is not continuous, expect height %d prevHash %s", sb.Height, sb.Hash, w.nextHeight, w.prevHash)
+	}
+
+	for _, ab := range chunk.AccountBlocks {
+		data, err := ab.Serialize()
+		if err != nil {
+			return err
+		}
+		if err = w.writeRecord(chain_block.BlockTypeAccountBlock, data); err != nil {
+			return err
+		}
+	}
+	data, err := sb.Serialize()
+	if err != nil {
+		return err
+	}
+	if err = w.writeRecord(chain_block.BlockTypeSnapshotBlock, data); err != nil {
+		return err
+	}
+
+	w.nextHeight++
+	w.prevHash = sb.Hash
+	return nil
+}
+
+// Close writes the checksum and closes the file. All chunks of the segment must have been written.
+func (w *ExportWriter) Close() error {
+	defer w.fd.Close()
+
+	if w.nextHeight != w.segment.To+1 || w.prevHash != w.segment.Hash {
+		return fmt.Errorf("export file is incomplete, written to %d, expect %d", w.nextHeight-1, w.segment.To)
+	}
+	trailer := make([]byte, 4, 4+sha256.Size)
+	trailer = append(trailer, w.hasher.Sum(nil)...)
+	if _, err := w.w.Write(trailer); err != nil {
+		return err
+	}
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return w.fd.Sync()
+}
+
+func (w *ExportWriter) writeRecord(code byte, data []byte) error {
+	if len(data) > maxExportRecordSize {
+		return fmt.Errorf("block size %d exceeds the limit %d", len(data), maxExportRecordSize)
+	}
+	size := 5 + snappy.MaxEncodedLen(len(data))
+	if cap(w.buf) < size {
+		w.buf = make([]byte, size)
+	}
+	buf := w.buf[:size]
+	buf[4] = code
+	sBuf := snappy.Encode(buf[5:], data)
+	if len(sBuf) > maxExportRecordSize {
+		return fmt.Errorf("record size %d exceeds the limit %d", len(sBuf), maxExportRecordSize)
+	}
+	binary.BigEndian.PutUint32(buf, uint32(len(sBuf)+1))
+	return w.write(buf[:5+len(sBuf)])
+}
+
+func (w *ExportWriter) write(p []byte) error {
+	w.hasher.Write(p)
+	_, err := w.w.Write(p)
+	return err
+}
+
+// ExportReader reads snapshot chunks from an export file, and checks the blocks hash,
+// the continuity of snapshot blocks and the checksum of the file.
+type ExportReader struct {
+	fd      *os.File
+	r       *bufio.Reader
+	hasher  hash.Hash
+	segment interfaces.Segment
+
+	readBuffer   []byte
+	decodeBuffer []byte
+	nextHeight   uint64
+	prevHash     types.Hash
+}
+
+func NewExportReader(filename string) (*ExportReader, error) {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file %s: %v", filename, err)
+	}
+	r := &ExportReader{
+		fd:     fd,
+		r:      bufio.NewReader(fd),
+		hasher: sha256.New(),
+	}
+
+	header := make([]byte, exportFileHeaderSize)
+	if err = r.read(header); err != nil {
+		fd.Close()
+		return nil, fmt.Errorf("failed to read header of export file %s: %v", filename, err)
+	}
+	if !bytes.Equal(header[:len(exportFileMagic)], []byte(exportFileMagic)) {
+		fd.Close()
+		return nil, fmt.Errorf("%s is not an export file", filename)
+	}
+	header = header[len(exportFileMagic):]
+	if header[0] != ExportFileVersion {
+		fd.Close()
+		return nil, fmt.Errorf("unsupported export file version %d", header[0])
+	}
+	header = header[1:]
+	r.segment.From = binary.BigEndian.Uint64(header[:8])
+	r.segment.To = binary.BigEndian.Uint64(header[8:16])
+	r.segment.PrevHash, _ = types.BytesToHash(header[16 : 16+types.HashSize])
+	r.segment.Hash, _ = types.BytesToHash(header[16+types.HashSize:])
+
+	r.nextHeight = r.segment.From
+	r.prevHash = r.segment.PrevHash
+	return r, nil
+}
+
+// Segment returns the snapshot block range declared by the header.
+func (r *ExportReader) Segment() interfaces.Segment {
+	return r.segment
+}
+
+// ReadChunk returns the next snapshot chunk, or io.EOF after the last chunk is read and the checksum is verified.
+func (r *ExportReader) ReadChunk() (*ledger.SnapshotChunk, error) {
+	chunk := &ledger.SnapshotChunk{}
+	for {
+		// the size is hashed only if it's not the trailer
+		sizeBuf := make([]byte, 4)
+		if _, err := io.ReadFull(r.r, sizeBuf); err != nil {
+			if err == io.EOF {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		size := binary.BigEndian.Uint32(sizeBuf)
+		if size == 0 {
+			if len(chunk.AccountBlocks) > 0 {
+				return nil, errors.New("account blocks are not followed by a snapshot block")
+			}
+			return nil, r.readTrailer()
+		}
+		if size > maxExportRecordSize+1 {
+			return nil, fmt.Errorf("record size %d exceeds the limit %d", size, maxExportRecordSize)
+		}
+		r.hasher.Write(sizeBuf)
+
+		if cap(r.readBuffer) < int(size) {
+			r.readBuffer = make([]byte, size)
+		}
+		buf := r.readBuffer[:size]
+		if err := r.read(buf); err != nil {
+			return nil, err
+		}
+
+		if size < 2 {
+			return nil, fmt.Errorf("record size %d is too small", size)
+		}
+		decodeLen, err := snappy.DecodedLen(buf[1:])
+		if err != nil {
+			return nil, err
+		}
+		if decodeLen > maxExportRecordSize {
+			return nil, fmt.Errorf("decoded record size %d exceeds the limit %d", decodeLen, maxExportRecordSize)
+		}
+		if cap(r.decodeBuffer) < decodeLen {
+			r.decodeBuffer = make([]byte, decodeLen)
+		}
+		sBuf, err := snappy.Decode(r.decodeBuffer, buf[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch buf[0] {
+		case chain_block.BlockTypeAccountBlock:
+			ab := &ledger.AccountBlock{}
+			if err = ab.Deserialize(sBuf); err != nil {
+				return nil, err
+			}
+			if ab.ComputeHash() != ab.Hash {
+				return nil, fmt.Errorf("account block %s hash is invalid", ab.Hash)
+			}
+			chunk.AccountBlocks = append(chunk.AccountBlocks, ab)
+		case chain_block.BlockTypeSnapshotBlock:
+			sb := &ledger.SnapshotBlock{}
+			if err = sb.Deserialize(sBuf); err != nil {
+				return nil, err
+			}
+			if sb.ComputeHash() != sb.Hash {
+				return nil, fmt.Errorf("snapshot block %s hash is invalid", sb.Hash)
+			}
+			if sb.Height != r.nextHeight || sb.PrevHash != r.prevHash || sb.Height > r.segment.To {
+				return nil, fmt.Errorf("snapshot block %d %s is not continuous, expect height %d prevHash %s", sb.Height, sb.Hash, r.nextHeight, r.prevHash)
+			}
+			r.nextHeight++
+			r.prevHash = sb.Hash
+			chunk.SnapshotBlock = sb
+			return chunk, nil
+		default:
+			return nil, fmt.Errorf("unknown block type %d", buf[0])
+		}
+	}
+}
+
+func (r *ExportReader) Close() error {
+	return r.fd.Close()
+}
+
+func (r *ExportReader) readTrailer() error {
+	sum := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(r.r, sum); err != nil {
+		return err
+	}
+	if !bytes.Equal(sum, r.hasher.Sum(nil)) {
+		return errExportFileChecksum
+	}
+	if r.nextHeight != r.segment.To+1 || r.prevHash != r.segment.Hash {
+		return fmt.Errorf("export file is incomplete, read to %d, expect %d", r.nextHeight-1, r.segment.To)
+	}
+	return io.EOF
+}
+
+func (r *ExportReader) read(p []byte) error {
+	if _, err := io.ReadFull(r.r, p); err != nil {
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	r.hasher.Write(p)
+	return nil
+}
+
+// VerifyExportFile reads through an export file and returns its segment if the file is intact.
+func VerifyExportFile(filename string) (interfaces.Segment, error) {
+	r, err := NewExportReader(filename)
+	if err != nil {
+		return interfaces.Segment{}, err
+	}
+	defer r.Close()
+
+	for {
+		if _, err = r.ReadChunk(); err != nil {
+			if err == io.EOF {
+				return r.Segment(), nil
+			}
+			return interfaces.Segment{}, fmt.Errorf("failed to verify export file %s: %v", filename, err)
+		}
+	}
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], n)
+	return append(buf, b[:]...)
+}
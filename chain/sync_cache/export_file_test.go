@@ -0,0 +1,201 @@
+package sync_cache
+
+import (
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func init() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 1, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 2, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 3, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 4, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 5, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 6, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 7, Version: 7},
+	})
+}
+
+func mockExportChunks(prevHash types.Hash, from, to uint64) []*ledger.SnapshotChunk {
+	var chunks []*ledger.SnapshotChunk
+	for h := from; h <= to; h++ {
+		now := time.Unix(int64(h), 0)
+		chunk := &ledger.SnapshotChunk{}
+		for i := 0; i < int(h%3); i++ {
+			ab := &ledger.AccountBlock{
+				BlockType:      ledger.BlockTypeSendCall,
+				AccountAddress: types.Address{byte(i)},
+				ToAddress:      types.Address{byte(i + 1)},
+				Height:         h,
+				Amount:         big.NewInt(int64(h)),
+				Fee:            big.NewInt(0),
+				TokenId:        ledger.ViteTokenId,
+			}
+			ab.Hash = ab.ComputeHash()
+			chunk.AccountBlocks = append(chunk.AccountBlocks, ab)
+		}
+		sb := &ledger.SnapshotBlock{
+			Height:    h,
+			PrevHash:  prevHash,
+			Timestamp: &now,
+		}
+		sb.Hash = sb.ComputeHash()
+		prevHash = sb.Hash
+		chunk.SnapshotBlock = sb
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+func writeExportFile(t *testing.T, dir string, chunks []*ledger.SnapshotChunk) string {
+	first, last := chunks[0].SnapshotBlock, chunks[len(chunks)-1].SnapshotBlock
+	segment := interfaces.Segment{From: first.Height, To: last.Height, PrevHash: first.PrevHash, Hash: last.Hash}
+	filename := path.Join(dir, ExportFileName(segment))
+	w, err := NewExportWriter(filename, segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if err = w.WriteChunk(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestExportFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	chunks := mockExportChunks(types.Hash{1}, 10, 20)
+	filename := writeExportFile(t, dir, chunks)
+
+	seg, err := SegmentByExportFileName(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seg.From != 10 || seg.To != 20 || seg.PrevHash != (types.Hash{1}) || seg.Hash != chunks[10].SnapshotBlock.Hash {
+		t.Fatalf("wrong segment from filename: %v", seg)
+	}
+	if seg, err = VerifyExportFile(filename); err != nil {
+		t.Fatal(err)
+	}
+	if seg.From != 10 || seg.To != 20 {
+		t.Fatalf("wrong segment from header: %d-%d", seg.From, seg.To)
+	}
+
+	r, err := NewExportReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for i := 0; ; i++ {
+		chunk, err := r.ReadChunk()
+		if err == io.EOF {
+			if i != len(chunks) {
+				t.Fatalf("read %d chunks, expect %d", i, len(chunks))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if chunk.SnapshotBlock.Hash != chunks[i].SnapshotBlock.Hash || len(chunk.AccountBlocks) != len(chunks[i].AccountBlocks) {
+			t.Fatalf("chunk %d is different", i)
+		}
+		for j, ab := range chunk.AccountBlocks {
+			if ab.Hash != chunks[i].AccountBlocks[j].Hash {
+				t.Fatalf("account block %d of chunk %d is different", j, i)
+			}
+		}
+	}
+}
+
+func TestExportFileCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := writeExportFile(t, dir, mockExportChunks(types.Hash{}, 1, 5))
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flip a byte of the checksum
+	data[len(data)-1] ^= 0xff
+	if err = ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = VerifyExportFile(filename); err == nil {
+		t.Fatal("corrupted file should not pass verification")
+	}
+
+	// truncated file
+	if err = ioutil.WriteFile(filename, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = VerifyExportFile(filename); err == nil {
+		t.Fatal("truncated file should not pass verification")
+	}
+
+	// chunks must be continuous
+	chunks := mockExportChunks(types.Hash{}, 1, 5)
+	seg := interfaces.Segment{From: 1, To: 5, Hash: chunks[4].SnapshotBlock.Hash}
+	w, err := NewExportWriter(path.Join(dir, "gap"), seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if err = w.WriteChunk(chunks[1]); err == nil {
+		t.Fatal("gap should be rejected")
+	}
+}
+
+func TestExportFileRecordSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "export_file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := writeExportFile(t, dir, mockExportChunks(types.Hash{}, 1, 5))
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the size of the first record is beyond the limit
+	data[exportFileHeaderSize] = 0xff
+	if err = ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewExportReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err = r.ReadChunk(); err == nil || !strings.Contains(err.Error(), "exceeds the limit") {
+		t.Fatalf("oversized record should be rejected, error: %v", err)
+	}
+}
@@ -0,0 +1,418 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vitelabs/go-vite/client"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/rpcapi/api/filters"
+	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+var (
+	errNoSigner       = errors.New("no signer to sign the block")
+	errNoCode         = errors.New("no code to deploy the contract")
+	errNoOffchainCode = errors.New("no offchain code to call the getter")
+)
+
+// SignerFn signs the block built for the contract through the client
+type SignerFn func(cli client.Client, block *api.AccountBlock) error
+
+// TransactOpts is the collection of data required to create a valid call block
+type TransactOpts struct {
+	From    types.Address
+	Amount  *big.Int           // amount sent to the contract, nil means 0
+	TokenId types.TokenTypeId  // token of the amount, empty means vite token
+	Fee     *big.Int           // fee of deploying a contract, nil means the default fee
+	Prev    *ledger.HashHeight // previous block of From, nil means the latest block
+	Signer  SignerFn
+	NoSend  bool // only build and sign the block, the caller sends it by itself
+}
+
+// NewKeyedTransactor creates the transact options signing with the private key
+func NewKeyedTransactor(key *derivation.Key) (*TransactOpts, error) {
+	addr, err := key.Address()
+	if err != nil {
+		return nil, err
+	}
+	return &TransactOpts{
+		From: *addr,
+		Signer: func(cli client.Client, block *api.AccountBlock) error {
+			return cli.SignDataWithPriKey(key, block)
+		},
+	}, nil
+}
+
+// NewWalletTransactor creates the transact options signing with the unlocked wallet
+func NewWalletTransactor(wallet *entropystore.Manager, addr types.Address) *TransactOpts {
+	return &TransactOpts{
+		From: addr,
+		Signer: func(cli client.Client, block *api.AccountBlock) error {
+			return cli.SignData(wallet, block)
+		},
+	}
+}
+
+// CallOpts is the collection of options to call an offchain getter, nil means the latest state
+type CallOpts struct {
+	Height       *uint64     // the account block height of the contract
+	SnapshotHash *types.Hash // the snapshot block to read the state at
+}
+
+// FilterOpts is the account block height range of the contract to filter logs,
+// zero FromHeight means the first block and zero ToHeight means the latest block
+type FilterOpts struct {
+	FromHeight uint64
+	ToHeight   uint64
+}
+
+// WatchOpts is the checkpoint to resume the log subscription from, logs after it are replayed first.
+// Only one of FromSnapshotHeight and FromAccountBlockHash can be set, nil means only watching new logs.
+type WatchOpts struct {
+	Context              context.Context // context to set up the subscription
+	FromSnapshotHeight   uint64
+	FromAccountBlockHash *types.Hash
+}
+
+// Log is a vm log of the contract with the account block it's in
+type Log struct {
+	*ledger.VmLog
+	AccountBlockHash   types.Hash
+	AccountBlockHeight uint64
+	Removed            bool // the log is reverted by a chain fork, only set by watching
+}
+
+// Subscription is a subscription of contract logs, Err is closed after Unsubscribe
+type Subscription interface {
+	Unsubscribe()
+	Err() <-chan error
+}
+
+// BoundContract is the base wrapper of a deployed contract, the generated bindings are built on it
+type BoundContract struct {
+	address      types.Address
+	abi          abi.ABIContract
+	offchainCode string
+	rpc          client.RpcClient
+	client       client.Client
+}
+
+// NewBoundContract creates a wrapper of the contract at address,
+// offchainCode is the hex code to call the getters, it can be empty if there's no getter.
+func NewBoundContract(address types.Address, abiContract abi.ABIContract, offchainCode string, rpc client.RpcClient) (*BoundContract, error) {
+	cli, err := client.NewClient(rpc)
+	if err != nil {
+		return nil, err
+	}
+	return &BoundContract{
+		address:      address,
+		abi:          abiContract,
+		offchainCode: offchainCode,
+		rpc:          rpc,
+		client:       cli,
+	}, nil
+}
+
+// DeployContract builds, signs and sends a create contract block of the hex code,
+// params are the arguments of the constructor, meta.HexCode and meta.Params are filled by it.
+func DeployContract(opts *TransactOpts, abiStr string, code string, offchainCode string, meta api.CreateContractDataParam, rpc client.RpcClient, params ...interface{}) (types.Address, *api.AccountBlock, *BoundContract, error) {
+	if len(code) == 0 {
+		return types.Address{}, nil, nil, errNoCode
+	}
+	abiContract, err := abi.JSONToABIContract(strings.NewReader(abiStr))
+	if err != nil {
+		return types.Address{}, nil, nil, err
+	}
+	c, err := NewBoundContract(types.Address{}, abiContract, offchainCode, rpc)
+	if err != nil {
+		return types.Address{}, nil, nil, err
+	}
+
+	meta.HexCode = code
+	block, err := c.client.BuildRequestCreateContractBlock(client.RequestCreateContractParams{
+		SelfAddr:   opts.From,
+		Fee:        opts.Fee,
+		Arguments:  params,
+		AbiStr:     abiStr,
+		MetaParams: meta,
+	}, opts.Prev)
+	if err != nil {
+		return types.Address{}, nil, nil, err
+	}
+	if err := c.signAndSend(opts, block); err != nil {
+		return types.Address{}, nil, nil, err
+	}
+
+	c.address = block.ToAddress
+	return c.address, block, c, nil
+}
+
+// Address returns the address of the contract
+func (c *BoundContract) Address() types.Address {
+	return c.address
+}
+
+// Transact builds, signs and sends a call block of the method with params
+func (c *BoundContract) Transact(opts *TransactOpts, method string, params ...interface{}) (*api.AccountBlock, error) {
+	data, err := c.abi.PackMethod(method, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	amount := opts.Amount
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	tokenId := opts.TokenId
+	if tokenId == (types.TokenTypeId{}) {
+		tokenId = ledger.ViteTokenId
+	}
+
+	block, err := c.client.BuildNormalRequestBlock(client.RequestTxParams{
+		ToAddr:   c.address,
+		SelfAddr: opts.From,
+		Amount:   amount,
+		TokenId:  tokenId,
+		Data:     data,
+	}, opts.Prev)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.signAndSend(opts, block); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+func (c *BoundContract) signAndSend(opts *TransactOpts, block *api.AccountBlock) error {
+	if opts.Signer == nil {
+		return errNoSigner
+	}
+	if err := opts.Signer(c.client, block); err != nil {
+		return err
+	}
+	if opts.NoSend {
+		return nil
+	}
+	return c.rpc.SendRawTx(block)
+}
+
+// Call calls the offchain getter with params, and assigns the outputs to results, which are pointers
+func (c *BoundContract) Call(opts *CallOpts, results []interface{}, method string, params ...interface{}) error {
+	if len(c.offchainCode) == 0 {
+		return errNoOffchainCode
+	}
+	data, err := c.abi.PackOffChain(method, params...)
+	if err != nil {
+		return err
+	}
+
+	addr := c.address
+	param := api.CallOffChainMethodParam{
+		Addr:         &addr,
+		OffChainCode: c.offchainCode,
+		Data:         data,
+	}
+	if opts != nil {
+		param.Height = opts.Height
+		param.SnapshotHash = opts.SnapshotHash
+	}
+	output, err := c.rpc.CallOffChainMethod(param)
+	if err != nil {
+		return err
+	}
+
+	values, err := c.abi.DirectUnpackOffchainOutput(method, output)
+	if err != nil {
+		return err
+	}
+	if len(values) != len(results) {
+		return fmt.Errorf("%s returns %d values, but %d results are given", method, len(values), len(results))
+	}
+	for i, v := range values {
+		if err := abi.Assign(results[i], v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FilterLogs gets the logs of the event, query are the values of the indexed inputs in order, see abi.Event.MakeTopics
+func (c *BoundContract) FilterLogs(opts *FilterOpts, event string, query ...[]interface{}) ([]*Log, error) {
+	topics, err := c.makeTopics(event, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &api.Range{}
+	if opts != nil {
+		r.FromHeight = strconv.FormatUint(opts.FromHeight, 10)
+		r.ToHeight = strconv.FormatUint(opts.ToHeight, 10)
+	}
+	list, err := c.rpc.GetVmLogsByFilter(api.VmLogFilterParam{
+		AddrRange: map[string]*api.Range{c.address.String(): r},
+		Topics:    topics,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logs := make([]*Log, 0, len(list))
+	for _, l := range list {
+		height, err := strconv.ParseUint(l.AccountHeight, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, &Log{VmLog: l.Log, AccountBlockHash: l.AccountBlockHash, AccountBlockHeight: height})
+	}
+	return logs, nil
+}
+
+// WatchLogs subscribes the logs of the event, handle is called in order for every log,
+// quit is closed when the subscription is ending, the subscription ends with the error handle returned.
+func (c *BoundContract) WatchLogs(opts *WatchOpts, event string, handle func(log *Log, quit <-chan struct{}) error, query ...[]interface{}) (Subscription, error) {
+	topics, err := c.makeTopics(event, query)
+	if err != nil {
+		return nil, err
+	}
+
+	param := filters.VmLogSubscriptionParam{
+		VmLogFilterParam: api.VmLogFilterParam{
+			AddrRange: map[string]*api.Range{c.address.String(): {}},
+			Topics:    topics,
+		},
+	}
+	ctx := context.Background()
+	if opts != nil {
+		if opts.Context != nil {
+			ctx = opts.Context
+		}
+		if opts.FromSnapshotHeight > 0 {
+			param.FromSnapshotHeight = strconv.FormatUint(opts.FromSnapshotHeight, 10)
+		}
+		param.FromAccountBlockHash = opts.FromAccountBlockHash
+	}
+
+	ch := make(chan []*filters.LogsV2, 128)
+	sub, err := c.rpc.CreateVmLogSubscription(ctx, param, ch)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &logSubscription{
+		sub:  sub,
+		quit: make(chan struct{}),
+		err:  make(chan error, 1),
+	}
+	go s.loop(ch, handle)
+	return s, nil
+}
+
+func (c *BoundContract) makeTopics(event string, query [][]interface{}) ([][]types.Hash, error) {
+	e, ok := c.abi.Events[event]
+	if !ok {
+		return nil, fmt.Errorf("event %s not found", event)
+	}
+	return e.MakeTopics(query...)
+}
+
+// UnpackLog unpacks the log of the event into out, which is a pointer to the generated event struct
+func (c *BoundContract) UnpackLog(out interface{}, event string, log *Log) error {
+	e, ok := c.abi.Events[event]
+	if !ok {
+		return fmt.Errorf("event %s not found", event)
+	}
+	if len(log.Topics) == 0 || log.Topics[0] != e.Id() {
+		return fmt.Errorf("log is not the event %s", event)
+	}
+	if len(log.Topics) != len(e.IndexedInputs)+1 {
+		return fmt.Errorf("event %s has %d indexed inputs, but the log has %d topics", event, len(e.IndexedInputs), len(log.Topics))
+	}
+
+	values, err := e.DirectUnPack(log.Topics, log.Data)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("can not unpack log into %T", out)
+	}
+	value = value.Elem()
+	for i, input := range e.Inputs {
+		name := fieldName(input.Name, i)
+		field := value.FieldByName(name)
+		if !field.IsValid() {
+			return fmt.Errorf("field %s not found in %T", name, out)
+		}
+		if err := abi.Assign(field.Addr().Interface(), values[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type logSubscription struct {
+	sub  *rpc.ClientSubscription
+	once sync.Once
+	quit chan struct{}
+	err  chan error
+}
+
+func (s *logSubscription) loop(ch <-chan []*filters.LogsV2, handle func(log *Log, quit <-chan struct{}) error) {
+	defer close(s.err)
+	defer s.sub.Unsubscribe()
+
+	for {
+		select {
+		case list := <-ch:
+			for _, l := range list {
+				height, err := strconv.ParseUint(l.AccountHeight, 10, 64)
+				if err == nil {
+					err = handle(&Log{
+						VmLog:              l.Log,
+						AccountBlockHash:   l.AccountBlockHash,
+						AccountBlockHeight: height,
+						Removed:            l.Removed,
+					}, s.quit)
+				}
+				if err != nil {
+					s.err <- err
+					return
+				}
+			}
+		case err := <-s.sub.Err():
+			if err != nil {
+				s.err <- err
+			}
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *logSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.quit)
+	})
+	// wait for the loop to exit
+	for range s.err {
+	}
+}
+
+func (s *logSubscription) Err() <-chan error {
+	return s.err
+}
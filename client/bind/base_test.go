@@ -0,0 +1,347 @@
+package bind
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/client"
+	rpc2 "github.com/vitelabs/go-vite/client/rpc"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/rpcapi/api/filters"
+	"github.com/vitelabs/go-vite/vm/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+)
+
+type testRpc struct {
+	client.RpcClient
+
+	sent      []*api.AccountBlock
+	offchain  api.CallOffChainMethodParam
+	output    []byte
+	filter    api.VmLogFilterParam
+	logs      []*api.Logs
+	subscribe rpc2.SubscribeApi
+}
+
+func (r *testRpc) GetLatestBlock(addr types.Address) (*api.AccountBlock, error) {
+	return &api.AccountBlock{}, nil
+}
+
+func (r *testRpc) SendRawTx(block *api.AccountBlock) error {
+	r.sent = append(r.sent, block)
+	return nil
+}
+
+func (r *testRpc) GetCreateContractData(param api.CreateContractDataParam) ([]byte, error) {
+	return append([]byte(param.HexCode), param.Params...), nil
+}
+
+func (r *testRpc) CallOffChainMethod(param api.CallOffChainMethodParam) ([]byte, error) {
+	r.offchain = param
+	return r.output, nil
+}
+
+func (r *testRpc) GetVmLogsByFilter(param api.VmLogFilterParam) ([]*api.Logs, error) {
+	r.filter = param
+	return r.logs, nil
+}
+
+func (r *testRpc) CreateVmLogSubscription(ctx context.Context, param filters.VmLogSubscriptionParam, ch chan<- []*filters.LogsV2) (*rpc.ClientSubscription, error) {
+	return r.subscribe.CreateVmLogSubscription(ctx, param, ch)
+}
+
+type testItem struct {
+	Name    string
+	Amounts [2]*big.Int
+}
+
+type testOrder struct {
+	Id    uint64
+	Owner types.Address
+	Items []testItem
+}
+
+type testOrderPlaced struct {
+	Order types.Hash
+	Memo  types.Hash
+	Data  [32]byte
+}
+
+var testAddr, _ = types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+
+func newTestContract(t *testing.T, name string, offchainCode string) (*BoundContract, abi.ABIContract, *testRpc) {
+	abiContract, err := abi.JSONToABIContract(strings.NewReader(readTestData(t, name+".abi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(testRpc)
+	c, err := NewBoundContract(testAddr, abiContract, offchainCode, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, abiContract, r
+}
+
+func newTestTransactor(t *testing.T) *TransactOpts {
+	key, err := derivation.NewMasterKey(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts, err := NewKeyedTransactor(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func TestBoundContract_Transact(t *testing.T) {
+	c, abiContract, r := newTestContract(t, "token", "")
+	opts := newTestTransactor(t)
+
+	block, err := c.Transact(opts, "transfer", testAddr, big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := abiContract.PackMethod("transfer", testAddr, big.NewInt(100))
+	if len(r.sent) != 1 || r.sent[0] != block {
+		t.Fatal("block is not sent")
+	}
+	if block.BlockType != ledger.BlockTypeSendCall || block.ToAddress != testAddr || block.AccountAddress != opts.From ||
+		block.TokenId != ledger.ViteTokenId || *block.Amount != "0" || !bytes.Equal(block.Data, data) {
+		t.Fatalf("wrong block: %+v", block)
+	}
+	if !ed25519.Verify(block.PublicKey, block.Hash.Bytes(), block.Signature) {
+		t.Fatal("block is not signed")
+	}
+
+	opts.NoSend = true
+	opts.Amount = big.NewInt(1)
+	if block, err = c.Transact(opts, "burn", big.NewInt(1)); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.sent) != 1 || *block.Amount != "1" || block.Signature == nil {
+		t.Fatalf("wrong no send block: %+v", block)
+	}
+
+	opts.Signer = nil
+	if _, err := c.Transact(opts, "burn", big.NewInt(1)); err != errNoSigner {
+		t.Fatalf("want errNoSigner, got %v", err)
+	}
+	if _, err := c.Transact(newTestTransactor(t), "burn", "1"); err == nil {
+		t.Fatal("wrong argument type should fail")
+	}
+}
+
+func TestDeployContract(t *testing.T) {
+	opts := newTestTransactor(t)
+	r := new(testRpc)
+	abiStr := readTestData(t, "token.abi")
+
+	addr, block, c, err := DeployContract(opts, abiStr, "6080", "60", api.CreateContractDataParam{Gid: types.DELEGATE_GID}, r, "vite", big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != util.NewContractAddress(opts.From, 1, types.Hash{}) || c.Address() != addr || block.ToAddress != addr {
+		t.Fatalf("wrong contract address %s", addr)
+	}
+	abiContract, _ := abi.JSONToABIContract(strings.NewReader(abiStr))
+	params, _ := abiContract.PackMethod("", "vite", big.NewInt(1000))
+	if block.BlockType != ledger.BlockTypeSendCreate || !bytes.Equal(block.Data, append([]byte("6080"), params...)) || len(r.sent) != 1 {
+		t.Fatalf("wrong create block: %+v", block)
+	}
+
+	if _, _, _, err := DeployContract(opts, abiStr, "", "", api.CreateContractDataParam{}, r); err != errNoCode {
+		t.Fatalf("want errNoCode, got %v", err)
+	}
+}
+
+func TestBoundContract_Call(t *testing.T) {
+	c, abiContract, r := newTestContract(t, "exchange", "6080")
+	order := testOrder{Id: 1, Owner: testAddr, Items: []testItem{{"apple", [2]*big.Int{big.NewInt(1), big.NewInt(2)}}}}
+	r.output, _ = abiContract.OffChains["getOrder"].Outputs.Pack(order, true)
+
+	var (
+		out0 testOrder
+		out1 bool
+	)
+	height := uint64(10)
+	if err := c.Call(&CallOpts{Height: &height}, []interface{}{&out0, &out1}, "getOrder", uint64(1)); err != nil {
+		t.Fatal(err)
+	}
+	if !out1 || out0.Id != 1 || out0.Owner != testAddr || len(out0.Items) != 1 || out0.Items[0].Amounts[1].Cmp(big.NewInt(2)) != 0 {
+		t.Fatalf("wrong outputs: %+v %v", out0, out1)
+	}
+	data, _ := abiContract.PackOffChain("getOrder", uint64(1))
+	if *r.offchain.Addr != testAddr || r.offchain.OffChainCode != "6080" || *r.offchain.Height != 10 || !bytes.Equal(r.offchain.Data, data) {
+		t.Fatalf("wrong offchain param: %+v", r.offchain)
+	}
+
+	if err := c.Call(nil, []interface{}{&out0}, "getOrder", uint64(1)); err == nil {
+		t.Fatal("wrong result count should fail")
+	}
+	var wrong string
+	if err := c.Call(nil, []interface{}{&out0, &wrong}, "getOrder", uint64(1)); err == nil {
+		t.Fatal("wrong result type should fail")
+	}
+
+	c.offchainCode = ""
+	if err := c.Call(nil, []interface{}{&out0, &out1}, "getOrder", uint64(1)); err != errNoOffchainCode {
+		t.Fatalf("want errNoOffchainCode, got %v", err)
+	}
+}
+
+func TestBoundContract_FilterLogs(t *testing.T) {
+	c, abiContract, r := newTestContract(t, "exchange", "")
+	order := testOrder{Id: 1, Owner: testAddr}
+	topics, data, err := abiContract.PackEvent("OrderPlaced", order, "memo", [32]byte{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.logs = []*api.Logs{{Log: &ledger.VmLog{Topics: topics, Data: data}, AccountBlockHash: types.Hash{2}, AccountHeight: "5", Addr: &testAddr}}
+
+	logs, err := c.FilterLogs(&FilterOpts{FromHeight: 1}, "OrderPlaced", nil, []interface{}{"memo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].AccountBlockHash != (types.Hash{2}) || logs[0].AccountBlockHeight != 5 {
+		t.Fatalf("wrong logs: %+v", logs)
+	}
+	if r := r.filter.AddrRange[testAddr.String()]; r == nil || r.FromHeight != "1" || r.ToHeight != "0" {
+		t.Fatalf("wrong filter range: %+v", r)
+	}
+	if len(r.filter.Topics) != 3 || r.filter.Topics[0][0] != topics[0] || len(r.filter.Topics[1]) != 0 || r.filter.Topics[2][0] != topics[2] {
+		t.Fatalf("wrong filter topics: %v", r.filter.Topics)
+	}
+
+	var event testOrderPlaced
+	if err := c.UnpackLog(&event, "OrderPlaced", logs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if event.Order != topics[1] || event.Memo != topics[2] || event.Data != [32]byte{1} {
+		t.Fatalf("wrong event: %+v", event)
+	}
+
+	logs[0].Topics = topics[:2]
+	if err := c.UnpackLog(&event, "OrderPlaced", logs[0]); err == nil {
+		t.Fatal("unpack log with missing topics should fail")
+	}
+	if _, err := c.FilterLogs(nil, "OrderPlaced", nil, nil, nil); err == nil {
+		t.Fatal("too many queries should fail")
+	}
+	if _, err := c.FilterLogs(nil, "OrderCanceled"); err == nil {
+		t.Fatal("unknown event should fail")
+	}
+}
+
+type MockSubscribeApi struct {
+	params chan filters.VmLogSubscriptionParam
+	logs   []*filters.LogsV2
+}
+
+func (s *MockSubscribeApi) CreateVmlogSubscription(ctx context.Context, param filters.VmLogSubscriptionParam) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	s.params <- param
+	sub := notifier.CreateSubscription()
+	go func() {
+		// notifications are dropped until the subscription is activated
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				notifier.Notify(sub.ID, s.logs)
+			case <-sub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return sub, nil
+}
+
+func TestBoundContract_WatchLogs(t *testing.T) {
+	abiContract, err := abi.JSONToABIContract(strings.NewReader(readTestData(t, "token.abi")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	topics, data, err := abiContract.PackEvent("Transfer", testAddr, types.Address{}, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &MockSubscribeApi{
+		params: make(chan filters.VmLogSubscriptionParam, 1),
+		logs: []*filters.LogsV2{{
+			Log:              &ledger.VmLog{Topics: topics, Data: data},
+			AccountBlockHash: types.Hash{3},
+			AccountHeight:    "8",
+			Addr:             &testAddr,
+			Removed:          true,
+		}},
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("subscribe", service); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Stop()
+	cc := rpc.DialInProc(server)
+	defer cc.Close()
+
+	c, err := NewBoundContract(testAddr, abiContract, "", &testRpc{subscribe: rpc2.NewSubscribeApi(cc)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := make(chan *Log)
+	sub, err := c.WatchLogs(&WatchOpts{FromSnapshotHeight: 100}, "Transfer", func(log *Log, quit <-chan struct{}) error {
+		select {
+		case logs <- log:
+		case <-quit:
+		}
+		return nil
+	}, []interface{}{testAddr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	param := <-service.params
+	if param.FromSnapshotHeight != "100" || param.AddrRange[testAddr.String()] == nil || len(param.Topics) != 2 || param.Topics[1][0] != topics[1] {
+		t.Fatalf("wrong subscription param: %+v", param)
+	}
+
+	select {
+	case log := <-logs:
+		var event struct {
+			From, To types.Address
+			Value    *big.Int
+		}
+		if err := c.UnpackLog(&event, "Transfer", log); err != nil {
+			t.Fatal(err)
+		}
+		if !log.Removed || log.AccountBlockHeight != 8 || event.From != testAddr || event.Value.Cmp(big.NewInt(7)) != 0 {
+			t.Fatalf("wrong log: %+v %+v", log, event)
+		}
+	case err := <-sub.Err():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no log is received")
+	}
+
+	sub.Unsubscribe()
+	if _, ok := <-sub.Err(); ok {
+		t.Fatal("err channel should be closed after unsubscribe")
+	}
+}
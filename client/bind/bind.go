@@ -0,0 +1,364 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+// Bind generates the go binding of a contract, typ is the name of the contract type,
+// code and offchainCode are hex strings, the deploy helper is generated only if code is given.
+func Bind(pkg string, typ string, abiJSON string, code string, offchainCode string) (string, error) {
+	if !token.IsIdentifier(pkg) {
+		return "", fmt.Errorf("invalid package name %q", pkg)
+	}
+	if !token.IsIdentifier(typ) || !token.IsExported(typ) {
+		return "", fmt.Errorf("invalid type name %q", typ)
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(abiJSON)); err != nil {
+		return "", err
+	}
+	contract, err := abi.JSONToABIContract(bytes.NewReader(compacted.Bytes()))
+	if err != nil {
+		return "", err
+	}
+
+	b := &binder{
+		data: &tmplData{
+			Package:      pkg,
+			Type:         typ,
+			InputABI:     strconv.Quote(compacted.String()),
+			InputBin:     strconv.Quote(strings.TrimPrefix(code, "0x")),
+			OffchainCode: strconv.Quote(strings.TrimPrefix(offchainCode, "0x")),
+			HasBin:       len(code) > 0,
+		},
+		structs: make(map[string]*tmplStruct),
+		names:   map[string]bool{"Address": true},
+	}
+	if err := b.bind(contract); err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := template.Must(template.New("").Parse(tmplSource)).Execute(&buf, b.data); err != nil {
+		return "", err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return "", fmt.Errorf("%v\n%s", err, buf.String())
+	}
+	return string(src), nil
+}
+
+type tmplData struct {
+	Package      string
+	Type         string
+	InputABI     string
+	InputBin     string
+	OffchainCode string
+	HasBin       bool
+
+	Constructor *tmplMethod
+	Methods     []*tmplMethod
+	Getters     []*tmplMethod
+	Events      []*tmplEvent
+	Structs     []*tmplStruct
+}
+
+type tmplArg struct {
+	Name string // name of the parameter or the struct field
+	Type string // go type
+}
+
+type tmplMethod struct {
+	Name     string // go method name
+	Original string // abi method name
+	Sig      string
+	Id       string
+	Inputs   []tmplArg
+	Outputs  []tmplArg
+}
+
+type tmplEvent struct {
+	Name     string // go name without the contract type prefix
+	Original string // abi event name
+	Sig      string
+	Id       string
+	Fields   []tmplArg
+	Indexed  []tmplArg // query parameters of the indexed inputs
+}
+
+type tmplStruct struct {
+	Name   string
+	Sig    string
+	Fields []tmplArg
+}
+
+type binder struct {
+	data    *tmplData
+	structs map[string]*tmplStruct // by struct name
+	names   map[string]bool        // used go method names
+}
+
+func (b *binder) bind(contract abi.ABIContract) (err error) {
+	if b.data.Constructor, err = b.bindMethod("", contract.Constructor); err != nil {
+		return err
+	}
+
+	for _, name := range sortedMethodNames(contract.Methods) {
+		method, err := b.bindMethod(b.methodName(name, ""), contract.Methods[name])
+		if err != nil {
+			return err
+		}
+		b.data.Methods = append(b.data.Methods, method)
+	}
+
+	// a getter may have the same name as a method
+	for _, name := range sortedMethodNames(contract.OffChains) {
+		method, err := b.bindMethod(b.methodName(name, "Offchain"), contract.OffChains[name])
+		if err != nil {
+			return err
+		}
+		b.data.Getters = append(b.data.Getters, method)
+	}
+
+	names := make([]string, 0, len(contract.Events))
+	for name := range contract.Events {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		event, err := b.bindEvent(contract.Events[name])
+		if err != nil {
+			return err
+		}
+		b.data.Events = append(b.data.Events, event)
+	}
+
+	for _, s := range b.structs {
+		b.data.Structs = append(b.data.Structs, s)
+	}
+	sort.Slice(b.data.Structs, func(i, j int) bool {
+		return b.data.Structs[i].Name < b.data.Structs[j].Name
+	})
+	return nil
+}
+
+func sortedMethodNames(methods map[string]abi.Method) []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// methodName returns an unused go method name, suffix is appended if the name is used
+func (b *binder) methodName(name string, suffix string) string {
+	goName := capitalise(name)
+	if b.names[goName] {
+		goName += suffix
+	}
+	for b.names[goName] {
+		goName += "0"
+	}
+	b.names[goName] = true
+	return goName
+}
+
+func (b *binder) bindMethod(name string, method abi.Method) (*tmplMethod, error) {
+	m := &tmplMethod{
+		Name:     name,
+		Original: method.Name,
+		Sig:      methodSig(method),
+		Id:       fmt.Sprintf("0x%x", method.Id()),
+	}
+	for i, input := range method.Inputs {
+		typ, err := b.bindType(input.Type)
+		if err != nil {
+			return nil, err
+		}
+		m.Inputs = append(m.Inputs, tmplArg{Name: paramName(input.Name, i), Type: typ})
+	}
+	for i, output := range method.Outputs {
+		typ, err := b.bindType(output.Type)
+		if err != nil {
+			return nil, err
+		}
+		m.Outputs = append(m.Outputs, tmplArg{Name: fmt.Sprintf("out%d", i), Type: typ})
+	}
+	return m, nil
+}
+
+func (b *binder) bindEvent(event abi.Event) (*tmplEvent, error) {
+	name := capitalise(event.Name)
+	for _, prefix := range []string{"Filter", "Watch", "Parse"} {
+		if b.names[prefix+name] {
+			return nil, fmt.Errorf("%s%s of event %s is used by a method", prefix, name, event.Name)
+		}
+		b.names[prefix+name] = true
+	}
+	e := &tmplEvent{
+		Name:     name,
+		Original: event.Name,
+		Sig:      eventSig(event),
+		Id:       "0x" + event.Id().String(),
+	}
+	for i, input := range event.Inputs {
+		typ, err := b.bindType(input.Type)
+		if err != nil {
+			return nil, err
+		}
+		field := typ
+		if input.Indexed {
+			e.Indexed = append(e.Indexed, tmplArg{Name: paramName(input.Name, i), Type: typ})
+			// the topics of the dynamic types are the hashes of the values
+			switch input.Type.T {
+			case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy, abi.TupleTy:
+				field = "types.Hash"
+			}
+		}
+		e.Fields = append(e.Fields, tmplArg{Name: fieldName(input.Name, i), Type: field})
+	}
+	return e, nil
+}
+
+// bindType returns the go type of the abi type, the structs of the tuples are collected
+func (b *binder) bindType(t abi.Type) (string, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		if t.Type.Kind() == reflect.Ptr {
+			return "*big.Int", nil
+		}
+		return t.Type.String(), nil
+	case abi.BoolTy:
+		return "bool", nil
+	case abi.StringTy:
+		return "string", nil
+	case abi.AddressTy:
+		return "types.Address", nil
+	case abi.GidTy:
+		return "types.Gid", nil
+	case abi.TokenIdTy:
+		return "types.TokenTypeId", nil
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size), nil
+	case abi.BytesTy:
+		return "[]byte", nil
+	case abi.SliceTy:
+		elem, err := b.bindType(*t.Elem)
+		return "[]" + elem, err
+	case abi.ArrayTy:
+		elem, err := b.bindType(*t.Elem)
+		return fmt.Sprintf("[%d]", t.Size) + elem, err
+	case abi.TupleTy:
+		return b.bindStruct(t)
+	}
+	return "", fmt.Errorf("unsupported abi type %s", t.String())
+}
+
+func (b *binder) bindStruct(t abi.Type) (string, error) {
+	name := capitalise(t.TupleRawName)
+	if name == "" {
+		// anonymous tuples are named by the order they are found
+		for _, s := range b.structs {
+			if strings.HasPrefix(s.Name, "Struct") && s.Sig == t.String() {
+				return s.Name, nil
+			}
+		}
+		name = fmt.Sprintf("Struct%d", len(b.structs))
+	}
+	if s, ok := b.structs[name]; ok {
+		if s.Sig != t.String() {
+			return "", fmt.Errorf("struct %s is defined as both %s and %s", name, s.Sig, t.String())
+		}
+		return name, nil
+	}
+
+	s := &tmplStruct{Name: name, Sig: t.String()}
+	for i, elem := range t.TupleElems {
+		typ, err := b.bindType(*elem)
+		if err != nil {
+			return "", err
+		}
+		s.Fields = append(s.Fields, tmplArg{Name: capitalise(t.TupleRawNames[i]), Type: typ})
+	}
+	b.structs[name] = s
+	return name, nil
+}
+
+func methodSig(method abi.Method) string {
+	args := func(list abi.Arguments) string {
+		s := make([]string, len(list))
+		for i, arg := range list {
+			s[i] = strings.TrimSpace(arg.Type.String() + " " + arg.Name)
+		}
+		return strings.Join(s, ", ")
+	}
+	if method.Name == "" {
+		return fmt.Sprintf("constructor(%s)", args(method.Inputs))
+	}
+	if len(method.Outputs) > 0 {
+		return fmt.Sprintf("getter %s(%s) returns(%s)", method.Name, args(method.Inputs), args(method.Outputs))
+	}
+	return fmt.Sprintf("onMessage %s(%s)", method.Name, args(method.Inputs))
+}
+
+func eventSig(event abi.Event) string {
+	s := make([]string, len(event.Inputs))
+	for i, input := range event.Inputs {
+		s[i] = input.Type.String()
+		if input.Indexed {
+			s[i] += " indexed"
+		}
+		s[i] = strings.TrimSpace(s[i] + " " + input.Name)
+	}
+	return fmt.Sprintf("event %s(%s)", event.Name, strings.Join(s, ", "))
+}
+
+// capitalise makes the first character upper case and removes the prefixing underscores, like the abi struct fields
+func capitalise(input string) string {
+	input = strings.TrimLeft(input, "_")
+	if len(input) == 0 {
+		return ""
+	}
+	return strings.ToUpper(input[:1]) + input[1:]
+}
+
+// fieldName is the name of the event struct field of the i-th input
+func fieldName(name string, i int) string {
+	if field := capitalise(name); field != "" {
+		return field
+	}
+	return fmt.Sprintf("Arg%d", i)
+}
+
+// reservedNames are the parameters and imported packages of the generated functions
+var reservedNames = map[string]bool{
+	"opts": true, "sink": true, "rpc": true, "meta": true, "err": true, "address": true, "block": true,
+	"contract": true, "parsed": true, "log": true, "logs": true, "event": true, "events": true, "quit": true,
+	"abi": true, "api": true, "big": true, "bind": true, "client": true, "strings": true, "types": true,
+}
+
+// paramName is the name of the go parameter of the i-th input, it can't conflict with the keywords and reserved names
+func paramName(name string, i int) string {
+	name = strings.TrimLeft(name, "_")
+	if len(name) > 0 {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	if name == "" || token.IsKeyword(name) || reservedNames[name] {
+		return fmt.Sprintf("arg%d", i)
+	}
+	return name
+}
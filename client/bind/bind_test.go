@@ -0,0 +1,105 @@
+package bind
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the generated bindings")
+
+func readTestData(t *testing.T, name string) string {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if os.IsNotExist(err) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func TestBind_golden(t *testing.T) {
+	cases := []struct {
+		name string
+		pkg  string
+		typ  string
+	}{
+		{"token", "token", "Token"},
+		{"exchange", "exchange", "Exchange"},
+	}
+
+	for _, c := range cases {
+		src, err := Bind(c.pkg, c.typ, readTestData(t, c.name+".abi"), readTestData(t, c.name+".bin"), readTestData(t, c.name+".offchain"))
+		if err != nil {
+			t.Fatalf("bind %s failed: %v", c.name, err)
+		}
+
+		golden := filepath.Join("testdata", c.name+".go.golden")
+		if *update {
+			if err := ioutil.WriteFile(golden, []byte(src), 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		want, err := ioutil.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if src != string(want) {
+			t.Errorf("binding of %s is different from %s, run the test with -update if it's expected:\n%s", c.name, golden, src)
+		}
+	}
+}
+
+func TestBind_invalid(t *testing.T) {
+	abiJSON := readTestData(t, "token.abi")
+	if _, err := Bind("token", "token", abiJSON, "", ""); err == nil {
+		t.Error("unexported type name should fail")
+	}
+	if _, err := Bind("my-token", "Token", abiJSON, "", ""); err == nil {
+		t.Error("invalid package name should fail")
+	}
+	if _, err := Bind("token", "Token", `[{"type":"function","name":"f","inputs":[{"name":"a","type":"uint"}]}]`, "", ""); err == nil {
+		t.Error("invalid abi type should fail")
+	}
+
+	// methods and getters with the same names are renamed, but the generated event methods can't be renamed
+	src, err := Bind("token", "Token", `[
+		{"type":"function","name":"total","inputs":[]},
+		{"type":"offchain","name":"total","inputs":[],"outputs":[{"name":"","type":"uint256"}]}
+	]`, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(src, ") Total(opts *bind.TransactOpts)") || !strings.Contains(src, ") TotalOffchain(opts *bind.CallOpts)") {
+		t.Errorf("method and getter are not renamed:\n%s", src)
+	}
+	if _, err := Bind("token", "Token", `[
+		{"type":"function","name":"filterTransfer","inputs":[]},
+		{"type":"event","name":"Transfer","inputs":[]}
+	]`, "", ""); err == nil {
+		t.Error("event method conflicts should fail")
+	}
+}
+
+func TestParamName(t *testing.T) {
+	cases := map[string]string{
+		"to":     "to",
+		"_value": "value",
+		"Owner":  "owner",
+		"":       "arg2",
+		"___":    "arg2",
+		"type":   "arg2",
+		"opts":   "arg2",
+		"types":  "arg2",
+	}
+	for name, want := range cases {
+		if got := paramName(name, 2); got != want {
+			t.Errorf("param name of %q: want %s, got %s", name, want, got)
+		}
+	}
+}
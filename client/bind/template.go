@@ -0,0 +1,176 @@
+package bind
+
+// tmplSource is the go source template of the generated binding
+const tmplSource = `// Code generated by gvite abigen. DO NOT EDIT.
+// This file is a generated binding and any manual changes will be lost.
+
+package {{.Package}}
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/vitelabs/go-vite/client"
+	"github.com/vitelabs/go-vite/client/bind"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var (
+	_ = big.NewInt
+	_ = strings.NewReader
+	_ = client.NewClient
+	_ = bind.Bind
+	_ = types.Address{}
+	_ = api.AccountBlock{}
+	_ = abi.JSONToABIContract
+)
+
+{{$contract := .Type}}
+// {{.Type}}ABI is the input ABI used to generate the binding from.
+const {{.Type}}ABI = {{.InputABI}}
+
+// {{.Type}}Bin is the hex code used for deploying new contracts.
+const {{.Type}}Bin = {{.InputBin}}
+
+// {{.Type}}OffchainCode is the hex offchain code used for calling the getters.
+const {{.Type}}OffchainCode = {{.OffchainCode}}
+
+{{range .Structs}}
+// {{.Name}} is an auto generated Go binding around the tuple {{.Sig}}.
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{end}}
+
+// {{.Type}} is an auto generated Go binding around a contract.
+type {{.Type}} struct {
+	contract *bind.BoundContract
+}
+
+// New{{.Type}} creates a new instance of {{.Type}}, bound to a specific deployed contract.
+func New{{.Type}}(address types.Address, rpc client.RpcClient) (*{{.Type}}, error) {
+	parsed, err := abi.JSONToABIContract(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	contract, err := bind.NewBoundContract(address, parsed, {{.Type}}OffchainCode, rpc)
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{contract: contract}, nil
+}
+{{if .HasBin}}
+// Deploy{{.Type}} deploys a new contract, binding an instance of {{.Type}} to it.
+//
+// Solidity++: {{.Constructor.Sig}}
+func Deploy{{.Type}}(opts *bind.TransactOpts, rpc client.RpcClient, meta api.CreateContractDataParam{{range .Constructor.Inputs}}, {{.Name}} {{.Type}}{{end}}) (types.Address, *api.AccountBlock, *{{.Type}}, error) {
+	address, block, contract, err := bind.DeployContract(opts, {{.Type}}ABI, {{.Type}}Bin, {{.Type}}OffchainCode, meta, rpc{{range .Constructor.Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return types.Address{}, nil, nil, err
+	}
+	return address, block, &{{.Type}}{contract: contract}, nil
+}
+{{end}}
+// Address returns the address of the contract.
+func (_{{.Type}} *{{.Type}}) Address() types.Address {
+	return _{{.Type}}.contract.Address()
+}
+{{range .Methods}}
+// {{.Name}} builds, signs and sends a call block of the method {{.Id}}.
+//
+// Solidity++: {{.Sig}}
+func (_{{$contract}} *{{$contract}}) {{.Name}}(opts *bind.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*api.AccountBlock, error) {
+	return _{{$contract}}.contract.Transact(opts, "{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+}
+{{end}}
+{{range .Getters}}
+// {{.Name}} calls the offchain getter {{.Id}}.
+//
+// Solidity++: {{.Sig}}
+func (_{{$contract}} *{{$contract}}) {{.Name}}(opts *bind.CallOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) ({{range .Outputs}}{{.Type}}, {{end}}error) {
+	var (
+	{{- range .Outputs}}
+		{{.Name}} {{.Type}}
+	{{- end}}
+	)
+	err := _{{$contract}}.contract.Call(opts, []interface{}{ {{- range $i, $o := .Outputs}}{{if $i}}, {{end}}&{{$o.Name}}{{end -}} }, "{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+	return {{range .Outputs}}{{.Name}}, {{end}}err
+}
+{{end}}
+{{range .Events}}
+// {{$contract}}{{.Name}} represents the {{.Original}} event raised by the {{$contract}} contract.
+type {{$contract}}{{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+	Raw *bind.Log // the vm log and its account block
+}
+
+// Filter{{.Name}} gets the logs of the event {{.Id}}.
+//
+// Solidity++: {{.Sig}}
+func (_{{$contract}} *{{$contract}}) Filter{{.Name}}(opts *bind.FilterOpts{{range .Indexed}}, {{.Name}} []{{.Type}}{{end}}) ([]*{{$contract}}{{.Name}}, error) {
+	{{- range .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+	{{- end}}
+
+	logs, err := _{{$contract}}.contract.FilterLogs(opts, "{{.Original}}"{{range .Indexed}}, {{.Name}}Rule{{end}})
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*{{$contract}}{{.Name}}, 0, len(logs))
+	for _, log := range logs {
+		event, err := _{{$contract}}.Parse{{.Name}}(log)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Watch{{.Name}} subscribes the logs of the event {{.Id}}.
+//
+// Solidity++: {{.Sig}}
+func (_{{$contract}} *{{$contract}}) Watch{{.Name}}(opts *bind.WatchOpts, sink chan<- *{{$contract}}{{.Name}}{{range .Indexed}}, {{.Name}} []{{.Type}}{{end}}) (bind.Subscription, error) {
+	{{- range .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, {{.Name}}Item := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, {{.Name}}Item)
+	}
+	{{- end}}
+
+	return _{{$contract}}.contract.WatchLogs(opts, "{{.Original}}", func(log *bind.Log, quit <-chan struct{}) error {
+		event, err := _{{$contract}}.Parse{{.Name}}(log)
+		if err != nil {
+			return err
+		}
+		select {
+		case sink <- event:
+		case <-quit:
+		}
+		return nil
+	}{{range .Indexed}}, {{.Name}}Rule{{end}})
+}
+
+// Parse{{.Name}} unpacks a log of the event {{.Id}}.
+//
+// Solidity++: {{.Sig}}
+func (_{{$contract}} *{{$contract}}) Parse{{.Name}}(log *bind.Log) (*{{$contract}}{{.Name}}, error) {
+	event := new({{$contract}}{{.Name}})
+	if err := _{{$contract}}.contract.UnpackLog(event, "{{.Original}}", log); err != nil {
+		return nil, err
+	}
+	event.Raw = log
+	return event, nil
+}
+{{end}}
+`
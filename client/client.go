@@ -30,10 +30,10 @@ type RequestTxParams struct {
 
 type RequestCreateContractParams struct {
 	SelfAddr   types.Address
-	fee        *big.Int
-	arguments  []interface{}
-	abiStr     string
-	metaParams api.CreateContractDataParam
+	Fee        *big.Int // default is 10 vite
+	Arguments  []interface{}
+	AbiStr     string
+	MetaParams api.CreateContractDataParam
 }
 
 type ResponseTxParams struct {
@@ -109,25 +109,25 @@ func (c *client) BuildRequestCreateContractBlock(params RequestCreateContractPar
 		}
 	}
 	contractAddr := util.NewContractAddress(params.SelfAddr, prev.Height+1, prev.Hash)
-	abiContract, err := abi.JSONToABIContract(strings.NewReader(params.abiStr))
+	abiContract, err := abi.JSONToABIContract(strings.NewReader(params.AbiStr))
 	if err != nil {
 		return nil, err
 	}
-	constructorParams, err := abiContract.PackMethod("", params.arguments...)
+	constructorParams, err := abiContract.PackMethod("", params.Arguments...)
 	if err != nil {
 		return nil, err
 	}
-	params.metaParams.Params = constructorParams
-	data, err := c.rpc.GetCreateContractData(params.metaParams)
+	params.MetaParams.Params = constructorParams
+	data, err := c.rpc.GetCreateContractData(params.MetaParams)
 	if err != nil {
 		return nil, err
 	}
 
-	if params.fee == nil {
+	if params.Fee == nil {
 		one := big.NewInt(1e18)
-		params.fee = one.Mul(one, big.NewInt(10))
+		params.Fee = one.Mul(one, big.NewInt(10))
 	}
-	fee := params.fee.String()
+	fee := params.Fee.String()
 	block = &api.AccountBlock{
 		BlockType:          ledger.BlockTypeSendCreate,
 		Hash:               types.Hash{},
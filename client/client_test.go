@@ -155,8 +155,8 @@ func TestClient_CreateContract(t *testing.T) {
 	code := ``
 	block, err := client.BuildRequestCreateContractBlock(RequestCreateContractParams{
 		SelfAddr: self,
-		abiStr:   definition,
-		metaParams: api.CreateContractDataParam{
+		AbiStr:   definition,
+		MetaParams: api.CreateContractDataParam{
 			Gid:         types.DELEGATE_GID,
 			ConfirmTime: 12,
 			SeedCount:   12,
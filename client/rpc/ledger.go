@@ -28,6 +28,7 @@ type LedgerApi interface {
 	GetLatestSnapshotChainHash() *types.Hash
 	GetLatestBlock(addr types.Address) (*api.AccountBlock, error)
 	GetVmLogList(blockHash types.Hash) (ledger.VmLogList, error)
+	GetVmLogsByFilter(param api.VmLogFilterParam) ([]*api.Logs, error)
 	GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock
 	GetConfirmedBalances(snapshotHash types.Hash, addrList []types.Address, tokenIds []types.TokenTypeId) (api.GetBalancesRes, error)
 	GetHourSBPStats(startIdx uint64, endIdx uint64) ([]map[string]interface{}, error)
@@ -135,6 +136,11 @@ func (li ledgerApi) GetVmLogList(blockHash types.Hash) (logs ledger.VmLogList, e
 	return
 }
 
+func (li ledgerApi) GetVmLogsByFilter(param api.VmLogFilterParam) (logs []*api.Logs, err error) {
+	err = li.cc.Call(&logs, "ledger_getVmLogsByFilter", param)
+	return
+}
+
 func (li ledgerApi) GetUnconfirmedBlocks(addr types.Address) (blocks []*ledger.AccountBlock) {
 	li.cc.Call(&blocks, "ledger_getUnconfirmedBlocks", addr)
 	return
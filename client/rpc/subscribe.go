@@ -0,0 +1,28 @@
+//
+
+package rpc
+
+import (
+	"context"
+
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api/filters"
+)
+
+// SubscribeApi ...
+type SubscribeApi interface {
+	CreateVmLogSubscription(ctx context.Context, param filters.VmLogSubscriptionParam, ch chan<- []*filters.LogsV2) (*rpc.ClientSubscription, error)
+}
+
+type subscribeApi struct {
+	cc *rpc.Client
+}
+
+func NewSubscribeApi(cc *rpc.Client) SubscribeApi {
+	return &subscribeApi{cc: cc}
+}
+
+// CreateVmLogSubscription sends the matched vm logs to ch, it's only supported by websocket and ipc clients
+func (si subscribeApi) CreateVmLogSubscription(ctx context.Context, param filters.VmLogSubscriptionParam, ch chan<- []*filters.LogsV2) (*rpc.ClientSubscription, error) {
+	return si.cc.Subscribe(ctx, "subscribe", ch, "createVmlogSubscription", param)
+}
@@ -0,0 +1,68 @@
+package gvite_plugins
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/vitelabs/go-vite/client/bind"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	abigenCommand = cli.Command{
+		Action:   utils.MigrateFlags(abigenAction),
+		Name:     "abigen",
+		Usage:    "abigen --abigen.abi=./token.abi --abigen.bin=./token.bin --abigen.offchain=./token.offchain --abigen.pkg=token --abigen.out=./token.go",
+		Flags:    abigenFlags,
+		Category: "DEVELOPER COMMANDS",
+		Description: `
+Generate the go binding of a contract from its abi, the binding sends call blocks, calls the offchain getters,
+filters and watches the events through the rpc client, and deploys the contract if the code is given.
+`,
+	}
+)
+
+func abigenAction(ctx *cli.Context) error {
+	abiFile := ctx.GlobalString(utils.AbigenAbiFlag.Name)
+	pkg := ctx.GlobalString(utils.AbigenPkgFlag.Name)
+	if abiFile == "" || pkg == "" {
+		return errors.New("abigen.abi and abigen.pkg are required")
+	}
+	typ := ctx.GlobalString(utils.AbigenTypeFlag.Name)
+	if typ == "" {
+		typ = strings.ToUpper(pkg[:1]) + pkg[1:]
+	}
+
+	readFile := func(name string) (string, error) {
+		if name == "" {
+			return "", nil
+		}
+		data, err := ioutil.ReadFile(name)
+		return strings.TrimSpace(string(data)), err
+	}
+	abiJSON, err := readFile(abiFile)
+	if err != nil {
+		return err
+	}
+	code, err := readFile(ctx.GlobalString(utils.AbigenBinFlag.Name))
+	if err != nil {
+		return err
+	}
+	offchainCode, err := readFile(ctx.GlobalString(utils.AbigenOffchainFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	src, err := bind.Bind(pkg, typ, abiJSON, code, offchainCode)
+	if err != nil {
+		return err
+	}
+	if out := ctx.GlobalString(utils.AbigenOutFlag.Name); out != "" {
+		return ioutil.WriteFile(out, []byte(src), 0644)
+	}
+	fmt.Print(src)
+	return nil
+}
@@ -0,0 +1,48 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	devNetCommand = cli.Command{
+		Action:   utils.MigrateFlags(devNetAction),
+		Name:     "devnet",
+		Usage:    "devnet --devnet.accounts=10 --devnet.instamine",
+		Flags:    devNetFlags,
+		Category: "DEVNET COMMANDS",
+		Description: `
+Start a throwaway local chain for integration tests. The genesis funds the accounts derived from the mnemonic,
+the first account is the only SBP, and all accounts receive their transactions automatically.
+The data is removed on exit unless --datadir is specified.
+With --devnet.instamine, a snapshot block is produced as soon as an account block arrives.
+Snapshot blocks can also be produced by dev_mineSnapshot and dev_setTime.
+`,
+	}
+)
+
+func devNetAction(ctx *cli.Context) error {
+	maker := &nodemanager.DevNetNodeMaker{}
+	nodeManager, err := nodemanager.NewDefaultNodeManager(ctx, maker)
+	if maker.TempDir != "" {
+		defer os.RemoveAll(maker.TempDir)
+	}
+	if err != nil {
+		return fmt.Errorf("new devnet node error, %+v", err)
+	}
+
+	nodemanager.Register("devnet_autoreceive", nodemanager.NewDevNetAutoReceive(maker.Accounts))
+
+	fmt.Println("Devnet accounts:")
+	for i, addr := range maker.Accounts {
+		fmt.Printf("(%d) %s\n", i, addr)
+	}
+	fmt.Printf("Mnemonic: %s\n", ctx.GlobalString(utils.DevNetMnemonicFlag.Name))
+
+	return nodeManager.Start()
+}
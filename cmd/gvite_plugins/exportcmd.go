@@ -12,11 +12,12 @@ var (
 	exportCommand = cli.Command{
 		Action:   utils.MigrateFlags(exportLedgerAction),
 		Name:     "export",
-		Usage:    "export --sbHeight=5000000",
+		Usage:    "export --from=2 --to=5000000 --out=./ledger_export",
 		Flags:    append(exportFlags, configFlags...),
 		Category: "EXPORT COMMANDS",
 		Description: `
-Export ledger.
+Export the snapshot chunks of [from, to] into checksummed ledger files,
+which can be imported by the import command. --sbHeight is an alias of --to.
 `,
 	}
 )
@@ -34,6 +35,7 @@ func exportLedgerAction(ctx *cli.Context) error {
 		fmt.Println(err.Error())
 		return err
 	}
+	nodeManager.Stop()
 
 	os.Exit(0)
 	return nil
@@ -0,0 +1,41 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+)
+
+var (
+	importCommand = cli.Command{
+		Action:   utils.MigrateFlags(importLedgerAction),
+		Name:     "import",
+		Usage:    "import --in=./ledger_export",
+		Flags:    append(importFlags, configFlags...),
+		Category: "EXPORT COMMANDS",
+		Description: `
+Verify the ledger files written by the export command and insert them into the local ledger.
+`,
+	}
+)
+
+func importLedgerAction(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	nodeManager, err := nodemanager.NewImportNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		return err
+	}
+	nodeManager.Stop()
+
+	os.Exit(0)
+	return nil
+}
@@ -33,6 +33,7 @@ var (
 	generalFlags = []cli.Flag{
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
+		utils.ExternalSignerFlag,
 	}
 
 	//p2p
@@ -60,6 +61,7 @@ var (
 		utils.RPCEnabledFlag,
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
+		utils.GraphQLEnabledFlag,
 	}
 
 	//WS
@@ -86,6 +88,7 @@ var (
 	//Log
 	logFlags = []cli.Flag{
 		utils.LogLvlFlag,
+		utils.LogFormatFlag,
 	}
 
 	//VM
@@ -98,6 +101,12 @@ var (
 	netFlags = []cli.Flag{
 		utils.SingleFlag,
 		utils.FilePortFlag,
+		utils.StateSyncFlag,
+		utils.StateSyncCheckpointFlag,
+		utils.LightServeFlag,
+		utils.RequireEncryptionFlag,
+		utils.NodeModeFlag,
+		utils.LightServersFlag,
 	}
 
 	//Stat
@@ -115,6 +124,7 @@ var (
 		utils.InfluxDBUsernameFlag,
 		utils.InfluxDBPasswordFlag,
 		utils.InfluxDBHostTagFlag,
+		utils.PrometheusEnableFlag,
 	}
 
 	// Ledger
@@ -126,7 +136,43 @@ var (
 	// Export
 	exportFlags = []cli.Flag{
 		utils.ExportSbHeightFlags,
+		utils.ExportFromFlag,
+		utils.ExportToFlag,
+		utils.ExportOutFlag,
 	}
+
+	// Import
+	importFlags = []cli.Flag{
+		utils.ImportInFlag,
+	}
+
+	// Pow server
+	powServerFlags = []cli.Flag{
+		utils.PowServerListenFlag,
+		utils.PowServerWorkersFlag,
+		utils.PowServerQueueFlag,
+		utils.PowServerRateLimitFlag,
+		utils.PowServerRateBurstFlag,
+	}
+
+	// Abigen
+	abigenFlags = []cli.Flag{
+		utils.AbigenAbiFlag,
+		utils.AbigenBinFlag,
+		utils.AbigenOffchainFlag,
+		utils.AbigenPkgFlag,
+		utils.AbigenTypeFlag,
+		utils.AbigenOutFlag,
+	}
+
+	// Devnet
+	devNetOnlyFlags = []cli.Flag{
+		utils.DevNetAccountsFlag,
+		utils.DevNetMnemonicFlag,
+		utils.DevNetBalanceFlag,
+		utils.DevNetInstamineFlag,
+	}
+	devNetFlags = utils.MergeFlags(generalFlags, ipcFlags, httpFlags, wsFlags, logFlags, vmFlags, metricsFlags, devNetOnlyFlags)
 )
 
 func init() {
@@ -153,15 +199,19 @@ func init() {
 		attachCommand,
 		ledgerRecoverCommand,
 		exportCommand,
+		importCommand,
+		powServerCommand,
+		devNetCommand,
 		pluginDataCommand,
 		checkChainCommand,
+		abigenCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	//Import: Please add the New Flags here
 	app.Flags = utils.MergeFlags(configFlags, generalFlags, p2pFlags,
 		ipcFlags, httpFlags, wsFlags, consoleFlags, producerFlags, logFlags,
-		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags)
+		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags, importFlags, powServerFlags, abigenFlags, devNetOnlyFlags)
 
 	app.Before = beforeAction
 	app.Action = action
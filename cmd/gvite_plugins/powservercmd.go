@@ -0,0 +1,48 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/pow/remote"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	powServerCommand = cli.Command{
+		Action:   utils.MigrateFlags(powServerAction),
+		Name:     "pow-server",
+		Usage:    "pow-server --pow.listen=127.0.0.1:6007",
+		Flags:    powServerFlags,
+		Category: "POW COMMANDS",
+		Description: `
+Serve the remote pow protocol (/api/generate_work, /api/cancel_work, /api/validate_work),
+so that wallets can calculate pow for accounts without stake. Metrics are served on /api/metrics.
+`,
+	}
+)
+
+func powServerAction(ctx *cli.Context) error {
+	server := remote.NewServer(remote.ServerConfig{
+		ListenAddr: ctx.GlobalString(utils.PowServerListenFlag.Name),
+		Workers:    ctx.GlobalInt(utils.PowServerWorkersFlag.Name),
+		QueueSize:  ctx.GlobalInt(utils.PowServerQueueFlag.Name),
+		RateLimit:  ctx.GlobalFloat64(utils.PowServerRateLimitFlag.Name),
+		RateBurst:  ctx.GlobalInt(utils.PowServerRateBurstFlag.Name),
+	})
+	if err := server.Start(); err != nil {
+		log.Error(fmt.Sprintf("start pow server error, %+v", err))
+		return err
+	}
+	fmt.Printf("Pow server is listening on %s\n", ctx.GlobalString(utils.PowServerListenFlag.Name))
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(c)
+	<-c
+	server.Stop()
+	return nil
+}
@@ -100,6 +100,10 @@ web3._extend({
 			call: 'onroad_stopAutoReceive',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'getAutoReceiveStatus',
+			call: 'onroad_getAutoReceiveStatus'
+		}),
 	]
 });
 `
@@ -58,6 +58,17 @@ func StartNode(node *node.Node) error {
 			}
 		}
 	}()
+
+	// Reload the config file on SIGHUP, the result is logged by ReloadConfig
+	go func() {
+		c := make(chan os.Signal, 1)
+		signal.Notify(c, syscall.SIGHUP)
+		for range c {
+			if _, err := node.ReloadConfig(); err != nil {
+				log.Error(fmt.Sprintf("Failed to reload the config, %v", err))
+			}
+		}
+	}()
 	return nil
 }
 
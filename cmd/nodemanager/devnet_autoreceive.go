@@ -0,0 +1,41 @@
+package nodemanager
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/onroad"
+)
+
+// DevNetAutoReceive is a NodeExtender which enables the auto-receive of the devnet accounts,
+// the blocks are received by the AutoReceiver of the onroad manager.
+type DevNetAutoReceive struct {
+	accounts []types.Address
+}
+
+func NewDevNetAutoReceive(accounts []types.Address) *DevNetAutoReceive {
+	return &DevNetAutoReceive{accounts: accounts}
+}
+
+func (e *DevNetAutoReceive) Prepare(node *node.Node) error {
+	return nil
+}
+
+func (e *DevNetAutoReceive) Start(node *node.Node) error {
+	vite := node.Vite()
+	if vite == nil {
+		return errors.New("the devnet node has no vite server")
+	}
+	for _, addr := range e.accounts {
+		if err := vite.OnRoad().AutoReceiver().Start(addr, &onroad.AutoReceiveFilter{PoW: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop does nothing, the AutoReceiver is stopped along with the node
+func (e *DevNetAutoReceive) Stop(node *node.Node) error {
+	return nil
+}
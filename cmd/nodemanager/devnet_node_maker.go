@@ -0,0 +1,155 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+	"gopkg.in/urfave/cli.v1"
+)
+
+const (
+	devNetID       = 5
+	devNetPassword = "devnet"
+)
+
+var devNetPublicModules = []string{
+	"ledger", "net", "contract", "util", "health", "tx", "wallet", "private_onroad", "pledge", "register", "vote",
+	"mintage", "consensusGroup", "dexfund", "dextrade", "dex", "dexsubscribe", "subscribe", "pool", "debug", "dev",
+	"consensus",
+}
+
+// DevNetNodeMaker makes a node of a throwaway local chain, the genesis funds the accounts derived from a mnemonic,
+// and the first account is the only SBP.
+type DevNetNodeMaker struct {
+	// the funded accounts, they are set by MakeNodeConfig
+	Accounts []types.Address
+	// the temporary data dir created by MakeNodeConfig, it should be removed after the node is stopped
+	TempDir string
+}
+
+func (maker *DevNetNodeMaker) MakeNode(ctx *cli.Context) (*node.Node, error) {
+	nodeConfig, err := maker.MakeNodeConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("NodeConfig info: %v", nodeConfig))
+
+	return node.New(nodeConfig)
+}
+
+func (maker *DevNetNodeMaker) MakeNodeConfig(ctx *cli.Context) (*node.Config, error) {
+	cfg := node.DefaultNodeConfig
+	mappingNodeConfig(ctx, &cfg)
+
+	// the data is kept only if the data dir is specified
+	if !ctx.GlobalIsSet(utils.DataDirFlag.Name) {
+		dir, err := ioutil.TempDir("", "gvite-devnet")
+		if err != nil {
+			return nil, err
+		}
+		cfg.DataDir = dir
+		maker.TempDir = dir
+	} else if err := os.MkdirAll(cfg.DataDir, 0700); err != nil {
+		return nil, err
+	}
+	cfg.KeyStoreDir = filepath.Join(cfg.DataDir, "wallet")
+
+	mnemonic := ctx.GlobalString(utils.DevNetMnemonicFlag.Name)
+	accounts, err := deriveDevNetAccounts(mnemonic, ctx.GlobalInt(utils.DevNetAccountsFlag.Name))
+	if err != nil {
+		return nil, err
+	}
+	maker.Accounts = accounts
+
+	balance, ok := new(big.Int).SetString(ctx.GlobalString(utils.DevNetBalanceFlag.Name), 10)
+	if !ok || balance.Sign() < 0 {
+		return nil, fmt.Errorf("invalid devnet balance %s", ctx.GlobalString(utils.DevNetBalanceFlag.Name))
+	}
+	balance.Mul(balance, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
+	genesis, err := config.MakeDevNetGenesis(accounts, balance)
+	if err != nil {
+		return nil, err
+	}
+	genesisJson, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	cfg.GenesisFile = filepath.Join(cfg.DataDir, "genesis.json")
+	if err = ioutil.WriteFile(cfg.GenesisFile, genesisJson, 0600); err != nil {
+		return nil, err
+	}
+
+	entropyStore, err := entropystore.StoreNewEntropy(cfg.KeyStoreDir, mnemonic, devNetPassword, entropystore.DefaultMaxIndex)
+	if err != nil {
+		return nil, err
+	}
+	cfg.EntropyStorePath = entropyStore.GetEntropyStoreFile()
+	cfg.EntropyStorePassword = devNetPassword
+
+	// a single SBP without peers
+	cfg.NetSelect = "dev"
+	cfg.NetID = devNetID
+	cfg.Single = true
+	cfg.Discover = false
+	cfg.BootSeeds = nil
+	cfg.MinerEnabled = true
+	cfg.CoinBase = "0:" + accounts[0].String()
+	cfg.MinerInstamine = ctx.GlobalBool(utils.DevNetInstamineFlag.Name)
+	cfg.MinerDevNet = true
+
+	cfg.IPCEnabled = true
+	cfg.RPCEnabled = true
+	cfg.WSEnabled = true
+	if cfg.HttpHost == "" {
+		cfg.HttpHost = "127.0.0.1"
+	}
+	if cfg.WSHost == "" {
+		cfg.WSHost = "127.0.0.1"
+	}
+	cfg.SubscribeEnabled = true
+	cfg.SBPMonitorEnabled = true
+	cfg.PublicModules = devNetPublicModules
+
+	if err = cfg.DataDirPathAbs(); err != nil {
+		return nil, err
+	}
+	makeRunLogFile(&cfg)
+	return &cfg, nil
+}
+
+// deriveDevNetAccounts returns the addresses of the first count indexes of the mnemonic
+func deriveDevNetAccounts(mnemonic string, count int) ([]types.Address, error) {
+	if count <= 0 || count > int(entropystore.DefaultMaxIndex) {
+		return nil, fmt.Errorf("the count of devnet accounts should be in [1, %d]", entropystore.DefaultMaxIndex)
+	}
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, fmt.Errorf("invalid devnet mnemonic")
+	}
+
+	seed := bip39.NewSeed(mnemonic, "")
+	accounts := make([]types.Address, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := derivation.DeriveWithIndex(uint32(i), seed)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := key.Address()
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, *addr)
+	}
+	return accounts, nil
+}
@@ -1,15 +1,28 @@
 package nodemanager
 
 import (
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/sync_cache"
 	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/node"
 	"gopkg.in/urfave/cli.v1"
-	"math/big"
 )
 
+// ExportSnapshotBlocksPerFile is the max count of snapshot blocks in an exported ledger file.
+const ExportSnapshotBlocksPerFile = 1000
+
 type ExportNodeManager struct {
 	ctx  *cli.Context
 	node *node.Node
+
+	chain chain.Chain
 }
 
 var digits = big.NewInt(1000000000000000000)
@@ -47,8 +60,128 @@ func (nodeManager *ExportNodeManager) getSbHeight() uint64 {
 	return sbHeight
 }
 
+func (nodeManager *ExportNodeManager) getRange(latestHeight uint64) (uint64, uint64, error) {
+	from := uint64(2)
+	if nodeManager.ctx.GlobalIsSet(utils.ExportFromFlag.Name) {
+		from = nodeManager.ctx.GlobalUint64(utils.ExportFromFlag.Name)
+	}
+	to := latestHeight
+	if nodeManager.ctx.GlobalIsSet(utils.ExportToFlag.Name) {
+		to = nodeManager.ctx.GlobalUint64(utils.ExportToFlag.Name)
+	} else if sbHeight := nodeManager.getSbHeight(); sbHeight > 0 {
+		to = sbHeight
+	}
+
+	if from < 2 {
+		return 0, 0, fmt.Errorf("from is %d, the genesis snapshot block can't be exported", from)
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("from %d > to %d", from, to)
+	}
+	if to > latestHeight {
+		return 0, 0, fmt.Errorf("to %d is higher than the latest snapshot height %d", to, latestHeight)
+	}
+	return from, to, nil
+}
+
+func (nodeManager *ExportNodeManager) getOutDir() string {
+	if nodeManager.ctx.GlobalIsSet(utils.ExportOutFlag.Name) {
+		return nodeManager.ctx.GlobalString(utils.ExportOutFlag.Name)
+	}
+	return filepath.Join(nodeManager.node.ViteConfig().DataDir, "ledger_export")
+}
+
 func (nodeManager *ExportNodeManager) Start() error {
+	node := nodeManager.node
+	viteConfig := node.ViteConfig()
+
+	// set fork points
+	fork.SetForkPoints(viteConfig.ForkPoints)
+
+	c := chain.NewChain(viteConfig.DataDir, viteConfig.Chain, viteConfig.Genesis)
+	nodeManager.chain = c
 
+	if err := c.Init(); err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	from, to, err := nodeManager.getRange(c.GetLatestSnapshotBlock().Height)
+	if err != nil {
+		return err
+	}
+	outDir := nodeManager.getOutDir()
+	if err := os.MkdirAll(outDir, 0744); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exporting snapshot blocks %d-%d to %s\n", from, to, outDir)
+	for start := from; start <= to; start += ExportSnapshotBlocksPerFile {
+		end := start + ExportSnapshotBlocksPerFile - 1
+		if end > to {
+			end = to
+		}
+		filename, err := exportLedgerFile(c, start, end, outDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s\n", filename)
+	}
 	return nil
+}
+
+// exportLedgerFile writes the snapshot chunks of [from, to] into a file under dir.
+func exportLedgerFile(c chain.Chain, from, to uint64, dir string) (string, error) {
+	prevHash, err := c.GetSnapshotHashByHeight(from - 1)
+	if err != nil {
+		return "", err
+	}
+	hash, err := c.GetSnapshotHashByHeight(to)
+	if err != nil {
+		return "", err
+	}
+	if prevHash == nil || hash == nil {
+		return "", fmt.Errorf("snapshot block %d or %d doesn't exist", from-1, to)
+	}
+
+	chunks, err := c.GetSubLedger(from-1, to)
+	if err != nil {
+		return "", err
+	}
+	// the first chunk is the snapshot block of from-1
+	if len(chunks) > 0 && chunks[0].SnapshotBlock != nil && chunks[0].SnapshotBlock.Height == from-1 {
+		chunks = chunks[1:]
+	}
+
+	segment := interfaces.Segment{From: from, To: to, PrevHash: *prevHash, Hash: *hash}
+	filename := filepath.Join(dir, sync_cache.ExportFileName(segment))
+	w, err := sync_cache.NewExportWriter(filename, segment)
+	if err != nil {
+		return "", err
+	}
+	for _, chunk := range chunks {
+		if err = w.WriteChunk(chunk); err != nil {
+			w.Close()
+			os.Remove(filename)
+			return "", err
+		}
+	}
+	if err = w.Close(); err != nil {
+		os.Remove(filename)
+		return "", err
+	}
+	return filename, nil
+}
+
+func (nodeManager *ExportNodeManager) Stop() error {
+	if nodeManager.chain != nil {
+		nodeManager.chain.Stop()
+	}
+	return nil
+}
 
+func (nodeManager *ExportNodeManager) Node() *node.Node {
+	return nodeManager.node
 }
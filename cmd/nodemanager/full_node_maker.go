@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/vitelabs/go-vite/cmd/utils"
 	"github.com/vitelabs/go-vite/common"
@@ -78,6 +79,9 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if keyStoreDir := ctx.GlobalString(utils.KeyStoreDirFlag.Name); len(keyStoreDir) > 0 {
 		cfg.KeyStoreDir = keyStoreDir
 	}
+	if externalSigner := ctx.GlobalString(utils.ExternalSignerFlag.Name); len(externalSigner) > 0 {
+		cfg.ExternalSigner = externalSigner
+	}
 
 	//Network Config
 	if identity := ctx.GlobalString(utils.IdentityFlag.Name); len(identity) > 0 {
@@ -118,6 +122,10 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.HttpPort = ctx.GlobalInt(utils.RPCPortFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(utils.GraphQLEnabledFlag.Name) {
+		cfg.GraphQLEnabled = ctx.GlobalBool(utils.GraphQLEnabledFlag.Name)
+	}
+
 	//WS Config
 	if ctx.GlobalIsSet(utils.WSEnabledFlag.Name) {
 		cfg.WSEnabled = ctx.GlobalBool(utils.WSEnabledFlag.Name)
@@ -148,6 +156,9 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if logLevel := ctx.GlobalString(utils.LogLvlFlag.Name); len(logLevel) > 0 {
 		cfg.LogLevel = logLevel
 	}
+	if logFormat := ctx.GlobalString(utils.LogFormatFlag.Name); len(logFormat) > 0 {
+		cfg.LogFormat = logFormat
+	}
 
 	//VM
 	if ctx.GlobalIsSet(utils.VMTestFlag.Name) {
@@ -173,6 +184,30 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.Single = ctx.GlobalBool(utils.SingleFlag.Name)
 	}
 
+	if ctx.GlobalIsSet(utils.StateSyncFlag.Name) {
+		cfg.StateSync = ctx.GlobalBool(utils.StateSyncFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.StateSyncCheckpointFlag.Name) {
+		cfg.StateSyncCheckpoint = ctx.GlobalString(utils.StateSyncCheckpointFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.LightServeFlag.Name) {
+		cfg.LightServe = ctx.GlobalBool(utils.LightServeFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.RequireEncryptionFlag.Name) {
+		cfg.RequireEncryption = ctx.GlobalBool(utils.RequireEncryptionFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.NodeModeFlag.Name) {
+		cfg.NodeMode = ctx.GlobalString(utils.NodeModeFlag.Name)
+	}
+
+	if ctx.GlobalIsSet(utils.LightServersFlag.Name) {
+		cfg.LightServers = strings.Split(ctx.GlobalString(utils.LightServersFlag.Name), ",")
+	}
+
 	//metrics
 	if ctx.GlobalIsSet(utils.MetricsEnabledFlag.Name) {
 		mBool := ctx.GlobalBool(utils.MetricsEnabledFlag.Name)
@@ -197,6 +232,10 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if tag := ctx.GlobalString(utils.InfluxDBHostTagFlag.Name); len(tag) > 0 {
 		cfg.InfluxDBHostTag = &tag
 	}
+	if ctx.GlobalIsSet(utils.PrometheusEnableFlag.Name) {
+		pBool := ctx.GlobalBool(utils.PrometheusEnableFlag.Name)
+		cfg.PrometheusEnable = &pBool
+	}
 }
 
 func overrideNodeConfigs(ctx *cli.Context, cfg *node.Config) {
@@ -258,6 +297,7 @@ func loadNodeConfigFromFile(ctx *cli.Context, cfg *node.Config) error {
 		if jsonConf, err := ioutil.ReadFile(file); err == nil {
 			err = json.Unmarshal(jsonConf, &cfg)
 			if err == nil {
+				cfg.ConfigFile = file
 				return nil
 			}
 
@@ -272,6 +312,7 @@ func loadNodeConfigFromFile(ctx *cli.Context, cfg *node.Config) error {
 	if jsonConf, err := ioutil.ReadFile(defaultNodeConfigFileName); err == nil {
 		err = json.Unmarshal(jsonConf, &cfg)
 		if err == nil {
+			cfg.ConfigFile = defaultNodeConfigFileName
 			return nil
 		}
 		log.Error("Cannot unmarshal the default config file content", "error", err)
@@ -291,27 +332,26 @@ func makeRunLogFile(cfg *node.Config) {
 
 	logHandle := []log15.Handler{}
 
+	cfg.SetLogFileConfig()
+
 	logLevel, err := log15.LvlFromString(cfg.LogLevel)
 	if err != nil {
 		logLevel = log15.LvlInfo
 	}
 
-	logHandle = append(logHandle, errorExcludeLvlFilterHandler(logLevel, cfg.RunLogHandler()))
+	for module, lvlStr := range cfg.LogModuleLevels {
+		lvl, err := log15.LvlFromString(lvlStr)
+		if err != nil {
+			log.Warn("invalid module log level", "module", module, "level", lvlStr)
+			continue
+		}
+		log15.SetModuleLvl(module, lvl)
+	}
+
+	logHandle = append(logHandle, log15.ModuleLvlFilterHandler(logLevel, cfg.RunLogHandler()))
 	logHandle = append(logHandle, log15.LvlFilterHandler(log15.LvlError, cfg.RunErrorLogHandler()))
 
 	log15.Root().SetHandler(log15.MultiHandler(
 		logHandle...,
 	))
 }
-
-func errorExcludeLvlFilterHandler(maxLvl log15.Lvl, h log15.Handler) log15.Handler {
-	return log15.FilterHandler(func(r *log15.Record) (ss bool) {
-
-		////Error、Crit 级别的过滤掉
-		//if r.Lvl <= log15.LvlError {
-		//	return false
-		//}
-
-		return r.Lvl <= maxLvl
-	}, h)
-}
@@ -0,0 +1,203 @@
+package nodemanager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/sync_cache"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/verifier"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type ImportNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+func NewImportNodeManager(ctx *cli.Context, maker NodeMaker) (*ImportNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// single mode
+	node.Config().Single = true
+	node.ViteConfig().Net.Single = true
+
+	// no miner
+	node.Config().MinerEnabled = false
+	node.ViteConfig().Producer.Producer = false
+
+	return &ImportNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+// getFiles returns the ledger files to import, sorted by snapshot height.
+func (nodeManager *ImportNodeManager) getFiles() ([]string, error) {
+	if !nodeManager.ctx.GlobalIsSet(utils.ImportInFlag.Name) {
+		return nil, errors.New("--in is required")
+	}
+	in := nodeManager.ctx.GlobalString(utils.ImportInFlag.Name)
+	st, err := os.Stat(in)
+	if err != nil {
+		return nil, err
+	}
+	if !st.IsDir() {
+		return []string{in}, nil
+	}
+
+	infos, err := ioutil.ReadDir(in)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	segments := make(map[string]interfaces.Segment)
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), sync_cache.ExportFileSuffix) {
+			continue
+		}
+		filename := filepath.Join(in, info.Name())
+		seg, err := sync_cache.SegmentByExportFileName(filename)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, filename)
+		segments[filename] = seg
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return segments[files[i]].From < segments[files[j]].From
+	})
+	return files, nil
+}
+
+func (nodeManager *ImportNodeManager) Start() error {
+	files, err := nodeManager.getFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no ledger file to import")
+	}
+
+	// verify all files before touching the ledger
+	for _, filename := range files {
+		if _, err := sync_cache.VerifyExportFile(filename); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("%d ledger files verified\n", len(files))
+
+	if err := StartNode(nodeManager.node); err != nil {
+		return err
+	}
+
+	v := nodeManager.node.Vite()
+	for _, filename := range files {
+		if err := importLedgerFile(v.Chain(), v.Verifier(), filename); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %s, latest snapshot height is %d\n", filename, v.Chain().GetLatestSnapshotBlock().Height)
+	}
+	return nil
+}
+
+// importLedgerFile inserts the snapshot chunks of a ledger file following the local ledger,
+// snapshot blocks which are already in the local ledger are skipped.
+func importLedgerFile(c chain.Chain, v verifier.Verifier, filename string) error {
+	r, err := sync_cache.NewExportReader(filename)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	seg := r.Segment()
+	latest := c.GetLatestSnapshotBlock()
+	if seg.From > latest.Height+1 {
+		return fmt.Errorf("%s starts from %d, but the latest snapshot height is %d", filename, seg.From, latest.Height)
+	}
+
+	for {
+		chunk, err := r.ReadChunk()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", filename, err)
+		}
+
+		sb := chunk.SnapshotBlock
+		if sb.Height <= c.GetLatestSnapshotBlock().Height {
+			hash, err := c.GetSnapshotHashByHeight(sb.Height)
+			if err != nil {
+				return err
+			}
+			if hash == nil || *hash != sb.Hash {
+				return fmt.Errorf("snapshot block %d %s is different from the local ledger", sb.Height, sb.Hash)
+			}
+			continue
+		}
+		if err := insertChunk(c, v, chunk); err != nil {
+			return fmt.Errorf("failed to import snapshot block %d %s: %v", sb.Height, sb.Hash, err)
+		}
+	}
+}
+
+func insertChunk(c chain.Chain, v verifier.Verifier, chunk *ledger.SnapshotChunk) error {
+	for _, ab := range chunk.AccountBlocks {
+		existed, err := c.IsAccountBlockExisted(ab.Hash)
+		if err != nil {
+			return err
+		}
+		if existed {
+			continue
+		}
+
+		task, vmBlock, err := v.VerifyPoolAccountBlock(ab, c.GetLatestSnapshotBlock())
+		if err != nil {
+			return err
+		}
+		if task != nil {
+			return fmt.Errorf("account block %s refers to blocks which are not in the ledger", ab.Hash)
+		}
+		if vmBlock.AccountBlock.Hash != ab.Hash {
+			return fmt.Errorf("account block %s is different after execution, got %s", ab.Hash, vmBlock.AccountBlock.Hash)
+		}
+		if err := c.InsertAccountBlock(vmBlock); err != nil {
+			return err
+		}
+	}
+
+	if err := v.VerifyNetSnapshotBlock(chunk.SnapshotBlock); err != nil {
+		return err
+	}
+	invalidBlocks, err := c.InsertSnapshotBlock(chunk.SnapshotBlock)
+	if err != nil {
+		return err
+	}
+	if len(invalidBlocks) > 0 {
+		return fmt.Errorf("%d account blocks are rolled back by the snapshot block", len(invalidBlocks))
+	}
+	return nil
+}
+
+func (nodeManager *ImportNodeManager) Stop() error {
+	StopNode(nodeManager.node)
+	return nil
+}
+
+func (nodeManager *ImportNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
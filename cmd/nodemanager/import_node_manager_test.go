@@ -0,0 +1,184 @@
+package nodemanager
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/sync_cache"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+func init() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 1, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 2, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 3, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 4, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 5, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 6, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 7, Version: 7},
+	})
+}
+
+// importTestChain keeps the inserted blocks in memory
+type importTestChain struct {
+	chain.Chain
+	snapshotBlocks []*ledger.SnapshotBlock
+	accountBlocks  map[types.Hash]*ledger.AccountBlock
+}
+
+func (c *importTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.snapshotBlocks[len(c.snapshotBlocks)-1]
+}
+
+func (c *importTestChain) GetSnapshotHashByHeight(height uint64) (*types.Hash, error) {
+	if height >= uint64(len(c.snapshotBlocks)) {
+		return nil, nil
+	}
+	return &c.snapshotBlocks[height].Hash, nil
+}
+
+func (c *importTestChain) IsAccountBlockExisted(hash types.Hash) (bool, error) {
+	_, ok := c.accountBlocks[hash]
+	return ok, nil
+}
+
+func (c *importTestChain) InsertAccountBlock(vmBlock *vm_db.VmAccountBlock) error {
+	c.accountBlocks[vmBlock.AccountBlock.Hash] = vmBlock.AccountBlock
+	return nil
+}
+
+func (c *importTestChain) InsertSnapshotBlock(sb *ledger.SnapshotBlock) ([]*ledger.AccountBlock, error) {
+	c.snapshotBlocks = append(c.snapshotBlocks, sb)
+	return nil, nil
+}
+
+type importTestVerifier struct {
+	verifier.Verifier
+}
+
+func (v *importTestVerifier) VerifyPoolAccountBlock(block *ledger.AccountBlock, snapshot *ledger.SnapshotBlock) (*verifier.AccBlockPendingTask, *vm_db.VmAccountBlock, error) {
+	return nil, &vm_db.VmAccountBlock{AccountBlock: block}, nil
+}
+
+func (v *importTestVerifier) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
+	return nil
+}
+
+func newImportTestChunks(prevHash types.Hash, from, to uint64) []*ledger.SnapshotChunk {
+	var chunks []*ledger.SnapshotChunk
+	for h := from; h <= to; h++ {
+		now := time.Unix(int64(h), 0)
+		ab := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			AccountAddress: types.Address{1},
+			ToAddress:      types.Address{2},
+			Height:         h,
+			Amount:         big.NewInt(int64(h)),
+			Fee:            big.NewInt(0),
+			TokenId:        ledger.ViteTokenId,
+		}
+		ab.Hash = ab.ComputeHash()
+		sb := &ledger.SnapshotBlock{Height: h, PrevHash: prevHash, Timestamp: &now}
+		sb.Hash = sb.ComputeHash()
+		prevHash = sb.Hash
+		chunks = append(chunks, &ledger.SnapshotChunk{SnapshotBlock: sb, AccountBlocks: []*ledger.AccountBlock{ab}})
+	}
+	return chunks
+}
+
+func writeImportTestFile(t *testing.T, dir string, chunks []*ledger.SnapshotChunk) string {
+	first, last := chunks[0].SnapshotBlock, chunks[len(chunks)-1].SnapshotBlock
+	segment := interfaces.Segment{From: first.Height, To: last.Height, PrevHash: first.PrevHash, Hash: last.Hash}
+	filename := path.Join(dir, sync_cache.ExportFileName(segment))
+	w, err := sync_cache.NewExportWriter(filename, segment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, chunk := range chunks {
+		if err = w.WriteChunk(chunk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestImportLedgerFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "import_ledger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Unix(0, 0)
+	genesis := &ledger.SnapshotBlock{Height: 0, Timestamp: &now}
+	genesis.Hash = genesis.ComputeHash()
+	chunks := newImportTestChunks(genesis.Hash, 1, 10)
+
+	c := &importTestChain{
+		snapshotBlocks: []*ledger.SnapshotBlock{genesis},
+		accountBlocks:  make(map[types.Hash]*ledger.AccountBlock),
+	}
+	v := &importTestVerifier{}
+
+	// a file which doesn't follow the local ledger is rejected
+	if err := importLedgerFile(c, v, writeImportTestFile(t, dir, chunks[5:])); err == nil {
+		t.Fatal("file with a gap should be rejected")
+	}
+
+	if err := importLedgerFile(c, v, writeImportTestFile(t, dir, chunks[:6])); err != nil {
+		t.Fatal(err)
+	}
+	if height := c.GetLatestSnapshotBlock().Height; height != 6 {
+		t.Fatalf("latest snapshot height is %d, should be 6", height)
+	}
+
+	// the blocks already in the local ledger are skipped
+	if err := importLedgerFile(c, v, writeImportTestFile(t, dir, chunks[3:])); err != nil {
+		t.Fatal(err)
+	}
+	if height := c.GetLatestSnapshotBlock().Height; height != 10 || len(c.accountBlocks) != 10 {
+		t.Fatalf("latest snapshot height is %d with %d account blocks, should be 10", height, len(c.accountBlocks))
+	}
+
+	// a fork of the local ledger is rejected
+	forkChunks := newImportTestChunks(types.Hash{1}, 1, 3)
+	if err := importLedgerFile(c, v, writeImportTestFile(t, dir, forkChunks)); err == nil {
+		t.Fatal("fork of the local ledger should be rejected")
+	}
+
+	// an oversized record is rejected before it's read
+	filename := writeImportTestFile(t, dir, newImportTestChunks(c.GetLatestSnapshotBlock().Hash, 11, 12))
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the size of the first record follows the header: magic(7) | version(1) | from(8) | to(8) | prevHash | hash
+	data[7+1+8+8+types.HashSize+types.HashSize] = 0xff
+	if err = ioutil.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sync_cache.VerifyExportFile(filename); err == nil {
+		t.Fatal("oversized record should not pass verification")
+	}
+	if err := importLedgerFile(c, v, filename); err == nil {
+		t.Fatal("oversized record should be rejected")
+	}
+	if height := c.GetLatestSnapshotBlock().Height; height != 10 {
+		t.Fatalf("latest snapshot height is %d, should be 10", height)
+	}
+}
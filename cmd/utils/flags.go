@@ -24,6 +24,10 @@ var (
 		Name:  "keystore",
 		Usage: "Directory for the keystore (default = inside the datadir)",
 	}
+	ExternalSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "The ipc path or http url of an external signer which keeps the keys",
+	}
 
 	// Network Settings
 	TestNetFlag = cli.BoolFlag{
@@ -96,6 +100,10 @@ var (
 		Name:  "rpcport",
 		Usage: "HTTP-RPC server listening port",
 	}
+	GraphQLEnabledFlag = cli.BoolFlag{
+		Name:  "graphql",
+		Usage: "Enable the GraphQL endpoint on the HTTP-RPC server at /graphql",
+	}
 
 	//WS Settings
 	WSEnabledFlag = cli.BoolFlag{
@@ -147,6 +155,10 @@ var (
 		Name:  "loglevel",
 		Usage: "log level (info,eror,warn,dbug)",
 	}
+	LogFormatFlag = cli.StringFlag{
+		Name:  "logformat",
+		Usage: "format of the log files (logfmt,json)",
+	}
 
 	//VM
 	VMTestFlag = cli.BoolFlag{
@@ -189,6 +201,96 @@ var (
 		Name:  "sbHeight",
 		Usage: "The snapshot block height",
 	}
+	ExportFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The first snapshot block height to export, at least 2",
+	}
+	ExportToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The last snapshot block height to export, default is the latest height",
+	}
+	ExportOutFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "The directory the exported ledger files are written to",
+	}
+
+	// Import
+	ImportInFlag = cli.StringFlag{
+		Name:  "in",
+		Usage: "The exported ledger file or the directory of files to import",
+	}
+
+	// Pow server
+	PowServerListenFlag = cli.StringFlag{
+		Name:  "pow.listen",
+		Usage: "The address the pow server listens on",
+		Value: "127.0.0.1:6007",
+	}
+	PowServerWorkersFlag = cli.IntFlag{
+		Name:  "pow.workers",
+		Usage: "The count of works calculated at the same time, default is the count of cpu",
+	}
+	PowServerQueueFlag = cli.IntFlag{
+		Name:  "pow.queue",
+		Usage: "The max count of queued works",
+		Value: 1000,
+	}
+	PowServerRateLimitFlag = cli.Float64Flag{
+		Name:  "pow.ratelimit",
+		Usage: "The generate requests per second of a client, 0 means no limit",
+	}
+	PowServerRateBurstFlag = cli.IntFlag{
+		Name:  "pow.rateburst",
+		Usage: "The max burst of generate requests of a client",
+		Value: 10,
+	}
+
+	// Abigen
+	AbigenAbiFlag = cli.StringFlag{
+		Name:  "abigen.abi",
+		Usage: "The contract abi json file to generate the go binding from",
+	}
+	AbigenBinFlag = cli.StringFlag{
+		Name:  "abigen.bin",
+		Usage: "The hex code file of the contract, the deploy function is generated only if it's set",
+	}
+	AbigenOffchainFlag = cli.StringFlag{
+		Name:  "abigen.offchain",
+		Usage: "The hex offchain code file of the contract, which is required to call the getters",
+	}
+	AbigenPkgFlag = cli.StringFlag{
+		Name:  "abigen.pkg",
+		Usage: "The package name of the generated go binding",
+	}
+	AbigenTypeFlag = cli.StringFlag{
+		Name:  "abigen.type",
+		Usage: "The type name of the generated contract, default is the capitalised package name",
+	}
+	AbigenOutFlag = cli.StringFlag{
+		Name:  "abigen.out",
+		Usage: "The file the generated go binding is written to, default is the stdout",
+	}
+
+	// Devnet
+	DevNetAccountsFlag = cli.IntFlag{
+		Name:  "devnet.accounts",
+		Usage: "The count of funded accounts derived from the mnemonic, the first one is the SBP",
+		Value: 10,
+	}
+	DevNetMnemonicFlag = cli.StringFlag{
+		Name:  "devnet.mnemonic",
+		Usage: "The mnemonic of the funded accounts",
+		Value: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+	}
+	DevNetBalanceFlag = cli.StringFlag{
+		Name:  "devnet.balance",
+		Usage: "The VITE balance of every funded account",
+		Value: "1000000",
+	}
+	DevNetInstamineFlag = cli.BoolFlag{
+		Name:  "devnet.instamine",
+		Usage: "Produce a snapshot block as soon as an account block arrives",
+	}
 
 	//Net
 	SingleFlag = cli.BoolFlag{
@@ -201,6 +303,36 @@ var (
 		Usage: "File transfer listening port",
 	}
 
+	StateSyncFlag = cli.BoolFlag{
+		Name:  "statesync",
+		Usage: "Download the state of a recent snapshot block from peers when the ledger is new, instead of all blocks",
+	}
+
+	StateSyncCheckpointFlag = cli.StringFlag{
+		Name:  "statesynccheckpoint",
+		Usage: "The trusted state snapshot to download, like \"height-snapshotHash-stateRoot\"",
+	}
+
+	LightServeFlag = cli.BoolFlag{
+		Name:  "lightserve",
+		Usage: "Serve the light clients of the edge nodes on the file port",
+	}
+
+	RequireEncryptionFlag = cli.BoolFlag{
+		Name:  "requireencryption",
+		Usage: "Refuse the peers can not encrypt messages, instead of fallback to plaintext",
+	}
+
+	NodeModeFlag = cli.StringFlag{
+		Name:  "nodemode",
+		Usage: "Node mode, the edge node runs the light client instead of the full ledger, \"edge\" or empty",
+	}
+
+	LightServersFlag = cli.StringFlag{
+		Name:  "lightservers",
+		Usage: "Comma separated light servers of the edge node, like: <hex_node_id>@host:fileport",
+	}
+
 	//Stat
 	PProfEnabledFlag = cli.BoolFlag{
 		Name:  "pprof",
@@ -249,6 +381,10 @@ var (
 		Usage: "InfluxDB `host` tag attached to all measurements",
 		Value: "localhost",
 	}
+	PrometheusEnableFlag = cli.BoolFlag{
+		Name:  "metrics.prometheus",
+		Usage: "Enable the Prometheus endpoint at /metrics of the HTTP-RPC server",
+	}
 )
 
 // This allows the use of the existing configuration functionality.
@@ -58,12 +58,32 @@ func DefaultIpcFile() string {
 	return endpoint
 }
 
+// the rotation of the log files made by MakeDefaultLogger, it's changed by SetLogRotation
+var (
+	logMaxSize    = 100
+	logMaxBackups = 14
+	logMaxAge     = 14
+)
+
+// SetLogRotation sets the rotation of the log files made after it, 0 keeps the default value
+func SetLogRotation(maxSize, maxAge, maxBackups int) {
+	if maxSize > 0 {
+		logMaxSize = maxSize
+	}
+	if maxAge > 0 {
+		logMaxAge = maxAge
+	}
+	if maxBackups > 0 {
+		logMaxBackups = maxBackups
+	}
+}
+
 func MakeDefaultLogger(absFilePath string) *lumberjack.Logger {
 	return &lumberjack.Logger{
 		Filename:   absFilePath,
-		MaxSize:    100,
-		MaxBackups: 14,
-		MaxAge:     14,
+		MaxSize:    logMaxSize,
+		MaxBackups: logMaxBackups,
+		MaxAge:     logMaxAge,
 		Compress:   true,
 		LocalTime:  true,
 	}
@@ -8,6 +8,10 @@ type Chain struct {
 	GenesisFile    string // genesis file path
 	LedgerGc       bool   // open or close ledger garbage collector
 	OpenPlugins    bool   // open or close chain plugins. eg, filter account blocks by token.
+	ArchiveMode    bool   // keep the state redo logs of all snapshot blocks, it will cost more disk space
+
+	SqlIndexerDriver string // database/sql driver name of the sql indexer plugin, "sqlite3" is built in, other drivers must be registered in the binary. empty means closed
+	SqlIndexerDSN    string // data source name of the sql indexer plugin, eg, "file:/path/index.db". default is sql_indexer.db in the ledger dir for sqlite3
 
 	VmLogWhiteList []types.Address // contract address white list which save VM logs
 	VmLogAll       bool            // save all VM logs, it will cost more disk space
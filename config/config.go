@@ -17,6 +17,8 @@ type Config struct {
 	*Net        `json:"Net"`
 	*biz.Reward `json:"Reward"`
 	*Genesis    `json:"Genesis"`
+	*SBPMonitor `json:"SBPMonitor"`
+	*Light      `json:"Light"`
 
 	// global keys
 	DataDir string `json:"DataDir"`
@@ -1,8 +1,10 @@
 package config_gen
 
 import (
+	"math/big"
 	"testing"
 
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/crypto"
@@ -24,3 +26,35 @@ func TestGenesisCfg(t *testing.T) {
 	t.Log(hash)
 	assert.Equal(t, "79803fa6fc50f7e7ce18366fd6595394d6d257a191ed47850ca09609749e8f21", hash.String())
 }
+
+func TestMakeDevNetGenesis(t *testing.T) {
+	addr1, err := types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr2, err := types.HexToAddress("vite_360232b0378111b122685a15e612143dc9a89cfa7e803f4b5a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance := big.NewInt(1e18)
+
+	cfg, err := config.MakeDevNetGenesis([]types.Address{addr1, addr2}, balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !config.IsCompleteGenesisConfig(cfg) {
+		t.Fatalf("devnet genesis config is incomplete")
+	}
+	if err := fork.CheckForkPoints(*cfg.ForkPoints); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, *cfg.GenesisAccountAddress, addr1)
+	assert.Equal(t, len(cfg.AccountBalanceMap), 4)
+
+	if _, err := config.MakeDevNetGenesis([]types.Address{addr1, addr1}, balance); err == nil {
+		t.Fatalf("duplicate accounts should fail")
+	}
+	if _, err := config.MakeDevNetGenesis(nil, balance); err == nil {
+		t.Fatalf("empty accounts should fail")
+	}
+}
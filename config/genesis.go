@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/vitelabs/go-vite/common/types"
 	"math/big"
 )
@@ -174,3 +175,141 @@ type StakeInfo struct {
 	BeneficialAddr   *types.Address // Deprecated
 	Beneficiary      *types.Address
 }
+
+const (
+	devNetSbpName = "s1"
+	viteTokenId   = "tti_5649544520544f4b454e6e40"
+)
+
+var (
+	devNetRegisterAmount = new(big.Int).Mul(big.NewInt(1e5), big.NewInt(1e18))
+	devNetStakeAmount    = new(big.Int).Mul(big.NewInt(1e4), big.NewInt(1e18))
+)
+
+// MakeDevNetGenesis makes the genesis of a local development network. The first account is the only SBP
+// of both consensus groups, every account is funded with balance and gets quota from a genesis stake.
+// All forks are active from the beginning.
+func MakeDevNetGenesis(accounts []types.Address, balance *big.Int) (*Genesis, error) {
+	if len(accounts) == 0 {
+		return nil, errors.New("devnet genesis needs at least one account")
+	}
+	tokenId, err := types.HexToTokenTypeId(viteTokenId)
+	if err != nil {
+		return nil, err
+	}
+	sbp := accounts[0]
+
+	g := &Genesis{
+		GenesisAccountAddress: &sbp,
+		ForkPoints: &ForkPoints{
+			SeedFork:      &ForkPoint{Height: 1, Version: 1},
+			DexFork:       &ForkPoint{Height: 2, Version: 2},
+			DexFeeFork:    &ForkPoint{Height: 3, Version: 3},
+			StemFork:      &ForkPoint{Height: 4, Version: 4},
+			LeafFork:      &ForkPoint{Height: 5, Version: 5},
+			EarthFork:     &ForkPoint{Height: 6, Version: 6},
+			DexMiningFork: &ForkPoint{Height: 7, Version: 7},
+		},
+		GovernanceInfo: &GovernanceContractInfo{
+			ConsensusGroupInfoMap: make(map[string]*ConsensusGroupInfo),
+			RegistrationInfoMap:   make(map[string]map[string]*RegistrationInfo),
+			VoteStatusMap:         make(map[string]map[string]string),
+		},
+		AssetInfo: &AssetContractInfo{
+			TokenInfoMap: make(map[string]*TokenInfo),
+		},
+		QuotaInfo: &QuotaContractInfo{
+			StakeInfoMap:       make(map[string][]*StakeInfo),
+			StakeBeneficialMap: make(map[string]*big.Int),
+		},
+		AccountBalanceMap: make(map[string]map[string]*big.Int),
+	}
+
+	// snapshot blocks are produced every second, contract blocks every 3 seconds
+	groups := map[types.Gid]struct {
+		interval, perCount int64
+		checkLevel         uint8
+	}{
+		types.SNAPSHOT_GID: {1, 3, 0},
+		types.DELEGATE_GID: {3, 1, 1},
+	}
+	for gid, group := range groups {
+		g.GovernanceInfo.ConsensusGroupInfoMap[gid.String()] = &ConsensusGroupInfo{
+			NodeCount:           1,
+			Interval:            group.interval,
+			PerCount:            group.perCount,
+			RandCount:           0,
+			RandRank:            100,
+			Repeat:              1,
+			CheckLevel:          group.checkLevel,
+			CountingTokenId:     tokenId,
+			RegisterConditionId: 1,
+			RegisterConditionParam: RegisterConditionParam{
+				StakeAmount: devNetRegisterAmount,
+				StakeToken:  tokenId,
+				StakeHeight: 1,
+			},
+			VoteConditionId:  1,
+			Owner:            sbp,
+			StakeAmount:      big.NewInt(0),
+			ExpirationHeight: 1,
+		}
+		g.GovernanceInfo.RegistrationInfoMap[gid.String()] = map[string]*RegistrationInfo{
+			devNetSbpName: {
+				BlockProducingAddress: &sbp,
+				StakeAddress:          &sbp,
+				Amount:                devNetRegisterAmount,
+				ExpirationHeight:      1,
+				RewardTime:            1,
+				HistoryAddressList:    []types.Address{sbp},
+			},
+		}
+		g.GovernanceInfo.VoteStatusMap[gid.String()] = map[string]string{sbp.String(): devNetSbpName}
+	}
+
+	totalSupply := new(big.Int)
+	stakeAmount := new(big.Int)
+	for _, addr := range accounts {
+		if _, ok := g.AccountBalanceMap[addr.String()]; ok {
+			return nil, errors.New("duplicated devnet account " + addr.String())
+		}
+		beneficiary := addr
+		g.AccountBalanceMap[addr.String()] = map[string]*big.Int{viteTokenId: new(big.Int).Set(balance)}
+		g.QuotaInfo.StakeInfoMap[addr.String()] = []*StakeInfo{{
+			Amount:           devNetStakeAmount,
+			ExpirationHeight: 1,
+			Beneficiary:      &beneficiary,
+		}}
+		g.QuotaInfo.StakeBeneficialMap[addr.String()] = devNetStakeAmount
+		totalSupply.Add(totalSupply, balance)
+		stakeAmount.Add(stakeAmount, devNetStakeAmount)
+	}
+
+	// the staked tokens are kept by the contracts
+	registerAmount := new(big.Int).Mul(devNetRegisterAmount, big.NewInt(int64(len(groups))))
+	g.AccountBalanceMap[types.AddressGovernance.String()] = map[string]*big.Int{viteTokenId: registerAmount}
+	g.AccountBalanceMap[types.AddressQuota.String()] = map[string]*big.Int{viteTokenId: stakeAmount}
+	totalSupply.Add(totalSupply, registerAmount)
+	totalSupply.Add(totalSupply, stakeAmount)
+
+	maxSupply, _ := new(big.Int).SetString("115792089237316195423570985008687907853269984665640564039457584007913129639935", 10)
+	g.AssetInfo.TokenInfoMap[viteTokenId] = &TokenInfo{
+		TokenName:    "Vite Token",
+		TokenSymbol:  "VITE",
+		TotalSupply:  totalSupply,
+		Decimals:     18,
+		Owner:        sbp,
+		MaxSupply:    maxSupply,
+		IsReIssuable: true,
+	}
+	// the issue event of vite token
+	issueTopic, err := types.HexToHash("3f9dcc00d5e929040142c3fb2b67a3be1b0e91e98dac18d5bc2b7817a4cfecb6")
+	if err != nil {
+		return nil, err
+	}
+	var tokenTopic types.Hash
+	copy(tokenTopic[types.HashSize-types.TokenTypeIdSize:], tokenId.Bytes())
+	g.AssetInfo.LogList = []*GenesisVmLog{{Topics: []types.Hash{issueTopic, tokenTopic}}}
+
+	return g, nil
+}
@@ -0,0 +1,14 @@
+package config
+
+// Light configures the light client of the edge nodes, it syncs the snapshot headers from the light servers,
+// and it requests the account blocks with the proofs on demand
+type Light struct {
+	// the full nodes which serve light clients, like: <hex_node_id>@host:fileport
+	Servers []string `json:"Servers"`
+	// the trusted snapshot block which the headers are synced from, like: "hash/height",
+	// the headers are synced from the genesis snapshot block if it's empty
+	Checkpoint string `json:"Checkpoint"`
+	// the count of the servers which must agree on the producers of a round, it must be a majority of the servers,
+	// a majority is used if it's 0
+	Quorum int `json:"Quorum"`
+}
@@ -25,6 +25,7 @@ const (
 	DefaultMaxInboundRatio = 2
 	DefaultMinPeers        = 5
 	DefaultMaxPendingPeers = 10
+	DefaultMaxLightClients = 50
 
 	DefaultNetDirName = "net"
 	PeerKeyFileName   = "peerKey"
@@ -93,6 +94,20 @@ type Net struct {
 	BlackBlockHashList []string
 	WhiteBlockList     []string
 
+	// StateSync means a new ledger downloads the state of a recent snapshot block from peers, instead of all blocks
+	StateSync bool
+	// StateSyncCheckpoint is the trusted state snapshot to download, like "height-snapshotHash-stateRoot",
+	// it is required by StateSync, and can be taken from a trusted node by debug_getStateCheckpoint
+	StateSyncCheckpoint string
+
+	// LightServe means the node serves the light clients of the edge nodes on the file port
+	LightServe bool
+	// MaxLightClients is the max count of the light client connections
+	MaxLightClients int
+
+	// RequireEncryption means the node refuses the peers can not encrypt messages, instead of fallback to plaintext
+	RequireEncryption bool
+
 	MineKey ed25519.PrivateKey
 }
 
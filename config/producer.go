@@ -4,4 +4,6 @@ type Producer struct {
 	Producer         bool   `json:"Producer"`
 	Coinbase         string `json:"Coinbase"`
 	EntropyStorePath string `json:"EntropyStorePath"`
+	Instamine        bool   `json:"Instamine"` // produce a snapshot block as soon as account blocks are inserted, for development networks
+	DevNet           bool   `json:"DevNet"`    // the producer serves a local development network, its clock can be moved
 }
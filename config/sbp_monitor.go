@@ -0,0 +1,13 @@
+package config
+
+// SBPMonitor configures the monitor of the snapshot block producers, it tracks the missed slots
+// and the success rates of the SBPs
+type SBPMonitor struct {
+	MonitorSBP bool `json:"MonitorSBP"`
+	// the SBPs which are alerted by webhooks and have their own metrics, all SBPs if it's empty
+	MonitorAddresses []string `json:"MonitorAddresses"`
+	// the urls which the missedSlot and producersChanged events are posted to
+	MonitorWebhooks []string `json:"MonitorWebhooks"`
+	// the number of rounds which the success rates are counted in
+	MonitorRateWindow int `json:"MonitorRateWindow"`
+}
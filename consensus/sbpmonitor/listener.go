@@ -0,0 +1,61 @@
+package sbpmonitor
+
+import (
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// chainListener passes the inserted and deleted snapshot blocks to the monitor, the blocks are
+// handled in the loop of the monitor so the chain isn't blocked by the events.
+type chainListener struct {
+	m *Monitor
+}
+
+func (l *chainListener) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (l *chainListener) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (l *chainListener) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (l *chainListener) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	l.m.post(&input{inserted: snapshotInfos(chunks)})
+	return nil
+}
+
+func (l *chainListener) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (l *chainListener) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (l *chainListener) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (l *chainListener) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	l.m.post(&input{deleted: snapshotInfos(chunks)})
+	return nil
+}
+
+func snapshotInfos(chunks []*ledger.SnapshotChunk) []*snapshotInfo {
+	var blocks []*snapshotInfo
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		blocks = append(blocks, &snapshotInfo{
+			hash:      chunk.SnapshotBlock.Hash,
+			producer:  chunk.SnapshotBlock.Producer(),
+			timestamp: *chunk.SnapshotBlock.Timestamp,
+		})
+	}
+	return blocks
+}
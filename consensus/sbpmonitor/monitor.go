@@ -0,0 +1,558 @@
+package sbpmonitor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+// the types of ProducerEvent
+const (
+	EventMissedSlot       = "missedSlot"
+	EventRoundFinished    = "roundFinished"
+	EventProducersChanged = "producersChanged"
+)
+
+const (
+	subscribeId       = "sbpmonitor"
+	defaultRateWindow = 48
+	// the rounds are dropped without checking if the chain doesn't reach them, e.g. the node is syncing
+	maxPendingRounds = 10
+)
+
+var log = log15.New("module", "sbpmonitor")
+
+// ProducerEvent is published when a slot of the snapshot chain is missed, a round is finished
+// or the producers of the next round are different from the last round.
+type ProducerEvent struct {
+	Type  string `json:"type"`
+	Round uint64 `json:"round"`
+
+	// the slot which is missed, for missedSlot
+	Address *types.Address `json:"address,omitempty"`
+	STime   *time.Time     `json:"sTime,omitempty"`
+	ETime   *time.Time     `json:"eTime,omitempty"`
+
+	// the SBPs which missed slots in the round and the count of the slots, for roundFinished
+	Missed   []types.Address `json:"missed,omitempty"`
+	Produced int             `json:"produced,omitempty"`
+	Expected int             `json:"expected,omitempty"`
+
+	// the producers of the round and the changes from the last round, for producersChanged
+	Producers []types.Address `json:"producers,omitempty"`
+	Added     []types.Address `json:"added,omitempty"`
+	Removed   []types.Address `json:"removed,omitempty"`
+}
+
+// ProducerStat is the rolling success rate of an SBP in the last rounds
+type ProducerStat struct {
+	Address     types.Address `json:"address"`
+	Expected    int           `json:"expected"`
+	Produced    int           `json:"produced"`
+	SuccessRate float64       `json:"successRate"`
+}
+
+type consensusReader interface {
+	SubscribeProducers(gid types.Gid, id string, fn func(event consensus.ProducersEvent))
+	UnSubscribe(gid types.Gid, id string)
+	ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error)
+}
+
+type chainReader interface {
+	Register(listener chain.EventListener)
+	UnRegister(listener chain.EventListener)
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+}
+
+type slot struct {
+	addr     types.Address
+	stime    time.Time
+	etime    time.Time
+	produced *types.Hash
+	done     bool
+}
+
+type round struct {
+	index   uint64
+	slots   []*slot
+	pending int
+}
+
+type roundStat struct {
+	index    uint64
+	expected map[types.Address]int
+	produced map[types.Address]int
+}
+
+type snapshotInfo struct {
+	hash      types.Hash
+	producer  types.Address
+	timestamp time.Time
+}
+
+// input is an input of the loop of the monitor, the producers of a round, or the inserted or deleted snapshot blocks
+type input struct {
+	producers *consensus.ProducersEvent
+	inserted  []*snapshotInfo
+	deleted   []*snapshotInfo
+}
+
+type addrMetrics struct {
+	missed metrics.Counter
+	rate   metrics.GaugeFloat64
+}
+
+// Monitor tracks the slots of every round of the snapshot chain. A slot is checked when the chain has
+// a snapshot block after it, so the slots aren't reported as missed while the node is syncing.
+type Monitor struct {
+	cs      consensusReader
+	chain   chainReader
+	watched map[types.Address]bool
+	window  int
+	webhook *webhookSender
+
+	mu            sync.Mutex
+	rounds        map[uint64]*round
+	lastIndex     uint64 // the index of the latest finished round
+	lastProducers []types.Address
+	latest        time.Time // the timestamp of the latest snapshot block
+	history       []*roundStat
+
+	subMu  sync.RWMutex
+	subs   map[int]func(*ProducerEvent)
+	nextId int
+
+	inputC   chan *input
+	stopC    chan struct{}
+	wg       sync.WaitGroup
+	listener *chainListener
+
+	registry        metrics.Registry
+	missedCounter   metrics.Counter
+	producedCounter metrics.Counter
+	rotationCounter metrics.Counter
+	roundGauge      metrics.Gauge
+	addrMetrics     map[types.Address]*addrMetrics
+}
+
+// New creates the monitor by the config, cs provides the producers of the rounds and ch provides the snapshot blocks
+func New(cfg *config.SBPMonitor, cs consensusReader, ch chainReader) (*Monitor, error) {
+	m := &Monitor{
+		cs:          cs,
+		chain:       ch,
+		watched:     make(map[types.Address]bool),
+		window:      cfg.MonitorRateWindow,
+		rounds:      make(map[uint64]*round),
+		subs:        make(map[int]func(*ProducerEvent)),
+		inputC:      make(chan *input, 64),
+		stopC:       make(chan struct{}),
+		addrMetrics: make(map[types.Address]*addrMetrics),
+	}
+	if m.window <= 0 {
+		m.window = defaultRateWindow
+	}
+	for _, s := range cfg.MonitorAddresses {
+		addr, err := types.HexToAddress(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid monitor address %s", s)
+		}
+		m.watched[addr] = true
+	}
+	if len(cfg.MonitorWebhooks) > 0 {
+		m.webhook = newWebhookSender(cfg.MonitorWebhooks)
+	}
+	m.listener = &chainListener{m: m}
+
+	m.registry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/sbp")
+	m.missedCounter = metrics.NewRegisteredCounter("/slots/missed", m.registry)
+	m.producedCounter = metrics.NewRegisteredCounter("/slots/produced", m.registry)
+	m.rotationCounter = metrics.NewRegisteredCounter("/rotations", m.registry)
+	m.roundGauge = metrics.NewRegisteredGauge("/round", m.registry)
+	return m, nil
+}
+
+// Start subscribes the producers of the rounds and the snapshot blocks
+func (m *Monitor) Start() {
+	if latest := m.chain.GetLatestSnapshotBlock(); latest != nil {
+		m.latest = *latest.Timestamp
+	}
+	if m.webhook != nil {
+		m.webhook.start()
+	}
+	m.wg.Add(1)
+	go m.loop()
+
+	m.chain.Register(m.listener)
+	m.cs.SubscribeProducers(types.SNAPSHOT_GID, subscribeId, m.onProducers)
+}
+
+// Stop unsubscribes the events, the pending rounds are dropped
+func (m *Monitor) Stop() {
+	m.cs.UnSubscribe(types.SNAPSHOT_GID, subscribeId)
+	m.chain.UnRegister(m.listener)
+
+	close(m.stopC)
+	m.wg.Wait()
+	if m.webhook != nil {
+		m.webhook.stop()
+	}
+}
+
+// SubscribeProducerEvents adds fn which is called with every event, it must not block
+func (m *Monitor) SubscribeProducerEvents(fn func(*ProducerEvent)) int {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	m.nextId++
+	m.subs[m.nextId] = fn
+	return m.nextId
+}
+
+// UnsubscribeProducerEvents removes the function added by SubscribeProducerEvents
+func (m *Monitor) UnsubscribeProducerEvents(id int) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+	delete(m.subs, id)
+}
+
+// ProducerStats returns the success rates of the SBPs in the last finished rounds, the size of the window
+// is MonitorRateWindow
+func (m *Monitor) ProducerStats() []*ProducerStat {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make(map[types.Address]*ProducerStat)
+	for _, h := range m.history {
+		for addr, expected := range h.expected {
+			stat, ok := stats[addr]
+			if !ok {
+				stat = &ProducerStat{Address: addr}
+				stats[addr] = stat
+			}
+			stat.Expected += expected
+			stat.Produced += h.produced[addr]
+		}
+	}
+	result := make([]*ProducerStat, 0, len(stats))
+	for _, stat := range stats {
+		stat.SuccessRate = float64(stat.Produced) / float64(stat.Expected)
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Address.String() < result[j].Address.String()
+	})
+	return result
+}
+
+// successRate is the rolling success rate of addr, it's 1 if addr isn't a producer of the last rounds
+func (m *Monitor) successRate(addr types.Address) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	expected, produced := 0, 0
+	for _, h := range m.history {
+		expected += h.expected[addr]
+		produced += h.produced[addr]
+	}
+	if expected == 0 {
+		return 1
+	}
+	return float64(produced) / float64(expected)
+}
+
+func (m *Monitor) onProducers(e consensus.ProducersEvent) {
+	m.post(&input{producers: &e})
+}
+
+// post passes in to the loop, the inputs are handled in order
+func (m *Monitor) post(in *input) {
+	select {
+	case m.inputC <- in:
+	case <-m.stopC:
+	}
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+	for {
+		var events []*ProducerEvent
+		select {
+		case in := <-m.inputC:
+			switch {
+			case in.producers != nil:
+				events = m.handleProducers(*in.producers)
+			case in.inserted != nil:
+				events = m.handleInserted(in.inserted)
+			case in.deleted != nil:
+				m.handleDeleted(in.deleted)
+			}
+		case <-m.stopC:
+			return
+		}
+		m.publish(events)
+	}
+}
+
+func (m *Monitor) handleProducers(e consensus.ProducersEvent) []*ProducerEvent {
+	plans, index, err := m.cs.ReadByIndex(types.SNAPSHOT_GID, e.Index)
+	if err != nil {
+		log.Error("read the plans of round failed", "round", e.Index, "err", err)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.rounds[index]; ok || (m.lastIndex > 0 && index <= m.lastIndex) {
+		return nil
+	}
+
+	var events []*ProducerEvent
+	producers := uniqueAddresses(e.Addrs)
+	if m.lastProducers != nil {
+		added, removed := diffAddresses(m.lastProducers, producers)
+		if len(added) > 0 || len(removed) > 0 {
+			m.rotationCounter.Inc(1)
+			log.Info("producers changed", "round", index, "added", added, "removed", removed)
+			events = append(events, &ProducerEvent{
+				Type:      EventProducersChanged,
+				Round:     index,
+				Producers: producers,
+				Added:     added,
+				Removed:   removed,
+			})
+		}
+	}
+	m.lastProducers = producers
+
+	r := &round{index: index}
+	for _, p := range plans {
+		r.slots = append(r.slots, &slot{addr: p.Address, stime: p.Stime, etime: p.Etime})
+		m.registerAddrMetrics(p.Address)
+	}
+	r.pending = len(r.slots)
+	m.rounds[index] = r
+
+	for len(m.rounds) > maxPendingRounds {
+		oldest := m.sortedRounds()[0]
+		log.Warn("the chain is behind the round, drop it", "round", oldest.index)
+		delete(m.rounds, oldest.index)
+	}
+	return append(events, m.settle()...)
+}
+
+func (m *Monitor) handleInserted(blocks []*snapshotInfo) []*ProducerEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, b := range blocks {
+		if b.timestamp.After(m.latest) {
+			m.latest = b.timestamp
+		}
+		for _, r := range m.rounds {
+			for _, s := range r.slots {
+				if s.done || s.produced != nil || s.addr != b.producer {
+					continue
+				}
+				if !b.timestamp.Before(s.stime) && b.timestamp.Before(s.etime) {
+					hash := b.hash
+					s.produced = &hash
+				}
+			}
+		}
+	}
+	return m.settle()
+}
+
+func (m *Monitor) handleDeleted(blocks []*snapshotInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, b := range blocks {
+		// the chain falls back to the block before the deleted blocks
+		if !b.timestamp.After(m.latest) {
+			m.latest = b.timestamp.Add(-time.Nanosecond)
+		}
+		for _, r := range m.rounds {
+			for _, s := range r.slots {
+				if !s.done && s.produced != nil && *s.produced == b.hash {
+					s.produced = nil
+				}
+			}
+		}
+	}
+}
+
+// settle checks the slots which the chain has passed, and finishes the rounds whose slots are all checked
+func (m *Monitor) settle() []*ProducerEvent {
+	var events []*ProducerEvent
+	for _, r := range m.sortedRounds() {
+		for _, s := range r.slots {
+			if s.done || m.latest.Before(s.etime) {
+				continue
+			}
+			s.done = true
+			r.pending--
+			if s.produced != nil {
+				m.producedCounter.Inc(1)
+				continue
+			}
+			m.missedCounter.Inc(1)
+			if am, ok := m.addrMetrics[s.addr]; ok {
+				am.missed.Inc(1)
+			}
+			addr, stime, etime := s.addr, s.stime, s.etime
+			log.Warn("SBP missed the slot", "round", r.index, "address", addr, "stime", stime, "etime", etime)
+			events = append(events, &ProducerEvent{
+				Type:    EventMissedSlot,
+				Round:   r.index,
+				Address: &addr,
+				STime:   &stime,
+				ETime:   &etime,
+			})
+		}
+		if r.pending > 0 {
+			continue
+		}
+		events = append(events, m.finishRound(r))
+	}
+	return events
+}
+
+func (m *Monitor) finishRound(r *round) *ProducerEvent {
+	delete(m.rounds, r.index)
+	if r.index > m.lastIndex {
+		m.lastIndex = r.index
+		m.roundGauge.Update(int64(r.index))
+	}
+
+	stat := &roundStat{
+		index:    r.index,
+		expected: make(map[types.Address]int),
+		produced: make(map[types.Address]int),
+	}
+	e := &ProducerEvent{Type: EventRoundFinished, Round: r.index, Expected: len(r.slots)}
+	missed := make(map[types.Address]bool)
+	for _, s := range r.slots {
+		stat.expected[s.addr]++
+		if s.produced != nil {
+			stat.produced[s.addr]++
+			e.Produced++
+		} else if !missed[s.addr] {
+			missed[s.addr] = true
+			e.Missed = append(e.Missed, s.addr)
+		}
+	}
+	m.history = append(m.history, stat)
+	if len(m.history) > m.window {
+		m.history = m.history[len(m.history)-m.window:]
+	}
+	return e
+}
+
+func (m *Monitor) registerAddrMetrics(addr types.Address) {
+	if _, ok := m.addrMetrics[addr]; ok {
+		return
+	}
+	if len(m.watched) > 0 && !m.watched[addr] {
+		return
+	}
+	m.addrMetrics[addr] = &addrMetrics{
+		missed: metrics.NewRegisteredCounter("/producer/"+addr.String()+"/missed", m.registry),
+		rate: metrics.NewRegisteredFunctionalGaugeFloat64("/producer/"+addr.String()+"/successrate", m.registry, func() float64 {
+			return m.successRate(addr)
+		}),
+	}
+}
+
+func (m *Monitor) sortedRounds() []*round {
+	rounds := make([]*round, 0, len(m.rounds))
+	for _, r := range m.rounds {
+		rounds = append(rounds, r)
+	}
+	sort.Slice(rounds, func(i, j int) bool {
+		return rounds[i].index < rounds[j].index
+	})
+	return rounds
+}
+
+func (m *Monitor) publish(events []*ProducerEvent) {
+	if len(events) == 0 {
+		return
+	}
+	m.subMu.RLock()
+	for _, fn := range m.subs {
+		for _, e := range events {
+			fn(e)
+		}
+	}
+	m.subMu.RUnlock()
+
+	if m.webhook == nil {
+		return
+	}
+	for _, e := range events {
+		if m.alerting(e) {
+			m.webhook.send(e)
+		}
+	}
+}
+
+// alerting returns true if e is posted to the webhooks, they are the missed slots and the producer changes
+// of the watched SBPs
+func (m *Monitor) alerting(e *ProducerEvent) bool {
+	switch e.Type {
+	case EventMissedSlot:
+		return len(m.watched) == 0 || m.watched[*e.Address]
+	case EventProducersChanged:
+		if len(m.watched) == 0 {
+			return true
+		}
+		for _, addr := range append(e.Added, e.Removed...) {
+			if m.watched[addr] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func uniqueAddresses(addrs []types.Address) []types.Address {
+	seen := make(map[types.Address]bool, len(addrs))
+	var result []types.Address
+	for _, addr := range addrs {
+		if !seen[addr] {
+			seen[addr] = true
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+func diffAddresses(old, current []types.Address) (added, removed []types.Address) {
+	oldSet := make(map[types.Address]bool, len(old))
+	for _, addr := range old {
+		oldSet[addr] = true
+	}
+	currentSet := make(map[types.Address]bool, len(current))
+	for _, addr := range current {
+		currentSet[addr] = true
+		if !oldSet[addr] {
+			added = append(added, addr)
+		}
+	}
+	for _, addr := range old {
+		if !currentSet[addr] {
+			removed = append(removed, addr)
+		}
+	}
+	return
+}
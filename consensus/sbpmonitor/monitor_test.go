@@ -0,0 +1,234 @@
+package sbpmonitor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	testGenesisTime = time.Unix(1600000000, 0)
+	testKeyA        = []byte("sbp-a")
+	testKeyB        = []byte("sbp-b")
+	testKeyC        = []byte("sbp-c")
+	testAddrA       = types.PubkeyToAddress(testKeyA)
+	testAddrB       = types.PubkeyToAddress(testKeyB)
+	testAddrC       = types.PubkeyToAddress(testKeyC)
+)
+
+type testConsensus struct {
+	fn    func(consensus.ProducersEvent)
+	plans map[uint64][]*consensus.Event
+}
+
+func (c *testConsensus) SubscribeProducers(gid types.Gid, id string, fn func(event consensus.ProducersEvent)) {
+	c.fn = fn
+}
+
+func (c *testConsensus) UnSubscribe(gid types.Gid, id string) {
+	c.fn = nil
+}
+
+func (c *testConsensus) ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error) {
+	return c.plans[index], index, nil
+}
+
+// round sets the plans of the round, every producer has a slot of a second
+func (c *testConsensus) round(index uint64, producers ...types.Address) {
+	stime := testGenesisTime.Add(time.Duration(index*uint64(len(producers))) * time.Second)
+	var plans []*consensus.Event
+	for i, addr := range producers {
+		s := stime.Add(time.Duration(i) * time.Second)
+		plans = append(plans, &consensus.Event{Address: addr, Stime: s, Etime: s.Add(time.Second)})
+	}
+	c.plans[index] = plans
+	c.fn(consensus.ProducersEvent{Addrs: producers, Index: index, Gid: types.SNAPSHOT_GID})
+}
+
+type testChain struct {
+	listener chain.EventListener
+}
+
+func (c *testChain) Register(listener chain.EventListener) {
+	c.listener = listener
+}
+
+func (c *testChain) UnRegister(listener chain.EventListener) {
+	c.listener = nil
+}
+
+func (c *testChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{Timestamp: &testGenesisTime}
+}
+
+func testChunks(key []byte, second int64) []*ledger.SnapshotChunk {
+	timestamp := testGenesisTime.Add(time.Duration(second) * time.Second)
+	block := &ledger.SnapshotBlock{PublicKey: key, Timestamp: &timestamp}
+	block.Hash = types.DataHash([]byte(timestamp.String()))
+	return []*ledger.SnapshotChunk{{SnapshotBlock: block}}
+}
+
+func newTestMonitor(t *testing.T, cfg *config.SBPMonitor) (*Monitor, *testConsensus, *testChain, chan *ProducerEvent) {
+	cs := &testConsensus{plans: make(map[uint64][]*consensus.Event)}
+	ch := &testChain{}
+	m, err := New(cfg, cs, ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *ProducerEvent, 16)
+	m.SubscribeProducerEvents(func(e *ProducerEvent) {
+		events <- e
+	})
+	m.Start()
+	return m, cs, ch, events
+}
+
+func nextEvent(t *testing.T, events chan *ProducerEvent) *ProducerEvent {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("no event")
+	}
+	return nil
+}
+
+func noEvent(t *testing.T, events chan *ProducerEvent) {
+	select {
+	case e := <-events:
+		t.Fatalf("unexpected event %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestMonitor_MissedSlot(t *testing.T) {
+	m, cs, ch, events := newTestMonitor(t, &config.SBPMonitor{MonitorSBP: true})
+	defer m.Stop()
+
+	// round 1 is in [3, 6)
+	cs.round(1, testAddrA, testAddrB, testAddrC)
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 3))
+	noEvent(t, events)
+
+	// B produces in the slot of C, the slot of B is missed when the chain passes it
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyB, 5))
+	e := nextEvent(t, events)
+	if e.Type != EventMissedSlot || *e.Address != testAddrB || e.Round != 1 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	noEvent(t, events)
+
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 6))
+	e = nextEvent(t, events)
+	if e.Type != EventMissedSlot || *e.Address != testAddrC {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	e = nextEvent(t, events)
+	if e.Type != EventRoundFinished || e.Expected != 3 || e.Produced != 1 || len(e.Missed) != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	stats := m.ProducerStats()
+	if len(stats) != 3 {
+		t.Fatalf("unexpected stats %+v", stats)
+	}
+	for _, stat := range stats {
+		if stat.Address == testAddrA && stat.SuccessRate != 1 || stat.Address != testAddrA && stat.SuccessRate != 0 {
+			t.Fatalf("unexpected stat %+v", stat)
+		}
+	}
+}
+
+func TestMonitor_DeleteSnapshotBlocks(t *testing.T) {
+	m, cs, ch, events := newTestMonitor(t, &config.SBPMonitor{MonitorSBP: true})
+	defer m.Stop()
+
+	// round 1 is in [2, 4)
+	cs.round(1, testAddrA, testAddrB)
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 2))
+	chunks := testChunks(testKeyB, 3)
+	ch.listener.InsertSnapshotBlocks(chunks)
+	ch.listener.DeleteSnapshotBlocks(chunks)
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 4))
+
+	e := nextEvent(t, events)
+	if e.Type != EventMissedSlot || *e.Address != testAddrB {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	e = nextEvent(t, events)
+	if e.Type != EventRoundFinished || e.Produced != 1 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
+
+func TestMonitor_ProducersChanged(t *testing.T) {
+	m, cs, ch, events := newTestMonitor(t, &config.SBPMonitor{MonitorSBP: true})
+	defer m.Stop()
+
+	cs.round(1, testAddrA, testAddrB)
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 2))
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyB, 3))
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 4))
+	if e := nextEvent(t, events); e.Type != EventRoundFinished || e.Produced != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+
+	cs.round(2, testAddrA, testAddrC)
+	e := nextEvent(t, events)
+	if e.Type != EventProducersChanged || e.Round != 2 {
+		t.Fatalf("unexpected event %+v", e)
+	}
+	if len(e.Added) != 1 || e.Added[0] != testAddrC || len(e.Removed) != 1 || e.Removed[0] != testAddrB {
+		t.Fatalf("unexpected changes %+v", e)
+	}
+}
+
+func TestMonitor_Webhook(t *testing.T) {
+	posted := make(chan *ProducerEvent, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		e := &ProducerEvent{}
+		if err := json.Unmarshal(body, e); err != nil {
+			t.Error(err)
+		}
+		posted <- e
+	}))
+	defer server.Close()
+
+	m, cs, ch, events := newTestMonitor(t, &config.SBPMonitor{
+		MonitorSBP:       true,
+		MonitorAddresses: []string{testAddrB.String()},
+		MonitorWebhooks:  []string{server.URL},
+	})
+	defer m.Stop()
+
+	// only the missed slot of the watched SBP is posted
+	cs.round(1, testAddrA, testAddrB, testAddrC)
+	ch.listener.InsertSnapshotBlocks(testChunks(testKeyA, 6))
+	for i := 0; i < 4; i++ {
+		nextEvent(t, events)
+	}
+
+	select {
+	case e := <-posted:
+		if e.Type != EventMissedSlot || *e.Address != testAddrB {
+			t.Fatalf("unexpected event %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event posted")
+	}
+	select {
+	case e := <-posted:
+		t.Fatalf("unexpected event posted %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
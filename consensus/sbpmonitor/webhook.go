@@ -0,0 +1,99 @@
+package sbpmonitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	webhookQueueSize = 256
+	webhookTimeout   = 5 * time.Second
+	webhookRetries   = 3
+	webhookRetryWait = time.Second
+)
+
+// webhookSender posts the events to the urls in order, the events are dropped if the queue is full
+type webhookSender struct {
+	urls   []string
+	client *http.Client
+	queue  chan *ProducerEvent
+	stopC  chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newWebhookSender(urls []string) *webhookSender {
+	return &webhookSender{
+		urls:   urls,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan *ProducerEvent, webhookQueueSize),
+		stopC:  make(chan struct{}),
+	}
+}
+
+func (w *webhookSender) start() {
+	w.wg.Add(1)
+	go w.loop()
+}
+
+func (w *webhookSender) stop() {
+	close(w.stopC)
+	w.wg.Wait()
+}
+
+func (w *webhookSender) send(e *ProducerEvent) {
+	select {
+	case w.queue <- e:
+	default:
+		log.Warn("the webhook queue is full, drop the event", "type", e.Type, "round", e.Round)
+	}
+}
+
+func (w *webhookSender) loop() {
+	defer w.wg.Done()
+	for {
+		select {
+		case e := <-w.queue:
+			body, err := json.Marshal(e)
+			if err != nil {
+				log.Error("marshal the event failed", "err", err)
+				continue
+			}
+			for _, url := range w.urls {
+				w.post(url, body)
+			}
+		case <-w.stopC:
+			return
+		}
+	}
+}
+
+func (w *webhookSender) post(url string, body []byte) {
+	for i := 0; i < webhookRetries; i++ {
+		err := w.postOnce(url, body)
+		if err == nil {
+			return
+		}
+		log.Warn("post the event to webhook failed", "url", url, "try", i+1, "err", err)
+		select {
+		case <-time.After(webhookRetryWait):
+		case <-w.stopC:
+			return
+		}
+	}
+}
+
+func (w *webhookSender) postOnce(url string, body []byte) error {
+	resp, err := w.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -3,7 +3,6 @@ package generator
 import (
 	"errors"
 	"fmt"
-	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/consensus/core"
 	"github.com/vitelabs/go-vite/header"
@@ -126,29 +125,13 @@ func (gen *generator) generateBlock(block *ledger.AccountBlock, fromBlock *ledge
 		if latestSb == nil {
 			return nil, fmt.Errorf("vmDb's latestSnapshotBlock is nil")
 		}
-
-		limitSb, err := gen.chain.GetSnapshotBlockByContractMeta(block.AccountAddress, fromBlock.Hash)
+		var err error
+		state, err = NewReceiveVMGlobalStatus(gen.chain, latestSb, block.AccountAddress, fromBlock.Hash)
 		if err != nil {
-			return nil, fmt.Errorf("GetSnapshotBlockByContractMeta failed", "err", err)
-		}
-		if fork.IsSeedFork(latestSb.Height) {
-			limitSeedSb, err := gen.chain.GetSeedConfirmedSnapshotBlock(block.AccountAddress, fromBlock.Hash)
-			if err != nil {
-				return nil, fmt.Errorf("GetSeedConfirmedSnapshotBlock failed", "err", err)
-			}
-			if limitSb == nil {
-				if limitSeedSb != nil {
-					limitSb = limitSeedSb
-				}
-			} else {
-				if limitSeedSb != nil && limitSb.Height < limitSeedSb.Height {
-					limitSb = limitSeedSb
-				}
-			}
+			return nil, err
 		}
-		if limitSb != nil {
-			state = NewVMGlobalStatus(gen.chain, limitSb, fromBlock.Hash)
-			gen.log.Info("gen GlobalStatus", "hash", limitSb.Hash, "fromHash", fromBlock.Hash)
+		if state != nil {
+			gen.log.Info("gen GlobalStatus", "hash", state.sb.Hash, "fromHash", fromBlock.Hash)
 		}
 	}
 
@@ -1,6 +1,8 @@
 package generator
 
 import (
+	"fmt"
+	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
@@ -59,6 +61,34 @@ func NewVMGlobalStatus(c chain, sb *ledger.SnapshotBlock, fromHash types.Hash) *
 	return &VMGlobalStatus{c: c, sb: sb, fromHash: fromHash, setSeed: false}
 }
 
+// NewReceiveVMGlobalStatus returns the global status for a contract receive block, or nil if
+// the seed of the snapshot block is not needed, latestSb is the snapshot block the vm runs upon.
+func NewReceiveVMGlobalStatus(c chain, latestSb *ledger.SnapshotBlock, addr types.Address, fromHash types.Hash) (*VMGlobalStatus, error) {
+	limitSb, err := c.GetSnapshotBlockByContractMeta(addr, fromHash)
+	if err != nil {
+		return nil, fmt.Errorf("GetSnapshotBlockByContractMeta failed, err:%v", err)
+	}
+	if fork.IsSeedFork(latestSb.Height) {
+		limitSeedSb, err := c.GetSeedConfirmedSnapshotBlock(addr, fromHash)
+		if err != nil {
+			return nil, fmt.Errorf("GetSeedConfirmedSnapshotBlock failed, err:%v", err)
+		}
+		if limitSb == nil {
+			if limitSeedSb != nil {
+				limitSb = limitSeedSb
+			}
+		} else {
+			if limitSeedSb != nil && limitSb.Height < limitSeedSb.Height {
+				limitSb = limitSeedSb
+			}
+		}
+	}
+	if limitSb == nil {
+		return nil, nil
+	}
+	return NewVMGlobalStatus(c, limitSb, fromHash), nil
+}
+
 // Seed returns the random seed.
 func (g *VMGlobalStatus) Seed() (uint64, error) {
 	if g.setSeed {
@@ -0,0 +1,446 @@
+// Package graphql provides a read-only GraphQL interface over the ledger, on-road and contract data.
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const (
+	// maxPageSize limits the count of blocks returned by a list field
+	maxPageSize = 100
+)
+
+// Resolver is the root resolver of the query type.
+type Resolver struct {
+	chain chain.Chain
+}
+
+func (r *Resolver) snapshotBlock(sb *ledger.SnapshotBlock) *SnapshotBlock {
+	if sb == nil {
+		return nil
+	}
+	return &SnapshotBlock{r: r, block: sb}
+}
+
+func (r *Resolver) accountBlock(ab *ledger.AccountBlock) *AccountBlock {
+	if ab == nil {
+		return nil
+	}
+	return &AccountBlock{r: r, block: ab}
+}
+
+func (r *Resolver) accountBlocks(blocks []*ledger.AccountBlock) []*AccountBlock {
+	result := make([]*AccountBlock, 0, len(blocks))
+	for _, ab := range blocks {
+		if ab != nil {
+			result = append(result, r.accountBlock(ab))
+		}
+	}
+	return result
+}
+
+func (r *Resolver) token(tokenId types.TokenTypeId) (*Token, error) {
+	info, err := r.chain.GetTokenInfoById(tokenId)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	return &Token{id: tokenId, info: info}, nil
+}
+
+func (r *Resolver) contract(addr types.Address) (*Contract, error) {
+	meta, err := r.chain.GetContractMeta(addr)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return &Contract{r: r, address: addr, meta: meta}, nil
+}
+
+func checkPageSize(size int32) error {
+	if size < 0 || size > maxPageSize {
+		return errors.New(fmt.Sprintf("page size should be in [0, %d]", maxPageSize))
+	}
+	return nil
+}
+
+func (r *Resolver) SnapshotBlock(args struct {
+	Height *Long
+	Hash   *Hash
+}) (*SnapshotBlock, error) {
+	if args.Height != nil && args.Hash != nil {
+		return nil, errors.New("height and hash can't be set at the same time")
+	}
+	if args.Hash != nil {
+		sb, err := r.chain.GetSnapshotBlockByHash(args.Hash.Hash)
+		return r.snapshotBlock(sb), err
+	}
+	if args.Height != nil {
+		sb, err := r.chain.GetSnapshotBlockByHeight(uint64(*args.Height))
+		return r.snapshotBlock(sb), err
+	}
+	return r.snapshotBlock(r.chain.GetLatestSnapshotBlock()), nil
+}
+
+func (r *Resolver) SnapshotBlocks(args struct {
+	From Long
+	To   *Long
+}) ([]*SnapshotBlock, error) {
+	latestHeight := r.chain.GetLatestSnapshotBlock().Height
+	from, to := uint64(args.From), latestHeight
+	if args.To != nil && uint64(*args.To) < to {
+		to = uint64(*args.To)
+	}
+	if from == 0 {
+		from = 1
+	}
+	if from > to {
+		return []*SnapshotBlock{}, nil
+	}
+	if to-from+1 > maxPageSize {
+		return nil, errors.New(fmt.Sprintf("at most %d snapshot blocks can be queried", maxPageSize))
+	}
+	blocks, err := r.chain.GetSnapshotBlocksByHeight(from, true, to-from+1)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*SnapshotBlock, 0, len(blocks))
+	for _, sb := range blocks {
+		result = append(result, r.snapshotBlock(sb))
+	}
+	return result, nil
+}
+
+func (r *Resolver) AccountBlock(args struct{ Hash Hash }) (*AccountBlock, error) {
+	ab, err := r.chain.GetAccountBlockByHash(args.Hash.Hash)
+	return r.accountBlock(ab), err
+}
+
+func (r *Resolver) Account(args struct{ Address Address }) *Account {
+	return &Account{r: r, address: args.Address.Address}
+}
+
+func (r *Resolver) Token(args struct{ Id TokenId }) (*Token, error) {
+	return r.token(args.Id.TokenTypeId)
+}
+
+func (r *Resolver) Contract(args struct{ Address Address }) (*Contract, error) {
+	return r.contract(args.Address.Address)
+}
+
+// SnapshotBlock resolves a snapshot block.
+type SnapshotBlock struct {
+	r     *Resolver
+	block *ledger.SnapshotBlock
+}
+
+func (s *SnapshotBlock) Hash() Hash        { return Hash{s.block.Hash} }
+func (s *SnapshotBlock) PrevHash() Hash    { return Hash{s.block.PrevHash} }
+func (s *SnapshotBlock) Height() Long      { return Long(s.block.Height) }
+func (s *SnapshotBlock) Producer() Address { return Address{s.block.Producer()} }
+func (s *SnapshotBlock) Seed() Long        { return Long(s.block.Seed) }
+func (s *SnapshotBlock) Version() int32    { return int32(s.block.Version) }
+func (s *SnapshotBlock) PublicKey() *Bytes { return toBytes(s.block.PublicKey) }
+func (s *SnapshotBlock) Signature() *Bytes { return toBytes(s.block.Signature) }
+
+func (s *SnapshotBlock) Timestamp() Long {
+	if s.block.Timestamp == nil {
+		return 0
+	}
+	return Long(s.block.Timestamp.Unix())
+}
+
+func (s *SnapshotBlock) SeedHash() *Hash {
+	if s.block.SeedHash == nil {
+		return nil
+	}
+	return &Hash{*s.block.SeedHash}
+}
+
+func (s *SnapshotBlock) SnapshotContent() ([]*AccountBlock, error) {
+	hashes := make([]types.Hash, 0, len(s.block.SnapshotContent))
+	for _, hashHeight := range s.block.SnapshotContent {
+		hashes = append(hashes, hashHeight.Hash)
+	}
+	// the snapshot content is a map, sort it to keep the result stable
+	sort.Slice(hashes, func(i, j int) bool {
+		return hashes[i].String() < hashes[j].String()
+	})
+	result := make([]*AccountBlock, 0, len(hashes))
+	for _, hash := range hashes {
+		ab, err := s.r.chain.GetAccountBlockByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		if ab != nil {
+			result = append(result, s.r.accountBlock(ab))
+		}
+	}
+	return result, nil
+}
+
+// AccountBlock resolves an account block.
+type AccountBlock struct {
+	r     *Resolver
+	block *ledger.AccountBlock
+}
+
+func (a *AccountBlock) Hash() Hash          { return Hash{a.block.Hash} }
+func (a *AccountBlock) PrevHash() Hash      { return Hash{a.block.PrevHash} }
+func (a *AccountBlock) Height() Long        { return Long(a.block.Height) }
+func (a *AccountBlock) BlockType() int32    { return int32(a.block.BlockType) }
+func (a *AccountBlock) Producer() Address   { return Address{a.block.Producer()} }
+func (a *AccountBlock) ToAddress() Address  { return Address{a.block.ToAddress} }
+func (a *AccountBlock) FromBlockHash() Hash { return Hash{a.block.FromBlockHash} }
+func (a *AccountBlock) Amount() BigInt      { return toBigInt(a.block.Amount) }
+func (a *AccountBlock) Fee() BigInt         { return toBigInt(a.block.Fee) }
+func (a *AccountBlock) Data() *Bytes        { return toBytes(a.block.Data) }
+func (a *AccountBlock) Quota() Long         { return Long(a.block.Quota) }
+func (a *AccountBlock) QuotaUsed() Long     { return Long(a.block.QuotaUsed) }
+func (a *AccountBlock) Nonce() *Bytes       { return toBytes(a.block.Nonce) }
+func (a *AccountBlock) PublicKey() *Bytes   { return toBytes(a.block.PublicKey) }
+func (a *AccountBlock) Signature() *Bytes   { return toBytes(a.block.Signature) }
+
+func (a *AccountBlock) Account() *Account {
+	return &Account{r: a.r, address: a.block.AccountAddress}
+}
+
+func (a *AccountBlock) Difficulty() *BigInt {
+	if a.block.Difficulty == nil {
+		return nil
+	}
+	d := toBigInt(a.block.Difficulty)
+	return &d
+}
+
+func (a *AccountBlock) Token() (*Token, error) {
+	if !a.block.IsSendBlock() {
+		return nil, nil
+	}
+	return a.r.token(a.block.TokenId)
+}
+
+func (a *AccountBlock) SendBlock() (*AccountBlock, error) {
+	if !a.block.IsReceiveBlock() {
+		return nil, nil
+	}
+	ab, err := a.r.chain.GetAccountBlockByHash(a.block.FromBlockHash)
+	return a.r.accountBlock(ab), err
+}
+
+func (a *AccountBlock) ReceiveBlock() (*AccountBlock, error) {
+	if !a.block.IsSendBlock() {
+		return nil, nil
+	}
+	ab, err := a.r.chain.GetReceiveAbBySendAb(a.block.Hash)
+	return a.r.accountBlock(ab), err
+}
+
+func (a *AccountBlock) SendBlockList() []*AccountBlock {
+	return a.r.accountBlocks(a.block.SendBlockList)
+}
+
+func (a *AccountBlock) VmLogs() ([]*VmLog, error) {
+	if a.block.LogHash == nil {
+		return []*VmLog{}, nil
+	}
+	logList, err := a.r.chain.GetVmLogList(a.block.LogHash)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*VmLog, 0, len(logList))
+	for i, log := range logList {
+		result = append(result, &VmLog{index: i, log: log})
+	}
+	return result, nil
+}
+
+func (a *AccountBlock) ConfirmSnapshotBlock() (*SnapshotBlock, error) {
+	sb, err := a.r.chain.GetConfirmSnapshotHeaderByAbHash(a.block.Hash)
+	return a.r.snapshotBlock(sb), err
+}
+
+func (a *AccountBlock) ConfirmedTimes() (Long, error) {
+	times, err := a.r.chain.GetConfirmedTimes(a.block.Hash)
+	return Long(times), err
+}
+
+// Account resolves the state of an address.
+type Account struct {
+	r       *Resolver
+	address types.Address
+}
+
+func (a *Account) Address() Address { return Address{a.address} }
+
+func (a *Account) Height() (Long, error) {
+	height, err := a.r.chain.GetLatestAccountHeight(a.address)
+	return Long(height), err
+}
+
+func (a *Account) LatestBlock() (*AccountBlock, error) {
+	ab, err := a.r.chain.GetLatestAccountBlock(a.address)
+	return a.r.accountBlock(ab), err
+}
+
+func (a *Account) Blocks(args struct {
+	Height *Long
+	Count  int32
+}) ([]*AccountBlock, error) {
+	if err := checkPageSize(args.Count); err != nil {
+		return nil, err
+	}
+	height := uint64(0)
+	if args.Height != nil {
+		height = uint64(*args.Height)
+	} else {
+		latestHeight, err := a.r.chain.GetLatestAccountHeight(a.address)
+		if err != nil {
+			return nil, err
+		}
+		height = latestHeight
+	}
+	if height == 0 || args.Count == 0 {
+		return []*AccountBlock{}, nil
+	}
+	blocks, err := a.r.chain.GetAccountBlocksByHeight(a.address, height, uint64(args.Count))
+	if err != nil {
+		return nil, err
+	}
+	return a.r.accountBlocks(blocks), nil
+}
+
+func (a *Account) Balance(args struct{ TokenId TokenId }) (BigInt, error) {
+	balance, err := a.r.chain.GetBalance(a.address, args.TokenId.TokenTypeId)
+	if err != nil {
+		return BigInt{}, err
+	}
+	return toBigInt(balance), nil
+}
+
+func (a *Account) Balances() ([]*Balance, error) {
+	balances, err := a.r.chain.GetBalanceMap(a.address)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Balance, 0, len(balances))
+	for tokenId, balance := range balances {
+		token, err := a.r.token(tokenId)
+		if err != nil {
+			return nil, err
+		}
+		if token == nil {
+			continue
+		}
+		result = append(result, &Balance{token: token, balance: toBigInt(balance)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].token.id.String() < result[j].token.id.String()
+	})
+	return result, nil
+}
+
+func (a *Account) UnreceivedBlocks(args struct {
+	PageIndex int32
+	PageSize  int32
+}) ([]*AccountBlock, error) {
+	if err := checkPageSize(args.PageSize); err != nil {
+		return nil, err
+	}
+	if args.PageIndex < 0 {
+		return nil, errors.New("page index should not be negative")
+	}
+	blocks, err := a.r.chain.GetOnRoadBlocksByAddr(a.address, int(args.PageIndex), int(args.PageSize))
+	if err != nil {
+		return nil, err
+	}
+	return a.r.accountBlocks(blocks), nil
+}
+
+func (a *Account) Contract() (*Contract, error) {
+	return a.r.contract(a.address)
+}
+
+// Balance resolves the balance of a token.
+type Balance struct {
+	token   *Token
+	balance BigInt
+}
+
+func (b *Balance) Token() *Token   { return b.token }
+func (b *Balance) Balance() BigInt { return b.balance }
+
+// Token resolves a token info.
+type Token struct {
+	id   types.TokenTypeId
+	info *types.TokenInfo
+}
+
+func (t *Token) Id() TokenId         { return TokenId{t.id} }
+func (t *Token) Name() string        { return t.info.TokenName }
+func (t *Token) Symbol() string      { return t.info.TokenSymbol }
+func (t *Token) Decimals() int32     { return int32(t.info.Decimals) }
+func (t *Token) TotalSupply() BigInt { return toBigInt(t.info.TotalSupply) }
+func (t *Token) MaxSupply() BigInt   { return toBigInt(t.info.MaxSupply) }
+func (t *Token) Owner() Address      { return Address{t.info.Owner} }
+func (t *Token) OwnerBurnOnly() bool { return t.info.OwnerBurnOnly }
+func (t *Token) IsReIssuable() bool  { return t.info.IsReIssuable }
+func (t *Token) Index() int32        { return int32(t.info.Index) }
+
+// Contract resolves the meta and code of a contract.
+type Contract struct {
+	r       *Resolver
+	address types.Address
+	meta    *ledger.ContractMeta
+}
+
+func (c *Contract) Address() Address          { return Address{c.address} }
+func (c *Contract) Gid() string               { return c.meta.Gid.String() }
+func (c *Contract) SendConfirmedTimes() int32 { return int32(c.meta.SendConfirmedTimes) }
+func (c *Contract) SeedConfirmedTimes() int32 { return int32(c.meta.SeedConfirmedTimes) }
+func (c *Contract) QuotaRatio() int32         { return int32(c.meta.QuotaRatio) }
+
+func (c *Contract) Code() (*Bytes, error) {
+	code, err := c.r.chain.GetContractCode(c.address)
+	if err != nil {
+		return nil, err
+	}
+	return toBytes(code), nil
+}
+
+func (c *Contract) CreateBlock() (*AccountBlock, error) {
+	if c.meta.CreateBlockHash.IsZero() {
+		return nil, nil
+	}
+	ab, err := c.r.chain.GetAccountBlockByHash(c.meta.CreateBlockHash)
+	return c.r.accountBlock(ab), err
+}
+
+// VmLog resolves a vm log of an account block.
+type VmLog struct {
+	index int
+	log   *ledger.VmLog
+}
+
+func (l *VmLog) Index() int32 { return int32(l.index) }
+func (l *VmLog) Data() *Bytes { return toBytes(l.log.Data) }
+
+func (l *VmLog) Topics() []Hash {
+	topics := make([]Hash, 0, len(l.log.Topics))
+	for _, topic := range l.log.Topics {
+		topics = append(topics, Hash{topic})
+	}
+	return topics
+}
+
+func toBytes(data []byte) *Bytes {
+	if len(data) == 0 {
+		return nil
+	}
+	b := Bytes(data)
+	return &b
+}
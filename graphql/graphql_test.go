@@ -0,0 +1,208 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func init() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 1, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 2, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 3, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 4, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 5, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 6, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 7, Version: 7},
+	})
+}
+
+// mockChain implements the chain methods used by the resolvers, other methods panic.
+type mockChain struct {
+	chain.Chain
+	snapshotBlocks []*ledger.SnapshotBlock
+	accountBlocks  map[types.Hash]*ledger.AccountBlock
+	balances       map[types.TokenTypeId]*big.Int
+}
+
+func (c *mockChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.snapshotBlocks[len(c.snapshotBlocks)-1]
+}
+
+func (c *mockChain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == 0 || height > uint64(len(c.snapshotBlocks)) {
+		return nil, nil
+	}
+	return c.snapshotBlocks[height-1], nil
+}
+
+func (c *mockChain) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	return c.snapshotBlocks[height-1 : height-1+count], nil
+}
+
+func (c *mockChain) GetAccountBlockByHash(hash types.Hash) (*ledger.AccountBlock, error) {
+	return c.accountBlocks[hash], nil
+}
+
+func (c *mockChain) GetReceiveAbBySendAb(sendBlockHash types.Hash) (*ledger.AccountBlock, error) {
+	for _, ab := range c.accountBlocks {
+		if ab.IsReceiveBlock() && ab.FromBlockHash == sendBlockHash {
+			return ab, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *mockChain) GetBalanceMap(addr types.Address) (map[types.TokenTypeId]*big.Int, error) {
+	return c.balances, nil
+}
+
+func (c *mockChain) GetTokenInfoById(tokenId types.TokenTypeId) (*types.TokenInfo, error) {
+	if tokenId != ledger.ViteTokenId {
+		return nil, nil
+	}
+	return &types.TokenInfo{TokenName: "Vite Token", TokenSymbol: "VITE", Decimals: 18}, nil
+}
+
+func (c *mockChain) GetContractMeta(addr types.Address) (*ledger.ContractMeta, error) {
+	return nil, nil
+}
+
+func newMockChain() *mockChain {
+	c := &mockChain{
+		accountBlocks: make(map[types.Hash]*ledger.AccountBlock),
+		balances:      map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(100)},
+	}
+	send := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: types.Address{1},
+		ToAddress:      types.Address{2},
+		Height:         1,
+		Amount:         big.NewInt(10),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+	send.Hash = send.ComputeHash()
+	receive := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: types.Address{2},
+		Height:         1,
+		FromBlockHash:  send.Hash,
+	}
+	receive.Hash = receive.ComputeHash()
+	c.accountBlocks[send.Hash] = send
+	c.accountBlocks[receive.Hash] = receive
+
+	prevHash := types.Hash{}
+	for h := uint64(1); h <= 3; h++ {
+		now := time.Unix(int64(h), 0)
+		sb := &ledger.SnapshotBlock{Height: h, PrevHash: prevHash, Timestamp: &now}
+		if h == 3 {
+			sb.SnapshotContent = ledger.SnapshotContent{
+				send.AccountAddress:    &ledger.HashHeight{Hash: send.Hash, Height: 1},
+				receive.AccountAddress: &ledger.HashHeight{Hash: receive.Hash, Height: 1},
+			}
+		}
+		sb.Hash = sb.ComputeHash()
+		prevHash = sb.Hash
+		c.snapshotBlocks = append(c.snapshotBlocks, sb)
+	}
+	return c
+}
+
+func query(t *testing.T, h http.Handler, q string, vars map[string]interface{}) map[string]interface{} {
+	body, err := json.Marshal(map[string]interface{}{"query": q, "variables": vars})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		Data   map[string]interface{}
+		Errors []interface{}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Errors) > 0 {
+		t.Fatalf("query failed: %v", result.Errors)
+	}
+	return result.Data
+}
+
+func TestGraphQL(t *testing.T) {
+	c := newMockChain()
+	h, err := New(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := query(t, h, `{ snapshotBlock { height snapshotContent { hash blockType receiveBlock { fromBlockHash } } } }`, nil)
+	sb := data["snapshotBlock"].(map[string]interface{})
+	if sb["height"] != "3" {
+		t.Fatalf("wrong height %v", sb["height"])
+	}
+	content := sb["snapshotContent"].([]interface{})
+	if len(content) != 2 {
+		t.Fatalf("wrong snapshot content %v", content)
+	}
+	for _, item := range content {
+		ab := item.(map[string]interface{})
+		if ab["blockType"].(float64) != float64(ledger.BlockTypeSendCall) {
+			continue
+		}
+		receive := ab["receiveBlock"].(map[string]interface{})
+		if receive["fromBlockHash"] != ab["hash"] {
+			t.Fatalf("wrong receive block %v", receive)
+		}
+	}
+
+	data = query(t, h, `query($from: Long!) { snapshotBlocks(from: $from) { height } }`, map[string]interface{}{"from": "2"})
+	if blocks := data["snapshotBlocks"].([]interface{}); len(blocks) != 2 {
+		t.Fatalf("wrong snapshot blocks %v", blocks)
+	}
+
+	data = query(t, h, `query($addr: Address!) { account(address: $addr) { balances { token { symbol } balance } contract { gid } } }`,
+		map[string]interface{}{"addr": types.Address{1}.String()})
+	account := data["account"].(map[string]interface{})
+	balances := account["balances"].([]interface{})
+	if len(balances) != 1 || balances[0].(map[string]interface{})["balance"] != "100" {
+		t.Fatalf("wrong balances %v", balances)
+	}
+	if account["contract"] != nil {
+		t.Fatalf("not a contract %v", account["contract"])
+	}
+}
+
+func TestGraphQLLimits(t *testing.T) {
+	h, err := New(newMockChain())
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(map[string]interface{}{"query": `{ account(address: "` + types.Address{1}.String() + `") { blocks(count: 1000) { hash } } }`})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, bytes.NewReader(body)))
+	if !bytes.Contains(w.Body.Bytes(), []byte("page size should be in")) {
+		t.Fatalf("page size should be limited: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, Path, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status %d", w.Code)
+	}
+}
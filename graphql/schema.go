@@ -0,0 +1,125 @@
+package graphql
+
+const schema string = `
+    # Address is a vite address, like vite_...
+    scalar Address
+    # Hash is a 32 bytes hash in hex
+    scalar Hash
+    # TokenId is a token type id, like tti_...
+    scalar TokenId
+    # BigInt is an arbitrary precision integer in decimal string
+    scalar BigInt
+    # Long is an uint64 in decimal string
+    scalar Long
+    # Bytes is binary data in base64, the same as the JSON-RPC API
+    scalar Bytes
+
+    schema {
+        query: Query
+    }
+
+    type Query {
+        # The snapshot block by height or hash, the latest snapshot block if both are null.
+        snapshotBlock(height: Long, hash: Hash): SnapshotBlock
+        # At most 100 snapshot blocks from height from to height to.
+        snapshotBlocks(from: Long!, to: Long): [SnapshotBlock!]!
+        accountBlock(hash: Hash!): AccountBlock
+        account(address: Address!): Account!
+        token(id: TokenId!): Token
+        contract(address: Address!): Contract
+    }
+
+    type SnapshotBlock {
+        hash: Hash!
+        prevHash: Hash!
+        height: Long!
+        timestamp: Long!
+        producer: Address!
+        seed: Long!
+        seedHash: Hash
+        version: Int!
+        publicKey: Bytes
+        signature: Bytes
+        # The account blocks confirmed by the snapshot block, latest block of each account only.
+        snapshotContent: [AccountBlock!]!
+    }
+
+    type AccountBlock {
+        hash: Hash!
+        prevHash: Hash!
+        height: Long!
+        blockType: Int!
+        account: Account!
+        producer: Address!
+        toAddress: Address!
+        fromBlockHash: Hash!
+        amount: BigInt!
+        token: Token
+        fee: BigInt!
+        data: Bytes
+        quota: Long!
+        quotaUsed: Long!
+        difficulty: BigInt
+        nonce: Bytes
+        publicKey: Bytes
+        signature: Bytes
+        # The send block received by a receive block.
+        sendBlock: AccountBlock
+        # The receive block of a send block, null if it's unreceived.
+        receiveBlock: AccountBlock
+        # The send blocks triggered by a contract receive block.
+        sendBlockList: [AccountBlock!]!
+        vmLogs: [VmLog!]!
+        # The snapshot block confirming the block, null if it's unconfirmed.
+        confirmSnapshotBlock: SnapshotBlock
+        confirmedTimes: Long!
+    }
+
+    type Account {
+        address: Address!
+        height: Long!
+        latestBlock: AccountBlock
+        # At most 100 blocks from height down, from the latest block if height is null.
+        blocks(height: Long, count: Int = 10): [AccountBlock!]!
+        balance(tokenId: TokenId!): BigInt!
+        balances: [Balance!]!
+        # At most 100 unreceived send blocks of the account.
+        unreceivedBlocks(pageIndex: Int = 0, pageSize: Int = 10): [AccountBlock!]!
+        # Null if it's not a contract.
+        contract: Contract
+    }
+
+    type Balance {
+        token: Token!
+        balance: BigInt!
+    }
+
+    type Token {
+        id: TokenId!
+        name: String!
+        symbol: String!
+        decimals: Int!
+        totalSupply: BigInt!
+        maxSupply: BigInt!
+        owner: Address!
+        ownerBurnOnly: Boolean!
+        isReIssuable: Boolean!
+        index: Int!
+    }
+
+    type Contract {
+        address: Address!
+        gid: String!
+        code: Bytes
+        sendConfirmedTimes: Int!
+        seedConfirmedTimes: Int!
+        quotaRatio: Int!
+        createBlock: AccountBlock
+    }
+
+    type VmLog {
+        index: Int!
+        topics: [Hash!]!
+        data: Bytes
+    }
+`
@@ -0,0 +1,71 @@
+package graphql
+
+import (
+	"encoding/json"
+	"net/http"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vitelabs/go-vite/chain"
+)
+
+const (
+	// Path is the path of the GraphQL endpoint on the HTTP RPC server
+	Path = "/graphql"
+
+	maxRequestContentLength = 1024 * 128
+	maxQueryDepth           = 10
+	maxParallelism          = 16
+)
+
+// handler serves GraphQL queries in the POST body or the query string of a GET request.
+type handler struct {
+	schema *graphqlgo.Schema
+}
+
+// New returns the http handler of the GraphQL endpoint over the ledger of c.
+func New(c chain.Chain) (http.Handler, error) {
+	s, err := graphqlgo.ParseSchema(schema, &Resolver{chain: c},
+		graphqlgo.MaxDepth(maxQueryDepth), graphqlgo.MaxParallelism(maxParallelism))
+	if err != nil {
+		return nil, err
+	}
+	return &handler{schema: s}, nil
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		params.Query = q.Get("query")
+		params.OperationName = q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestContentLength)
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := h.schema.Exec(r.Context(), params.Query, params.OperationName, params.Variables)
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(responseJSON)
+}
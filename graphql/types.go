@@ -0,0 +1,164 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// The scalars are encoded the same way as the JSON-RPC API: addresses, hashes and token ids
+// in their string forms, uint64 and big integers in decimal strings and bytes in base64.
+
+// Address is the GraphQL scalar of types.Address.
+type Address struct {
+	types.Address
+}
+
+func (Address) ImplementsGraphQLType(name string) bool { return name == "Address" }
+
+func (a *Address) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid address %v", input))
+	}
+	addr, err := types.HexToAddress(s)
+	if err != nil {
+		return err
+	}
+	a.Address = addr
+	return nil
+}
+
+func (a Address) MarshalJSON() ([]byte, error) {
+	return json.Marshal(a.Address.String())
+}
+
+// Hash is the GraphQL scalar of types.Hash.
+type Hash struct {
+	types.Hash
+}
+
+func (Hash) ImplementsGraphQLType(name string) bool { return name == "Hash" }
+
+func (h *Hash) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid hash %v", input))
+	}
+	hash, err := types.HexToHash(s)
+	if err != nil {
+		return err
+	}
+	h.Hash = hash
+	return nil
+}
+
+func (h Hash) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Hash.String())
+}
+
+// TokenId is the GraphQL scalar of types.TokenTypeId.
+type TokenId struct {
+	types.TokenTypeId
+}
+
+func (TokenId) ImplementsGraphQLType(name string) bool { return name == "TokenId" }
+
+func (t *TokenId) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid token id %v", input))
+	}
+	tti, err := types.HexToTokenTypeId(s)
+	if err != nil {
+		return err
+	}
+	t.TokenTypeId = tti
+	return nil
+}
+
+func (t TokenId) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.TokenTypeId.String())
+}
+
+// Long is the GraphQL scalar of uint64, both strings and numbers are accepted as input.
+type Long uint64
+
+func (Long) ImplementsGraphQLType(name string) bool { return name == "Long" }
+
+func (l *Long) UnmarshalGraphQL(input interface{}) error {
+	switch v := input.(type) {
+	case string:
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return err
+		}
+		*l = Long(n)
+	case int32:
+		if v < 0 {
+			return errors.New(fmt.Sprintf("invalid long %v", v))
+		}
+		*l = Long(v)
+	case float64:
+		if v < 0 || v != float64(uint64(v)) {
+			return errors.New(fmt.Sprintf("invalid long %v", v))
+		}
+		*l = Long(v)
+	default:
+		return errors.New(fmt.Sprintf("invalid long %v", input))
+	}
+	return nil
+}
+
+func (l Long) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(l), 10))
+}
+
+// BigInt is the GraphQL scalar of *big.Int.
+type BigInt big.Int
+
+func (BigInt) ImplementsGraphQLType(name string) bool { return name == "BigInt" }
+
+func (b *BigInt) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid big int %v", input))
+	}
+	if _, ok := (*big.Int)(b).SetString(s, 10); !ok {
+		return errors.New(fmt.Sprintf("invalid big int %v", input))
+	}
+	return nil
+}
+
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*big.Int)(&b).String())
+}
+
+func toBigInt(n *big.Int) BigInt {
+	if n == nil {
+		return BigInt{}
+	}
+	return BigInt(*n)
+}
+
+// Bytes is the GraphQL scalar of []byte.
+type Bytes []byte
+
+func (Bytes) ImplementsGraphQLType(name string) bool { return name == "Bytes" }
+
+func (b *Bytes) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return errors.New(fmt.Sprintf("invalid bytes %v", input))
+	}
+	var data []byte
+	if err := json.Unmarshal([]byte(strconv.Quote(s)), &data); err != nil {
+		return err
+	}
+	*b = data
+	return nil
+}
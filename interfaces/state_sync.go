@@ -0,0 +1,68 @@
+package interfaces
+
+import (
+	"encoding/binary"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// StateBlockKeyPrefix is the prefix of the state entries of account blocks, which are iterated after the state db,
+// the key is StateBlockKeyPrefix + address + height, the value is the confirmed snapshot height + the serialized block.
+const StateBlockKeyPrefix = byte(255)
+
+// StateEntry is a key value pair of the account and contract state at a snapshot block,
+// the key is in the form of the latest state key of the state db.
+type StateEntry struct {
+	Key   []byte
+	Value []byte
+}
+
+// StateChunkInfo describes a continuous range of state entries.
+type StateChunkInfo struct {
+	StartKey []byte // the key of the first entry
+	Count    uint64
+	Hash     types.Hash
+}
+
+// StateManifest is the commitment of the state at a snapshot block, the state is split into chunks,
+// Root commits to the snapshot block and all chunk hashes, so that every chunk can be verified alone.
+type StateManifest struct {
+	Block  *ledger.SnapshotBlock
+	Root   types.Hash
+	Chunks []StateChunkInfo
+}
+
+// ComputeStateChunkHash returns the hash of the entries, every key and value is prefixed by its length.
+func ComputeStateChunkHash(entries []StateEntry) types.Hash {
+	source := make([]byte, 0, len(entries)*64)
+	var l [4]byte
+	for _, entry := range entries {
+		binary.BigEndian.PutUint32(l[:], uint32(len(entry.Key)))
+		source = append(source, l[:]...)
+		source = append(source, entry.Key...)
+		binary.BigEndian.PutUint32(l[:], uint32(len(entry.Value)))
+		source = append(source, l[:]...)
+		source = append(source, entry.Value...)
+	}
+	hash, _ := types.BytesToHash(crypto.Hash256(source))
+	return hash
+}
+
+// ComputeRoot returns the root of the manifest, it doesn't use the Root field.
+func (m *StateManifest) ComputeRoot() types.Hash {
+	source := make([]byte, 0, 8+types.HashSize*(len(m.Chunks)+1))
+	source = append(source, m.Block.Hash.Bytes()...)
+	var h [8]byte
+	binary.BigEndian.PutUint64(h[:], m.Block.Height)
+	source = append(source, h[:]...)
+	for _, chunk := range m.Chunks {
+		binary.BigEndian.PutUint64(h[:], chunk.Count)
+		source = append(source, h[:]...)
+		source = append(source, chunk.StartKey...)
+		source = append(source, chunk.Hash.Bytes()...)
+	}
+	hash, _ := types.BytesToHash(crypto.Hash256(source))
+	return hash
+}
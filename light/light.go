@@ -0,0 +1,618 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package light implements the light client of the edge nodes. It syncs and verifies the snapshot headers
+// from the full nodes which serve light clients, and it requests the account blocks with the proofs on demand.
+package light
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	chain_genesis "github.com/vitelabs/go-vite/chain/genesis"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+const syncInterval = time.Second
+
+// the count of cached round producers
+const maxCachedRounds = 64
+
+const storeDirName = "light"
+
+var errNoServers = errors.New("no light servers")
+var errNotStarted = errors.New("light client is not started")
+
+// Status is the sync status of the light client
+type Status struct {
+	BaseHeight   uint64     `json:"baseHeight"`
+	BaseHash     types.Hash `json:"baseHash"`
+	LatestHeight uint64     `json:"latestHeight"`
+	LatestHash   types.Hash `json:"latestHash"`
+	Servers      []string   `json:"servers"`
+	Error        string     `json:"error,omitempty"` // the error of the last sync, empty if the last sync succeeded
+}
+
+// Client syncs the snapshot headers from the light servers and verifies them by the producer schedule of
+// the snapshot consensus group, the producers of every round must be agreed by a quorum of the servers.
+// The account blocks are requested on demand with the snapshot blocks which confirm them,
+// and they are verified against the synced headers.
+// The snapshot consensus group is read from the genesis config, it can not be changed by the governance.
+type Client struct {
+	genesis    *ledger.SnapshotBlock
+	checkpoint *ledger.HashHeight // nil means the headers are synced from the genesis snapshot block
+	group      *core.GroupInfo
+	store      *headerStore
+	conns      []*net.LightConn
+	quorum     int
+	next       uint32
+
+	roundsMu sync.Mutex
+	rounds   map[uint64][]types.Address
+
+	errMu   sync.RWMutex
+	syncErr error
+
+	running int32
+	term    chan struct{}
+	wg      sync.WaitGroup
+
+	log log15.Logger
+}
+
+// New creates the light client, the headers are stored in the light directory of cfg.DataDir
+func New(cfg *config.Config) (*Client, error) {
+	if cfg.Light == nil || len(cfg.Light.Servers) == 0 {
+		return nil, errNoServers
+	}
+	if cfg.Genesis == nil || cfg.Genesis.GovernanceInfo == nil {
+		return nil, errors.New("missing genesis governance info")
+	}
+
+	fork.SetForkPoints(cfg.ForkPoints)
+
+	genesis := chain_genesis.NewGenesisSnapshotBlock(chain_genesis.NewGenesisAccountBlocks(cfg.Genesis))
+
+	info, ok := cfg.Genesis.GovernanceInfo.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()]
+	if !ok {
+		return nil, errors.New("missing snapshot consensus group in the genesis config")
+	}
+	group := core.NewGroupInfo(*genesis.Timestamp, types.ConsensusGroupInfo{
+		Gid:              types.SNAPSHOT_GID,
+		NodeCount:        info.NodeCount,
+		Interval:         info.Interval,
+		PerCount:         info.PerCount,
+		RandCount:        info.RandCount,
+		RandRank:         info.RandRank,
+		Repeat:           info.Repeat,
+		CheckLevel:       info.CheckLevel,
+		CountingTokenId:  info.CountingTokenId,
+		Owner:            info.Owner,
+		StakeAmount:      info.StakeAmount,
+		ExpirationHeight: info.ExpirationHeight,
+	})
+
+	var checkpoint *ledger.HashHeight
+	if cfg.Light.Checkpoint != "" {
+		var err error
+		if checkpoint, err = parseCheckpoint(cfg.Light.Checkpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Net == nil {
+		return nil, errors.New("missing net config")
+	}
+	peerKey, err := cfg.Net.Init()
+	if err != nil {
+		return nil, err
+	}
+
+	conns := make([]*net.LightConn, 0, len(cfg.Light.Servers))
+	known := make(map[vnode.NodeID]struct{}, len(cfg.Light.Servers))
+	for _, str := range cfg.Light.Servers {
+		node, err := vnode.ParseNode(str)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse light server %s: %v", str, err)
+		}
+		if node.ID == vnode.ZERO {
+			return nil, fmt.Errorf("missing node id of light server %s", str)
+		}
+		if _, ok := known[node.ID]; ok {
+			return nil, fmt.Errorf("duplicate light server %s", str)
+		}
+		known[node.ID] = struct{}{}
+		conns = append(conns, net.NewLightConn(node, peerKey))
+	}
+
+	// a majority of the servers, so there is at most one agreed producer list of a round
+	quorum := cfg.Light.Quorum
+	if quorum == 0 {
+		quorum = len(conns)/2 + 1
+	}
+	if quorum <= len(conns)/2 || quorum > len(conns) {
+		return nil, fmt.Errorf("light quorum %d should be a majority of the %d servers", quorum, len(conns))
+	}
+
+	var path string
+	if cfg.DataDir != "" {
+		path = filepath.Join(cfg.DataDir, storeDirName)
+	}
+	store, err := newHeaderStore(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open light header store: %v", err)
+	}
+
+	return &Client{
+		genesis:    genesis,
+		checkpoint: checkpoint,
+		group:      group,
+		store:      store,
+		conns:      conns,
+		quorum:     quorum,
+		rounds:     make(map[uint64][]types.Address),
+		log:        log15.New("module", "light"),
+	}, nil
+}
+
+// parseCheckpoint parses the checkpoint like: "hash/height"
+func parseCheckpoint(str string) (*ledger.HashHeight, error) {
+	strs := strings.Split(str, "/")
+	if len(strs) != 2 {
+		return nil, fmt.Errorf("checkpoint %s should be like hash/height", str)
+	}
+	hash, err := types.HexToHash(strs[0])
+	if err != nil {
+		return nil, err
+	}
+	height, err := strconv.ParseUint(strs[1], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	if height == 0 {
+		return nil, fmt.Errorf("checkpoint height should be greater than 0")
+	}
+	return &ledger.HashHeight{
+		Height: height,
+		Hash:   hash,
+	}, nil
+}
+
+// Start syncs the headers in background
+func (c *Client) Start() error {
+	if !atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		return errors.New("light client has started")
+	}
+
+	if c.checkpoint == nil {
+		if err := c.store.init(c.genesis); err != nil {
+			return err
+		}
+	}
+
+	c.term = make(chan struct{})
+	c.wg.Add(1)
+	go c.loop()
+
+	return nil
+}
+
+// Stop stops syncing, closes the connections and the header store
+func (c *Client) Stop() error {
+	if !atomic.CompareAndSwapInt32(&c.running, 1, 0) {
+		return errNotStarted
+	}
+
+	close(c.term)
+	c.wg.Wait()
+
+	for _, conn := range c.conns {
+		_ = conn.Close()
+	}
+	return c.store.close()
+}
+
+func (c *Client) loop() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		err := c.sync()
+		if err != nil {
+			c.log.Warn(fmt.Sprintf("failed to sync headers: %v", err))
+		}
+		c.errMu.Lock()
+		c.syncErr = err
+		c.errMu.Unlock()
+
+		select {
+		case <-c.term:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) terminated() bool {
+	select {
+	case <-c.term:
+		return true
+	default:
+		return false
+	}
+}
+
+// pick returns the servers in turn
+func (c *Client) pick() *net.LightConn {
+	return c.conns[int(atomic.AddUint32(&c.next, 1)%uint32(len(c.conns)))]
+}
+
+// anchor stores the checkpoint snapshot block as the base header
+func (c *Client) anchor() (err error) {
+	if base := c.store.getBase(); base != nil && base.Hash == c.checkpoint.Hash {
+		return nil
+	}
+
+	for range c.conns {
+		conn := c.pick()
+		var blocks []*ledger.SnapshotBlock
+		if blocks, err = conn.GetSnapshotBlocks(c.checkpoint.Height, 1); err != nil {
+			continue
+		}
+		if len(blocks) != 1 {
+			err = fmt.Errorf("%s has no checkpoint snapshot block", conn)
+			continue
+		}
+		block := blocks[0]
+		if err = verifySnapshotBlock(&ledger.SnapshotBlock{
+			Height: c.checkpoint.Height,
+			Hash:   c.checkpoint.Hash,
+		}, block); err != nil {
+			err = fmt.Errorf("checkpoint from %s: %v", conn, err)
+			continue
+		}
+
+		c.log.Info(fmt.Sprintf("anchor headers at checkpoint %s/%d", block.Hash, block.Height))
+		return c.store.init(block)
+	}
+
+	return
+}
+
+// sync downloads the headers after the latest header, the latest header is requested again
+// to find out whether the server is on another fork.
+func (c *Client) sync() error {
+	if c.checkpoint != nil {
+		if err := c.anchor(); err != nil {
+			return err
+		}
+	}
+
+	for !c.terminated() {
+		latest := c.store.getLatest()
+		conn := c.pick()
+		headers, err := conn.GetSnapshotHeaders(latest.Height, net.MaxLightHeaders)
+		if err == net.ExpMissing {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get headers from %s: %v", conn, err)
+		}
+		if len(headers) == 0 {
+			return nil
+		}
+
+		if headers[0].Hash != latest.Hash {
+			// a valid header at the same height, the latest header is on another fork
+			producers, err := c.producers(c.group.Time2Index(*headers[0].Timestamp))
+			if err != nil {
+				return err
+			}
+			if err = verifyProducer(c.group, headers[0], producers); err != nil {
+				return fmt.Errorf("header %s/%d from %s: %v", headers[0].Hash, headers[0].Height, conn, err)
+			}
+			c.log.Warn(fmt.Sprintf("rollback header %s/%d, %s has header %s", latest.Hash, latest.Height, conn, headers[0].Hash))
+			if err = c.store.rollback(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		headers = headers[1:]
+		prev := latest
+		for _, header := range headers {
+			producers, err := c.producers(c.group.Time2Index(*header.Timestamp))
+			if err != nil {
+				return err
+			}
+			if err = verifyHeader(c.group, prev, header, producers); err != nil {
+				return fmt.Errorf("header %s/%d from %s: %v", header.Hash, header.Height, conn, err)
+			}
+			prev = header
+		}
+		// the snapshot contents are only used to verify the hashes
+		for _, header := range headers {
+			header.SnapshotContent = nil
+		}
+		if err = c.store.append(headers); err != nil {
+			return err
+		}
+
+		// the server returns less headers if the snapshot contents are large, so it's synced only if no new headers
+		if len(headers) == 0 {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// producers returns the snapshot block producers of the round, they must be agreed by a quorum of the servers
+func (c *Client) producers(index uint64) ([]types.Address, error) {
+	c.roundsMu.Lock()
+	producers, ok := c.rounds[index]
+	c.roundsMu.Unlock()
+	if ok {
+		return producers, nil
+	}
+
+	var err error
+	lists := make([][]types.Address, 0, len(c.conns))
+	for _, conn := range c.conns {
+		list, err2 := conn.GetRoundProducers(index)
+		if err2 != nil {
+			err = fmt.Errorf("failed to get producers of round %d from %s: %v", index, conn, err2)
+			continue
+		}
+		lists = append(lists, list)
+	}
+
+	if producers, ok = agreedProducers(lists, c.quorum); !ok {
+		if err != nil {
+			return nil, fmt.Errorf("producers of round %d are not agreed by %d of %d servers, %v", index, c.quorum, len(c.conns), err)
+		}
+		return nil, fmt.Errorf("producers of round %d are not agreed by %d of %d servers", index, c.quorum, len(c.conns))
+	}
+
+	c.roundsMu.Lock()
+	if len(c.rounds) >= maxCachedRounds {
+		c.rounds = make(map[uint64][]types.Address)
+	}
+	c.rounds[index] = producers
+	c.roundsMu.Unlock()
+
+	return producers, nil
+}
+
+// agreedProducers returns the producer list which is the same in at least quorum lists
+func agreedProducers(lists [][]types.Address, quorum int) ([]types.Address, bool) {
+	for i, list := range lists {
+		count := 1
+		for _, other := range lists[i+1:] {
+			if equalAddresses(list, other) {
+				count++
+			}
+		}
+		if count >= quorum {
+			return list, true
+		}
+	}
+	return nil, false
+}
+
+func equalAddresses(a, b []types.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Status returns the sync status
+func (c *Client) Status() Status {
+	s := Status{
+		Servers: make([]string, len(c.conns)),
+	}
+	for i, conn := range c.conns {
+		s.Servers[i] = conn.String()
+	}
+	if base := c.store.getBase(); base != nil {
+		s.BaseHeight, s.BaseHash = base.Height, base.Hash
+	}
+	if latest := c.store.getLatest(); latest != nil {
+		s.LatestHeight, s.LatestHash = latest.Height, latest.Hash
+	}
+
+	c.errMu.RLock()
+	if c.syncErr != nil {
+		s.Error = c.syncErr.Error()
+	}
+	c.errMu.RUnlock()
+
+	return s
+}
+
+// LatestHeader returns the latest verified snapshot header, its snapshot content is nil.
+// It's nil before the checkpoint is anchored.
+func (c *Client) LatestHeader() *ledger.SnapshotBlock {
+	return c.store.getLatest()
+}
+
+// GetHeaderByHeight returns nil if the header has not been synced
+func (c *Client) GetHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	return c.store.header(height)
+}
+
+// GetHeaderByHash returns nil if the header has not been synced
+func (c *Client) GetHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	return c.store.headerByHash(hash)
+}
+
+// GetSnapshotBlockByHeight returns the snapshot block with the snapshot content, it's nil if the header has not been synced
+func (c *Client) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	header, err := c.store.header(height)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return c.getSnapshotBlock(header)
+}
+
+// GetSnapshotBlockByHash returns the snapshot block with the snapshot content, it's nil if the header has not been synced
+func (c *Client) GetSnapshotBlockByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	header, err := c.store.headerByHash(hash)
+	if err != nil || header == nil {
+		return nil, err
+	}
+	return c.getSnapshotBlock(header)
+}
+
+func (c *Client) getSnapshotBlock(header *ledger.SnapshotBlock) (block *ledger.SnapshotBlock, err error) {
+	if header.Hash == c.genesis.Hash {
+		return c.genesis, nil
+	}
+
+	for range c.conns {
+		conn := c.pick()
+		var blocks []*ledger.SnapshotBlock
+		if blocks, err = conn.GetSnapshotBlocks(header.Height, 1); err != nil {
+			continue
+		}
+		if len(blocks) != 1 {
+			err = fmt.Errorf("%s has no snapshot block %d", conn, header.Height)
+			continue
+		}
+		if err = verifySnapshotBlock(header, blocks[0]); err != nil {
+			c.log.Warn(fmt.Sprintf("invalid snapshot block from %s: %v", conn, err))
+			continue
+		}
+		return blocks[0], nil
+	}
+
+	return nil, err
+}
+
+// GetAccountBlockByHash returns the account block and the snapshot header which confirms it,
+// they are nil if the block is missing or unconfirmed.
+func (c *Client) GetAccountBlockByHash(hash types.Hash) (*ledger.AccountBlock, *ledger.SnapshotBlock, error) {
+	blocks, header, err := c.getProof(&net.GetAccountBlockProof{
+		Hash: hash,
+	})
+	if err != nil || blocks == nil {
+		return nil, nil, err
+	}
+
+	block := findAccountBlock(blocks[0], hash)
+	if block == nil {
+		return nil, nil, fmt.Errorf("account block proof is not about %s", hash)
+	}
+	return block, header, nil
+}
+
+// GetAccountBlockByHeight returns the account block and the snapshot header which confirms it,
+// they are nil if the block is missing or unconfirmed.
+func (c *Client) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, *ledger.SnapshotBlock, error) {
+	if height == 0 {
+		return nil, nil, nil
+	}
+	return c.getAccountBlock(addr, height)
+}
+
+// GetLatestAccountBlock returns the latest confirmed account block and the snapshot header which confirms it,
+// they are nil if the account has no confirmed blocks.
+func (c *Client) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, *ledger.SnapshotBlock, error) {
+	return c.getAccountBlock(addr, 0)
+}
+
+func (c *Client) getAccountBlock(addr types.Address, height uint64) (*ledger.AccountBlock, *ledger.SnapshotBlock, error) {
+	blocks, header, err := c.getProof(&net.GetAccountBlockProof{
+		Address: addr,
+		Height:  height,
+	})
+	if err != nil || blocks == nil {
+		return nil, nil, err
+	}
+
+	block := blocks[0]
+	if block.AccountAddress != addr || (height > 0 && block.Height != height) {
+		return nil, nil, fmt.Errorf("account block proof is not about %s/%d", addr, height)
+	}
+	return block, header, nil
+}
+
+// getProof requests the proof from the servers in turn until it's verified, the blocks are nil if all servers miss it
+func (c *Client) getProof(request *net.GetAccountBlockProof) (blocks []*ledger.AccountBlock, header *ledger.SnapshotBlock, err error) {
+	if c.store.getLatest() == nil {
+		return nil, nil, errNotStarted
+	}
+
+	var missing int
+	for range c.conns {
+		conn := c.pick()
+		var proof *net.AccountBlockProof
+		if proof, err = conn.GetAccountBlockProof(request); err != nil {
+			if err == net.ExpMissing {
+				missing++
+			}
+			continue
+		}
+
+		if proof.Snapshot == nil {
+			err = fmt.Errorf("missing snapshot block of the proof from %s", conn)
+			continue
+		}
+		if header, err = c.store.header(proof.Snapshot.Height); err != nil {
+			return nil, nil, err
+		}
+		if header == nil {
+			if latest := c.store.getLatest(); proof.Snapshot.Height > latest.Height {
+				return nil, nil, fmt.Errorf("snapshot block %d is higher than the latest header %d", proof.Snapshot.Height, latest.Height)
+			}
+			return nil, nil, fmt.Errorf("snapshot block %d is lower than the base header", proof.Snapshot.Height)
+		}
+
+		if err = verifyAccountBlockProof(header, proof.Blocks, proof.Snapshot); err != nil {
+			c.log.Warn(fmt.Sprintf("invalid account block proof from %s: %v", conn, err))
+			continue
+		}
+		return proof.Blocks, header, nil
+	}
+
+	if missing == len(c.conns) {
+		return nil, nil, nil
+	}
+	return nil, nil, err
+}
@@ -0,0 +1,28 @@
+package light
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestAgreedProducers(t *testing.T) {
+	honest := []types.Address{{1}, {2}}
+	lying := []types.Address{{1}, {3}}
+
+	// a single lying server among 3 servers is outvoted
+	if producers, ok := agreedProducers([][]types.Address{lying, honest, honest}, 2); !ok || !equalAddresses(producers, honest) {
+		t.Fatalf("agreed producers are %v, should be %v", producers, honest)
+	}
+	// a lying server can't make the quorum alone
+	if producers, ok := agreedProducers([][]types.Address{lying, honest}, 2); ok {
+		t.Fatalf("different producers should not be agreed, got %v", producers)
+	}
+	// the other servers are unreachable
+	if producers, ok := agreedProducers([][]types.Address{lying}, 2); ok {
+		t.Fatalf("producers of one server should not make the quorum, got %v", producers)
+	}
+	if _, ok := agreedProducers(nil, 1); ok {
+		t.Fatal("no producers should be agreed without servers")
+	}
+}
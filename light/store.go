@@ -0,0 +1,295 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package light
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	headerPrefix = []byte("h") // height -> header
+	hashPrefix   = []byte("n") // hash -> height
+	baseKey      = []byte("base")
+	latestKey    = []byte("latest")
+)
+
+var errStoreEmpty = errors.New("header store is empty")
+
+// headerStore stores the verified snapshot headers from the base header to the latest header
+type headerStore struct {
+	db *leveldb.DB
+
+	mu     sync.RWMutex
+	base   *ledger.SnapshotBlock
+	latest *ledger.SnapshotBlock
+}
+
+// newHeaderStore opens the store at path, the store is in memory if path is empty
+func newHeaderStore(path string) (*headerStore, error) {
+	var db *leveldb.DB
+	var err error
+	if path == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := &headerStore{
+		db: db,
+	}
+	if err = s.load(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func heightToBytes(height uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, height)
+	return buf
+}
+
+func headerKey(height uint64) []byte {
+	return append(append([]byte{}, headerPrefix...), heightToBytes(height)...)
+}
+
+func hashKey(hash types.Hash) []byte {
+	return append(append([]byte{}, hashPrefix...), hash.Bytes()...)
+}
+
+func (s *headerStore) load() (err error) {
+	base, err := s.db.Get(baseKey, nil)
+	if err == leveldb.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return
+	}
+	latest, err := s.db.Get(latestKey, nil)
+	if err != nil {
+		return
+	}
+
+	if s.base, err = s.read(binary.BigEndian.Uint64(base)); err != nil {
+		return
+	}
+	if s.latest, err = s.read(binary.BigEndian.Uint64(latest)); err != nil {
+		return
+	}
+	if s.base == nil || s.latest == nil {
+		return fmt.Errorf("header store is corrupted, base %d latest %d", binary.BigEndian.Uint64(base), binary.BigEndian.Uint64(latest))
+	}
+
+	return nil
+}
+
+func (s *headerStore) read(height uint64) (*ledger.SnapshotBlock, error) {
+	data, err := s.db.Get(headerKey(height), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := &ledger.SnapshotBlock{}
+	if err = header.Deserialize(data); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// init makes the trusted header as the base, the stored headers are dropped if the base is different
+func (s *headerStore) init(base *ledger.SnapshotBlock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.base != nil && s.base.Hash == base.Hash {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	iter := s.db.NewIterator(nil, nil)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	if err := s.put(batch, base); err != nil {
+		return err
+	}
+	batch.Put(baseKey, heightToBytes(base.Height))
+	batch.Put(latestKey, heightToBytes(base.Height))
+	if err := s.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	s.base = base
+	s.latest = base
+	return nil
+}
+
+func (s *headerStore) put(batch *leveldb.Batch, header *ledger.SnapshotBlock) error {
+	// the snapshot content is not stored
+	h := *header
+	h.SnapshotContent = nil
+	data, err := h.Serialize()
+	if err != nil {
+		return err
+	}
+
+	batch.Put(headerKey(header.Height), data)
+	batch.Put(hashKey(header.Hash), heightToBytes(header.Height))
+	return nil
+}
+
+// getBase returns the trusted header which the headers are synced from, it's nil before init
+func (s *headerStore) getBase() *ledger.SnapshotBlock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.base
+}
+
+// getLatest returns the latest verified header, it's nil before init
+func (s *headerStore) getLatest() *ledger.SnapshotBlock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
+
+// header returns nil if the header at the height is not stored
+func (s *headerStore) header(height uint64) (*ledger.SnapshotBlock, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.latest == nil || height < s.base.Height || height > s.latest.Height {
+		return nil, nil
+	}
+	return s.read(height)
+}
+
+// headerByHash returns nil if the header is not stored
+func (s *headerStore) headerByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := s.db.Get(hashKey(hash), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.read(binary.BigEndian.Uint64(data))
+}
+
+// append stores the headers after the latest header, they must have been verified
+func (s *headerStore) append(headers []*ledger.SnapshotBlock) error {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latest == nil {
+		return errStoreEmpty
+	}
+	if headers[0].Height != s.latest.Height+1 {
+		return fmt.Errorf("header %d is not next to the latest header %d", headers[0].Height, s.latest.Height)
+	}
+
+	batch := new(leveldb.Batch)
+	for _, header := range headers {
+		if err := s.put(batch, header); err != nil {
+			return err
+		}
+	}
+	latest := headers[len(headers)-1]
+	batch.Put(latestKey, heightToBytes(latest.Height))
+	if err := s.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	s.latest = latest
+	return nil
+}
+
+// rollback deletes the latest header, the base header can not be deleted
+func (s *headerStore) rollback() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.latest == nil {
+		return errStoreEmpty
+	}
+	if s.latest.Height == s.base.Height {
+		return errors.New("the base header can not be rolled back")
+	}
+
+	prev, err := s.read(s.latest.Height - 1)
+	if err != nil {
+		return err
+	}
+	if prev == nil {
+		return fmt.Errorf("missing header %d", s.latest.Height-1)
+	}
+
+	batch := new(leveldb.Batch)
+	batch.Delete(headerKey(s.latest.Height))
+	batch.Delete(hashKey(s.latest.Hash))
+	batch.Put(latestKey, heightToBytes(prev.Height))
+	if err = s.db.Write(batch, nil); err != nil {
+		return err
+	}
+
+	s.latest = prev
+	return nil
+}
+
+// count returns the count of the stored headers
+func (s *headerStore) count() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.latest == nil {
+		return 0
+	}
+	return s.latest.Height - s.base.Height + 1
+}
+
+func (s *headerStore) close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,120 @@
+package light
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func mockHeaders(base *ledger.SnapshotBlock, count int) []*ledger.SnapshotBlock {
+	headers := make([]*ledger.SnapshotBlock, count)
+	prev := base
+	for i := range headers {
+		timestamp := prev.Timestamp.Add(time.Second)
+		header := &ledger.SnapshotBlock{
+			PrevHash:  prev.Hash,
+			Height:    prev.Height + 1,
+			Timestamp: &timestamp,
+		}
+		header.Hash = header.ComputeHash()
+		headers[i] = header
+		prev = header
+	}
+	return headers
+}
+
+func TestHeaderStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "light")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := newHeaderStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.getLatest() != nil || store.count() != 0 {
+		t.Fatal("store should be empty")
+	}
+
+	base := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &genesisTime,
+	}
+	base.Hash = base.ComputeHash()
+	if err = store.init(base); err != nil {
+		t.Fatal(err)
+	}
+
+	headers := mockHeaders(base, 10)
+	if err = store.append(headers[1:]); err == nil {
+		t.Fatal("should fail to append discontinuous headers")
+	}
+	if err = store.append(headers); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopen
+	store, err = newHeaderStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.close()
+
+	if store.getBase().Hash != base.Hash {
+		t.Errorf("base %s is not %s", store.getBase().Hash, base.Hash)
+	}
+	if latest := store.getLatest(); latest.Hash != headers[8].Hash {
+		t.Errorf("latest %s/%d is not %s/%d", latest.Hash, latest.Height, headers[8].Hash, headers[8].Height)
+	}
+	if store.count() != 10 {
+		t.Errorf("count %d is not 10", store.count())
+	}
+
+	header, err := store.header(5)
+	if err != nil || header == nil || header.Hash != headers[3].Hash {
+		t.Errorf("wrong header 5: %v %v", header, err)
+	}
+	header, err = store.headerByHash(headers[6].Hash)
+	if err != nil || header == nil || header.Height != 8 {
+		t.Errorf("wrong header of hash: %v %v", header, err)
+	}
+	// rolled back
+	if header, err = store.headerByHash(headers[9].Hash); err != nil || header != nil {
+		t.Errorf("header %s should be rolled back", headers[9].Hash)
+	}
+	if header, err = store.header(11); err != nil || header != nil {
+		t.Errorf("header 11 should be rolled back")
+	}
+
+	// the base can not be rolled back
+	for i := 0; i < 9; i++ {
+		if err = store.rollback(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = store.rollback(); err == nil {
+		t.Error("should fail to roll back the base")
+	}
+
+	// another base drops all headers
+	if err = store.init(headers[4]); err != nil {
+		t.Fatal(err)
+	}
+	if store.count() != 1 || store.getLatest().Hash != headers[4].Hash {
+		t.Errorf("wrong store after init")
+	}
+	if header, err = store.header(1); err != nil || header != nil {
+		t.Errorf("header 1 should be dropped")
+	}
+}
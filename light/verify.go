@@ -0,0 +1,170 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package light
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+var (
+	errHeaderHeight    = errors.New("header height is not continuous")
+	errHeaderPrevHash  = errors.New("header prev hash is not the hash of the previous header")
+	errHeaderTimestamp = errors.New("header timestamp is not after the previous header")
+	errHeaderHash      = errors.New("header hash is not the hash of its content")
+	errHeaderSignature = errors.New("header signature is invalid")
+	errHeaderProducer  = errors.New("header is not produced by the planned producer")
+)
+
+// verifyHeader verifies the header is next to prev, and it is signed by the producer of its slot,
+// producers are the snapshot block producers of the round which the header belongs to.
+func verifyHeader(group *core.GroupInfo, prev, header *ledger.SnapshotBlock, producers []types.Address) error {
+	if header.Height != prev.Height+1 {
+		return errHeaderHeight
+	}
+	if header.PrevHash != prev.Hash {
+		return errHeaderPrevHash
+	}
+	if header.Timestamp == nil || !header.Timestamp.After(*prev.Timestamp) {
+		return errHeaderTimestamp
+	}
+
+	return verifyProducer(group, header, producers)
+}
+
+// verifyProducer verifies the header hash is recomputed from the header with its snapshot content,
+// the hash is signed by the header producer, and the producer has the slot at the header timestamp
+func verifyProducer(group *core.GroupInfo, header *ledger.SnapshotBlock, producers []types.Address) error {
+	if header.Timestamp == nil {
+		return errHeaderTimestamp
+	}
+	if header.ComputeHash() != header.Hash {
+		return errHeaderHash
+	}
+	if len(header.Signature) == 0 || len(header.PublicKey) == 0 || !header.VerifySignature() {
+		return errHeaderSignature
+	}
+
+	producer := header.Producer()
+	index := group.Time2Index(*header.Timestamp)
+	for _, plan := range group.GenPlanByAddress(index, producers) {
+		if plan.Member == producer && plan.STime.Equal(*header.Timestamp) {
+			return nil
+		}
+	}
+
+	return errHeaderProducer
+}
+
+// verifySnapshotBlock verifies the full snapshot block is the header
+func verifySnapshotBlock(header, block *ledger.SnapshotBlock) error {
+	if block.Height != header.Height || block.Hash != header.Hash {
+		return fmt.Errorf("snapshot block %s/%d is not the header %s/%d", block.Hash, block.Height, header.Hash, header.Height)
+	}
+	if hash := block.ComputeHash(); hash != block.Hash {
+		return fmt.Errorf("snapshot block %d hash %s is not %s", block.Height, block.Hash, hash)
+	}
+	return nil
+}
+
+// verifyAccountBlockProof verifies the account blocks are confirmed by the header.
+// The snapshot block of the proof must be the header, then the last account block is its snapshot content,
+// and the former blocks are linked to it by the prev hash.
+func verifyAccountBlockProof(header *ledger.SnapshotBlock, blocks []*ledger.AccountBlock, snapshot *ledger.SnapshotBlock) error {
+	if len(blocks) == 0 {
+		return errors.New("account block proof is empty")
+	}
+	if snapshot == nil {
+		return errors.New("missing snapshot block of the account block proof")
+	}
+	if err := verifySnapshotBlock(header, snapshot); err != nil {
+		return err
+	}
+
+	var prev *ledger.AccountBlock
+	for _, block := range blocks {
+		if hash := block.ComputeHash(); hash != block.Hash {
+			return fmt.Errorf("account block %s/%d hash %s is not %s", block.AccountAddress, block.Height, block.Hash, hash)
+		}
+		if err := verifyAccountBlockSignature(block); err != nil {
+			return err
+		}
+		for i, send := range block.SendBlockList {
+			if hash := send.ComputeSendHash(block, uint8(i)); hash != send.Hash {
+				return fmt.Errorf("send block %d of account block %s hash %s is not %s", i, block.Hash, send.Hash, hash)
+			}
+		}
+
+		if prev != nil {
+			if block.AccountAddress != prev.AccountAddress {
+				return fmt.Errorf("account block %s address %s is not %s", block.Hash, block.AccountAddress, prev.AccountAddress)
+			}
+			if block.Height != prev.Height+1 {
+				return fmt.Errorf("account block %s height %d is not %d", block.Hash, block.Height, prev.Height+1)
+			}
+			if block.PrevHash != prev.Hash {
+				return fmt.Errorf("account block %s prev hash %s is not %s", block.Hash, block.PrevHash, prev.Hash)
+			}
+		}
+		prev = block
+	}
+
+	hh, ok := snapshot.SnapshotContent[prev.AccountAddress]
+	if !ok {
+		return fmt.Errorf("account %s is not in the snapshot block %d", prev.AccountAddress, snapshot.Height)
+	}
+	if hh.Hash != prev.Hash || hh.Height != prev.Height {
+		return fmt.Errorf("account block %s/%d is not the snapshot content %s/%d", prev.Hash, prev.Height, hh.Hash, hh.Height)
+	}
+
+	return nil
+}
+
+// verifyAccountBlockSignature verifies the signature of the account block, the blocks of the user accounts
+// are signed by the accounts, the blocks of the contracts are signed by the producers of their consensus groups,
+// the genesis blocks and the send blocks of the contracts are not signed.
+func verifyAccountBlockSignature(block *ledger.AccountBlock) error {
+	if block.BlockType == ledger.BlockTypeGenesisReceive {
+		return nil
+	}
+	if len(block.Signature) == 0 || len(block.PublicKey) == 0 || !block.VerifySignature() {
+		return fmt.Errorf("account block %s signature is invalid", block.Hash)
+	}
+	if !types.IsContractAddr(block.AccountAddress) && block.Producer() != block.AccountAddress {
+		return fmt.Errorf("account block %s is not signed by %s", block.Hash, block.AccountAddress)
+	}
+	return nil
+}
+
+// findAccountBlock returns the account block or the send block of a contract receive block of the hash
+func findAccountBlock(block *ledger.AccountBlock, hash types.Hash) *ledger.AccountBlock {
+	if block.Hash == hash {
+		return block
+	}
+	for _, send := range block.SendBlockList {
+		if send.Hash == hash {
+			return send
+		}
+	}
+	return nil
+}
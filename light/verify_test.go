@@ -0,0 +1,280 @@
+package light
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func init() {
+	if len(fork.GetActiveForkPointList()) <= 0 {
+		fork.SetForkPoints(&config.ForkPoints{
+			SeedFork:      &config.ForkPoint{Height: 1, Version: 1},
+			DexFork:       &config.ForkPoint{Height: 2, Version: 2},
+			DexFeeFork:    &config.ForkPoint{Height: 3, Version: 3},
+			StemFork:      &config.ForkPoint{Height: 4, Version: 4},
+			LeafFork:      &config.ForkPoint{Height: 5, Version: 5},
+			EarthFork:     &config.ForkPoint{Height: 6, Version: 6},
+			DexMiningFork: &config.ForkPoint{Height: 7, Version: 7},
+		})
+	}
+}
+
+var genesisTime = time.Unix(1558411200, 0)
+
+// 2 producers, every producer has 3 slots of 1 second in a round
+func mockGroup() *core.GroupInfo {
+	return core.NewGroupInfo(genesisTime, types.ConsensusGroupInfo{
+		Gid:       types.SNAPSHOT_GID,
+		NodeCount: 2,
+		Interval:  1,
+		PerCount:  3,
+		Repeat:    1,
+	})
+}
+
+func mockKeys(t *testing.T, n int) []ed25519.PrivateKey {
+	keys := make([]ed25519.PrivateKey, n)
+	for i := range keys {
+		_, priv, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i] = priv
+	}
+	return keys
+}
+
+func mockHeader(prev *ledger.SnapshotBlock, key ed25519.PrivateKey, timestamp time.Time) *ledger.SnapshotBlock {
+	header := &ledger.SnapshotBlock{
+		PrevHash:  prev.Hash,
+		Height:    prev.Height + 1,
+		Timestamp: &timestamp,
+		PublicKey: key.PubByte(),
+	}
+	header.Hash = header.ComputeHash()
+	header.Signature = ed25519.Sign(key, header.Hash.Bytes())
+	return header
+}
+
+func TestVerifyHeader(t *testing.T) {
+	group := mockGroup()
+	keys := mockKeys(t, 3)
+	producers := []types.Address{
+		types.PubkeyToAddress(keys[0].PubByte()),
+		types.PubkeyToAddress(keys[1].PubByte()),
+	}
+
+	genesis := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &genesisTime,
+	}
+	genesis.Hash = genesis.ComputeHash()
+
+	// the slots of round 0: keys[0] at 0, 1, 2, keys[1] at 3, 4, 5
+	prev := genesis
+	for i, slot := range []int64{1, 2, 3, 5} {
+		key := keys[0]
+		if slot >= 3 {
+			key = keys[1]
+		}
+		header := mockHeader(prev, key, genesisTime.Add(time.Duration(slot)*time.Second))
+		if err := verifyHeader(group, prev, header, producers); err != nil {
+			t.Fatalf("header %d: %v", i, err)
+		}
+		prev = header
+	}
+
+	slot := func(n int64) time.Time {
+		return genesisTime.Add(time.Duration(n) * time.Second)
+	}
+
+	// not the producer of the slot
+	if err := verifyHeader(group, genesis, mockHeader(genesis, keys[1], slot(1)), producers); err != errHeaderProducer {
+		t.Errorf("wrong producer: %v", err)
+	}
+	// not a producer of the round
+	if err := verifyHeader(group, genesis, mockHeader(genesis, keys[2], slot(1)), producers); err != errHeaderProducer {
+		t.Errorf("unknown producer: %v", err)
+	}
+	// not at the start of a slot
+	if err := verifyHeader(group, genesis, mockHeader(genesis, keys[0], slot(1).Add(time.Millisecond*500)), producers); err != errHeaderProducer {
+		t.Errorf("wrong slot: %v", err)
+	}
+	// not after the previous header
+	if err := verifyHeader(group, genesis, mockHeader(genesis, keys[0], slot(0)), producers); err != errHeaderTimestamp {
+		t.Errorf("wrong timestamp: %v", err)
+	}
+
+	header := mockHeader(genesis, keys[0], slot(1))
+	header.Signature = ed25519.Sign(keys[0], types.Hash{1}.Bytes())
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderSignature {
+		t.Errorf("wrong signature: %v", err)
+	}
+
+	// a lying server can't change the snapshot content or the hash of a signed header
+	header = mockHeader(genesis, keys[0], slot(1))
+	header.SnapshotContent = ledger.SnapshotContent{types.Address{1}: &ledger.HashHeight{Height: 1}}
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderHash {
+		t.Errorf("tampered content: %v", err)
+	}
+	header = mockHeader(genesis, keys[0], slot(1))
+	header.Hash = types.Hash{1}
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderHash {
+		t.Errorf("tampered hash: %v", err)
+	}
+
+	header = mockHeader(genesis, keys[0], slot(1))
+	header.Signature = nil
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderSignature {
+		t.Errorf("missing signature: %v", err)
+	}
+
+	header = mockHeader(genesis, keys[0], slot(1))
+	header.Height++
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderHeight {
+		t.Errorf("wrong height: %v", err)
+	}
+
+	header = mockHeader(&ledger.SnapshotBlock{Height: 1}, keys[0], slot(1))
+	if err := verifyHeader(group, genesis, header, producers); err != errHeaderPrevHash {
+		t.Errorf("wrong prev hash: %v", err)
+	}
+}
+
+func mockAccountBlocks(key ed25519.PrivateKey, count int) []*ledger.AccountBlock {
+	addr := types.PubkeyToAddress(key.PubByte())
+	blocks := make([]*ledger.AccountBlock, count)
+	var prev types.Hash
+	for i := range blocks {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			PrevHash:       prev,
+			Height:         uint64(i + 1),
+			AccountAddress: addr,
+			Amount:         big.NewInt(int64(i)),
+		}
+		if i == count-1 {
+			// a contract receive block with a send block
+			block.BlockType = ledger.BlockTypeReceive
+			block.Amount = nil
+			block.FromBlockHash = types.Hash{2}
+			send := &ledger.AccountBlock{
+				BlockType:      ledger.BlockTypeSendCall,
+				AccountAddress: addr,
+				Amount:         big.NewInt(1),
+			}
+			send.Hash = send.ComputeSendHash(block, 0)
+			block.SendBlockList = []*ledger.AccountBlock{send}
+		}
+		block.PublicKey = key.PubByte()
+		block.Hash = block.ComputeHash()
+		block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+		blocks[i] = block
+		prev = block.Hash
+	}
+	return blocks
+}
+
+func mockProof(addr types.Address, blocks []*ledger.AccountBlock) (header, snapshot *ledger.SnapshotBlock) {
+	last := blocks[len(blocks)-1]
+	timestamp := genesisTime.Add(time.Second)
+	snapshot = &ledger.SnapshotBlock{
+		Height:    10,
+		Timestamp: &timestamp,
+		SnapshotContent: ledger.SnapshotContent{
+			addr: &ledger.HashHeight{Height: last.Height, Hash: last.Hash},
+		},
+	}
+	snapshot.Hash = snapshot.ComputeHash()
+
+	h := *snapshot
+	h.SnapshotContent = nil
+	return &h, snapshot
+}
+
+func TestVerifyAccountBlockProof(t *testing.T) {
+	keys := mockKeys(t, 2)
+	addr := types.PubkeyToAddress(keys[0].PubByte())
+	blocks := mockAccountBlocks(keys[0], 3)
+	header, snapshot := mockProof(addr, blocks)
+
+	if err := verifyAccountBlockProof(header, blocks, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyAccountBlockProof(header, blocks[1:], snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	send := blocks[2].SendBlockList[0]
+	if findAccountBlock(blocks[2], send.Hash) != send {
+		t.Error("failed to find the send block")
+	}
+	if findAccountBlock(blocks[2], types.Hash{3}) != nil {
+		t.Error("should not find the unknown hash")
+	}
+
+	// the last block is not the snapshot content
+	if err := verifyAccountBlockProof(header, blocks[:2], snapshot); err == nil {
+		t.Error("should fail without the confirmed block")
+	}
+
+	// the snapshot block is not the header
+	other := *header
+	other.Hash = types.Hash{1}
+	if err := verifyAccountBlockProof(&other, blocks, snapshot); err == nil {
+		t.Error("should fail with another header")
+	}
+
+	// the snapshot content is changed
+	changed := *snapshot
+	changed.SnapshotContent = ledger.SnapshotContent{
+		addr: &ledger.HashHeight{Height: 2, Hash: blocks[1].Hash},
+	}
+	if err := verifyAccountBlockProof(header, blocks[:2], &changed); err == nil {
+		t.Error("should fail with the changed snapshot content")
+	}
+
+	// the block is changed
+	tampered := mockAccountBlocks(keys[0], 3)
+	tampered[0].Amount = big.NewInt(100)
+	if err := verifyAccountBlockProof(header, tampered, snapshot); err == nil {
+		t.Error("should fail with the changed block")
+	}
+
+	// the block is replaced with a valid block of another chain
+	tampered = mockAccountBlocks(keys[0], 3)
+	tampered[0] = mockAccountBlocks(keys[1], 1)[0]
+	if err := verifyAccountBlockProof(header, tampered, snapshot); err == nil {
+		t.Error("should fail with the unlinked block")
+	}
+
+	// the send block is changed
+	tampered = mockAccountBlocks(keys[0], 3)
+	tampered[2].SendBlockList[0].Amount = big.NewInt(100)
+	if err := verifyAccountBlockProof(header, tampered, snapshot); err == nil {
+		t.Error("should fail with the changed send block")
+	}
+
+	// the signature is missing
+	tampered = mockAccountBlocks(keys[0], 3)
+	tampered[1].Signature = nil
+	if err := verifyAccountBlockProof(header, tampered, snapshot); err == nil {
+		t.Error("should fail without the signature")
+	}
+
+	// the block is signed by another account
+	tampered = mockAccountBlocks(keys[0], 3)
+	tampered[1].PublicKey = keys[1].PubByte()
+	tampered[1].Signature = ed25519.Sign(keys[1], tampered[1].Hash.Bytes())
+	if err := verifyAccountBlockProof(header, tampered, snapshot); err == nil {
+		t.Error("should fail with the signature of another account")
+	}
+}
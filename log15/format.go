@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -119,6 +120,24 @@ func logfmt(buf *bytes.Buffer, ctx []interface{}, color int) {
 	buf.WriteByte('\n')
 }
 
+// fileFormat is the format of the log files, it's set by SetFileFormat when the node starts
+var fileFormat atomic.Value
+
+type fileFormatHolder struct{ Format }
+
+// SetFileFormat sets the format of the log files made after it
+func SetFileFormat(f Format) {
+	fileFormat.Store(fileFormatHolder{f})
+}
+
+// FileFormat returns the format of the log files, it's LogfmtFormat if SetFileFormat isn't called
+func FileFormat() Format {
+	if h, ok := fileFormat.Load().(fileFormatHolder); ok {
+		return h.Format
+	}
+	return LogfmtFormat()
+}
+
 // JsonFormat formats log records as JSON objects separated by newlines.
 // It is the equivalent of JsonFormatEx(false, true).
 func JsonFormat() Format {
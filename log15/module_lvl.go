@@ -0,0 +1,87 @@
+package log15
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// module levels override the max level of ModuleLvlFilterHandler for the records
+// whose "module" context matches
+var (
+	moduleLvls     sync.Map
+	moduleLvlCount int32
+	moduleLvlMu    sync.Mutex
+)
+
+// SetModuleLvl sets the max level of the records of module. A module also applies
+// to its sub modules, e.g. "pool" applies to "pool/tree" if "pool/tree" isn't set.
+func SetModuleLvl(module string, lvl Lvl) {
+	moduleLvlMu.Lock()
+	defer moduleLvlMu.Unlock()
+	if _, loaded := moduleLvls.LoadOrStore(module, lvl); loaded {
+		moduleLvls.Store(module, lvl)
+		return
+	}
+	atomic.AddInt32(&moduleLvlCount, 1)
+}
+
+// ResetModuleLvl removes the level of module, the records fall back to the default level
+func ResetModuleLvl(module string) {
+	moduleLvlMu.Lock()
+	defer moduleLvlMu.Unlock()
+	if _, ok := moduleLvls.Load(module); !ok {
+		return
+	}
+	moduleLvls.Delete(module)
+	atomic.AddInt32(&moduleLvlCount, -1)
+}
+
+// ModuleLvls returns all the module levels which are set
+func ModuleLvls() map[string]Lvl {
+	lvls := make(map[string]Lvl)
+	moduleLvls.Range(func(k, v interface{}) bool {
+		lvls[k.(string)] = v.(Lvl)
+		return true
+	})
+	return lvls
+}
+
+// ModuleLvlFilterHandler returns chain Handler that only writes records which are
+// less than the level of their module, the level is maxLvl for the modules which
+// aren't set by SetModuleLvl. The last "module" context which has a level wins if there are many,
+// e.g. the level of "broadcaster" for log.New("module", "net").New("module", "broadcaster"),
+// or the level of "net" if "broadcaster" isn't set.
+func ModuleLvlFilterHandler(maxLvl Lvl, h Handler) Handler {
+	return FilterHandler(func(r *Record) (pass bool) {
+		if atomic.LoadInt32(&moduleLvlCount) > 0 {
+			if lvl, ok := recordModuleLvl(r); ok {
+				return r.Lvl <= lvl
+			}
+		}
+		return r.Lvl <= maxLvl
+	}, h)
+}
+
+func recordModuleLvl(r *Record) (Lvl, bool) {
+	for i := len(r.Ctx) - 2; i >= 0; i -= 2 {
+		if key, ok := r.Ctx[i].(string); !ok || key != "module" {
+			continue
+		}
+		module, ok := r.Ctx[i+1].(string)
+		if !ok {
+			continue
+		}
+		for {
+			if v, ok := moduleLvls.Load(module); ok {
+				return v.(Lvl), true
+			}
+			idx := strings.LastIndex(module, "/")
+			if idx < 0 {
+				break
+			}
+			module = module[:idx]
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,80 @@
+package log15
+
+import (
+	"testing"
+)
+
+func TestModuleLvlFilterHandler(t *testing.T) {
+	var records []*Record
+	h := ModuleLvlFilterHandler(LvlInfo, FuncHandler(func(r *Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	logger := New()
+	logger.SetHandler(h)
+
+	pool := logger.New("module", "pool")
+	tree := pool.New("module", "pool/tree")
+	net := logger.New("module", "net")
+	broadcaster := net.New("module", "broadcaster")
+
+	count := func(f func()) int {
+		records = nil
+		f()
+		return len(records)
+	}
+
+	// without module levels, maxLvl applies to all modules
+	if n := count(func() { pool.Debug("d"); pool.Info("i"); net.Debug("d") }); n != 1 {
+		t.Fatalf("%d records are written, should be 1", n)
+	}
+
+	SetModuleLvl("pool", LvlDebug)
+	SetModuleLvl("net", LvlError)
+	defer ResetModuleLvl("pool")
+	defer ResetModuleLvl("net")
+
+	// a module applies to its sub modules
+	if n := count(func() { pool.Debug("d"); tree.Debug("d") }); n != 2 {
+		t.Fatalf("%d debug records of pool are written, should be 2", n)
+	}
+	if n := count(func() { net.Info("i"); net.Error("e") }); n != 1 {
+		t.Fatalf("%d records of net are written, should be 1", n)
+	}
+	// broadcaster isn't set, the level of net applies
+	if n := count(func() { broadcaster.Info("i"); broadcaster.Error("e") }); n != 1 {
+		t.Fatalf("%d records of broadcaster are written, should be 1", n)
+	}
+	SetModuleLvl("broadcaster", LvlDebug)
+	defer ResetModuleLvl("broadcaster")
+	if n := count(func() { broadcaster.Debug("d") }); n != 1 {
+		t.Fatalf("%d debug records of broadcaster are written, should be 1", n)
+	}
+
+	SetModuleLvl("pool/tree", LvlWarn)
+	if n := count(func() { tree.Info("i"); pool.Info("i") }); n != 1 {
+		t.Fatalf("%d info records are written, should be 1", n)
+	}
+	ResetModuleLvl("pool/tree")
+	if n := count(func() { tree.Debug("d") }); n != 1 {
+		t.Fatalf("%d records of pool/tree are written after reset, should be 1", n)
+	}
+
+	lvls := ModuleLvls()
+	if len(lvls) != 3 || lvls["pool"] != LvlDebug || lvls["net"] != LvlError {
+		t.Fatalf("unexpected module levels %v", lvls)
+	}
+}
+
+func TestSetModuleLvl(t *testing.T) {
+	SetModuleLvl("chain", LvlDebug)
+	SetModuleLvl("chain", LvlWarn)
+	if lvl := ModuleLvls()["chain"]; lvl != LvlWarn || moduleLvlCount != 1 {
+		t.Fatalf("level of chain is %v with %d modules set", lvl, moduleLvlCount)
+	}
+	ResetModuleLvl("chain")
+	ResetModuleLvl("chain")
+	if _, ok := ModuleLvls()["chain"]; ok || moduleLvlCount != 0 {
+		t.Fatalf("level of chain should be reset, %d modules set", moduleLvlCount)
+	}
+}
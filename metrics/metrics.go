@@ -33,9 +33,10 @@ type InfluxDBConfig struct {
 }
 
 type Config struct {
-	IsEnable         bool
-	IsInfluxDBEnable bool
-	InfluxDBInfo     *InfluxDBConfig
+	IsEnable           bool
+	IsInfluxDBEnable   bool
+	InfluxDBInfo       *InfluxDBConfig
+	IsPrometheusEnable bool
 }
 
 func InitMetrics(metricFlag, influxDBFlag bool) {
@@ -0,0 +1,225 @@
+// Package prometheus renders a metrics.Registry in the Prometheus text exposition format.
+package prometheus
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+const (
+	// Path is the path of the Prometheus endpoint on the HTTP-RPC server
+	Path = "/metrics"
+
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	log = log15.New("module", "metrics/prometheus")
+
+	quantiles      = []float64{0.5, 0.75, 0.95, 0.99, 0.999, 0.9999}
+	resettingRanks = []float64{50, 95, 99}
+)
+
+// Handler returns a http.Handler which renders all metrics of r, the names are prefixed with namespace.
+func Handler(r metrics.Registry, namespace string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(Render(r, namespace))
+	})
+}
+
+// Render writes all metrics of r in the Prometheus text format, sorted by names.
+// Counters and gauges are exported as they are, meters as a counter and rate gauges,
+// histograms and timers as summaries in their own units, timers are in nanoseconds.
+// The names of different metrics may be the same after MetricName, e.g. "/a/b" and "/a.b",
+// or a gauge "x_rate1" and the rate of a timer "x", the later ones in the order of registry names
+// are suffixed by "_2", "_3" and so on.
+func Render(r metrics.Registry, namespace string) []byte {
+	all := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) {
+		all[name] = i
+	})
+	keys := make([]string, 0, len(all))
+	for key := range all {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	used := make(map[string]struct{})
+	metricsByName := make(map[string]interface{}, len(keys))
+	names := make([]string, 0, len(keys))
+	for _, key := range keys {
+		m := all[key]
+		base := MetricName(namespace, key)
+		name := base
+		for i := 2; collides(used, name, m); i++ {
+			name = base + "_" + strconv.Itoa(i)
+		}
+		if name != base {
+			warnCollision(key, name)
+		}
+		for _, sample := range sampleNames(name, m) {
+			used[sample] = struct{}{}
+		}
+		metricsByName[name] = m
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		switch m := metricsByName[name].(type) {
+		case metrics.Counter:
+			writeType(&buf, name, "counter")
+			writeSample(&buf, name, "", float64(m.Count()))
+		case metrics.Gauge:
+			writeType(&buf, name, "gauge")
+			writeSample(&buf, name, "", float64(m.Snapshot().Value()))
+		case metrics.GaugeFloat64:
+			writeType(&buf, name, "gauge")
+			writeSample(&buf, name, "", m.Snapshot().Value())
+		case metrics.Meter:
+			ms := m.Snapshot()
+			writeType(&buf, name+"_total", "counter")
+			writeSample(&buf, name+"_total", "", float64(ms.Count()))
+			writeRates(&buf, name, ms.Rate1(), ms.Rate5(), ms.Rate15(), ms.RateMean())
+		case metrics.Histogram:
+			ms := m.Snapshot()
+			writeSummary(&buf, name, quantiles, ms.Percentiles(quantiles), float64(ms.Sum()), ms.Count())
+		case metrics.Timer:
+			ms := m.Snapshot()
+			writeSummary(&buf, name, quantiles, ms.Percentiles(quantiles), float64(ms.Sum()), ms.Count())
+			writeRates(&buf, name, ms.Rate1(), ms.Rate5(), ms.Rate15(), ms.RateMean())
+		case metrics.ResettingTimer:
+			// the snapshot resets the timer, so the summary covers the values since the last scrape
+			ms := m.Snapshot()
+			values := ms.Values()
+			var sum float64
+			for _, v := range values {
+				sum += float64(v)
+			}
+			ps := make([]float64, len(resettingRanks))
+			if len(values) > 0 {
+				for i, p := range ms.Percentiles(resettingRanks) {
+					ps[i] = float64(p)
+				}
+			}
+			qs := make([]float64, len(resettingRanks))
+			for i, rank := range resettingRanks {
+				qs[i] = rank / 100
+			}
+			writeSummary(&buf, name, qs, ps, sum, int64(len(values)))
+		default:
+			log.Debug("unknown metric type", "name", name, "type", fmt.Sprintf("%T", m))
+		}
+	}
+	return buf.Bytes()
+}
+
+// sampleNames returns the names of the samples written for the metric
+func sampleNames(name string, m interface{}) []string {
+	rates := []string{name + "_rate1", name + "_rate5", name + "_rate15", name + "_rate_mean"}
+	switch m.(type) {
+	case metrics.Counter, metrics.Gauge, metrics.GaugeFloat64:
+		return []string{name}
+	case metrics.Meter:
+		return append([]string{name + "_total"}, rates...)
+	case metrics.Histogram, metrics.ResettingTimer:
+		return []string{name, name + "_sum", name + "_count"}
+	case metrics.Timer:
+		return append([]string{name, name + "_sum", name + "_count"}, rates...)
+	}
+	return nil
+}
+
+func collides(used map[string]struct{}, name string, m interface{}) bool {
+	for _, sample := range sampleNames(name, m) {
+		if _, ok := used[sample]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// the collisions are logged once, the metrics are rendered on every scrape
+var warned sync.Map
+
+func warnCollision(key, name string) {
+	if _, ok := warned.LoadOrStore(key, struct{}{}); !ok {
+		log.Warn("metric name collides with another metric, it is suffixed", "metric", key, "name", name)
+	}
+}
+
+// MetricName converts a registry name like "/system/cpu/sysload" into a valid Prometheus
+// metric name like "gvite_system_cpu_sysload".
+func MetricName(namespace, name string) string {
+	var b strings.Builder
+	lastUnderscore := true
+	if namespace != "" {
+		b.WriteString(strings.TrimSuffix(namespace, "_") + "_")
+	}
+	for _, c := range name {
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == ':' {
+			if b.Len() == 0 && c >= '0' && c <= '9' {
+				b.WriteByte('_')
+			}
+			b.WriteRune(c)
+			lastUnderscore = false
+			continue
+		}
+		if !lastUnderscore && b.Len() > 0 {
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+func writeType(buf *bytes.Buffer, name, typ string) {
+	fmt.Fprintf(buf, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(buf *bytes.Buffer, name, labels string, value float64) {
+	buf.WriteString(name)
+	if labels != "" {
+		buf.WriteString("{" + labels + "}")
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(value))
+	buf.WriteByte('\n')
+}
+
+func writeRates(buf *bytes.Buffer, name string, m1, m5, m15, mean float64) {
+	for _, r := range []struct {
+		suffix string
+		value  float64
+	}{{"_rate1", m1}, {"_rate5", m5}, {"_rate15", m15}, {"_rate_mean", mean}} {
+		writeType(buf, name+r.suffix, "gauge")
+		writeSample(buf, name+r.suffix, "", r.value)
+	}
+}
+
+func writeSummary(buf *bytes.Buffer, name string, qs []float64, values []float64, sum float64, count int64) {
+	writeType(buf, name, "summary")
+	for i, q := range qs {
+		writeSample(buf, name, `quantile="`+formatFloat(q)+`"`, values[i])
+	}
+	writeSample(buf, name+"_sum", "", sum)
+	writeSample(buf, name+"_count", "", float64(count))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+func TestMetricName(t *testing.T) {
+	tests := []struct {
+		namespace, name, expected string
+	}{
+		{"gvite", "/system/cpu/sysload", "gvite_system_cpu_sysload"},
+		{"gvite", "/chain/pool/snapshot/pending", "gvite_chain_pool_snapshot_pending"},
+		{"gvite", "p2p//peers.count-", "gvite_p2p_peers_count"},
+		{"", "/system/memory/allocs", "system_memory_allocs"},
+		{"", "1st", "_1st"},
+	}
+	for _, test := range tests {
+		if name := MetricName(test.namespace, test.name); name != test.expected {
+			t.Errorf("expected %s, got %s", test.expected, name)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	enabled := metrics.MetricsEnabled
+	metrics.MetricsEnabled = true
+	defer func() { metrics.MetricsEnabled = enabled }()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("/test/counter", r).Inc(3)
+	metrics.NewRegisteredGauge("/test/gauge", r).Update(-7)
+	metrics.NewRegisteredFunctionalGauge("/test/height", r, func() int64 { return 42 })
+	metrics.NewRegisteredMeter("/test/meter", r).Mark(5)
+	h := metrics.NewRegisteredHistogram("/test/histogram", r, metrics.NewUniformSample(100))
+	for i := int64(1); i <= 4; i++ {
+		h.Update(i)
+	}
+	metrics.NewRegisteredTimer("/test/timer", r).Update(time.Millisecond)
+
+	out := string(Render(r, "gvite"))
+	for _, line := range []string{
+		"# TYPE gvite_test_counter counter\ngvite_test_counter 3\n",
+		"# TYPE gvite_test_gauge gauge\ngvite_test_gauge -7\n",
+		"gvite_test_height 42\n",
+		"# TYPE gvite_test_meter_total counter\ngvite_test_meter_total 5\n",
+		"# TYPE gvite_test_meter_rate1 gauge\n",
+		"# TYPE gvite_test_histogram summary\n",
+		"gvite_test_histogram{quantile=\"0.5\"} 2.5\n",
+		"gvite_test_histogram_sum 10\ngvite_test_histogram_count 4\n",
+		"gvite_test_timer_sum 1e+06\ngvite_test_timer_count 1\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q in\n%s", line, out)
+		}
+	}
+	// sorted by names
+	if strings.Index(out, "gvite_test_counter") > strings.Index(out, "gvite_test_gauge") {
+		t.Errorf("metrics are not sorted\n%s", out)
+	}
+}
+
+func TestRender_Collisions(t *testing.T) {
+	enabled := metrics.MetricsEnabled
+	metrics.MetricsEnabled = true
+	defer func() { metrics.MetricsEnabled = enabled }()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredGauge("/a/b", r).Update(1)
+	metrics.NewRegisteredGauge("/a.b", r).Update(2)
+	metrics.NewRegisteredTimer("/x", r).Update(time.Millisecond)
+	metrics.NewRegisteredGauge("/x_rate1", r).Update(3)
+
+	out := string(Render(r, "gvite"))
+	for _, line := range []string{
+		"# TYPE gvite_a_b gauge\ngvite_a_b 2\n",
+		"# TYPE gvite_a_b_2 gauge\ngvite_a_b_2 1\n",
+		"# TYPE gvite_x_rate1 gauge\n",
+		"# TYPE gvite_x_rate1_2 gauge\ngvite_x_rate1_2 3\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("missing %q in\n%s", line, out)
+		}
+	}
+
+	types := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "# TYPE ") {
+			if types[line] {
+				t.Errorf("duplicate %q in\n%s", line, out)
+			}
+			types[line] = true
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := metrics.NewRegistry()
+	r.Register("/test/gauge", metrics.NewFunctionalGauge(func() int64 { return 1 }))
+	h := Handler(r, "gvite")
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, Path, nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, Path, nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected response %d", w.Code)
+	}
+}
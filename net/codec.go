@@ -49,6 +49,11 @@ type CodecFactory interface {
 	CreateCodec(conn _net.Conn) Codec
 }
 
+// secureCodec can encrypt the following messages by the session keys negotiated in handshake
+type secureCodec interface {
+	secure(writeKey, readKey []byte) error
+}
+
 /*
  * message structure
  *  |------- head --------|
@@ -170,6 +175,16 @@ func NewTransport(conn _net.Conn, minCompressLength int, readTimeout, writeTimeo
 	}
 }
 
+func (t *transport) secure(writeKey, readKey []byte) error {
+	conn, err := newSecureConn(t.Conn, writeKey, readKey)
+	if err != nil {
+		return err
+	}
+
+	t.Conn = conn
+	return nil
+}
+
 func (t *transport) SetReadTimeout(timeout time.Duration) {
 	t.readTimeout = timeout
 }
@@ -57,6 +57,9 @@ type HandshakeMsg struct {
 
 	FileAddress   []byte
 	PublicAddress []byte
+
+	EphemeralKey []byte // X25519 public key of this connection
+	EphemeralSig []byte // signature of EphemeralKey by the node ID
 }
 
 func (b *HandshakeMsg) Serialize() (data []byte, err error) {
@@ -73,6 +76,8 @@ func (b *HandshakeMsg) Serialize() (data []byte, err error) {
 		Key:           b.Key,
 		Token:         b.Token,
 		PublicAddress: b.PublicAddress,
+		EphemeralKey:  b.EphemeralKey,
+		EphemeralSig:  b.EphemeralSig,
 	}
 
 	return proto.Marshal(pb)
@@ -110,6 +115,9 @@ func (b *HandshakeMsg) Deserialize(data []byte) (err error) {
 	b.Key = pb.Key
 	b.Token = pb.Token
 
+	b.EphemeralKey = pb.EphemeralKey
+	b.EphemeralSig = pb.EphemeralSig
+
 	return nil
 }
 
@@ -127,6 +135,9 @@ type handshaker struct {
 
 	codecFactory CodecFactory
 
+	// refuse the peers can not encrypt messages, instead of fallback to plaintext
+	requireEncryption bool
+
 	chain chainReader
 
 	blackList netool.BlackList
@@ -208,11 +219,36 @@ func (h *handshaker) getSecret(theirId peerId) (secret []byte, err error) {
 	return
 }
 
-func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err error) {
+// bindToken return whether the reply token of the receiver binds the version and the ephemeral key,
+// the peers of old versions can only verify the tokens of timestamp.
+func (h *handshaker) bindToken(their *HandshakeMsg) bool {
+	return h.version >= encryptVersion && their.Version >= encryptVersion
+}
+
+// handshakeToken is the token before xor with the secret, if bind is true, the version and the ephemeral key
+// are included, so they can not be downgraded or replaced by intermediaries who don't know the secret.
+func handshakeToken(msg *HandshakeMsg, bind bool) []byte {
 	t := make([]byte, 8)
-	binary.BigEndian.PutUint64(t, uint64(their.Timestamp))
-	hash := crypto.Hash256(t)
-	token := xor(hash, secret)
+	binary.BigEndian.PutUint64(t, uint64(msg.Timestamp))
+	if false == bind {
+		return crypto.Hash256(t)
+	}
+
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(msg.Version))
+	return crypto.Hash256(t, v, msg.EphemeralKey)
+}
+
+// ephemeralDigest is signed by the node ID with the ephemeral key, it binds the version too,
+// so the version of the initiator, whose token is not bound, can not be downgraded either.
+func ephemeralDigest(msg *HandshakeMsg) []byte {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(msg.Version))
+	return crypto.Hash256(v, msg.EphemeralKey)
+}
+
+func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte, bind bool) (err error) {
+	token := xor(handshakeToken(their, bind), secret)
 	if len(their.Key) != 0 {
 		if false == ed25519.Verify(their.Key, token, their.Token) {
 			err = PeerInvalidSignature
@@ -228,7 +264,8 @@ func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err er
 	return
 }
 
-func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
+// makeHandshake make our handshake message with the ephemeral key, the token binds the version and the ephemeral key if bind is true
+func (h *handshaker) makeHandshake(secret []byte, key *ephemeralKey, bind bool) (our *HandshakeMsg) {
 	latestBlock := h.chain.GetLatestSnapshotBlock()
 	our = &HandshakeMsg{
 		Version:       int64(h.version),
@@ -245,11 +282,12 @@ func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 		PublicAddress: h.publicAddress,
 	}
 
-	t := make([]byte, 8)
-	binary.BigEndian.PutUint64(t, uint64(our.Timestamp))
-	hash := crypto.Hash256(t)
+	if key != nil {
+		our.EphemeralKey = key.pub[:]
+		our.EphemeralSig = ed25519.Sign(h.peerKey, ephemeralDigest(our))
+	}
 
-	our.Token = xor(hash, secret)
+	our.Token = xor(handshakeToken(our, bind), secret)
 	if h.key != nil {
 		our.Key = h.key.PubByte()
 		our.Token = ed25519.Sign(h.key, our.Token)
@@ -258,6 +296,74 @@ func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 	return
 }
 
+// makeEphemeral return a new ephemeral key for the handshake message,
+// return nil if the codec can not encrypt messages.
+func (h *handshaker) makeEphemeral(c Codec) (key *ephemeralKey) {
+	if h.version < encryptVersion {
+		return nil
+	}
+	if _, ok := c.(secureCodec); !ok {
+		return nil
+	}
+
+	return newEphemeralKey()
+}
+
+// negotiate the session keys with their ephemeral key, nil keys means the messages will not be encrypted
+func (h *handshaker) negotiate(their *HandshakeMsg, key *ephemeralKey, secret []byte, initiator bool) (writeKey, readKey []byte, err error) {
+	// old peers don't send ephemeral keys, the version has been downgraded
+	if their.Version < encryptVersion && len(their.EphemeralKey) != 0 {
+		err = PeerInvalidSignature
+		return
+	}
+
+	if key == nil || their.Version < encryptVersion {
+		if h.requireEncryption {
+			err = PeerNotEncrypted
+			return
+		}
+		netLog.Warn(fmt.Sprintf("fallback to plaintext messages with %s: our version %d, their version %d", their.ID, h.version, their.Version))
+		return
+	}
+
+	if len(their.EphemeralKey) != len(key.pub) {
+		err = PeerNotEncrypted
+		return
+	}
+
+	if false == ed25519.Verify(their.ID.Bytes(), ephemeralDigest(their), their.EphemeralSig) {
+		err = PeerInvalidSignature
+		return
+	}
+
+	ephemeralSecret, err := crypto.X25519ComputeSecret(key.priv[:], their.EphemeralKey)
+	if err != nil {
+		err = PeerInvalidToken
+		return
+	}
+
+	if initiator {
+		writeKey, readKey = sessionKeys(secret, ephemeralSecret, key.pub[:], their.EphemeralKey)
+	} else {
+		readKey, writeKey = sessionKeys(secret, ephemeralSecret, their.EphemeralKey, key.pub[:])
+	}
+
+	return
+}
+
+func (h *handshaker) secure(c Codec, writeKey, readKey []byte) (err error) {
+	if writeKey == nil {
+		return
+	}
+
+	if err = c.(secureCodec).secure(writeKey, readKey); err != nil {
+		netLog.Warn(fmt.Sprintf("failed to encrypt messages with %s: %v", c.Address(), err))
+		err = PeerNetworkError
+	}
+
+	return
+}
+
 func (h *handshaker) sendHandshake(c Codec, our *HandshakeMsg, msgId MsgId) (err error) {
 	data, err := our.Serialize()
 	if err != nil {
@@ -303,7 +409,8 @@ func (h *handshaker) ReceiveHandshake(conn _net.Conn) (c Codec, their *Handshake
 		return
 	}
 
-	err = h.verifyHandshake(their, secret)
+	// the initiator doesn't know our version, its token is the one old peers can verify
+	err = h.verifyHandshake(their, secret, false)
 	if err != nil {
 		return
 	}
@@ -313,13 +420,26 @@ func (h *handshaker) ReceiveHandshake(conn _net.Conn) (c Codec, their *Handshake
 		return
 	}
 
+	// reply the token which the initiator can verify, it's bound if the initiator is not an old peer
+	key := h.makeEphemeral(c)
+	our := h.makeHandshake(secret, key, h.bindToken(their))
+	writeKey, readKey, err := h.negotiate(their, key, secret, false)
+	if err != nil {
+		return
+	}
+
 	superior, err = h.onHandshaker(c, PeerFlagOutbound, their)
 	if err != nil {
 		return
 	}
 
-	our := h.makeHandshake(secret)
 	err = h.sendHandshake(c, our, msgId)
+	if err != nil {
+		return
+	}
+
+	// their messages after our handshake are encrypted
+	err = h.secure(c, writeKey, readKey)
 	return
 }
 
@@ -337,7 +457,10 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 		}
 	}()
 
-	our := h.makeHandshake(secret)
+	// we don't know the version of the receiver, so the token is not bound and old peers can verify it,
+	// our version and ephemeral key are bound by the ephemeral signature, the new receivers reply a bound token
+	key := h.makeEphemeral(c)
+	our := h.makeHandshake(secret, key, false)
 	err = h.sendHandshake(c, our, 0)
 	if err != nil {
 		return
@@ -348,7 +471,18 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 		return
 	}
 
-	err = h.verifyHandshake(their, secret)
+	// the token is not bound if our version is downgraded, then the new receivers can not be verified
+	err = h.verifyHandshake(their, secret, h.bindToken(their))
+	if err != nil {
+		return
+	}
+
+	writeKey, readKey, err := h.negotiate(their, key, secret, true)
+	if err != nil {
+		return
+	}
+
+	err = h.secure(c, writeKey, readKey)
 	if err != nil {
 		return
 	}
@@ -327,8 +327,8 @@ check:
 		panic(err)
 	}
 
-	our := hkr.makeHandshake(secret)
-	err = hkr.verifyHandshake(our, secret)
+	our := hkr.makeHandshake(secret, nil, false)
+	err = hkr.verifyHandshake(our, secret, false)
 	if err != nil {
 		panic(err)
 	}
@@ -403,13 +403,13 @@ func TestHandshaker_verifyHandshake(t *testing.T) {
 	}
 
 	secret, our := makeHandshake(priv2, priv4, hkr.id)
-	err = hkr.verifyHandshake(our, secret)
+	err = hkr.verifyHandshake(our, secret, false)
 	if err != nil {
 		panic(err)
 	}
 
 	our.Timestamp = time.Now().Unix()
-	err = hkr.verifyHandshake(our, secret)
+	err = hkr.verifyHandshake(our, secret, false)
 	if err == nil {
 		t.Fatal("should verify failed")
 	} else {
@@ -417,8 +417,288 @@ func TestHandshaker_verifyHandshake(t *testing.T) {
 	}
 
 	_, our = makeHandshake(priv2, nil, hkr.id)
-	err = hkr.verifyHandshake(our, secret)
+	err = hkr.verifyHandshake(our, secret, false)
 	if err != nil {
 		panic(err)
 	}
 }
+
+func newTestHandshaker(version int) *handshaker {
+	_, peerKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic(err)
+	}
+
+	id, _ := vnode.Bytes2NodeID(peerKey.PubByte())
+	hk := &handshaker{
+		version: version,
+		netId:   7,
+		id:      id,
+		peerKey: peerKey,
+		codecFactory: &transportFactory{
+			minCompressLength: 100,
+			readTimeout:       readMsgTimeout,
+			writeTimeout:      writeMsgTimeout,
+		},
+		blackList: netool.NewBlackList(func(t int64, count int) bool {
+			return false
+		}),
+		onHandshaker: func(c Codec, flag PeerFlag, their *HandshakeMsg) (superior bool, err error) {
+			return false, nil
+		},
+	}
+	hk.setChain(mockChain{
+		height: 100,
+	})
+
+	return hk
+}
+
+// handshake between initiator and receiver, then exchange a message
+func testHandshake(initiator, receiver *handshaker) (c1, c2 Codec, err1, err2 error) {
+	conn1, conn2 := _net.Pipe()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c2, _, _, err2 = receiver.ReceiveHandshake(conn2)
+		if err2 != nil {
+			_ = Disconnect(c2, err2)
+			_ = conn2.Close()
+		}
+	}()
+
+	c1, _, _, err1 = initiator.InitiateHandshake(conn1, receiver.id)
+	if err1 != nil {
+		_ = conn1.Close()
+	}
+	wg.Wait()
+
+	return
+}
+
+func exchangeMsg(c1, c2 Codec) (err error) {
+	msg := Msg{
+		Code:    CodeNewSnapshotBlock,
+		Id:      3,
+		Payload: bytes.Repeat([]byte("vite"), secureRecordSize),
+	}
+
+	var werr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		werr = c1.WriteMsg(msg)
+	}()
+
+	msg2, err := c2.ReadMsg()
+	wg.Wait()
+	if werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+	if false == MsgEqual(msg, msg2) {
+		return fmt.Errorf("message not equal")
+	}
+
+	return nil
+}
+
+func isEncrypted(c Codec) bool {
+	_, ok := c.(*transport).Conn.(*secureConn)
+	return ok
+}
+
+func TestHandshake_encrypt(t *testing.T) {
+	initiator, receiver := newTestHandshaker(version), newTestHandshaker(version)
+
+	c1, c2, err1, err2 := testHandshake(initiator, receiver)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("failed to handshake: %v %v", err1, err2)
+	}
+	defer c1.Close()
+
+	if !isEncrypted(c1) || !isEncrypted(c2) {
+		t.Fatal("messages should be encrypted")
+	}
+
+	if err := exchangeMsg(c1, c2); err != nil {
+		t.Fatalf("initiator to receiver: %v", err)
+	}
+	if err := exchangeMsg(c2, c1); err != nil {
+		t.Fatalf("receiver to initiator: %v", err)
+	}
+}
+
+func TestHandshake_fallback(t *testing.T) {
+	initiator, receiver := newTestHandshaker(encryptVersion-1), newTestHandshaker(version)
+
+	c1, c2, err1, err2 := testHandshake(initiator, receiver)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("failed to handshake: %v %v", err1, err2)
+	}
+	defer c1.Close()
+
+	if isEncrypted(c1) || isEncrypted(c2) {
+		t.Fatal("messages should not be encrypted with old peer")
+	}
+
+	if err := exchangeMsg(c1, c2); err != nil {
+		t.Fatalf("initiator to receiver: %v", err)
+	}
+	if err := exchangeMsg(c2, c1); err != nil {
+		t.Fatalf("receiver to initiator: %v", err)
+	}
+
+	// new peer dials old peer
+	c1, c2, err1, err2 = testHandshake(receiver, initiator)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("failed to handshake with old peer: %v %v", err1, err2)
+	}
+	defer c1.Close()
+
+	if isEncrypted(c1) || isEncrypted(c2) {
+		t.Fatal("messages should not be encrypted with old peer")
+	}
+
+	if err := exchangeMsg(c1, c2); err != nil {
+		t.Fatalf("initiator to old receiver: %v", err)
+	}
+	if err := exchangeMsg(c2, c1); err != nil {
+		t.Fatalf("old receiver to initiator: %v", err)
+	}
+
+	// refuse old peer
+	initiator, receiver = newTestHandshaker(encryptVersion-1), newTestHandshaker(version)
+	receiver.requireEncryption = true
+	_, _, err1, err2 = testHandshake(initiator, receiver)
+	if err2 != PeerNotEncrypted {
+		t.Fatalf("receiver should refuse old peer: %v", err2)
+	}
+	if err1 != PeerNotEncrypted {
+		t.Fatalf("initiator should get the disconnect reason: %v", err1)
+	}
+}
+
+func TestHandshaker_negotiate(t *testing.T) {
+	initiator, receiver := newTestHandshaker(version), newTestHandshaker(version)
+
+	secret1, err := initiator.getSecret(receiver.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret2, err := receiver.getSecret(initiator.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &transport{}
+	key1, key2 := initiator.makeEphemeral(c), receiver.makeEphemeral(c)
+	our, their := initiator.makeHandshake(secret1, key1, true), receiver.makeHandshake(secret2, key2, true)
+
+	w1, r1, err := initiator.negotiate(their, key1, secret1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, r2, err := receiver.negotiate(our, key2, secret2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w1, r2) || !bytes.Equal(r1, w2) || bytes.Equal(w1, r1) {
+		t.Fatal("wrong session keys")
+	}
+
+	// ephemeral key is not signed by the node ID
+	other := newTestHandshaker(version)
+	their.EphemeralSig = ed25519.Sign(other.peerKey, their.EphemeralKey)
+	if _, _, err = initiator.negotiate(their, key1, secret1, true); err != PeerInvalidSignature {
+		t.Fatalf("should be invalid signature: %v", err)
+	}
+
+	// the version is bound by the ephemeral signature
+	their = receiver.makeHandshake(secret2, key2, true)
+	their.Version = version + 1
+	if _, _, err = initiator.negotiate(their, key1, secret1, true); err != PeerInvalidSignature {
+		t.Fatalf("changed version should be invalid signature: %v", err)
+	}
+	their.Version = encryptVersion - 1
+	if _, _, err = initiator.negotiate(their, key1, secret1, true); err != PeerInvalidSignature {
+		t.Fatalf("downgraded version should be invalid signature: %v", err)
+	}
+
+	their.Version = version
+	their.EphemeralKey = nil
+	if _, _, err = initiator.negotiate(their, key1, secret1, true); err != PeerNotEncrypted {
+		t.Fatalf("should be not encrypted: %v", err)
+	}
+}
+
+func TestHandshaker_verifyHandshake_tamper(t *testing.T) {
+	initiator, receiver := newTestHandshaker(version), newTestHandshaker(version)
+
+	secret1, err := initiator.getSecret(receiver.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret2, err := receiver.getSecret(initiator.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &transport{}
+	// the reply token binds the version and the ephemeral key
+	our := initiator.makeHandshake(secret1, initiator.makeEphemeral(c), true)
+	if err = receiver.verifyHandshake(our, secret2, receiver.bindToken(our)); err != nil {
+		t.Fatal(err)
+	}
+
+	// downgrade to an old peer
+	tampered := *our
+	tampered.Version = encryptVersion - 1
+	tampered.EphemeralKey, tampered.EphemeralSig = nil, nil
+	if err = receiver.verifyHandshake(&tampered, secret2, receiver.bindToken(&tampered)); err != PeerInvalidToken {
+		t.Fatalf("downgraded handshake should be invalid token: %v", err)
+	}
+
+	// replace the ephemeral key, even if it's signed by the node ID
+	tampered = *our
+	key := newEphemeralKey()
+	tampered.EphemeralKey = key.pub[:]
+	tampered.EphemeralSig = ed25519.Sign(initiator.peerKey, ephemeralDigest(&tampered))
+	if err = receiver.verifyHandshake(&tampered, secret2, receiver.bindToken(&tampered)); err != PeerInvalidToken {
+		t.Fatalf("replaced ephemeral key should be invalid token: %v", err)
+	}
+
+	// the token signed by the miner key binds them too
+	_, initiator.key, err = ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	our = initiator.makeHandshake(secret1, initiator.makeEphemeral(c), true)
+	if err = receiver.verifyHandshake(our, secret2, receiver.bindToken(our)); err != nil {
+		t.Fatal(err)
+	}
+	tampered = *our
+	tampered.Version = encryptVersion - 1
+	if err = receiver.verifyHandshake(&tampered, secret2, receiver.bindToken(&tampered)); err != PeerInvalidSignature {
+		t.Fatalf("downgraded handshake should be invalid signature: %v", err)
+	}
+
+	// old peer is still verified by the token of timestamp
+	old := newTestHandshaker(encryptVersion - 1)
+	secret3, err := old.getSecret(receiver.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret4, err := receiver.getSecret(old.id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = receiver.verifyHandshake(old.makeHandshake(secret3, nil, false), secret4, false); err != nil {
+		t.Fatalf("old peer should be verified: %v", err)
+	}
+}
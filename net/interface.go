@@ -256,4 +256,5 @@ type Net interface {
 	Nodes() []*vnode.Node
 	PeerCount() int
 	PeerKey() ed25519.PrivateKey
+	SetPeerAccess(allowKeys, denyKeys []string, maxPeers int)
 }
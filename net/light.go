@@ -0,0 +1,399 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	net2 "net"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// MaxLightHeaders is the max count of snapshot headers in one response
+const MaxLightHeaders = 200
+
+// the max count of full snapshot blocks in one response, their snapshot contents may be large
+const maxLightSnapshotBlocks = 10
+
+// the max count of snapshot content items of the headers in one response, about 6MB
+const maxLightHeaderContents = 100000
+
+// MaxLightProofBlocks is the max count of account blocks in one AccountBlockProof
+const MaxLightProofBlocks = 100
+
+const lightDialTimeout = 5 * time.Second
+
+var errLightConnClosed = errors.New("light connection has closed")
+
+type lightReader interface {
+	GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error)
+	GetCompleteBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+	GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error)
+	GetAccountBlocksByHeight(addr types.Address, height uint64, count uint64) ([]*ledger.AccountBlock, error)
+	GetLatestAccountHeight(addr types.Address) (uint64, error)
+	GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock
+	GetConfirmSnapshotBlockByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error)
+}
+
+type roundReader interface {
+	ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error)
+}
+
+// lightServer serves the light clients on sync connections, they sync the snapshot headers and verify
+// the producers by the round producers, the account blocks are requested with the proofs.
+type lightServer struct {
+	chain  lightReader
+	rounds roundReader
+
+	log log15.Logger
+}
+
+func newLightServer(chain lightReader, rounds roundReader) *lightServer {
+	return &lightServer{
+		chain:  chain,
+		rounds: rounds,
+		log:    netLog.New("module", "light_server"),
+	}
+}
+
+func isLightRequest(code Code) bool {
+	return code == CodeGetSnapshotHeaders || code == CodeGetSnapshotBlocks || code == CodeGetRoundProducers || code == CodeGetAccountBlockProof
+}
+
+// handle returns the response of the light request msg
+func (s *lightServer) handle(msg Msg) (code Code, payload Serializable) {
+	switch msg.Code {
+	case CodeGetSnapshotHeaders, CodeGetSnapshotBlocks:
+		request := &GetSnapshotBlocks{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+		if request.From.Height == 0 || request.Count == 0 || !request.Forward {
+			return CodeException, ExpUnsolicited
+		}
+
+		max := uint64(maxLightSnapshotBlocks)
+		if msg.Code == CodeGetSnapshotHeaders {
+			max = MaxLightHeaders
+		}
+		blocks, err := s.chain.GetSnapshotBlocksByHeight(request.From.Height, true, min64(request.Count, max))
+		if err != nil {
+			s.log.Error(fmt.Sprintf("failed to read snapshot blocks from %d: %v", request.From.Height, err))
+			return CodeException, ExpServerError
+		}
+		if len(blocks) == 0 {
+			return CodeException, ExpMissing
+		}
+
+		if msg.Code == CodeGetSnapshotHeaders {
+			return CodeSnapshotHeaders, &SnapshotBlocks{Blocks: cutSnapshotHeaders(blocks)}
+		}
+		return CodeSnapshotBlocks, &SnapshotBlocks{Blocks: blocks}
+
+	case CodeGetRoundProducers:
+		request := &GetRoundProducers{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+
+		events, _, err := s.rounds.ReadByIndex(types.SNAPSHOT_GID, request.Index)
+		if err != nil {
+			s.log.Warn(fmt.Sprintf("failed to read producers of round %d: %v", request.Index, err))
+			return CodeException, ExpMissing
+		}
+		return CodeRoundProducers, &RoundProducers{
+			Index:     request.Index,
+			Producers: roundProducers(events),
+		}
+
+	case CodeGetAccountBlockProof:
+		request := &GetAccountBlockProof{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+
+		return s.accountBlockProof(request)
+	}
+
+	return CodeException, ExpUnsolicited
+}
+
+// cutSnapshotHeaders cuts the headers by the size of the snapshot contents, the headers carry the contents,
+// so the hashes can be recomputed, at least 2 headers are returned to make progress
+func cutSnapshotHeaders(blocks []*ledger.SnapshotBlock) []*ledger.SnapshotBlock {
+	var contents int
+	for i, block := range blocks {
+		if contents += len(block.SnapshotContent); contents > maxLightHeaderContents && i >= 2 {
+			return blocks[:i]
+		}
+	}
+	return blocks
+}
+
+// roundProducers returns the producers in the order of the plans, the plans are repeated by the producers
+func roundProducers(events []*consensus.Event) []types.Address {
+	producers := make([]types.Address, 0, len(events))
+	known := make(map[types.Address]struct{}, len(events))
+	for _, e := range events {
+		if _, ok := known[e.Address]; ok {
+			continue
+		}
+		known[e.Address] = struct{}{}
+		producers = append(producers, e.Address)
+	}
+	return producers
+}
+
+func (s *lightServer) accountBlockProof(request *GetAccountBlockProof) (code Code, payload Serializable) {
+	var block *ledger.AccountBlock
+	var err error
+	if request.Hash != types.ZERO_HASH {
+		block, err = s.chain.GetCompleteBlockByHash(request.Hash)
+	} else {
+		height := request.Height
+		if height == 0 {
+			if height, err = s.chain.GetLatestAccountHeight(request.Address); err == nil {
+				height -= uint64(len(s.chain.GetUnconfirmedBlocks(request.Address)))
+			}
+		}
+		if err == nil && height > 0 {
+			block, err = s.chain.GetAccountBlockByHeight(request.Address, height)
+		}
+	}
+	if err != nil {
+		s.log.Error(fmt.Sprintf("failed to read account block %s %s/%d: %v", request.Hash, request.Address, request.Height, err))
+		return CodeException, ExpServerError
+	}
+	if block == nil {
+		return CodeException, ExpMissing
+	}
+
+	snapshot, err := s.chain.GetConfirmSnapshotBlockByAbHash(block.Hash)
+	if err != nil {
+		s.log.Error(fmt.Sprintf("failed to read the snapshot block confirms %s: %v", block.Hash, err))
+		return CodeException, ExpServerError
+	}
+	// unconfirmed
+	if snapshot == nil {
+		return CodeException, ExpMissing
+	}
+
+	hh, ok := snapshot.SnapshotContent[block.AccountAddress]
+	if !ok || hh.Height < block.Height {
+		return CodeException, ExpServerError
+	}
+	count := hh.Height - block.Height + 1
+	if count > MaxLightProofBlocks {
+		return CodeException, ExpUnsolicited
+	}
+
+	blocks, err := s.chain.GetAccountBlocksByHeight(block.AccountAddress, hh.Height, count)
+	if err != nil || uint64(len(blocks)) != count {
+		return CodeException, ExpServerError
+	}
+	// from low to high
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	if blocks[0].Hash != block.Hash {
+		return CodeException, ExpServerError
+	}
+
+	return CodeAccountBlockProof, &AccountBlockProof{
+		Blocks:   blocks,
+		Snapshot: snapshot,
+	}
+}
+
+func min64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// LightConn is the connection of a light client to a full node which serves light clients,
+// the responses are not verified, the caller must verify them.
+type LightConn struct {
+	node    *vnode.Node
+	factory syncConnInitiator
+	dialer  *net2.Dialer
+
+	mu sync.Mutex // one request at a time
+	c  *syncConn
+}
+
+// NewLightConn returns a connection to the node, it is authenticated by peerKey, and it is dialed by the first request
+func NewLightConn(node *vnode.Node, peerKey ed25519.PrivateKey) *LightConn {
+	id, _ := vnode.Bytes2NodeID(peerKey.PubByte())
+	return &LightConn{
+		node: node,
+		factory: &defaultSyncConnectionFactory{
+			id:      id,
+			peerKey: peerKey,
+		},
+		dialer: &net2.Dialer{
+			Timeout: lightDialTimeout,
+		},
+	}
+}
+
+// Node returns the remote node
+func (l *LightConn) Node() *vnode.Node {
+	return l.node
+}
+
+func (l *LightConn) String() string {
+	return l.node.String()
+}
+
+// Close closes the underlying connection, the next request dials again
+func (l *LightConn) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.c == nil {
+		return errLightConnClosed
+	}
+	err := l.c.close()
+	l.c = nil
+	return err
+}
+
+func (l *LightConn) dial() (*syncConn, error) {
+	tcp, err := l.dialer.Dial("tcp", l.node.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := l.factory.initiate(tcp, &Peer{Id: l.node.ID})
+	if err != nil {
+		_ = tcp.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// request dials if necessary, the full node closes the idle connection, so the request is retried once
+// on a new connection if it fails on the old one.
+func (l *LightConn) request(code Code, payload Serializable, responseCode Code) (msg Msg, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for retry := l.c != nil; ; retry = false {
+		if l.c == nil {
+			if l.c, err = l.dial(); err != nil {
+				l.c = nil
+				return
+			}
+		}
+
+		msg, err = l.c.request(code, payload, responseCode)
+		if _, ok := err.(Exception); ok || err == nil {
+			return
+		}
+
+		_ = l.c.close()
+		l.c = nil
+		if !retry {
+			return
+		}
+	}
+}
+
+// GetSnapshotHeaders returns at most count snapshot headers with the snapshot contents from the height, from low to high,
+// the server may return less headers if the snapshot contents are large.
+func (l *LightConn) GetSnapshotHeaders(from, count uint64) ([]*ledger.SnapshotBlock, error) {
+	return l.getSnapshotBlocks(CodeGetSnapshotHeaders, from, count, CodeSnapshotHeaders)
+}
+
+// GetSnapshotBlocks returns at most count snapshot blocks with the snapshot contents from the height, from low to high
+func (l *LightConn) GetSnapshotBlocks(from, count uint64) ([]*ledger.SnapshotBlock, error) {
+	return l.getSnapshotBlocks(CodeGetSnapshotBlocks, from, count, CodeSnapshotBlocks)
+}
+
+func (l *LightConn) getSnapshotBlocks(code Code, from, count uint64, responseCode Code) ([]*ledger.SnapshotBlock, error) {
+	msg, err := l.request(code, &GetSnapshotBlocks{
+		From:    ledger.HashHeight{Height: from},
+		Count:   count,
+		Forward: true,
+	}, responseCode)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &SnapshotBlocks{}
+	if err = response.Deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+	if uint64(len(response.Blocks)) > count {
+		return nil, fmt.Errorf("%d snapshot blocks are more than %d", len(response.Blocks), count)
+	}
+	for i, block := range response.Blocks {
+		if block.Height != from+uint64(i) {
+			return nil, fmt.Errorf("snapshot block height %d is not %d", block.Height, from+uint64(i))
+		}
+	}
+
+	return response.Blocks, nil
+}
+
+// GetRoundProducers returns the snapshot block producers of the round, in the order of the plans
+func (l *LightConn) GetRoundProducers(index uint64) ([]types.Address, error) {
+	msg, err := l.request(CodeGetRoundProducers, &GetRoundProducers{Index: index}, CodeRoundProducers)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &RoundProducers{}
+	if err = response.Deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+	if response.Index != index {
+		return nil, fmt.Errorf("round index %d is not %d", response.Index, index)
+	}
+
+	return response.Producers, nil
+}
+
+// GetAccountBlockProof returns the account block and the snapshot block which confirms it
+func (l *LightConn) GetAccountBlockProof(request *GetAccountBlockProof) (*AccountBlockProof, error) {
+	msg, err := l.request(CodeGetAccountBlockProof, request, CodeAccountBlockProof)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &AccountBlockProof{}
+	if err = response.Deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+	if len(response.Blocks) == 0 {
+		return nil, errors.New("account block proof is empty")
+	}
+
+	return response, nil
+}
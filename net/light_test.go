@@ -0,0 +1,228 @@
+package net
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// the account has 4 blocks, the former 3 blocks are confirmed by the snapshot block 3
+type mockLightReader struct {
+	addr      types.Address
+	snapshots []*ledger.SnapshotBlock
+	blocks    []*ledger.AccountBlock
+}
+
+func newMockLightReader() *mockLightReader {
+	m := &mockLightReader{
+		addr: types.Address{1},
+	}
+	var prev types.Hash
+	for i := 0; i < 4; i++ {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			PrevHash:       prev,
+			Height:         uint64(i + 1),
+			AccountAddress: m.addr,
+			Amount:         big.NewInt(int64(i)),
+		}
+		block.Hash = block.ComputeHash()
+		m.blocks = append(m.blocks, block)
+		prev = block.Hash
+	}
+
+	prev = types.Hash{}
+	for i := 0; i < 5; i++ {
+		timestamp := time.Unix(int64(1558411200+i), 0)
+		sb := &ledger.SnapshotBlock{
+			PrevHash:        prev,
+			Height:          uint64(i + 1),
+			Timestamp:       &timestamp,
+			SnapshotContent: ledger.SnapshotContent{},
+		}
+		if sb.Height == 3 {
+			sb.SnapshotContent[m.addr] = &ledger.HashHeight{Height: 3, Hash: m.blocks[2].Hash}
+		}
+		sb.Hash = sb.ComputeHash()
+		m.snapshots = append(m.snapshots, sb)
+		prev = sb.Hash
+	}
+	return m
+}
+
+func (m *mockLightReader) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	var blocks []*ledger.SnapshotBlock
+	for _, sb := range m.snapshots {
+		if sb.Height >= height && uint64(len(blocks)) < count {
+			blocks = append(blocks, sb)
+		}
+	}
+	return blocks, nil
+}
+
+func (m *mockLightReader) GetCompleteBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	for _, block := range m.blocks {
+		if block.Hash == blockHash {
+			return block, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *mockLightReader) GetAccountBlockByHeight(addr types.Address, height uint64) (*ledger.AccountBlock, error) {
+	if addr != m.addr || height == 0 || height > uint64(len(m.blocks)) {
+		return nil, nil
+	}
+	return m.blocks[height-1], nil
+}
+
+func (m *mockLightReader) GetAccountBlocksByHeight(addr types.Address, height uint64, count uint64) ([]*ledger.AccountBlock, error) {
+	var blocks []*ledger.AccountBlock
+	for h := height; h > 0 && uint64(len(blocks)) < count; h-- {
+		block, _ := m.GetAccountBlockByHeight(addr, h)
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+func (m *mockLightReader) GetLatestAccountHeight(addr types.Address) (uint64, error) {
+	if addr != m.addr {
+		return 0, nil
+	}
+	return uint64(len(m.blocks)), nil
+}
+
+func (m *mockLightReader) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	if addr != m.addr {
+		return nil
+	}
+	return m.blocks[3:]
+}
+
+func (m *mockLightReader) GetConfirmSnapshotBlockByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	for _, block := range m.blocks[:3] {
+		if block.Hash == abHash {
+			return m.snapshots[2], nil
+		}
+	}
+	return nil, nil
+}
+
+type mockRoundReader []types.Address
+
+func (m mockRoundReader) ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error) {
+	var events []*consensus.Event
+	for i := 0; i < 2; i++ {
+		for _, addr := range m {
+			events = append(events, &consensus.Event{Gid: gid, Address: addr})
+		}
+	}
+	return events, index, nil
+}
+
+func TestLightServer_Handle(t *testing.T) {
+	chain := newMockLightReader()
+	producers := mockRoundReader{{2}, {3}}
+	s := newLightServer(chain, producers)
+
+	request := func(code Code, payload Serializable) (Code, Serializable) {
+		data, err := payload.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.handle(Msg{Code: code, Payload: data})
+	}
+
+	code, payload := request(CodeGetSnapshotHeaders, &GetSnapshotBlocks{From: ledger.HashHeight{Height: 2}, Count: 10, Forward: true})
+	if code != CodeSnapshotHeaders {
+		t.Fatalf("wrong headers response %d %v", code, payload)
+	}
+	headers := payload.(*SnapshotBlocks).Blocks
+	if len(headers) != 4 || headers[0].Height != 2 || headers[1].Hash != chain.snapshots[2].Hash {
+		t.Fatalf("wrong headers %v", headers)
+	}
+	// the hashes can be recomputed by the light client
+	if len(headers[1].SnapshotContent) != 1 || headers[1].ComputeHash() != headers[1].Hash {
+		t.Fatalf("header %d should carry the snapshot content", headers[1].Height)
+	}
+
+	if code, payload = request(CodeGetSnapshotHeaders, &GetSnapshotBlocks{From: ledger.HashHeight{Height: 2}, Count: 10}); code != CodeException || payload != ExpUnsolicited {
+		t.Fatalf("backward should be unsolicited: %d %v", code, payload)
+	}
+
+	code, payload = request(CodeGetRoundProducers, &GetRoundProducers{Index: 7})
+	if code != CodeRoundProducers {
+		t.Fatalf("wrong producers response %d %v", code, payload)
+	}
+	if rp := payload.(*RoundProducers); rp.Index != 7 || len(rp.Producers) != 2 || rp.Producers[0] != producers[0] || rp.Producers[1] != producers[1] {
+		t.Fatalf("wrong producers %v", rp)
+	}
+
+	// the blocks from the requested block to the confirmed block
+	code, payload = request(CodeGetAccountBlockProof, &GetAccountBlockProof{Hash: chain.blocks[1].Hash})
+	if code != CodeAccountBlockProof {
+		t.Fatalf("wrong proof response %d %v", code, payload)
+	}
+	proof := payload.(*AccountBlockProof)
+	if len(proof.Blocks) != 2 || proof.Blocks[0].Hash != chain.blocks[1].Hash || proof.Blocks[1].Hash != chain.blocks[2].Hash || proof.Snapshot.Hash != chain.snapshots[2].Hash {
+		t.Fatalf("wrong proof %v", proof)
+	}
+
+	// the latest confirmed block
+	code, payload = request(CodeGetAccountBlockProof, &GetAccountBlockProof{Address: chain.addr})
+	if code != CodeAccountBlockProof {
+		t.Fatalf("wrong proof response %d %v", code, payload)
+	}
+	if proof = payload.(*AccountBlockProof); len(proof.Blocks) != 1 || proof.Blocks[0].Height != 3 {
+		t.Fatalf("wrong latest proof %v", proof)
+	}
+
+	// unconfirmed
+	if code, payload = request(CodeGetAccountBlockProof, &GetAccountBlockProof{Address: chain.addr, Height: 4}); code != CodeException || payload != ExpMissing {
+		t.Fatalf("unconfirmed block should be missing: %d %v", code, payload)
+	}
+	if code, payload = request(CodeGetAccountBlockProof, &GetAccountBlockProof{Hash: types.Hash{1}}); code != CodeException || payload != ExpMissing {
+		t.Fatalf("unknown block should be missing: %d %v", code, payload)
+	}
+}
+
+func TestAccountBlockProof_Serialize(t *testing.T) {
+	chain := newMockLightReader()
+	proof := &AccountBlockProof{
+		Blocks:   chain.blocks[:3],
+		Snapshot: chain.snapshots[2],
+	}
+
+	data, err := proof.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof2 := &AccountBlockProof{}
+	if err = proof2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if len(proof2.Blocks) != 3 || proof2.Snapshot.Hash != proof.Snapshot.Hash || proof2.Snapshot.ComputeHash() != proof.Snapshot.Hash {
+		t.Fatalf("wrong proof %v", proof2)
+	}
+	for i, block := range proof2.Blocks {
+		if block.Hash != proof.Blocks[i].Hash || block.ComputeHash() != block.Hash {
+			t.Fatalf("wrong block %d", i)
+		}
+	}
+
+	rp := &RoundProducers{Index: 3, Producers: []types.Address{{1}, {2}}}
+	if data, err = rp.Serialize(); err != nil {
+		t.Fatal(err)
+	}
+	rp2 := &RoundProducers{}
+	if err = rp2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if rp2.Index != 3 || len(rp2.Producers) != 2 || rp2.Producers[1] != rp.Producers[1] {
+		t.Fatalf("wrong round producers %v", rp2)
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vitepb"
 )
@@ -49,11 +50,32 @@ const (
 	CodeSyncRequest     Code = 62
 	CodeSyncReady       Code = 63
 
+	CodeGetStateManifest Code = 64
+	CodeStateManifest    Code = 65
+	CodeGetStateChunk    Code = 66
+	CodeStateChunk       Code = 67
+
+	CodeGetSnapshotHeaders   Code = 68
+	CodeSnapshotHeaders      Code = 69
+	CodeGetRoundProducers    Code = 70
+	CodeRoundProducers       Code = 71
+	CodeGetAccountBlockProof Code = 72
+	CodeAccountBlockProof    Code = 73
+
+	CodeGetStateHeaders Code = 74
+	CodeStateHeaders    Code = 75
+
 	CodeException Code = 127
 	CodeTrace     Code = 128
 )
 
-const version = iota
+// version 1: the messages after handshake are encrypted by the session keys negotiated in handshake
+const version = 1
+
+// encryptVersion is the min version of peers can encrypt messages, lower versions will fallback to plaintext.
+// The initiator sends the token old peers can verify, its version and ephemeral key are signed with the node ID,
+// the receiver of encryptVersion replies a token binding its version and ephemeral key to the new initiators.
+const encryptVersion = 1
 
 type Code = byte
 type MsgId = uint32
@@ -548,3 +570,287 @@ func (c *GetHashHeightList) Deserialize(data []byte) (err error) {
 
 	return
 }
+
+// GetStateManifest requests the state manifest at the snapshot height
+type GetStateManifest struct {
+	Height uint64
+}
+
+func (m *GetStateManifest) Serialize() ([]byte, error) {
+	return proto.Marshal(&vitepb.GetStateManifest{
+		Height: m.Height,
+	})
+}
+
+func (m *GetStateManifest) Deserialize(data []byte) error {
+	pb := &vitepb.GetStateManifest{}
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+	m.Height = pb.Height
+	return nil
+}
+
+// StateManifest is the response of GetStateManifest
+type StateManifest struct {
+	interfaces.StateManifest
+}
+
+func (m *StateManifest) Serialize() ([]byte, error) {
+	pb := &vitepb.StateManifest{
+		Block:  m.Block.Proto(),
+		Root:   m.Root.Bytes(),
+		Chunks: make([]*vitepb.StateChunkInfo, len(m.Chunks)),
+	}
+	for i, chunk := range m.Chunks {
+		pb.Chunks[i] = &vitepb.StateChunkInfo{
+			StartKey: chunk.StartKey,
+			Count:    chunk.Count,
+			Hash:     chunk.Hash.Bytes(),
+		}
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (m *StateManifest) Deserialize(data []byte) (err error) {
+	pb := &vitepb.StateManifest{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	if pb.Block == nil {
+		return errDeserialize
+	}
+	m.Block = new(ledger.SnapshotBlock)
+	if err = m.Block.DeProto(pb.Block); err != nil {
+		return err
+	}
+
+	if m.Root, err = types.BytesToHash(pb.Root); err != nil {
+		return err
+	}
+
+	m.Chunks = make([]interfaces.StateChunkInfo, len(pb.Chunks))
+	for i, chunk := range pb.Chunks {
+		m.Chunks[i].StartKey = chunk.StartKey
+		m.Chunks[i].Count = chunk.Count
+		if m.Chunks[i].Hash, err = types.BytesToHash(chunk.Hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetStateChunk requests a chunk of the state manifest, Root must be the same as the manifest of the peer
+type GetStateChunk struct {
+	Height uint64
+	Root   types.Hash
+	Index  uint64
+}
+
+func (m *GetStateChunk) Serialize() ([]byte, error) {
+	return proto.Marshal(&vitepb.GetStateChunk{
+		Height: m.Height,
+		Root:   m.Root.Bytes(),
+		Index:  m.Index,
+	})
+}
+
+func (m *GetStateChunk) Deserialize(data []byte) (err error) {
+	pb := &vitepb.GetStateChunk{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+	m.Height = pb.Height
+	m.Index = pb.Index
+	m.Root, err = types.BytesToHash(pb.Root)
+	return err
+}
+
+// StateChunk is the response of GetStateChunk
+type StateChunk struct {
+	Index   uint64
+	Entries []interfaces.StateEntry
+}
+
+func (m *StateChunk) Serialize() ([]byte, error) {
+	pb := &vitepb.StateChunk{
+		Index:   m.Index,
+		Entries: make([]*vitepb.StateEntry, len(m.Entries)),
+	}
+	for i, entry := range m.Entries {
+		pb.Entries[i] = &vitepb.StateEntry{
+			Key:   entry.Key,
+			Value: entry.Value,
+		}
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (m *StateChunk) Deserialize(data []byte) error {
+	pb := &vitepb.StateChunk{}
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	m.Index = pb.Index
+	m.Entries = make([]interfaces.StateEntry, len(pb.Entries))
+	for i, entry := range pb.Entries {
+		m.Entries[i].Key = entry.Key
+		m.Entries[i].Value = entry.Value
+		if m.Entries[i].Value == nil {
+			m.Entries[i].Value = []byte{}
+		}
+	}
+
+	return nil
+}
+
+// GetRoundProducers requests the snapshot block producers of the consensus round, in the order of the plans
+type GetRoundProducers struct {
+	Index uint64
+}
+
+func (m *GetRoundProducers) Serialize() ([]byte, error) {
+	return proto.Marshal(&vitepb.GetRoundProducers{
+		Index: m.Index,
+	})
+}
+
+func (m *GetRoundProducers) Deserialize(data []byte) error {
+	pb := &vitepb.GetRoundProducers{}
+	if err := proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+	m.Index = pb.Index
+	return nil
+}
+
+// RoundProducers is the response of GetRoundProducers
+type RoundProducers struct {
+	Index     uint64
+	Producers []types.Address
+}
+
+func (m *RoundProducers) Serialize() ([]byte, error) {
+	pb := &vitepb.RoundProducers{
+		Index:     m.Index,
+		Producers: make([][]byte, len(m.Producers)),
+	}
+	for i, addr := range m.Producers {
+		pb.Producers[i] = addr.Bytes()
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (m *RoundProducers) Deserialize(data []byte) (err error) {
+	pb := &vitepb.RoundProducers{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	m.Index = pb.Index
+	m.Producers = make([]types.Address, len(pb.Producers))
+	for i, buf := range pb.Producers {
+		if m.Producers[i], err = types.BytesToAddress(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetAccountBlockProof requests the account block and the snapshot block which confirms it,
+// the block is specified by Hash, or by Address and Height, Height 0 means the latest confirmed block
+type GetAccountBlockProof struct {
+	Hash    types.Hash
+	Address types.Address
+	Height  uint64
+}
+
+func (m *GetAccountBlockProof) Serialize() ([]byte, error) {
+	pb := &vitepb.GetAccountBlockProof{
+		Height: m.Height,
+	}
+	if m.Hash != types.ZERO_HASH {
+		pb.Hash = m.Hash.Bytes()
+	}
+	if m.Address != types.ZERO_ADDRESS {
+		pb.Address = m.Address.Bytes()
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (m *GetAccountBlockProof) Deserialize(data []byte) (err error) {
+	pb := &vitepb.GetAccountBlockProof{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	m.Height = pb.Height
+	if len(pb.Hash) > 0 {
+		if m.Hash, err = types.BytesToHash(pb.Hash); err != nil {
+			return err
+		}
+	}
+	if len(pb.Address) > 0 {
+		if m.Address, err = types.BytesToAddress(pb.Address); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AccountBlockProof is the response of GetAccountBlockProof, Blocks are continuous from the requested block
+// to the block in the snapshot content of Snapshot, the requested block is the first one, or it is in the
+// SendBlockList of the first one.
+type AccountBlockProof struct {
+	Blocks   []*ledger.AccountBlock
+	Snapshot *ledger.SnapshotBlock
+}
+
+func (m *AccountBlockProof) Serialize() ([]byte, error) {
+	pb := &vitepb.AccountBlockProof{
+		Blocks:   make([]*vitepb.AccountBlock, len(m.Blocks)),
+		Snapshot: m.Snapshot.Proto(),
+	}
+	for i, block := range m.Blocks {
+		pb.Blocks[i] = block.Proto()
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (m *AccountBlockProof) Deserialize(data []byte) (err error) {
+	pb := &vitepb.AccountBlockProof{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return err
+	}
+
+	if pb.Snapshot == nil {
+		return errDeserialize
+	}
+	m.Snapshot = new(ledger.SnapshotBlock)
+	if err = m.Snapshot.DeProto(pb.Snapshot); err != nil {
+		return err
+	}
+
+	m.Blocks = make([]*ledger.AccountBlock, len(pb.Blocks))
+	for i, bp := range pb.Blocks {
+		if bp == nil {
+			return errDeserialize
+		}
+		m.Blocks[i] = new(ledger.AccountBlock)
+		if err = m.Blocks[i].DeProto(bp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -105,6 +105,9 @@ func (n *mockNet) PeerCount() int {
 	return 0
 }
 
+func (n *mockNet) SetPeerAccess(allowKeys, denyKeys []string, maxPeers int) {
+}
+
 func mock(chain Chain) Net {
 	return &mockNet{
 		chain: chain,
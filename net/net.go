@@ -72,6 +72,9 @@ type net struct {
 
 	blackList netool.BlackList
 
+	// guard AccessAllowKeys, AccessDenyKeys and MaxPeers of config, they can be changed by SetPeerAccess
+	accessMu sync.RWMutex
+
 	running int32
 
 	log log15.Logger
@@ -193,6 +196,10 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 	if msg.Key != nil {
 		key = msg.Key.Hex()
 	}
+
+	n.accessMu.RLock()
+	defer n.accessMu.RUnlock()
+
 	for _, key2 := range n.config.AccessDenyKeys {
 		if key2 == id || key2 == key {
 			err = PeerNoPermission
@@ -405,12 +412,25 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		id:      id,
 		peerKey: peerKey,
 		mineKey: cfg.MineKey,
+		light:   cfg.LightServe,
 	}
 	downloader := newExecutor(50, 10, peers, syncConnFac)
 
 	reader := newCacheReader(chain, verifier, downloader, irreader, blackHashList)
 
 	syncer := newSyncer(chain, peers, reader, downloader, irreader, 10*time.Minute, blackHashList)
+	if cfg.StateSync {
+		if cfg.StateSyncCheckpoint == "" {
+			return nil, errors.New("StateSync needs StateSyncCheckpoint, which can be taken from a trusted node")
+		}
+		checkpoint, err := parseStateCheckpoint(cfg.StateSyncCheckpoint)
+		if err != nil {
+			return nil, err
+		}
+		if writer, ok := chain.(stateWriter); ok {
+			syncer.stateSync = newStateSyncer(writer, peers, syncConnFac, checkpoint)
+		}
+	}
 
 	fetcher := newFetcher(peers, receiver, blackHashList)
 
@@ -450,6 +470,19 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		confirmedHashHeightList: confirmedHashList,
 	}
 
+	if cfg.LightServe {
+		reader, ok := chain.(lightReader)
+		rounds, ok2 := consensus.(roundReader)
+		if !ok || !ok2 {
+			return nil, errors.New("the chain cannot serve light clients")
+		}
+		n.syncServer.light = newLightServer(reader, rounds)
+		n.syncServer.maxLight = cfg.MaxLightClients
+		if n.syncServer.maxLight <= 0 {
+			n.syncServer.maxLight = config.DefaultMaxLightClients
+		}
+	}
+
 	fileAddress, err := retrieveAddressBytesFromConfig(cfg.FilePublicAddress, cfg.FilePort)
 	if err != nil {
 		return nil, err
@@ -474,9 +507,10 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 			readTimeout:       readMsgTimeout,
 			writeTimeout:      writeMsgTimeout,
 		},
-		chain:        chain,
-		blackList:    n.blackList,
-		onHandshaker: n.authorize,
+		requireEncryption: cfg.RequireEncryption,
+		chain:             chain,
+		blackList:         n.blackList,
+		onHandshaker:      n.authorize,
 	}
 
 	n.db, err = database.New(path.Join(cfg.DataDir, DBDirName), 1, n.node.ID)
@@ -733,6 +767,17 @@ func (n *net) Stop() error {
 	return errNetIsNotRunning
 }
 
+// SetPeerAccess replaces the allow keys, deny keys and max peers, they apply to the new peers,
+// the connected peers are kept
+func (n *net) SetPeerAccess(allowKeys, denyKeys []string, maxPeers int) {
+	n.accessMu.Lock()
+	defer n.accessMu.Unlock()
+
+	n.config.AccessAllowKeys = allowKeys
+	n.config.AccessDenyKeys = denyKeys
+	n.config.MaxPeers = maxPeers
+}
+
 func (n *net) Nodes() []*vnode.Node {
 	return n.discover.Nodes()
 }
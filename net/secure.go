@@ -0,0 +1,185 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+	_net "net"
+
+	"github.com/vitelabs/go-vite/crypto"
+	"golang.org/x/crypto/curve25519"
+)
+
+const secureRecordSize = 16 * 1024 // max plaintext bytes of a record
+const secureRecordHeadSize = 2
+
+var errSecureRecordTooLarge = errors.New("secure record is too large")
+
+/*
+ * secure record structure
+ *  +----------------+------------------------------------+
+ *  |     Length     |   Sealed plaintext (with GCM tag)  |
+ *  |     2 bytes    |       0 ~ 16K + 16 bytes           |
+ *  +----------------+------------------------------------+
+ * the nonce of a record is the count of records before it in the same direction,
+ * so records can not be replayed, reordered or dropped without being detected.
+ */
+
+// secureConn encrypts the byte stream of a connection into AES-256-GCM records,
+// write and read use different keys, each of them is NOT thread-safe, but they can be called concurrently.
+type secureConn struct {
+	_net.Conn
+
+	writeAead  cipher.AEAD
+	writeNonce uint64
+	writeBuf   []byte
+
+	readAead  cipher.AEAD
+	readNonce uint64
+	readBuf   []byte
+	pending   []byte // plaintext has not been read
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func newSecureConn(conn _net.Conn, writeKey, readKey []byte) (s *secureConn, err error) {
+	s = &secureConn{
+		Conn: conn,
+	}
+
+	if s.writeAead, err = newAead(writeKey); err != nil {
+		return nil, err
+	}
+	if s.readAead, err = newAead(readKey); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func putNonce(nonce []byte, n uint64) {
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], n)
+}
+
+func (s *secureConn) Write(p []byte) (n int, err error) {
+	nonce := make([]byte, s.writeAead.NonceSize())
+	overhead := s.writeAead.Overhead()
+
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > secureRecordSize {
+			chunk = chunk[:secureRecordSize]
+		}
+
+		size := secureRecordHeadSize + len(chunk) + overhead
+		if cap(s.writeBuf) < size {
+			s.writeBuf = make([]byte, size)
+		}
+
+		record := s.writeBuf[:secureRecordHeadSize]
+		binary.BigEndian.PutUint16(record, uint16(size-secureRecordHeadSize))
+
+		putNonce(nonce, s.writeNonce)
+		s.writeNonce++
+		record = s.writeAead.Seal(record, nonce, chunk, nil)
+
+		if _, err = s.Conn.Write(record); err != nil {
+			return
+		}
+
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+
+	return
+}
+
+func (s *secureConn) Read(p []byte) (n int, err error) {
+	for len(s.pending) == 0 {
+		if err = s.readRecord(); err != nil {
+			return
+		}
+	}
+
+	n = copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return
+}
+
+func (s *secureConn) readRecord() (err error) {
+	var head [secureRecordHeadSize]byte
+	if _, err = io.ReadFull(s.Conn, head[:]); err != nil {
+		return
+	}
+
+	overhead := s.readAead.Overhead()
+	length := int(binary.BigEndian.Uint16(head[:]))
+	if length > secureRecordSize+overhead {
+		return errSecureRecordTooLarge
+	}
+
+	if s.readBuf == nil {
+		s.readBuf = make([]byte, secureRecordSize+overhead)
+	}
+
+	record := s.readBuf[:length]
+	if _, err = io.ReadFull(s.Conn, record); err != nil {
+		return
+	}
+
+	nonce := make([]byte, s.readAead.NonceSize())
+	putNonce(nonce, s.readNonce)
+	s.readNonce++
+
+	s.pending, err = s.readAead.Open(record[:0], nonce, record, nil)
+	return
+}
+
+// ephemeralKey is the X25519 key pair generated for one connection, it will be discarded after handshake
+type ephemeralKey struct {
+	priv, pub [32]byte
+}
+
+func newEphemeralKey() (key *ephemeralKey) {
+	key = new(ephemeralKey)
+	copy(key.priv[:], crypto.GetEntropyCSPRNG(32))
+	curve25519.ScalarBaseMult(&key.pub, &key.priv)
+	return
+}
+
+// sessionKeys derive the write keys of initiator and receiver from the static secret of the two node IDs
+// and the secret of the two ephemeral keys, so only the owners of the node IDs can get the keys,
+// and the messages of past sessions are still safe when the node keys leaked.
+func sessionKeys(secret, ephemeralSecret, initiatorKey, receiverKey []byte) (initiatorWrite, receiverWrite []byte) {
+	master := crypto.Hash256(secret, ephemeralSecret, initiatorKey, receiverKey)
+	initiatorWrite = crypto.Hash256(master, []byte("initiator"))
+	receiverWrite = crypto.Hash256(master, []byte("receiver"))
+	return
+}
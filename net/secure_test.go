@@ -0,0 +1,121 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"io"
+	_net "net"
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+// tamperConn flip a bit of the written bytes at offset
+type tamperConn struct {
+	_net.Conn
+	offset  int
+	written int
+}
+
+func (c *tamperConn) Write(p []byte) (n int, err error) {
+	buf := make([]byte, len(p))
+	copy(buf, p)
+	if c.offset >= c.written && c.offset < c.written+len(p) {
+		buf[c.offset-c.written] ^= 1
+	}
+	c.written += len(p)
+
+	return c.Conn.Write(buf)
+}
+
+func TestSecureConn(t *testing.T) {
+	key1, key2 := crypto.GetEntropyCSPRNG(32), crypto.GetEntropyCSPRNG(32)
+
+	conn1, conn2 := _net.Pipe()
+	s1, err := newSecureConn(conn1, key1, key2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := newSecureConn(conn2, key2, key1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, secureRecordSize - 1, secureRecordSize, secureRecordSize + 1, 5*secureRecordSize + 7} {
+		data := make([]byte, size)
+		_, _ = crand.Read(data)
+
+		go func() {
+			_, _ = s1.Write(data)
+		}()
+
+		data2 := make([]byte, size)
+		if _, err = io.ReadFull(s2, data2); err != nil {
+			t.Fatalf("failed to read %d bytes: %v", size, err)
+		}
+		if !bytes.Equal(data, data2) {
+			t.Fatalf("wrong data of %d bytes", size)
+		}
+	}
+
+	_ = s1.Close()
+	_ = s2.Close()
+}
+
+func TestSecureConn_tamper(t *testing.T) {
+	key1, key2 := crypto.GetEntropyCSPRNG(32), crypto.GetEntropyCSPRNG(32)
+
+	conn1, conn2 := _net.Pipe()
+	s1, _ := newSecureConn(&tamperConn{Conn: conn1, offset: secureRecordHeadSize + 3}, key1, key2)
+	s2, _ := newSecureConn(conn2, key2, key1)
+
+	go func() {
+		_, _ = s1.Write([]byte("hello vite"))
+	}()
+
+	buf := make([]byte, 10)
+	if _, err := s2.Read(buf); err == nil {
+		t.Fatal("tampered record should not be decrypted")
+	}
+
+	_ = s1.Close()
+	_ = s2.Close()
+}
+
+func TestSecureConn_wrongKey(t *testing.T) {
+	key1, key2 := crypto.GetEntropyCSPRNG(32), crypto.GetEntropyCSPRNG(32)
+
+	conn1, conn2 := _net.Pipe()
+	s1, _ := newSecureConn(conn1, key1, key2)
+	s2, _ := newSecureConn(conn2, key1, key2)
+
+	go func() {
+		_, _ = s1.Write([]byte("hello vite"))
+	}()
+
+	buf := make([]byte, 10)
+	if _, err := s2.Read(buf); err == nil {
+		t.Fatal("record should not be decrypted by wrong key")
+	}
+
+	_ = s1.Close()
+	_ = s2.Close()
+}
@@ -0,0 +1,561 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	net2 "net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// the height of state snapshot is a multiple of stateSyncInterval, so that peers compute the same manifest,
+// it is also a multiple of syncTaskSize, the blocks after it are synced as usual.
+const stateSyncInterval = 36 * syncTaskSize
+
+// state snapshot must be lower than the height of peers by stateSyncMargin, to avoid rollback
+const stateSyncMargin = 10 * syncTaskSize
+
+// wait peers to compute the manifest
+const stateManifestRetryInterval = 10 * time.Second
+const stateManifestTimeout = 30 * time.Minute
+
+const stateChunkRetry = 3
+
+// the count of snapshot headers synced before the state snapshot, the random seed reads the headers
+// of 10 minutes back, and the consensus reads the proof blocks of the rounds before
+const stateHistoryHeaders = 10 * 60
+
+var errStateManifestNotMatch = errors.New("state manifests of peers are different")
+
+// stateCheckpoint is the trusted state snapshot, the manifest from peers must match it,
+// since neither the snapshot block nor its producer can be verified by a new ledger.
+type stateCheckpoint struct {
+	height uint64
+	hash   types.Hash
+	root   types.Hash
+}
+
+// parseStateCheckpoint parses the checkpoint like "height-snapshotHash-stateRoot"
+func parseStateCheckpoint(str string) (*stateCheckpoint, error) {
+	parts := strings.Split(str, "-")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("state checkpoint %q should be like \"height-snapshotHash-stateRoot\"", str)
+	}
+	height, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("state checkpoint height is invalid: %v", err)
+	}
+	if height == 0 || height%stateSyncInterval != 0 {
+		return nil, fmt.Errorf("state checkpoint height %d should be a multiple of %d", height, stateSyncInterval)
+	}
+	hash, err := types.HexToHash(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("state checkpoint snapshot hash is invalid: %v", err)
+	}
+	root, err := types.HexToHash(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("state checkpoint root is invalid: %v", err)
+	}
+	return &stateCheckpoint{height: height, hash: hash, root: root}, nil
+}
+
+func (c *stateCheckpoint) String() string {
+	return strconv.FormatUint(c.height, 10) + "-" + c.hash.String() + "-" + c.root.String()
+}
+
+func (c *stateCheckpoint) verify(manifest *interfaces.StateManifest) error {
+	if manifest.Block.Height != c.height || manifest.Block.Hash != c.hash || manifest.Root != c.root {
+		return fmt.Errorf("state manifest %d-%s-%s doesn't match the checkpoint %s", manifest.Block.Height, manifest.Block.Hash, manifest.Root, c)
+	}
+	return nil
+}
+
+var errStateSyncNotEnoughPeers = errors.New("not enough peers to sync state")
+
+type stateReader interface {
+	GetStateManifest(height uint64) (*interfaces.StateManifest, error)
+	GetStateChunk(height uint64, index int) ([]interfaces.StateEntry, error)
+	GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error)
+}
+
+type stateWriter interface {
+	chainReader
+	InsertStateChunk(manifest *interfaces.StateManifest, index int, entries []interfaces.StateEntry) error
+	InsertStateSnapshotBlock(manifest *interfaces.StateManifest, history []*ledger.SnapshotBlock) error
+}
+
+// stateServer serves the state manifests, chunks and history headers on sync connections,
+// the manifest is computed in background, ExpMissing is responded until it is ready.
+type stateServer struct {
+	chain stateReader
+
+	mu        sync.Mutex
+	computing bool
+	manifest  *interfaces.StateManifest
+
+	log log15.Logger
+}
+
+func newStateServer(chain stateReader) *stateServer {
+	return &stateServer{
+		chain: chain,
+		log:   netLog.New("module", "state_server"),
+	}
+}
+
+func (s *stateServer) getManifest(height uint64) *interfaces.StateManifest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.manifest != nil && s.manifest.Block.Height == height {
+		return s.manifest
+	}
+
+	if !s.computing {
+		s.computing = true
+		go func() {
+			start := time.Now()
+			manifest, err := s.chain.GetStateManifest(height)
+
+			s.mu.Lock()
+			s.computing = false
+			if err == nil {
+				s.manifest = manifest
+			}
+			s.mu.Unlock()
+
+			if err != nil {
+				s.log.Error(fmt.Sprintf("failed to compute state manifest at %d: %v", height, err))
+			} else {
+				s.log.Info(fmt.Sprintf("compute state manifest at %d: %d chunks, root %s, elapse %s", height, len(manifest.Chunks), manifest.Root, time.Now().Sub(start)))
+			}
+		}()
+	}
+
+	return nil
+}
+
+// handle returns the response of the state request msg
+func (s *stateServer) handle(msg Msg) (code Code, payload Serializable) {
+	switch msg.Code {
+	case CodeGetStateManifest:
+		request := &GetStateManifest{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+		if request.Height%stateSyncInterval != 0 {
+			return CodeException, ExpUnsolicited
+		}
+		manifest := s.getManifest(request.Height)
+		if manifest == nil {
+			return CodeException, ExpMissing
+		}
+		return CodeStateManifest, &StateManifest{*manifest}
+
+	case CodeGetStateChunk:
+		request := &GetStateChunk{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+
+		s.mu.Lock()
+		manifest := s.manifest
+		s.mu.Unlock()
+		if manifest == nil || manifest.Block.Height != request.Height || manifest.Root != request.Root {
+			return CodeException, ExpChunkNotMatch
+		}
+
+		entries, err := s.chain.GetStateChunk(request.Height, int(request.Index))
+		if err != nil {
+			s.log.Error(fmt.Sprintf("failed to read state chunk %d at %d: %v", request.Index, request.Height, err))
+			return CodeException, ExpServerError
+		}
+		return CodeStateChunk, &StateChunk{
+			Index:   request.Index,
+			Entries: entries,
+		}
+
+	case CodeGetStateHeaders:
+		request := &GetSnapshotBlocks{}
+		if err := request.Deserialize(msg.Payload); err != nil {
+			return CodeException, ExpOther
+		}
+		if request.From.Height == 0 || request.Count == 0 || !request.Forward {
+			return CodeException, ExpUnsolicited
+		}
+
+		blocks, err := s.chain.GetSnapshotBlocksByHeight(request.From.Height, true, min64(request.Count, MaxLightHeaders))
+		if err != nil {
+			s.log.Error(fmt.Sprintf("failed to read snapshot headers from %d: %v", request.From.Height, err))
+			return CodeException, ExpServerError
+		}
+		if len(blocks) == 0 {
+			return CodeException, ExpMissing
+		}
+		return CodeStateHeaders, &SnapshotBlocks{Blocks: cutSnapshotHeaders(blocks)}
+	}
+
+	return CodeException, ExpUnsolicited
+}
+
+// stateSyncer installs the state of the checkpoint into a new ledger, the state is downloaded in chunks
+// from several peers, their manifests must match the checkpoint, every chunk is verified against the manifest.
+// The snapshot headers before the checkpoint are synced first, they must be linked to the checkpoint.
+type stateSyncer struct {
+	chain      stateWriter
+	peers      *peerSet
+	factory    syncConnInitiator
+	dialer     *net2.Dialer
+	checkpoint *stateCheckpoint
+	failed     map[peerId]struct{} // the peers failed to sync state, they are tried after the others
+
+	log log15.Logger
+}
+
+func newStateSyncer(chain stateWriter, peers *peerSet, factory syncConnInitiator, checkpoint *stateCheckpoint) *stateSyncer {
+	return &stateSyncer{
+		chain:   chain,
+		peers:   peers,
+		factory: factory,
+		dialer: &net2.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 5 * time.Second,
+		},
+		checkpoint: checkpoint,
+		failed:     make(map[peerId]struct{}),
+		log:        netLog.New("module", "state_syncer"),
+	}
+}
+
+// stateSyncHeight returns the height of the checkpoint if enough peers have reached it, 0 means no need to sync state.
+func stateSyncHeight(current uint64, checkpoint *stateCheckpoint, ps peers) uint64 {
+	if len(ps) < enoughPeers {
+		return 0
+	}
+
+	// ps is sorted from low to high, the state must be known by the enough peers
+	if ps[len(ps)-enoughPeers].Height < checkpoint.height {
+		return 0
+	}
+
+	if !shouldSync(current, checkpoint.height) {
+		return 0
+	}
+
+	return checkpoint.height
+}
+
+// sync returns nil if there is no need to sync state
+func (s *stateSyncer) sync(term <-chan struct{}) error {
+	current := s.chain.GetLatestSnapshotBlock()
+	if current.Height != s.chain.GetGenesisSnapshotBlock().Height {
+		return nil
+	}
+
+	ps := s.peers.sortPeers(false)
+	height := stateSyncHeight(current.Height, s.checkpoint, ps)
+	if height == 0 {
+		return nil
+	}
+
+	conns := make([]*syncConn, 0, enoughPeers)
+	defer func() {
+		for _, c := range conns {
+			_ = c.close()
+		}
+	}()
+	for _, p := range s.syncPeers(ps) {
+		if len(conns) >= enoughPeers {
+			break
+		}
+		c, err := s.dial(p)
+		if err != nil {
+			s.fail(p)
+			s.log.Warn(fmt.Sprintf("failed to dial %s: %v", p, err))
+			continue
+		}
+		conns = append(conns, c)
+	}
+	if len(conns) < enoughPeers {
+		return errStateSyncNotEnoughPeers
+	}
+
+	manifest, err := s.getManifest(conns, height, term)
+	if err != nil {
+		return err
+	}
+	s.log.Info(fmt.Sprintf("sync state at %d %s: %d chunks, root %s", height, manifest.Block.Hash, len(manifest.Chunks), manifest.Root))
+
+	// the headers are synced before the chunks, so the ledger is still new if they cannot be synced
+	history, err := s.getHistory(conns, manifest, term)
+	if err != nil {
+		return err
+	}
+
+	for index := range manifest.Chunks {
+		var entries []interfaces.StateEntry
+		for i := 0; i < stateChunkRetry*len(conns); i++ {
+			select {
+			case <-term:
+				return errors.New("state sync canceled")
+			default:
+			}
+
+			c := conns[(index+i)%len(conns)]
+			entries, err = s.getChunk(c, manifest, index)
+			if err == nil {
+				err = s.chain.InsertStateChunk(manifest, index, entries)
+			}
+			if err == nil {
+				break
+			}
+			s.fail(c.peer)
+			s.log.Warn(fmt.Sprintf("failed to sync state chunk %d from %s: %v", index, c.address(), err))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = s.chain.InsertStateSnapshotBlock(manifest, history); err != nil {
+		return err
+	}
+
+	s.log.Info(fmt.Sprintf("sync state at %d %s done", height, manifest.Block.Hash))
+	return nil
+}
+
+// syncPeers returns the peers have reached the checkpoint from high to low, the failed peers are at last,
+// so the state is synced from other peers next time.
+func (s *stateSyncer) syncPeers(ps peers) peers {
+	var others, failed peers
+	for i := len(ps) - 1; i >= 0 && ps[i].Height >= s.checkpoint.height; i-- {
+		if _, ok := s.failed[ps[i].Id]; ok {
+			failed = append(failed, ps[i])
+		} else {
+			others = append(others, ps[i])
+		}
+	}
+	return append(others, failed...)
+}
+
+func (s *stateSyncer) fail(p *Peer) {
+	if p != nil {
+		s.failed[p.Id] = struct{}{}
+	}
+}
+
+func (s *stateSyncer) dial(p *Peer) (*syncConn, error) {
+	if p.fileAddress == "" {
+		return nil, errors.New("error file address")
+	}
+
+	tcp, err := s.dialer.Dial("tcp", p.fileAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.factory.initiate(tcp, p)
+	if err != nil {
+		_ = tcp.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// getManifest requests the manifest from all connections, and checks they are the same.
+func (s *stateSyncer) getManifest(conns []*syncConn, height uint64, term <-chan struct{}) (*interfaces.StateManifest, error) {
+	manifests := make([]*interfaces.StateManifest, len(conns))
+	deadline := time.Now().Add(stateManifestTimeout)
+
+	for {
+		ready := true
+		for i, c := range conns {
+			if manifests[i] != nil {
+				continue
+			}
+			manifest, err := s.requestManifest(c, height)
+			if err == ExpMissing {
+				ready = false
+				continue
+			}
+			if err != nil {
+				s.fail(c.peer)
+				return nil, fmt.Errorf("failed to get state manifest from %s: %v", c.address(), err)
+			}
+			manifests[i] = manifest
+		}
+
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.New("wait state manifest timeout")
+		}
+
+		select {
+		case <-term:
+			return nil, errors.New("state sync canceled")
+		case <-time.After(stateManifestRetryInterval):
+		}
+	}
+
+	manifest := manifests[0]
+	for _, m := range manifests[1:] {
+		if m.Root != manifest.Root || m.Block.Hash != manifest.Block.Hash {
+			return nil, errStateManifestNotMatch
+		}
+	}
+
+	return manifest, nil
+}
+
+func (s *stateSyncer) requestManifest(c *syncConn, height uint64) (*interfaces.StateManifest, error) {
+	msg, err := c.request(CodeGetStateManifest, &GetStateManifest{Height: height}, CodeStateManifest)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StateManifest{}
+	if err = response.Deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+
+	manifest := &response.StateManifest
+	block := manifest.Block
+	if block.Height != height {
+		return nil, fmt.Errorf("state manifest height %d is not %d", block.Height, height)
+	}
+	if block.ComputeHash() != block.Hash || !block.VerifySignature() {
+		return nil, fmt.Errorf("state manifest block %s is invalid", block.Hash)
+	}
+	if root := manifest.ComputeRoot(); root != manifest.Root {
+		return nil, fmt.Errorf("state manifest root is %s, should be %s", manifest.Root, root)
+	}
+	if err = s.checkpoint.verify(manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+func (s *stateSyncer) getChunk(c *syncConn, manifest *interfaces.StateManifest, index int) ([]interfaces.StateEntry, error) {
+	msg, err := c.request(CodeGetStateChunk, &GetStateChunk{
+		Height: manifest.Block.Height,
+		Root:   manifest.Root,
+		Index:  uint64(index),
+	}, CodeStateChunk)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &StateChunk{}
+	if err = response.Deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+	if response.Index != uint64(index) {
+		return nil, fmt.Errorf("state chunk index %d is not %d", response.Index, index)
+	}
+
+	return response.Entries, nil
+}
+
+// getHistory syncs the snapshot headers before the state snapshot, the headers of a connection are dropped
+// if they are not linked to the snapshot block of the manifest.
+func (s *stateSyncer) getHistory(conns []*syncConn, manifest *interfaces.StateManifest, term <-chan struct{}) (history []*ledger.SnapshotBlock, err error) {
+	from := s.chain.GetGenesisSnapshotBlock().Height + 1
+	if height := manifest.Block.Height; height > from+stateHistoryHeaders {
+		from = height - stateHistoryHeaders
+	}
+
+	for i := 0; i < stateChunkRetry*len(conns); i++ {
+		select {
+		case <-term:
+			return nil, errors.New("state sync canceled")
+		default:
+		}
+
+		c := conns[i%len(conns)]
+		history, err = s.requestHistory(c, from, manifest.Block)
+		if err == nil {
+			return history, nil
+		}
+		s.fail(c.peer)
+		s.log.Warn(fmt.Sprintf("failed to sync state headers from %s: %v", c.address(), err))
+	}
+
+	return nil, err
+}
+
+func (s *stateSyncer) requestHistory(c *syncConn, from uint64, snapshotBlock *ledger.SnapshotBlock) ([]*ledger.SnapshotBlock, error) {
+	history := make([]*ledger.SnapshotBlock, 0, snapshotBlock.Height-from)
+	for next := from; next < snapshotBlock.Height; next = from + uint64(len(history)) {
+		msg, err := c.request(CodeGetStateHeaders, &GetSnapshotBlocks{
+			From:    ledger.HashHeight{Height: next},
+			Count:   snapshotBlock.Height - next,
+			Forward: true,
+		}, CodeStateHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		response := &SnapshotBlocks{}
+		if err = response.Deserialize(msg.Payload); err != nil {
+			return nil, err
+		}
+		if len(response.Blocks) == 0 {
+			return nil, fmt.Errorf("no state headers from %d", next)
+		}
+		history = append(history, response.Blocks...)
+	}
+
+	if err := verifyStateHistory(history, from, snapshotBlock); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// verifyStateHistory checks the headers are from the height, signed, and linked to the snapshot block
+func verifyStateHistory(history []*ledger.SnapshotBlock, from uint64, snapshotBlock *ledger.SnapshotBlock) error {
+	if uint64(len(history)) != snapshotBlock.Height-from {
+		return fmt.Errorf("%d state headers from %d, should be %d", len(history), from, snapshotBlock.Height-from)
+	}
+
+	next := snapshotBlock
+	for i := len(history) - 1; i >= 0; i-- {
+		block := history[i]
+		if block == nil || block.Height != next.Height-1 || block.Hash != next.PrevHash {
+			return fmt.Errorf("state header before %d is not linked", next.Height)
+		}
+		if block.ComputeHash() != block.Hash || !block.VerifySignature() {
+			return fmt.Errorf("state header %s is invalid", block.Hash)
+		}
+		next = block
+	}
+	return nil
+}
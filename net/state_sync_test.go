@@ -0,0 +1,299 @@
+package net
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func init() {
+	if len(fork.GetActiveForkPointList()) <= 0 {
+		fork.SetForkPoints(&config.ForkPoints{
+			SeedFork:      &config.ForkPoint{Height: 1, Version: 1},
+			DexFork:       &config.ForkPoint{Height: 2, Version: 2},
+			DexFeeFork:    &config.ForkPoint{Height: 3, Version: 3},
+			StemFork:      &config.ForkPoint{Height: 4, Version: 4},
+			LeafFork:      &config.ForkPoint{Height: 5, Version: 5},
+			EarthFork:     &config.ForkPoint{Height: 6, Version: 6},
+			DexMiningFork: &config.ForkPoint{Height: 7, Version: 7},
+		})
+	}
+}
+
+type mockStateReader struct {
+	manifest *interfaces.StateManifest
+	entries  [][]interfaces.StateEntry
+	headers  []*ledger.SnapshotBlock
+}
+
+func (m *mockStateReader) GetStateManifest(height uint64) (*interfaces.StateManifest, error) {
+	return m.manifest, nil
+}
+
+func (m *mockStateReader) GetStateChunk(height uint64, index int) ([]interfaces.StateEntry, error) {
+	return m.entries[index], nil
+}
+
+func (m *mockStateReader) GetSnapshotBlocksByHeight(height uint64, higher bool, count uint64) ([]*ledger.SnapshotBlock, error) {
+	var blocks []*ledger.SnapshotBlock
+	for _, block := range m.headers {
+		if block.Height >= height && uint64(len(blocks)) < count {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks, nil
+}
+
+// mockStateHistory returns the signed snapshot blocks from the height, the last one is the snapshot block of the manifest
+func mockStateHistory(t *testing.T, from, count uint64) []*ledger.SnapshotBlock {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	blocks := make([]*ledger.SnapshotBlock, count)
+	for i := range blocks {
+		block := &ledger.SnapshotBlock{
+			Height:    from + uint64(i),
+			PublicKey: pub,
+			Timestamp: &now,
+		}
+		if i > 0 {
+			block.PrevHash = blocks[i-1].Hash
+		}
+		block.Hash = block.ComputeHash()
+		block.Signature = ed25519.Sign(priv, block.Hash.Bytes())
+		blocks[i] = block
+	}
+	return blocks
+}
+
+func mockStateManifest(height uint64) (*interfaces.StateManifest, [][]interfaces.StateEntry) {
+	now := time.Now()
+	manifest := &interfaces.StateManifest{
+		Block: &ledger.SnapshotBlock{
+			Height:    height,
+			PublicKey: []byte("hello"),
+			Signature: []byte("hello"),
+			Timestamp: &now,
+		},
+	}
+	manifest.Block.Hash = manifest.Block.ComputeHash()
+
+	chunks := [][]interfaces.StateEntry{
+		{{Key: []byte{1, 1}, Value: []byte("a")}, {Key: []byte{1, 2}, Value: []byte{}}},
+		{{Key: []byte{3, 1}, Value: []byte("b")}},
+	}
+	for _, entries := range chunks {
+		manifest.Chunks = append(manifest.Chunks, interfaces.StateChunkInfo{
+			StartKey: entries[0].Key,
+			Count:    uint64(len(entries)),
+			Hash:     interfaces.ComputeStateChunkHash(entries),
+		})
+	}
+	manifest.Root = manifest.ComputeRoot()
+
+	return manifest, chunks
+}
+
+func TestStateManifest_Serialize(t *testing.T) {
+	manifest, chunks := mockStateManifest(stateSyncInterval)
+
+	data, err := (&StateManifest{*manifest}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2 := &StateManifest{}
+	if err = m2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if m2.Block.ComputeHash() != manifest.Block.Hash || m2.ComputeRoot() != manifest.Root || m2.Root != manifest.Root {
+		t.Fatal("different manifest")
+	}
+
+	data, err = (&StateChunk{Index: 1, Entries: chunks[0]}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2 := &StateChunk{}
+	if err = c2.Deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if c2.Index != 1 || interfaces.ComputeStateChunkHash(c2.Entries) != manifest.Chunks[0].Hash {
+		t.Fatal("different chunk")
+	}
+}
+
+func TestStateSyncHeight(t *testing.T) {
+	checkpoint := &stateCheckpoint{height: 2 * stateSyncInterval}
+	ps := peers{{Height: 10}, {Height: 2 * stateSyncInterval}, {Height: 3 * stateSyncInterval}, {Height: 5 * stateSyncInterval}}
+
+	if height := stateSyncHeight(1, checkpoint, ps[:2]); height != 0 {
+		t.Fatalf("not enough peers, but height is %d", height)
+	}
+	// the checkpoint is reached by enough peers
+	if height := stateSyncHeight(1, checkpoint, ps); height != 2*stateSyncInterval {
+		t.Fatalf("height should be %d, but %d", 2*stateSyncInterval, height)
+	}
+	if height := stateSyncHeight(1, &stateCheckpoint{height: 3 * stateSyncInterval}, ps); height != 0 {
+		t.Fatalf("the checkpoint is not reached by enough peers, but height is %d", height)
+	}
+	if height := stateSyncHeight(2*stateSyncInterval, checkpoint, ps); height != 0 {
+		t.Fatalf("no need to sync state, but height is %d", height)
+	}
+}
+
+func TestStateCheckpoint(t *testing.T) {
+	manifest, _ := mockStateManifest(stateSyncInterval)
+
+	checkpoint, err := parseStateCheckpoint(fmt.Sprintf("%d-%s-%s", stateSyncInterval, manifest.Block.Hash, manifest.Root))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = checkpoint.verify(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if c2, err := parseStateCheckpoint(checkpoint.String()); err != nil || *c2 != *checkpoint {
+		t.Fatalf("checkpoint %s is parsed as %v, error: %v", checkpoint, c2, err)
+	}
+
+	// a manifest of another snapshot block at the same height
+	forged, _ := mockStateManifest(stateSyncInterval)
+	timestamp := manifest.Block.Timestamp.Add(time.Second)
+	forged.Block.Timestamp = &timestamp
+	forged.Block.Hash = forged.Block.ComputeHash()
+	if err = checkpoint.verify(forged); err == nil {
+		t.Fatal("forged manifest should not match the checkpoint")
+	}
+	forged, _ = mockStateManifest(stateSyncInterval)
+	forged.Block = manifest.Block
+	forged.Chunks = forged.Chunks[:1]
+	forged.Root = forged.ComputeRoot()
+	if err = checkpoint.verify(forged); err == nil {
+		t.Fatal("manifest with another root should not match the checkpoint")
+	}
+
+	for _, str := range []string{
+		"",
+		fmt.Sprintf("%d-%s", stateSyncInterval, manifest.Block.Hash),
+		fmt.Sprintf("%d-%s-%s", stateSyncInterval+1, manifest.Block.Hash, manifest.Root),
+		fmt.Sprintf("%d-%s-%s", stateSyncInterval, "hash", manifest.Root),
+	} {
+		if _, err = parseStateCheckpoint(str); err == nil {
+			t.Fatalf("checkpoint %q should be invalid", str)
+		}
+	}
+}
+
+func TestStateServer_Handle(t *testing.T) {
+	manifest, chunks := mockStateManifest(stateSyncInterval)
+	s := newStateServer(&mockStateReader{manifest: manifest, entries: chunks})
+
+	request := func(code Code, payload Serializable) (Code, Serializable) {
+		data, err := payload.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s.handle(Msg{Code: code, Payload: data})
+	}
+
+	if code, payload := request(CodeGetStateManifest, &GetStateManifest{Height: stateSyncInterval + 1}); code != CodeException || payload != ExpUnsolicited {
+		t.Fatalf("height should be multiple of interval: %d %v", code, payload)
+	}
+
+	// the manifest is computed in background
+	var code Code
+	var payload Serializable
+	for i := 0; i < 100; i++ {
+		code, payload = request(CodeGetStateManifest, &GetStateManifest{Height: stateSyncInterval})
+		if code != CodeException {
+			break
+		}
+		if payload != ExpMissing {
+			t.Fatalf("should wait manifest: %v", payload)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if code != CodeStateManifest || payload.(*StateManifest).Root != manifest.Root {
+		t.Fatalf("wrong manifest response %d %v", code, payload)
+	}
+
+	if code, payload = request(CodeGetStateChunk, &GetStateChunk{Height: stateSyncInterval, Root: types.Hash{1}, Index: 1}); code != CodeException || payload != ExpChunkNotMatch {
+		t.Fatalf("root should not match: %d %v", code, payload)
+	}
+
+	code, payload = request(CodeGetStateChunk, &GetStateChunk{Height: stateSyncInterval, Root: manifest.Root, Index: 1})
+	if code != CodeStateChunk || interfaces.ComputeStateChunkHash(payload.(*StateChunk).Entries) != manifest.Chunks[1].Hash {
+		t.Fatalf("wrong chunk response %d %v", code, payload)
+	}
+
+	if code, payload = request(CodeGetStateHeaders, &GetSnapshotBlocks{From: ledger.HashHeight{Height: 2}, Count: 10, Forward: true}); code != CodeException || payload != ExpMissing {
+		t.Fatalf("headers should be missing: %d %v", code, payload)
+	}
+	s.chain.(*mockStateReader).headers = mockStateHistory(t, 2, MaxLightHeaders+10)
+	code, payload = request(CodeGetStateHeaders, &GetSnapshotBlocks{From: ledger.HashHeight{Height: 5}, Count: MaxLightHeaders + 10, Forward: true})
+	if code != CodeStateHeaders {
+		t.Fatalf("wrong headers response %d %v", code, payload)
+	}
+	if blocks := payload.(*SnapshotBlocks).Blocks; len(blocks) != MaxLightHeaders || blocks[0].Height != 5 {
+		t.Fatalf("should respond %d headers from 5, but %d", MaxLightHeaders, len(blocks))
+	}
+}
+
+func TestVerifyStateHistory(t *testing.T) {
+	blocks := mockStateHistory(t, 2, 11)
+	history, snapshotBlock := blocks[:10], blocks[10]
+
+	if err := verifyStateHistory(history, 2, snapshotBlock); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyStateHistory(history[1:], 2, snapshotBlock); err == nil {
+		t.Fatal("headers should be missing")
+	}
+
+	// the headers of another chain
+	forged := mockStateHistory(t, 1, 11)
+	if err := verifyStateHistory(forged[1:], 2, snapshotBlock); err == nil {
+		t.Fatal("forged headers should not be linked")
+	}
+
+	unsigned := *history[9]
+	unsigned.Signature = history[8].Signature
+	if err := verifyStateHistory(append(history[:9:9], &unsigned), 2, snapshotBlock); err == nil {
+		t.Fatal("header with a wrong signature should be invalid")
+	}
+}
+
+func TestStateSyncer_SyncPeers(t *testing.T) {
+	s := &stateSyncer{
+		checkpoint: &stateCheckpoint{height: 2 * stateSyncInterval},
+		failed:     make(map[peerId]struct{}),
+	}
+	ps := peers{
+		{Id: peerId{1}, Height: 10},
+		{Id: peerId{2}, Height: 2 * stateSyncInterval},
+		{Id: peerId{3}, Height: 3 * stateSyncInterval},
+		{Id: peerId{4}, Height: 5 * stateSyncInterval},
+	}
+
+	s.fail(ps[3])
+	// the failed peer is tried at last, the peer lower than the checkpoint is skipped
+	want := []peerId{{3}, {2}, {4}}
+	got := s.syncPeers(ps)
+	if len(got) != len(want) {
+		t.Fatalf("should be %d peers, but %d", len(want), len(got))
+	}
+	for i, p := range got {
+		if p.Id != want[i] {
+			t.Fatalf("peer %d should be %s, but %s", i, want[i], p.Id)
+		}
+	}
+}
@@ -175,6 +175,7 @@ type defaultSyncConnectionFactory struct {
 	id      peerId
 	peerKey ed25519.PrivateKey
 	mineKey ed25519.PrivateKey
+	light   bool // accept the connections of light clients which are not peers
 }
 
 func (d *defaultSyncConnectionFactory) makeSyncConn(conn net2.Conn) *syncConn {
@@ -286,6 +287,11 @@ func (d *defaultSyncConnectionFactory) receive(conn net2.Conn) (*syncConn, error
 	}
 
 	p := d.peers.get(hk.id)
+	if p == nil && d.light {
+		// light clients are not peers, the placeholder identifies the connection
+		p = &Peer{Id: hk.id}
+		c.light = true
+	}
 	if p == nil {
 		_ = c.c.WriteMsg(Msg{
 			Code:    CodeDisconnect,
@@ -318,6 +324,7 @@ type syncConn struct {
 	cacher syncCacher
 	buf    [1024]byte
 	failed int32
+	light  bool // the connection of a light client, it can only request the light messages
 }
 
 var speedUnits = [...]string{
@@ -522,6 +529,38 @@ func (f *syncConn) close() error {
 	return errSyncConnClosed
 }
 
+// request writes the request msg and reads the response, the exception response is returned as error
+func (f *syncConn) request(code Code, payload Serializable, responseCode Code) (msg Msg, err error) {
+	data, err := payload.Serialize()
+	if err != nil {
+		return
+	}
+
+	if err = f.c.WriteMsg(Msg{
+		Code:    code,
+		Payload: data,
+	}); err != nil {
+		return
+	}
+
+	msg, err = f.c.ReadMsg()
+	if err != nil {
+		return
+	}
+
+	if msg.Code == CodeException {
+		if len(msg.Payload) != 1 {
+			return msg, errDeserialize
+		}
+		return msg, Exception(msg.Payload[0])
+	}
+	if msg.Code != responseCode {
+		return msg, fmt.Errorf("unexpected response code %d", msg.Code)
+	}
+
+	return
+}
+
 var errSyncConnExist = errors.New("sync connection has exist")
 var errSyncConnClosed = errors.New("sync connection has closed")
 var errPeerDialing = errors.New("peer is dialing")
@@ -46,6 +46,10 @@ type syncServer struct {
 	mu       sync.Mutex
 	sconnMap map[peerId]*syncConn // key is addr
 	chain    ledgerReader
+	state    *stateServer // nil if the chain cannot serve state
+	light    *lightServer // nil if light clients are not served
+	maxLight int          // the max count of light client connections
+	lights   int
 	factory  syncConnReceiver
 	running  int32
 	wg       sync.WaitGroup
@@ -53,13 +57,19 @@ type syncServer struct {
 }
 
 func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver) *syncServer {
-	return &syncServer{
+	s := &syncServer{
 		addr:     addr,
 		sconnMap: make(map[peerId]*syncConn),
 		chain:    chain,
 		factory:  factory,
 		log:      log15.New("module", "server"),
 	}
+
+	if reader, ok := chain.(stateReader); ok {
+		s.state = newStateServer(reader)
+	}
+
+	return s
 }
 
 func (s *syncServer) status() FileServerStatus {
@@ -139,17 +149,36 @@ func (s *syncServer) deleteConn(c *syncConn) {
 	// is running
 	if atomic.LoadInt32(&s.running) == 1 {
 		s.mu.Lock()
-		delete(s.sconnMap, c.peer.Id)
+		if s.sconnMap[c.peer.Id] == c {
+			delete(s.sconnMap, c.peer.Id)
+			if c.light {
+				s.lights--
+			}
+		}
 		s.mu.Unlock()
 	}
 }
 
-func (s *syncServer) addConn(c *syncConn) {
+// addConn returns false if there are too many light client connections
+func (s *syncServer) addConn(c *syncConn) bool {
 	if atomic.LoadInt32(&s.running) == 1 {
 		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		old := s.sconnMap[c.peer.Id]
+		if c.light && (old == nil || !old.light) {
+			if s.lights >= s.maxLight {
+				return false
+			}
+			s.lights++
+		}
+		if old != nil && old.light && !c.light {
+			s.lights--
+		}
 		s.sconnMap[c.peer.Id] = c
-		s.mu.Unlock()
 	}
+
+	return true
 }
 
 func (s *syncServer) handleConn(conn net2.Conn) {
@@ -161,7 +190,10 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		return
 	}
 
-	s.addConn(sconn)
+	if !s.addConn(sconn) {
+		_ = Disconnect(sconn.c, PeerTooManyPeers)
+		return
+	}
 	defer s.deleteConn(sconn)
 
 	var msg Msg
@@ -177,6 +209,31 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 			return
 		}
 
+		if isLightRequest(msg.Code) {
+			if err = s.handleLightRequest(sconn, msg); err != nil {
+				s.log.Error(fmt.Sprintf("failed to send light response to %s: %v", conn.RemoteAddr(), err))
+				return
+			}
+			continue
+		}
+
+		if sconn.light {
+			_ = sconn.c.WriteMsg(Msg{
+				Code:    CodeException,
+				Id:      msg.Id,
+				Payload: []byte{byte(ExpUnauthorized)},
+			})
+			continue
+		}
+
+		if msg.Code == CodeGetStateManifest || msg.Code == CodeGetStateChunk || msg.Code == CodeGetStateHeaders {
+			if err = s.handleStateRequest(sconn, msg); err != nil {
+				s.log.Error(fmt.Sprintf("failed to send state response to %s: %v", conn.RemoteAddr(), err))
+				return
+			}
+			continue
+		}
+
 		if msg.Code != CodeSyncRequest {
 			continue
 		}
@@ -259,3 +316,41 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		}
 	}
 }
+
+func (s *syncServer) handleStateRequest(sconn *syncConn, msg Msg) error {
+	var code Code = CodeException
+	var payload Serializable = ExpUnsolicited
+	if s.state != nil {
+		code, payload = s.state.handle(msg)
+	}
+
+	data, err := payload.Serialize()
+	if err != nil {
+		code, data = CodeException, []byte{byte(ExpOther)}
+	}
+
+	return sconn.c.WriteMsg(Msg{
+		Code:    code,
+		Id:      msg.Id,
+		Payload: data,
+	})
+}
+
+func (s *syncServer) handleLightRequest(sconn *syncConn, msg Msg) error {
+	var code Code = CodeException
+	var payload Serializable = ExpUnsolicited
+	if s.light != nil {
+		code, payload = s.light.handle(msg)
+	}
+
+	data, err := payload.Serialize()
+	if err != nil {
+		code, data = CodeException, []byte{byte(ExpOther)}
+	}
+
+	return sconn.c.WriteMsg(Msg{
+		Code:    code,
+		Id:      msg.Id,
+		Payload: data,
+	})
+}
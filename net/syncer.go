@@ -71,6 +71,7 @@ type syncer struct {
 	downloader syncDownloader
 	reader     syncCacheReader
 	irreader   IrreversibleReader
+	stateSync  *stateSyncer // nil if state sync is disabled
 
 	curSubId int // for subscribe
 	subs     map[int]SyncStateCallback
@@ -257,6 +258,16 @@ func (s *syncer) start() {
 		start.Stop()
 	}
 
+	if s.stateSync != nil {
+		if err := s.stateSync.sync(s.term); err != nil {
+			// the ledger may contain part of the state, it cannot be synced from genesis.
+			// checkLoop starts syncing again since the state is not done, the failed peers are tried at last.
+			s.log.Warn(fmt.Sprintf("failed to sync state, retry later: %v", err))
+			s.state.error(syncErrorDownload)
+			return
+		}
+	}
+
 	var retrySync int
 
 Prepare:
@@ -386,6 +397,10 @@ Start:
 		} else {
 			block, err = s.chain.GetSnapshotBlockByHeight(height)
 			if err != nil || block == nil {
+				// the ledger begins at a state snapshot, the blocks below it are missing
+				if len(start) > 0 {
+					break
+				}
 				s.log.Error(fmt.Sprintf("failed to find snapshot block at %d", height))
 				// maybe rollback
 				goto Start
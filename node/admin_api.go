@@ -0,0 +1,23 @@
+package node
+
+const adminNamespace = "admin"
+
+// AdminApi manages the running node, it's served by IPC, and it's served by HTTP and websocket
+// if "admin" is in PublicModules
+type AdminApi struct {
+	node *Node
+}
+
+func NewAdminApi(node *Node) *AdminApi {
+	return &AdminApi{node: node}
+}
+
+func (api AdminApi) String() string {
+	return "AdminApi"
+}
+
+// ReloadConfig reads the config file again and applies the settings which are safe to change live,
+// it returns the applied settings and the rejected settings with the reasons
+func (api AdminApi) ReloadConfig() (*ConfigReloadResult, error) {
+	return api.node.ReloadConfig()
+}
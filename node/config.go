@@ -18,12 +18,17 @@ import (
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/rpc"
 	"github.com/vitelabs/go-vite/wallet"
 )
 
 type Config struct {
 	NetSelect string
 
+	// the file which the config is loaded from, it's read again by Node.ReloadConfig
+	ConfigFile string `json:"-"`
+
 	DataDir string `json:"DataDir"`
 
 	KeyStoreDir string `json:"KeyStoreDir"`
@@ -32,49 +37,70 @@ type Config struct {
 	LedgerGcRetain uint64          `json:"LedgerGcRetain"`
 	LedgerGc       *bool           `json:"LedgerGc"`
 	OpenPlugins    *bool           `json:"OpenPlugins"`
+	ArchiveMode    bool            `json:"ArchiveMode"`    // keep the state redo logs of all snapshot blocks
 	VmLogWhiteList []types.Address `json:"vmLogWhiteList"` // contract address white list which save VM logs
 	VmLogAll       *bool           `json:"vmLogAll"`       // save all VM logs, it will cost more disk space
 
+	SqlIndexerDriver string `json:"SqlIndexerDriver"` // mirror the ledger into a sql database, works with OpenPlugins
+	SqlIndexerDSN    string `json:"SqlIndexerDSN"`
+
 	// genesis
 	GenesisFile string `json:"GenesisFile"`
 
 	// net
-	Single             bool
-	ListenInterface    string
-	Port               int
-	FilePort           int
-	PublicAddress      string
-	FilePublicAddress  string
-	Identity           string
-	NetID              int
-	PeerKey            string `json:"PrivateKey"`
-	Discover           bool
-	MaxPeers           int
-	MinPeers           int
-	MaxInboundRatio    int
-	MaxPendingPeers    int
-	BootNodes          []string
-	BootSeeds          []string
-	StaticNodes        []string
-	AccessControl      string
-	AccessAllowKeys    []string
-	AccessDenyKeys     []string
-	BlackBlockHashList []string // from high to low, like: "xxxxxx-11111"
-	WhiteBlockList     []string // from high to low, like: "xxxxxx-10001"
-	ForwardStrategy    string
+	Single              bool
+	ListenInterface     string
+	Port                int
+	FilePort            int
+	PublicAddress       string
+	FilePublicAddress   string
+	Identity            string
+	NetID               int
+	PeerKey             string `json:"PrivateKey"`
+	Discover            bool
+	MaxPeers            int
+	MinPeers            int
+	MaxInboundRatio     int
+	MaxPendingPeers     int
+	BootNodes           []string
+	BootSeeds           []string
+	StaticNodes         []string
+	AccessControl       string
+	AccessAllowKeys     []string
+	AccessDenyKeys      []string
+	BlackBlockHashList  []string // from high to low, like: "xxxxxx-11111"
+	WhiteBlockList      []string // from high to low, like: "xxxxxx-10001"
+	ForwardStrategy     string
+	StateSync           bool
+	StateSyncCheckpoint string // like "height-snapshotHash-stateRoot"
+	LightServe          bool   // serve the light clients of the edge nodes on the file port
+	MaxLightClients     int
+	RequireEncryption   bool // refuse the peers can not encrypt messages
+
+	// light client, the node syncs the snapshot headers from LightServers instead of running the full ledger
+	// if NodeMode is "edge", LightCheckpoint is the trusted snapshot block like "hash/height",
+	// LightQuorum servers must agree on the round producers, it's a majority of LightServers if it's 0
+	NodeMode        string   `json:"NodeMode"`
+	LightServers    []string `json:"LightServers"`
+	LightCheckpoint string   `json:"LightCheckpoint"`
+	LightQuorum     int      `json:"LightQuorum"`
 
 	//producer
 	EntropyStorePath     string `json:"EntropyStorePath"`
 	EntropyStorePassword string `json:"EntropyStorePassword"`
+	ExternalSigner       string `json:"ExternalSigner"`
 	CoinBase             string `json:"CoinBase"`
 	MinerEnabled         bool   `json:"Miner"`
 	MinerInterval        int    `json:"MinerInterval"`
+	MinerInstamine       bool   `json:"MinerInstamine"` // produce a snapshot block as soon as account blocks arrive, for development networks
+	MinerDevNet          bool   `json:"MinerDevNet"`    // the producer serves a local development network, set by `gvite devnet`
 
 	//rpc
-	RPCEnabled  bool  `json:"RPCEnabled"`
-	IPCEnabled  bool  `json:"IPCEnabled"`
-	WSEnabled   bool  `json:"WSEnabled"`
-	TxDexEnable *bool `json:"TxDexEnable"`
+	RPCEnabled     bool  `json:"RPCEnabled"`
+	IPCEnabled     bool  `json:"IPCEnabled"`
+	WSEnabled      bool  `json:"WSEnabled"`
+	GraphQLEnabled bool  `json:"GraphQLEnabled"` // serve GraphQL at /graphql of the HTTP-RPC server
+	TxDexEnable    *bool `json:"TxDexEnable"`
 
 	IPCPath          string   `json:"IPCPath"`
 	HttpHost         string   `json:"HttpHost"`
@@ -91,11 +117,24 @@ type Config struct {
 	TestTokenHexPrivKey string   `json:"TestTokenHexPrivKey"`
 	TestTokenTti        string   `json:"TestTokenTti"`
 
+	// api keys, rate limits and request limits of the HTTP and WebSocket endpoints, they are open if it's nil
+	RPCAuth *rpc.AuthConfig `json:"RPCAuth"`
+
 	PowServerUrl string `json:"PowServerUrl"`
 
 	//Log level
 	LogLevel    string `json:"LogLevel"`
 	ErrorLogDir string `json:"ErrorLogDir"`
+	// levels of the modules which override LogLevel, e.g. {"net": "debug"}
+	LogModuleLevels map[string]string `json:"LogModuleLevels"`
+	// format of the log files, logfmt or json, default is logfmt
+	LogFormat string `json:"LogFormat"`
+	// a log file is rotated when it's larger than LogMaxSize megabytes, and the rotated files are
+	// removed when they are older than LogMaxAge days or more than LogMaxBackups, 0 means the default value.
+	// They apply to the run logs and the chain, vm and rpc logs
+	LogMaxSize    int `json:"LogMaxSize"`
+	LogMaxAge     int `json:"LogMaxAge"`
+	LogMaxBackups int `json:"LogMaxBackups"`
 
 	//VM
 	VMTestEnabled         bool `json:"VMTestEnabled"`
@@ -106,6 +145,13 @@ type Config struct {
 	// subscribe
 	SubscribeEnabled bool `json:"SubscribeEnabled"`
 
+	// monitor the SBPs, the missed slots of SBPMonitorAddresses are posted to SBPMonitorWebhooks,
+	// all SBPs are monitored if SBPMonitorAddresses is empty
+	SBPMonitorEnabled    bool     `json:"SBPMonitorEnabled"`
+	SBPMonitorAddresses  []string `json:"SBPMonitorAddresses"`
+	SBPMonitorWebhooks   []string `json:"SBPMonitorWebhooks"`
+	SBPMonitorRateWindow int      `json:"SBPMonitorRateWindow"` // in rounds, default is 48
+
 	// dashboard
 	DashboardTargetURL string
 
@@ -120,6 +166,7 @@ type Config struct {
 	InfluxDBUsername *string `json:"InfluxDBUsername"`
 	InfluxDBPassword *string `json:"InfluxDBPassword"`
 	InfluxDBHostTag  *string `json:"InfluxDBHostTag"`
+	PrometheusEnable *bool   `json:"PrometheusEnable"` // serve the metrics at /metrics of the HTTP-RPC server
 }
 
 func (c *Config) makeWalletConfig() *wallet.Config {
@@ -128,15 +175,17 @@ func (c *Config) makeWalletConfig() *wallet.Config {
 
 func (c *Config) makeViteConfig() *config.Config {
 	return &config.Config{
-		Chain:     c.makeChainConfig(),
-		Producer:  c.makeMinerConfig(),
-		DataDir:   c.DataDir,
-		Net:       c.makeNetConfig(),
-		Vm:        c.makeVmConfig(),
-		Subscribe: c.makeSubscribeConfig(),
-		Reward:    c.makeRewardConfig(),
-		Genesis:   config_gen.MakeGenesisConfig(c.GenesisFile),
-		LogLevel:  c.LogLevel,
+		Chain:      c.makeChainConfig(),
+		Producer:   c.makeMinerConfig(),
+		DataDir:    c.DataDir,
+		Net:        c.makeNetConfig(),
+		Vm:         c.makeVmConfig(),
+		Subscribe:  c.makeSubscribeConfig(),
+		SBPMonitor: c.makeSBPMonitorConfig(),
+		Light:      c.makeLightConfig(),
+		Reward:     c.makeRewardConfig(),
+		Genesis:    config_gen.MakeGenesisConfig(c.GenesisFile),
+		LogLevel:   c.LogLevel,
 	}
 }
 
@@ -144,31 +193,36 @@ func (c *Config) makeNetConfig() *config.Net {
 	datadir := filepath.Join(c.DataDir, config.DefaultNetDirName)
 
 	return &config.Net{
-		Single:             c.Single,
-		Name:               c.Identity,
-		NetID:              c.NetID,
-		ListenInterface:    c.ListenInterface,
-		Port:               c.Port,
-		FilePort:           c.FilePort,
-		PublicAddress:      c.PublicAddress,
-		FilePublicAddress:  c.FilePublicAddress,
-		DataDir:            datadir,
-		PeerKey:            c.PeerKey,
-		Discover:           c.Discover,
-		BootNodes:          c.BootNodes,
-		BootSeeds:          c.BootSeeds,
-		StaticNodes:        c.StaticNodes,
-		MaxPeers:           c.MaxPeers,
-		MaxInboundRatio:    c.MaxInboundRatio,
-		MinPeers:           c.MinPeers,
-		MaxPendingPeers:    c.MaxPendingPeers,
-		ForwardStrategy:    c.ForwardStrategy,
-		AccessControl:      c.AccessControl,
-		AccessAllowKeys:    c.AccessAllowKeys,
-		AccessDenyKeys:     c.AccessDenyKeys,
-		BlackBlockHashList: c.BlackBlockHashList,
-		WhiteBlockList:     c.WhiteBlockList,
-		MineKey:            nil,
+		Single:              c.Single,
+		Name:                c.Identity,
+		NetID:               c.NetID,
+		ListenInterface:     c.ListenInterface,
+		Port:                c.Port,
+		FilePort:            c.FilePort,
+		PublicAddress:       c.PublicAddress,
+		FilePublicAddress:   c.FilePublicAddress,
+		DataDir:             datadir,
+		PeerKey:             c.PeerKey,
+		Discover:            c.Discover,
+		BootNodes:           c.BootNodes,
+		BootSeeds:           c.BootSeeds,
+		StaticNodes:         c.StaticNodes,
+		MaxPeers:            c.MaxPeers,
+		MaxInboundRatio:     c.MaxInboundRatio,
+		MinPeers:            c.MinPeers,
+		MaxPendingPeers:     c.MaxPendingPeers,
+		ForwardStrategy:     c.ForwardStrategy,
+		AccessControl:       c.AccessControl,
+		AccessAllowKeys:     c.AccessAllowKeys,
+		AccessDenyKeys:      c.AccessDenyKeys,
+		BlackBlockHashList:  c.BlackBlockHashList,
+		WhiteBlockList:      c.WhiteBlockList,
+		StateSync:           c.StateSync,
+		StateSyncCheckpoint: c.StateSyncCheckpoint,
+		LightServe:          c.LightServe,
+		MaxLightClients:     c.MaxLightClients,
+		RequireEncryption:   c.RequireEncryption,
+		MineKey:             nil,
 	}
 }
 
@@ -194,6 +248,28 @@ func (c *Config) makeSubscribeConfig() *config.Subscribe {
 	}
 }
 
+func (c *Config) makeSBPMonitorConfig() *config.SBPMonitor {
+	return &config.SBPMonitor{
+		MonitorSBP:        c.SBPMonitorEnabled,
+		MonitorAddresses:  c.SBPMonitorAddresses,
+		MonitorWebhooks:   c.SBPMonitorWebhooks,
+		MonitorRateWindow: c.SBPMonitorRateWindow,
+	}
+}
+
+func (c *Config) makeLightConfig() *config.Light {
+	return &config.Light{
+		Servers:    c.LightServers,
+		Checkpoint: c.LightCheckpoint,
+		Quorum:     c.LightQuorum,
+	}
+}
+
+// IsEdgeNode returns true if the node runs the light client
+func (c *Config) IsEdgeNode() bool {
+	return c.NodeMode == vnode.Edge.String()
+}
+
 func (c *Config) makeMetricsConfig() *metrics.Config {
 	mc := &metrics.Config{
 		IsEnable:         false,
@@ -214,6 +290,7 @@ func (c *Config) makeMetricsConfig() *metrics.Config {
 				HostTag:  *c.InfluxDBHostTag,
 			}
 		}
+		mc.IsPrometheusEnable = c.PrometheusEnable != nil && *c.PrometheusEnable
 	}
 
 	return mc
@@ -224,6 +301,8 @@ func (c *Config) makeMinerConfig() *config.Producer {
 		Producer:         c.MinerEnabled,
 		Coinbase:         c.CoinBase,
 		EntropyStorePath: c.EntropyStorePath,
+		Instamine:        c.MinerInstamine,
+		DevNet:           c.MinerDevNet,
 	}
 }
 
@@ -249,8 +328,12 @@ func (c *Config) makeChainConfig() *config.Chain {
 		LedgerGcRetain: c.LedgerGcRetain,
 		LedgerGc:       ledgerGc,
 		OpenPlugins:    openPlugins,
+		ArchiveMode:    c.ArchiveMode,
 		VmLogWhiteList: c.VmLogWhiteList,
 		VmLogAll:       vmLogAll,
+
+		SqlIndexerDriver: c.SqlIndexerDriver,
+		SqlIndexerDSN:    c.SqlIndexerDSN,
 	}
 }
 
@@ -311,14 +394,41 @@ func (c *Config) RunLogDir() string {
 
 func (c *Config) RunLogHandler() log15.Handler {
 	filename := "vite.log"
-	logger := common.MakeDefaultLogger(filepath.Join(c.RunLogDir(), filename))
-	return log15.StreamHandler(logger, log15.LogfmtFormat())
+	return c.runLogFileHandler(filepath.Join(c.RunLogDir(), filename))
 }
 
 func (c *Config) RunErrorLogHandler() log15.Handler {
 	filename := "vite.error.log"
-	logger := common.MakeDefaultLogger(filepath.Join(c.RunLogDir(), "error", filename))
-	return log15.StreamHandler(logger, log15.LogfmtFormat())
+	return c.runLogFileHandler(filepath.Join(c.RunLogDir(), "error", filename))
+}
+
+func (c *Config) runLogFileHandler(absFilePath string) log15.Handler {
+	logger := common.MakeDefaultLogger(absFilePath)
+	if c.LogMaxSize > 0 {
+		logger.MaxSize = c.LogMaxSize
+	}
+	if c.LogMaxAge > 0 {
+		logger.MaxAge = c.LogMaxAge
+	}
+	if c.LogMaxBackups > 0 {
+		logger.MaxBackups = c.LogMaxBackups
+	}
+	return log15.StreamHandler(logger, c.RunLogFormat())
+}
+
+// SetLogFileConfig applies LogFormat and the rotation to the other log files made after it, e.g. the chain,
+// vm and rpc logs
+func (c *Config) SetLogFileConfig() {
+	common.SetLogRotation(c.LogMaxSize, c.LogMaxAge, c.LogMaxBackups)
+	log15.SetFileFormat(c.RunLogFormat())
+}
+
+// RunLogFormat returns the format of the log files
+func (c *Config) RunLogFormat() log15.Format {
+	if strings.ToLower(c.LogFormat) == "json" {
+		return log15.JsonFormat()
+	}
+	return log15.LogfmtFormat()
 }
 
 // resolve the dataDir so future changes to the current working directory don't affect the node
@@ -0,0 +1,34 @@
+package node
+
+import (
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+// registerChainMetrics registers the gauges of the chain state, they are read when the metrics are reported
+func registerChainMetrics(v *vite.Vite) {
+	r := metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/chain")
+
+	metrics.NewRegisteredFunctionalGauge("/snapshot/height", r, func() int64 {
+		return int64(v.Chain().GetLatestSnapshotBlock().Height)
+	})
+	metrics.NewRegisteredFunctionalGauge("/pool/snapshot/pending", r, func() int64 {
+		return int64(v.Pool().SnapshotPendingNum())
+	})
+	metrics.NewRegisteredFunctionalGauge("/pool/account/pending", r, func() int64 {
+		return v.Pool().AccountPendingNum().Int64()
+	})
+	metrics.NewRegisteredFunctionalGauge("/net/peers", r, func() int64 {
+		return int64(v.Net().PeerCount())
+	})
+	// 0: not start, 1: syncing, 2: done, 3: error, 4: cancel
+	metrics.NewRegisteredFunctionalGauge("/net/sync/state", r, func() int64 {
+		return int64(v.Net().Status().State)
+	})
+	metrics.NewRegisteredFunctionalGauge("/net/sync/target", r, func() int64 {
+		return int64(v.Net().Status().To)
+	})
+	metrics.NewRegisteredFunctionalGauge("/onroad/contract/pending", r, func() int64 {
+		return int64(v.OnRoad().ContractOnRoadNum())
+	})
+}
@@ -1,9 +1,11 @@
 package node
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -13,9 +15,12 @@ import (
 
 	"github.com/vitelabs/go-vite/cmd/utils/flock"
 	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/graphql"
+	"github.com/vitelabs/go-vite/light"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/metrics"
 	"github.com/vitelabs/go-vite/metrics/influxdb"
+	"github.com/vitelabs/go-vite/metrics/prometheus"
 	"github.com/vitelabs/go-vite/monitor"
 	"github.com/vitelabs/go-vite/pow"
 	"github.com/vitelabs/go-vite/pow/remote"
@@ -25,6 +30,7 @@ import (
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/wallet"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/signer/remotesigner"
 )
 
 var (
@@ -36,19 +42,27 @@ type Node struct {
 	config *Config
 
 	//wallet
-	walletConfig  *wallet.Config
-	walletManager *wallet.Manager
+	walletConfig   *wallet.Config
+	walletManager  *wallet.Manager
+	externalSigner *remotesigner.Signer
 
 	//vite
 	viteConfig *config.Config
 	viteServer *vite.Vite
 
+	// the light client of the edge node, it runs instead of the vite server
+	lightClient *light.Client
+
 	// metrics
 	metricsConfig *metrics.Config
 	ifxReporter   *influxdb.Reporter
 
 	// List of APIs currently provided by the node
 	rpcAPIs          []rpc.API
+	publicApis       []rpc.API
+	moduleApis       map[string]rpc.API // the apis of PublicModules
+	adminApi         *AdminApi
+	configSettings   map[string]json.RawMessage // the settings of the config file which are in effect
 	inProcessHandler *rpc.Server
 
 	ipcEndpoint string
@@ -73,15 +87,24 @@ type Node struct {
 }
 
 func New(conf *Config) (*Node, error) {
+	configSettings := make(map[string]json.RawMessage)
+	if conf.ConfigFile != "" {
+		settings, err := readConfigSettings(conf.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		configSettings = settings
+	}
 	return &Node{
-		config:        conf,
-		walletConfig:  conf.makeWalletConfig(),
-		viteConfig:    conf.makeViteConfig(),
-		metricsConfig: conf.makeMetricsConfig(),
-		ipcEndpoint:   conf.IPCEndpoint(),
-		httpEndpoint:  conf.HTTPEndpoint(),
-		wsEndpoint:    conf.WSEndpoint(),
-		stop:          make(chan struct{}),
+		config:         conf,
+		walletConfig:   conf.makeWalletConfig(),
+		viteConfig:     conf.makeViteConfig(),
+		metricsConfig:  conf.makeMetricsConfig(),
+		ipcEndpoint:    conf.IPCEndpoint(),
+		httpEndpoint:   conf.HTTPEndpoint(),
+		wsEndpoint:     conf.WSEndpoint(),
+		configSettings: configSettings,
+		stop:           make(chan struct{}),
 	}, nil
 }
 
@@ -118,6 +141,16 @@ func (node *Node) Prepare() (err error) {
 		return err
 	}
 
+	// the edge node runs the light client instead of the vite server
+	if node.config.IsEdgeNode() {
+		log.Info(fmt.Sprintf("Begin Prepare light client... "))
+		if node.lightClient, err = light.New(node.viteConfig); err != nil {
+			log.Error(fmt.Sprintf("light client new error: %v", err))
+			return err
+		}
+		return nil
+	}
+
 	//Initialize the vite server
 	node.viteServer, err = vite.New(node.viteConfig, node.walletManager)
 	if err != nil {
@@ -234,6 +267,13 @@ func (node *Node) startWallet() (err error) {
 	if err != nil {
 		return
 	}
+	if node.config.ExternalSigner != "" {
+		if node.externalSigner, err = remotesigner.New(node.config.ExternalSigner); err != nil {
+			log.Error(fmt.Sprintf("remotesigner.New error: %v", err))
+			return err
+		}
+		node.walletManager.SetExternalSigner(node.externalSigner)
+	}
 	//unlock account
 	if node.config.EntropyStorePath != "" {
 
@@ -276,6 +316,12 @@ func (node *Node) startMetrics() {
 	if metrics.MetricsEnabled {
 		log.Info("start metrics collection")
 		go metrics.CollectProcessMetrics(3 * time.Second)
+		if node.viteServer != nil {
+			registerChainMetrics(node.viteServer)
+		}
+		if metricsCfg.IsPrometheusEnable && !node.config.RPCEnabled {
+			log.Warn("prometheus endpoint is disabled, it's served by the HTTP-RPC server")
+		}
 
 		if metrics.InfluxDBExportEnable {
 			influxDBInfo := metricsCfg.InfluxDBInfo
@@ -302,6 +348,16 @@ func (node *Node) stopMetrics() {
 }
 
 func (node *Node) startVite() error {
+	if node.lightClient != nil {
+		return node.lightClient.Start()
+	}
+
+	// the blocks of BlackBlockHashList are rejected by the pool too, the list can be changed by ReloadConfig
+	hashes, err := parseBlackBlockHashList(node.config.BlackBlockHashList)
+	if err != nil {
+		return err
+	}
+	node.viteServer.Pool().SetBlacklist(hashes)
 	return node.viteServer.Start()
 }
 
@@ -310,34 +366,57 @@ func (node *Node) startRPC() (e error) {
 	// Init rpc log
 	rpcapi.Init(node.config.DataDir, node.config.LogLevel, node.config.TestTokenHexPrivKey, node.config.TestTokenTti, uint(node.config.NetID), node.config.TxDexEnable)
 
-	publicApis := rpcapi.MergeApis(rpcapi.GetPublicApis(node.viteServer))
-	customApis := rpcapi.GetApis(node.viteServer, node.config.PublicModules...)
-	apis := rpcapi.MergeApis(publicApis, customApis)
-
-	// Start the various API endpoints, terminating all in case of errors
-	if err := node.startInProcess(apis); err != nil {
-		return err
+	// start event system, it's required by the subscribe api, the edge node has no event system
+	if node.config.SubscribeEnabled && node.viteServer != nil {
+		filters.Es = filters.NewEventSystem(node.Vite())
+		filters.Es.Start()
 	}
 	defer func() {
-		if e != nil {
-			node.stopInProcess()
+		if e != nil && filters.Es != nil {
+			filters.Es.Stop()
 		}
 	}()
 
-	// start event system
-	if node.config.SubscribeEnabled {
-		filters.Es = filters.NewEventSystem(node.Vite())
-		filters.Es.Start()
+	// the apis of PublicModules can be changed by ReloadConfig, the admin api is public if "admin" is in them
+	node.adminApi = NewAdminApi(node)
+	node.moduleApis = make(map[string]rpc.API)
+	modules := node.config.PublicModules
+	if node.lightClient != nil {
+		// the edge node serves the modules which are supported by the light client only
+		node.publicApis = rpcapi.GetLightPublicApis(node.lightClient)
+		modules = nil
+		for _, module := range node.config.PublicModules {
+			if api := node.getApi(module); api.Service != nil {
+				node.moduleApis[module] = api
+				modules = append(modules, module)
+			} else {
+				log.Warn(fmt.Sprintf("module %s isn't supported by the edge node", module))
+			}
+		}
+	} else {
+		node.publicApis = rpcapi.MergeApis(rpcapi.GetPublicApis(node.viteServer))
+		for _, module := range modules {
+			node.moduleApis[module] = node.getApi(module)
+		}
+	}
+	apis := node.mergeApis(modules, node.moduleApis)
+	node.rpcAPIs = apis
+	// the admin api is always served by the in-process and IPC endpoints
+	localApis := rpcapi.MergeApis(apis, []rpc.API{node.getApi(adminNamespace)})
+
+	// Start the various API endpoints, terminating all in case of errors
+	if err := node.startInProcess(localApis); err != nil {
+		return err
 	}
 	defer func() {
 		if e != nil {
-			filters.Es.Stop()
+			node.stopInProcess()
 		}
 	}()
 
 	// Start rpc
 	if node.config.IPCEnabled {
-		if err := node.startIPC(apis); err != nil {
+		if err := node.startIPC(localApis); err != nil {
 			return err
 		}
 		defer func() {
@@ -347,8 +426,29 @@ func (node *Node) startRPC() (e error) {
 		}()
 	}
 
+	// the public endpoints are authenticated if RPCAuth is set
+	var auth *rpc.Authenticator
+	if node.config.RPCAuth != nil {
+		a, err := rpc.NewAuthenticator(node.config.RPCAuth)
+		if err != nil {
+			return err
+		}
+		auth = a
+	}
+
 	if node.config.RPCEnabled {
-		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll); err != nil {
+		handlers := make(map[string]http.Handler)
+		if node.config.GraphQLEnabled && node.viteServer != nil {
+			h, err := graphql.New(node.viteServer.Chain())
+			if err != nil {
+				return err
+			}
+			handlers[graphql.Path] = h
+		}
+		if node.metricsConfig != nil && node.metricsConfig.IsPrometheusEnable && metrics.MetricsEnabled {
+			handlers[prometheus.Path] = prometheus.Handler(metrics.DefaultRegistry, "gvite")
+		}
+		if err := node.startHTTP(node.httpEndpoint, apis, nil, node.config.HTTPCors, node.config.HttpVirtualHosts, rpc.HTTPTimeouts{}, node.config.HttpExposeAll, handlers, auth); err != nil {
 			return err
 		}
 		defer func() {
@@ -359,7 +459,7 @@ func (node *Node) startRPC() (e error) {
 	}
 
 	if node.config.WSEnabled {
-		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll); err != nil {
+		if err := node.startWS(node.wsEndpoint, apis, nil, node.config.WSOrigins, node.config.WSExposeAll, auth); err != nil {
 			return err
 		}
 		defer func() {
@@ -368,7 +468,7 @@ func (node *Node) startRPC() (e error) {
 			}
 		}()
 	}
-	if len(node.config.DashboardTargetURL) > 0 {
+	if len(node.config.DashboardTargetURL) > 0 && node.viteServer != nil {
 		targetUrl := node.config.DashboardTargetURL + "/ws/gvite/" + strconv.FormatUint(uint64(node.config.NetID), 10) + "@" + node.Vite().Net().Info().ID.String()
 
 		u, e := url.Parse(targetUrl)
@@ -405,10 +505,17 @@ func (node *Node) stopWallet() error {
 	}
 
 	node.walletManager.Stop()
+	if node.externalSigner != nil {
+		node.externalSigner.Close()
+		node.externalSigner = nil
+	}
 	return nil
 }
 
 func (node *Node) stopVite() error {
+	if node.lightClient != nil {
+		return node.lightClient.Stop()
+	}
 
 	if node.viteServer == nil {
 		return ErrNodeStopped
@@ -0,0 +1,303 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi"
+)
+
+// the settings which can be changed by ReloadConfig while the node is running
+var reloadableSettings = []string{
+	"AccessAllowKeys",
+	"AccessDenyKeys",
+	"MaxPeers",
+	"BlackBlockHashList",
+	"PublicModules",
+	"HTTPCors",
+	"HttpVirtualHosts",
+	"WSOrigins",
+}
+
+// ConfigReloadResult lists the settings of the config file which were applied by ReloadConfig,
+// and the changed settings which were rejected with the reasons
+type ConfigReloadResult struct {
+	Applied  []string          `json:"applied"`
+	Rejected map[string]string `json:"rejected"`
+}
+
+// ReloadConfig reads the config file again and applies the changed settings which are safe to change live,
+// the other changed settings are rejected and they take effect after the node restarts.
+func (node *Node) ReloadConfig() (*ConfigReloadResult, error) {
+	node.lock.Lock()
+	defer node.lock.Unlock()
+
+	if node.lightClient != nil {
+		return nil, errors.New("the config of the edge node can't be reloaded")
+	}
+	if node.viteServer == nil {
+		return nil, ErrNodeStopped
+	}
+	if node.config.ConfigFile == "" {
+		return nil, errors.New("the node isn't started with a config file")
+	}
+	settings, err := readConfigSettings(node.config.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for key, value := range settings {
+		if old, ok := node.configSettings[key]; !ok || !equalJSON(old, value) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range node.configSettings {
+		if _, ok := settings[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &ConfigReloadResult{Applied: []string{}, Rejected: make(map[string]string)}
+	for _, key := range keys {
+		name := reloadableSetting(key)
+		if name == "" {
+			result.Rejected[key] = "it takes effect after restart"
+			continue
+		}
+		value, ok := settings[key]
+		if !ok {
+			result.Rejected[key] = "it can't be removed, set the value instead"
+			continue
+		}
+		if err := node.applySetting(name, value); err != nil {
+			result.Rejected[key] = err.Error()
+			continue
+		}
+		node.configSettings[key] = value
+		result.Applied = append(result.Applied, key)
+	}
+	log.Info("config reloaded", "file", node.config.ConfigFile, "applied", result.Applied, "rejected", result.Rejected)
+	return result, nil
+}
+
+func (node *Node) applySetting(name string, value json.RawMessage) error {
+	cfg := &Config{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(`{"%s":%s}`, name, value)), cfg); err != nil {
+		return err
+	}
+
+	switch name {
+	case "AccessAllowKeys", "AccessDenyKeys", "MaxPeers":
+		allowKeys, denyKeys, maxPeers := node.config.AccessAllowKeys, node.config.AccessDenyKeys, node.config.MaxPeers
+		switch name {
+		case "AccessAllowKeys":
+			allowKeys = cfg.AccessAllowKeys
+		case "AccessDenyKeys":
+			denyKeys = cfg.AccessDenyKeys
+		default:
+			if cfg.MaxPeers <= 0 {
+				return errors.New("MaxPeers must be positive")
+			}
+			maxPeers = cfg.MaxPeers
+		}
+		node.viteServer.Net().SetPeerAccess(allowKeys, denyKeys, maxPeers)
+		node.config.AccessAllowKeys, node.config.AccessDenyKeys, node.config.MaxPeers = allowKeys, denyKeys, maxPeers
+
+	case "BlackBlockHashList":
+		hashes, err := parseBlackBlockHashList(cfg.BlackBlockHashList)
+		if err != nil {
+			return err
+		}
+		node.viteServer.Pool().SetBlacklist(hashes)
+		node.config.BlackBlockHashList = cfg.BlackBlockHashList
+
+	case "PublicModules":
+		if err := node.reloadModules(cfg.PublicModules); err != nil {
+			return err
+		}
+		node.config.PublicModules = cfg.PublicModules
+
+	case "HTTPCors", "HttpVirtualHosts":
+		cors, vhosts := node.config.HTTPCors, node.config.HttpVirtualHosts
+		if name == "HTTPCors" {
+			cors = cfg.HTTPCors
+		} else {
+			vhosts = cfg.HttpVirtualHosts
+		}
+		if node.httpHandler != nil {
+			if err := node.httpHandler.SetHTTPAccess(cors, vhosts); err != nil {
+				return err
+			}
+		}
+		node.config.HTTPCors, node.config.HttpVirtualHosts = cors, vhosts
+
+	case "WSOrigins":
+		if node.wsHandler != nil {
+			if err := node.wsHandler.SetWSOrigins(cfg.WSOrigins); err != nil {
+				return err
+			}
+		}
+		node.config.WSOrigins = cfg.WSOrigins
+	}
+	return nil
+}
+
+// reloadModules registers the apis of the new modules and unregisters the apis of the removed modules
+// on the HTTP and websocket endpoints, the services of the kept modules are kept.
+func (node *Node) reloadModules(modules []string) error {
+	moduleApis := make(map[string]rpc.API, len(modules))
+	for _, module := range modules {
+		if api, ok := node.moduleApis[module]; ok {
+			moduleApis[module] = api
+			continue
+		}
+		api := node.getApi(module)
+		if api.Service == nil {
+			return fmt.Errorf("unknown module %s", module)
+		}
+		moduleApis[module] = api
+	}
+
+	apis := node.mergeApis(modules, moduleApis)
+	if node.httpHandler != nil {
+		if err := reloadServerApis(node.httpHandler, node.config.HttpExposeAll, node.rpcAPIs, apis); err != nil {
+			return err
+		}
+	}
+	if node.wsHandler != nil {
+		if err := reloadServerApis(node.wsHandler, node.config.WSExposeAll, node.rpcAPIs, apis); err != nil {
+			return err
+		}
+	}
+	node.moduleApis = moduleApis
+	node.rpcAPIs = apis
+	return nil
+}
+
+// getApi returns the api of module, the admin api is provided by the node itself
+func (node *Node) getApi(module string) rpc.API {
+	if module == adminNamespace {
+		return rpc.API{
+			Namespace: adminNamespace,
+			Version:   "1.0",
+			Service:   node.adminApi,
+			Public:    true,
+		}
+	}
+	if node.lightClient != nil {
+		return rpcapi.GetLightApi(node.lightClient, module)
+	}
+	return rpcapi.GetApi(node.viteServer, module)
+}
+
+// mergeApis returns the public apis and the apis of the modules
+func (node *Node) mergeApis(modules []string, moduleApis map[string]rpc.API) []rpc.API {
+	var customApis []rpc.API
+	for _, module := range modules {
+		customApis = append(customApis, moduleApis[module])
+	}
+	return rpcapi.MergeApis(node.publicApis, customApis)
+}
+
+// reloadServerApis registers the namespaces of srv again if their services are changed
+func reloadServerApis(srv *rpc.Server, exposeAll bool, oldApis, newApis []rpc.API) error {
+	exposed := func(apis []rpc.API) map[string][]interface{} {
+		services := make(map[string][]interface{})
+		for _, api := range apis {
+			if exposeAll || api.Public {
+				services[api.Namespace] = append(services[api.Namespace], api.Service)
+			}
+		}
+		return services
+	}
+	oldServices, newServices := exposed(oldApis), exposed(newApis)
+
+	for namespace := range oldServices {
+		if _, ok := newServices[namespace]; !ok {
+			srv.UnregisterName(namespace)
+		}
+	}
+	for namespace, services := range newServices {
+		if sameServices(oldServices[namespace], services) {
+			continue
+		}
+		srv.UnregisterName(namespace)
+		for _, service := range services {
+			if err := srv.RegisterName(namespace, service); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func sameServices(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		found := false
+		for _, s2 := range b {
+			if s == s2 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// reloadableSetting returns the name of the reloadable setting matching key, json keys are case insensitive
+func reloadableSetting(key string) string {
+	for _, name := range reloadableSettings {
+		if strings.EqualFold(name, key) {
+			return name
+		}
+	}
+	return ""
+}
+
+// parseBlackBlockHashList parses the hashes of BlackBlockHashList, the items are like "hash/height"
+func parseBlackBlockHashList(list []string) ([]types.Hash, error) {
+	hashes := make([]types.Hash, 0, len(list))
+	for _, hexStr := range list {
+		hash, err := types.HexToHash(strings.Split(hexStr, "/")[0])
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, nil
+}
+
+func readConfigSettings(file string) (map[string]json.RawMessage, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	settings := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func equalJSON(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
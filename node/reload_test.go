@@ -0,0 +1,59 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/vitelabs/go-vite/rpc"
+)
+
+type ReloadTestApi struct{}
+
+func (api *ReloadTestApi) Hello() string {
+	return "hello"
+}
+
+func TestReloadServerApis(t *testing.T) {
+	srv := rpc.NewServer()
+	public, private := &ReloadTestApi{}, &ReloadTestApi{}
+	oldApis := []rpc.API{{Namespace: "a", Service: public, Public: true}, {Namespace: "b", Service: private, Public: false}}
+	for _, api := range oldApis {
+		if api.Public {
+			if err := srv.RegisterName(api.Namespace, api.Service); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	newApis := []rpc.API{{Namespace: "b", Service: private, Public: true}}
+	if err := reloadServerApis(srv, false, oldApis, newApis); err != nil {
+		t.Fatal(err)
+	}
+	modules := func() map[string]string {
+		client := rpc.DialInProc(srv)
+		defer client.Close()
+		var m map[string]string
+		if err := client.Call(&m, "rpc_modules"); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}()
+	if _, ok := modules["a"]; ok {
+		t.Fatal("a should be unregistered")
+	}
+	if _, ok := modules["b"]; !ok {
+		t.Fatal("b should be registered")
+	}
+}
+
+func TestEqualJSON(t *testing.T) {
+	if !equalJSON(json.RawMessage(`{"a": [1, 2]}`), json.RawMessage(`{"a":[1,2]}`)) {
+		t.Fatal("should be equal")
+	}
+	if equalJSON(json.RawMessage(`["a"]`), json.RawMessage(`["b"]`)) {
+		t.Fatal("should not be equal")
+	}
+	if reloadableSetting("maxpeers") != "MaxPeers" || reloadableSetting("DataDir") != "" {
+		t.Fatal("wrong reloadable setting")
+	}
+}
@@ -0,0 +1,504 @@
+package onroad
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/pow"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/quota"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	autoReceiveInterval = 2 * time.Second
+	autoReceivePageSize = 100
+	// the max count of the unreceived blocks scanned for an address in a round
+	autoReceiveMaxScan = 10 * autoReceivePageSize
+	// the max count of the blocks received for an address in a round, so the addresses take turns
+	autoReceiveMaxPerRound = 50
+)
+
+var (
+	ErrAutoReceiveContract   = errors.New("auto-receive is not available for contract addresses")
+	ErrAutoReceiveNotStarted = errors.New("auto-receive is not started for the address")
+
+	errAutoReceiveCongestion = errors.New("the network is congested, can't receive by PoW")
+
+	autoReceiveRegistry = metrics.NewPrefixedChildRegistry(metrics.DefaultRegistry, "/onroad/autoreceive")
+	autoReceivedCounter = metrics.NewRegisteredCounter("/received", autoReceiveRegistry)
+	autoFailedCounter   = metrics.NewRegisteredCounter("/failed", autoReceiveRegistry)
+	autoPoWCounter      = metrics.NewRegisteredCounter("/pow", autoReceiveRegistry)
+)
+
+// AutoReceiveFilter selects the unreceived blocks which are received automatically.
+type AutoReceiveFilter struct {
+	// TokenIds is the whitelist of the tokens, all tokens are received if it's empty
+	TokenIds []types.TokenTypeId `json:"tokenIds,omitempty"`
+	// MinAmount is the min amount of the received blocks, it's ignored if it's nil
+	MinAmount *big.Int `json:"minAmount,omitempty"`
+	// PoW is whether to calc PoW if the stake quota isn't enough
+	PoW bool `json:"pow"`
+}
+
+// Match reports whether the send block passes the filter.
+func (f *AutoReceiveFilter) Match(sendBlock *ledger.AccountBlock) bool {
+	if len(f.TokenIds) > 0 {
+		found := false
+		for _, tti := range f.TokenIds {
+			if tti == sendBlock.TokenId {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.MinAmount != nil && f.MinAmount.Sign() > 0 {
+		if sendBlock.Amount == nil || sendBlock.Amount.Cmp(f.MinAmount) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AutoReceiveStatus is the status of the auto-receive of an address.
+type AutoReceiveStatus struct {
+	Address  types.Address      `json:"address"`
+	Filter   *AutoReceiveFilter `json:"filter"`
+	Unlocked bool               `json:"unlocked"`
+
+	Received        uint64     `json:"received"`
+	ReceivedByPoW   uint64     `json:"receivedByPoW"`
+	Failed          uint64     `json:"failed"`
+	LastReceiveTime *time.Time `json:"lastReceiveTime,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+	LastErrorTime   *time.Time `json:"lastErrorTime,omitempty"`
+}
+
+// autoReceiveEntry is an enabled address saved in the file
+type autoReceiveEntry struct {
+	Address types.Address      `json:"address"`
+	Filter  *AutoReceiveFilter `json:"filter"`
+}
+
+// AutoReceiver receives the unreceived blocks of the user addresses which are unlocked in the wallet,
+// the enabled addresses and their filters are saved in the file so they are kept after restart.
+type AutoReceiver struct {
+	manager *Manager
+	file    string
+
+	mu       sync.RWMutex
+	statuses map[types.Address]*AutoReceiveStatus
+
+	notifyC chan struct{}
+	term    chan struct{}
+	wg      sync.WaitGroup
+
+	log log15.Logger
+}
+
+func newAutoReceiver(manager *Manager) *AutoReceiver {
+	return &AutoReceiver{
+		manager:  manager,
+		statuses: make(map[types.Address]*AutoReceiveStatus),
+		notifyC:  make(chan struct{}, 1),
+		log:      slog.New("w", "autoreceive"),
+	}
+}
+
+// load reads the enabled addresses from file, the addresses aren't saved if file is empty.
+func (r *AutoReceiver) load(file string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.file = file
+	if file == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var entries []*autoReceiveEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", file, err)
+	}
+	for _, entry := range entries {
+		if entry.Filter == nil {
+			entry.Filter = &AutoReceiveFilter{}
+		}
+		r.statuses[entry.Address] = &AutoReceiveStatus{Address: entry.Address, Filter: entry.Filter}
+	}
+	r.log.Info("auto-receive addresses loaded", "file", file, "count", len(entries))
+	return nil
+}
+
+// save writes the enabled addresses to the file, the caller must hold the lock.
+func (r *AutoReceiver) save() error {
+	if r.file == "" {
+		return nil
+	}
+	entries := make([]*autoReceiveEntry, 0, len(r.statuses))
+	for addr, status := range r.statuses {
+		entries = append(entries, &autoReceiveEntry{Address: addr, Filter: status.Filter})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address.String() < entries[j].Address.String()
+	})
+	data, err := json.MarshalIndent(entries, "", "\t")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.file), 0700); err != nil {
+		return err
+	}
+	tmp := r.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.file)
+}
+
+func (r *AutoReceiver) start() {
+	r.term = make(chan struct{})
+	r.wg.Add(1)
+	common.Go(func() {
+		defer r.wg.Done()
+		r.loop()
+	})
+}
+
+func (r *AutoReceiver) stop() {
+	if r.term == nil {
+		return
+	}
+	close(r.term)
+	r.wg.Wait()
+	r.term = nil
+}
+
+// notify wakes up the loop, it doesn't block.
+func (r *AutoReceiver) notify() {
+	select {
+	case r.notifyC <- struct{}{}:
+	default:
+	}
+}
+
+// Start enables the auto-receive of addr with the filter, the filter of an enabled address is replaced.
+func (r *AutoReceiver) Start(addr types.Address, filter *AutoReceiveFilter) error {
+	if types.IsContractAddr(addr) {
+		return ErrAutoReceiveContract
+	}
+	if filter == nil {
+		filter = &AutoReceiveFilter{PoW: true}
+	}
+
+	r.mu.Lock()
+	old, ok := r.statuses[addr]
+	if ok {
+		status := *old
+		status.Filter = filter
+		r.statuses[addr] = &status
+	} else {
+		r.statuses[addr] = &AutoReceiveStatus{Address: addr, Filter: filter}
+	}
+	if err := r.save(); err != nil {
+		if ok {
+			r.statuses[addr] = old
+		} else {
+			delete(r.statuses, addr)
+		}
+		r.mu.Unlock()
+		return err
+	}
+	r.mu.Unlock()
+
+	r.log.Info("auto-receive started", "addr", addr)
+	r.notify()
+	return nil
+}
+
+// Stop disables the auto-receive of addr.
+func (r *AutoReceiver) Stop(addr types.Address) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old, ok := r.statuses[addr]
+	if !ok {
+		return ErrAutoReceiveNotStarted
+	}
+	delete(r.statuses, addr)
+	if err := r.save(); err != nil {
+		r.statuses[addr] = old
+		return err
+	}
+	r.log.Info("auto-receive stopped", "addr", addr)
+	return nil
+}
+
+// Addresses returns the enabled addresses which are unlocked in the wallet.
+func (r *AutoReceiver) Addresses() []types.Address {
+	var addrs []types.Address
+	for _, status := range r.Statuses() {
+		if status.Unlocked {
+			addrs = append(addrs, status.Address)
+		}
+	}
+	return addrs
+}
+
+// Statuses returns the statuses of the enabled addresses sorted by the address.
+func (r *AutoReceiver) Statuses() []*AutoReceiveStatus {
+	r.mu.RLock()
+	list := make([]*AutoReceiveStatus, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		s := *status
+		list = append(list, &s)
+	}
+	r.mu.RUnlock()
+
+	// the signer may be remote, so it's asked without holding the lock
+	for _, s := range list {
+		s.Unlocked = r.manager.signer != nil && r.manager.signer.HasAddress(s.Address)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address.String() < list[j].Address.String()
+	})
+	return list
+}
+
+func (r *AutoReceiver) isEnabled(addr types.Address) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.statuses[addr]
+	return ok
+}
+
+func (r *AutoReceiver) loop() {
+	ticker := time.NewTicker(autoReceiveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.term:
+			return
+		case <-ticker.C:
+		case <-r.notifyC:
+		}
+		if r.manager.Net().SyncState() != net.SyncDone {
+			continue
+		}
+		for _, status := range r.Statuses() {
+			if !status.Unlocked {
+				continue
+			}
+			select {
+			case <-r.term:
+				return
+			default:
+			}
+			r.receive(status.Address, status.Filter)
+		}
+	}
+}
+
+// receive receives the unreceived blocks of addr which pass the filter.
+func (r *AutoReceiver) receive(addr types.Address, filter *AutoReceiveFilter) {
+	var sendBlocks []*ledger.AccountBlock
+	for pageNum := 0; pageNum*autoReceivePageSize < autoReceiveMaxScan && len(sendBlocks) < autoReceiveMaxPerRound; pageNum++ {
+		blocks, err := r.manager.Chain().GetOnRoadBlocksByAddr(addr, pageNum, autoReceivePageSize)
+		if err != nil {
+			r.log.Error(fmt.Sprintf("failed to get unreceived blocks of %s: %v", addr, err))
+			return
+		}
+		for _, block := range blocks {
+			if filter.Match(block) {
+				sendBlocks = append(sendBlocks, block)
+			}
+		}
+		if len(blocks) < autoReceivePageSize {
+			break
+		}
+	}
+	if len(sendBlocks) > autoReceiveMaxPerRound {
+		sendBlocks = sendBlocks[:autoReceiveMaxPerRound]
+	}
+
+	for _, sendBlock := range sendBlocks {
+		byPoW, err := r.receiveBlock(addr, sendBlock, filter.PoW)
+		if err == util.ErrCalcPoWTwice || err == pow.ErrPowAborted {
+			// the next PoW must refer to a new snapshot block, or the receiver is stopped
+			return
+		}
+		if err != nil {
+			r.log.Error(fmt.Sprintf("failed to receive %s for %s: %v", sendBlock.Hash, addr, err))
+			r.setError(addr, err, err != util.ErrOutOfQuota && err != errAutoReceiveCongestion)
+			return
+		}
+		r.setReceived(addr, byPoW)
+	}
+}
+
+// receiveBlock generates the receive block of sendBlock and inserts it into the pool, the PoW is calculated
+// if the stake quota isn't enough and usePoW is true.
+func (r *AutoReceiver) receiveBlock(addr types.Address, sendBlock *ledger.AccountBlock, usePoW bool) (bool, error) {
+	c := r.manager.Chain()
+	addrState, err := generator.GetAddressStateForGenerator(c, &addr)
+	if err != nil || addrState == nil {
+		return false, fmt.Errorf("failed to get addr state for generator, err:%v", err)
+	}
+	difficulty, err := receiveDifficulty(c, addr, *addrState.LatestAccountHash)
+	if err != nil {
+		return false, err
+	}
+	if difficulty != nil && !usePoW {
+		return false, util.ErrOutOfQuota
+	}
+
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: addr,
+		FromBlockHash:  sendBlock.Hash,
+		PrevHash:       *addrState.LatestAccountHash,
+		Height:         addrState.LatestAccountHeight + 1,
+	}
+	if difficulty != nil {
+		// the generator can't abort the PoW, so the nonce is calculated here to stop with the receiver
+		nonce, err := pow.GetPowNonceWithAbort(difficulty, types.DataHash(append(addr.Bytes(), block.PrevHash.Bytes()...)), r.term)
+		if err != nil {
+			return false, err
+		}
+		block.Nonce = nonce
+		block.Difficulty = difficulty
+	}
+
+	gen, err := generator.NewGenerator(c, r.manager.Consensus(), addr, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
+	if err != nil {
+		return false, err
+	}
+	result, err := gen.GenerateWithBlock(block, sendBlock)
+	if err != nil {
+		return false, err
+	}
+	if result.Err != nil {
+		return false, result.Err
+	}
+	if result.VMBlock == nil {
+		return false, errors.New("generator gen an empty block")
+	}
+	vb := result.VMBlock.AccountBlock
+	if vb.Signature, vb.PublicKey, err = r.manager.signer.SignData(addr, vb.Hash.Bytes()); err != nil {
+		return false, err
+	}
+	if err := r.manager.insertBlockToPool(result.VMBlock); err != nil {
+		return false, err
+	}
+	return difficulty != nil, nil
+}
+
+// receiveDifficulty returns the PoW difficulty of the next receive block of addr, it's nil if the stake quota is enough.
+func receiveDifficulty(c chain.Chain, addr types.Address, prevHash types.Hash) (*big.Int, error) {
+	sb := c.GetLatestSnapshotBlock()
+	db, err := vm_db.NewVmDb(c, &addr, &sb.Hash, &prevHash)
+	if err != nil {
+		return nil, err
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: addr,
+		PrevHash:       prevHash,
+	}
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(sb.Height), sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	stakeAmount, err := c.GetStakeBeneficialAmount(addr)
+	if err != nil {
+		return nil, err
+	}
+	q, err := quota.GetQuota(db, addr, stakeAmount, sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	if q.Current() >= quotaRequired {
+		return nil, nil
+	}
+	if _, _, isCongestion := quota.CalcQc(db, sb.Height); isCongestion {
+		return nil, errAutoReceiveCongestion
+	}
+	if !quota.CanPoW(db, addr) {
+		return nil, util.ErrCalcPoWTwice
+	}
+	return quota.CalcPoWDifficulty(db, quotaRequired, q, sb.Height)
+}
+
+func (r *AutoReceiver) setReceived(addr types.Address, byPoW bool) {
+	autoReceivedCounter.Inc(1)
+	if byPoW {
+		autoPoWCounter.Inc(1)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.statuses[addr]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	status.Received++
+	if byPoW {
+		status.ReceivedByPoW++
+	}
+	status.LastReceiveTime = &now
+}
+
+// setError records the error of addr, failed is whether the error is counted as a failed receive
+func (r *AutoReceiver) setError(addr types.Address, err error, failed bool) {
+	if failed {
+		autoFailedCounter.Inc(1)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	status, ok := r.statuses[addr]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	if failed {
+		status.Failed++
+	}
+	status.LastError = err.Error()
+	status.LastErrorTime = &now
+}
+
+func hasSendBlock(blocks []*ledger.AccountBlock) bool {
+	for _, block := range blocks {
+		if block.IsSendBlock() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package onroad
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestAutoReceiveFilter_Match(t *testing.T) {
+	otherTti, _ := types.HexToTokenTypeId("tti_251a3e67a41b5ea2373936c8")
+	cases := []struct {
+		filter AutoReceiveFilter
+		tti    types.TokenTypeId
+		amount *big.Int
+		match  bool
+	}{
+		{AutoReceiveFilter{}, ledger.ViteTokenId, big.NewInt(0), true},
+		{AutoReceiveFilter{TokenIds: []types.TokenTypeId{ledger.ViteTokenId}}, ledger.ViteTokenId, big.NewInt(1), true},
+		{AutoReceiveFilter{TokenIds: []types.TokenTypeId{ledger.ViteTokenId}}, otherTti, big.NewInt(1), false},
+		{AutoReceiveFilter{MinAmount: big.NewInt(10)}, otherTti, big.NewInt(10), true},
+		{AutoReceiveFilter{MinAmount: big.NewInt(10)}, otherTti, big.NewInt(9), false},
+		{AutoReceiveFilter{MinAmount: big.NewInt(10)}, otherTti, nil, false},
+		{AutoReceiveFilter{TokenIds: []types.TokenTypeId{otherTti}, MinAmount: big.NewInt(10)}, ledger.ViteTokenId, big.NewInt(100), false},
+	}
+	for i, c := range cases {
+		block := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, TokenId: c.tti, Amount: c.amount}
+		if c.filter.Match(block) != c.match {
+			t.Fatalf("case %d: expected %v", i, c.match)
+		}
+	}
+}
+
+func TestAutoReceiver_Persistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "autoreceive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "onroad", "autoreceive.json")
+
+	addr1, _, _ := types.CreateAddress()
+	addr2, _, _ := types.CreateAddress()
+	r := newAutoReceiver(&Manager{})
+	if err := r.load(file); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(addr1, &AutoReceiveFilter{TokenIds: []types.TokenTypeId{ledger.ViteTokenId}, MinAmount: big.NewInt(100), PoW: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(addr2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Start(types.AddressQuota, nil); err != ErrAutoReceiveContract {
+		t.Fatalf("unexpected err %v", err)
+	}
+	if err := r.Stop(addr2); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Stop(addr2); err != ErrAutoReceiveNotStarted {
+		t.Fatalf("unexpected err %v", err)
+	}
+
+	r2 := newAutoReceiver(&Manager{})
+	if err := r2.load(file); err != nil {
+		t.Fatal(err)
+	}
+	statuses := r2.Statuses()
+	if len(statuses) != 1 || statuses[0].Address != addr1 {
+		t.Fatalf("unexpected statuses %+v", statuses)
+	}
+	filter := statuses[0].Filter
+	if len(filter.TokenIds) != 1 || filter.TokenIds[0] != ledger.ViteTokenId || filter.MinAmount.Cmp(big.NewInt(100)) != 0 || !filter.PoW {
+		t.Fatalf("unexpected filter %+v", filter)
+	}
+	if statuses[0].Unlocked || len(r2.Addresses()) != 0 {
+		t.Fatal("the address isn't unlocked")
+	}
+}
+
+// lockingSigner takes the lock of the receiver in HasAddress, like a remote signer which is slow
+type lockingSigner struct {
+	r        *AutoReceiver
+	unlocked types.Address
+}
+
+func (s *lockingSigner) HasAddress(addr types.Address) bool {
+	s.r.setError(addr, errAutoReceiveCongestion, false)
+	return addr == s.unlocked
+}
+
+func (s *lockingSigner) SignData(addr types.Address, data []byte) ([]byte, []byte, error) {
+	return nil, nil, nil
+}
+
+func TestAutoReceiver_StatusesUnlocked(t *testing.T) {
+	addr1, _, _ := types.CreateAddress()
+	addr2, _, _ := types.CreateAddress()
+	m := &Manager{}
+	r := newAutoReceiver(m)
+	m.signer = &lockingSigner{r: r, unlocked: addr2}
+	for _, addr := range []types.Address{addr1, addr2} {
+		if err := r.Start(addr, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	done := make(chan []types.Address)
+	go func() {
+		done <- r.Addresses()
+	}()
+	select {
+	case addrs := <-done:
+		if len(addrs) != 1 || addrs[0] != addr2 {
+			t.Fatalf("unlocked addresses are %v, should be %s", addrs, addr2)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signer is asked while holding the lock")
+	}
+}
@@ -37,6 +37,9 @@ func (manager *Manager) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) erro
 	for addr, list := range cutMap {
 		// handle contract onroad
 		if !types.IsContractAddr(addr) {
+			if manager.autoReceiver.isEnabled(addr) && hasSendBlock(list) {
+				manager.autoReceiver.notify()
+			}
 			continue
 		}
 		var gid types.Gid
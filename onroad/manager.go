@@ -15,6 +15,8 @@ import (
 	"github.com/vitelabs/go-vite/onroad/pool"
 	"github.com/vitelabs/go-vite/producer/producerevent"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 var (
@@ -29,6 +31,7 @@ type Manager struct {
 	net      netReader
 	producer producer
 	wallet   *wallet.Manager
+	signer   signer.Signer
 
 	pool      pool
 	chain     chain.Chain
@@ -45,6 +48,8 @@ type Manager struct {
 
 	lastProducerAccEvent *producerevent.AccountStartEvent
 
+	autoReceiver *AutoReceiver
+
 	log log15.Logger
 }
 
@@ -59,9 +64,19 @@ func NewManager(net netReader, pool pool, producer producer, consensus generator
 		contractWorkers: make(map[types.Gid]*ContractWorker),
 		log:             slog.New("w", "manager"),
 	}
+	if wallet != nil {
+		m.signer = wallet
+	}
+	m.autoReceiver = newAutoReceiver(m)
 	return m
 }
 
+// LoadAutoReceive loads the addresses which are enabled to receive automatically from file,
+// the changes of the addresses are saved to file.
+func (manager *Manager) LoadAutoReceive(file string) error {
+	return manager.autoReceiver.load(file)
+}
+
 // Init is used to load all onroad into pool cache,
 // for super node generating new contract receive block
 // and for verifier module verifying the sequence of contract receive.
@@ -79,11 +94,18 @@ func (manager *Manager) Start() {
 		manager.producer.SetAccountEventFunc(manager.producerStartEventFunc)
 	}
 	manager.Chain().Register(manager)
+	if manager.wallet != nil {
+		manager.unlockLid = manager.wallet.AddLockEventListener(func(event entropystore.UnlockEvent) {
+			manager.autoReceiver.notify()
+		})
+	}
+	manager.autoReceiver.start()
 }
 
 // Stop method cancel all subscriptions from other modules.
 func (manager *Manager) Stop() {
 	manager.log.Info("Close")
+	manager.autoReceiver.stop()
 	manager.Net().UnsubscribeSyncStatus(manager.netStateLid)
 	manager.wallet.RemoveUnlockChangeChannel(manager.unlockLid)
 	if manager.producer != nil {
@@ -133,7 +155,7 @@ func (manager *Manager) producerStartEventFunc(accevent producerevent.AccountEve
 		return
 	}
 
-	if !manager.wallet.GlobalCheckAddrUnlock(event.Address) {
+	if !manager.signer.HasAddress(event.Address) {
 		manager.log.Error("receive chain right event but address locked", "event", event)
 		return
 	}
@@ -204,6 +226,11 @@ func (manager Manager) Producer() producer {
 	return manager.producer
 }
 
+// AutoReceiver returns the service which receives the unreceived blocks of the unlocked user addresses.
+func (manager Manager) AutoReceiver() *AutoReceiver {
+	return manager.autoReceiver
+}
+
 // Consensus returns the implementation of Consensus which manager is dependent on.
 func (manager Manager) Consensus() generator.Consensus {
 	return manager.consensus
@@ -219,3 +246,15 @@ func (manager Manager) Info() map[string]interface{} {
 	})
 	return result
 }
+
+// ContractOnRoadNum returns the number of unreceived blocks of contracts in the onroad pools of all gids.
+func (manager Manager) ContractOnRoadNum() int {
+	sum := 0
+	manager.onRoadPools.Range(func(k, v interface{}) bool {
+		if n, ok := v.(onroad_pool.OnRoadPool).Info()["Sum"].(int); ok {
+			sum += n
+		}
+		return true
+	})
+	return sum
+}
@@ -105,14 +105,7 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 		blog.Error(fmt.Sprintf("NewGenerator failed, err:%v", err))
 		return true
 	}
-	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address,
-		func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-			_, key, _, err := tp.worker.manager.wallet.GlobalFindAddr(addr)
-			if err != nil {
-				return nil, nil, err
-			}
-			return key.SignData(data)
-		}, nil)
+	genResult, err := gen.GenerateWithOnRoad(sBlock, &tp.worker.address, tp.worker.manager.signer.SignData, nil)
 
 
 	// judge generator result
@@ -78,3 +78,21 @@ func (bl *blacklist) Exists(key types.Hash) bool {
 func (bl *blacklist) Remove(key types.Hash) {
 	bl.cache.Remove(key)
 }
+
+// SetBlacklist replaces the hashes which were set by the previous call, the hashes never time out
+func (pl *pool) SetBlacklist(hashes []types.Hash) {
+	pl.fixedBlacklistMu.Lock()
+	defer pl.fixedBlacklistMu.Unlock()
+
+	fixed := make(map[types.Hash]struct{}, len(hashes))
+	for _, hash := range hashes {
+		fixed[hash] = struct{}{}
+		pl.hashBlacklist.Add(hash)
+	}
+	for hash := range pl.fixedBlacklist {
+		if _, ok := fixed[hash]; !ok {
+			pl.hashBlacklist.Remove(hash)
+		}
+	}
+	pl.fixedBlacklist = fixed
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
 )
 
 func TestBlacklist_AddAddTimeout(t *testing.T) {
@@ -25,3 +26,19 @@ func TestBlacklist_AddAddTimeout(t *testing.T) {
 	bl.AddAddTimeout(hash, time.Second*5)
 	assert.True(t, bl.Exists(hash))
 }
+
+func TestPool_SetBlacklist(t *testing.T) {
+	bl, err := NewBlacklist()
+	if err != nil {
+		assert.Fail(t, err.Error())
+	}
+	pl := &pool{hashBlacklist: bl}
+	hash1, hash2 := common.MockHash(1), common.MockHash(2)
+
+	pl.SetBlacklist([]types.Hash{hash1})
+	assert.True(t, bl.Exists(hash1))
+
+	pl.SetBlacklist([]types.Hash{hash2})
+	assert.False(t, bl.Exists(hash1))
+	assert.True(t, bl.Exists(hash2))
+}
@@ -0,0 +1,45 @@
+package pool
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/monitor"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// ErrPrevBlockFailed is the error of the blocks which follow a failed block of the same account in a batch
+var ErrPrevBlockFailed = errors.New("a previous block of the account in the batch failed")
+
+// AddDirectAccountBlocks keeps the insert lock for the whole batch, so the chain isn't rolled back between
+// the blocks, every block is verified after its previous blocks are inserted.
+func (pl *pool) AddDirectAccountBlocks(blocks []*ledger.AccountBlock, verify func(block *ledger.AccountBlock) (*vm_db.VmAccountBlock, error)) []error {
+	pl.log.Info("receive account blocks from direct.", "count", len(blocks))
+	defer monitor.LogTime("pool", "addDirectAccounts", time.Now())
+	pl.RLockInsert()
+	defer pl.RUnLockInsert()
+
+	errs := make([]error, len(blocks))
+	failed := make(map[types.Address]bool)
+	for i, block := range blocks {
+		if failed[block.AccountAddress] {
+			errs[i] = ErrPrevBlockFailed
+			continue
+		}
+		vmBlock, err := verify(block)
+		if err == nil && vmBlock == nil {
+			err = errors.New("generator gen an empty block")
+		}
+		if err == nil {
+			err = pl.addDirectAccountBlock(block.AccountAddress, vmBlock)
+		}
+		if err != nil {
+			failed[block.AccountAddress] = true
+			errs[i] = err
+		}
+	}
+	return errs
+}
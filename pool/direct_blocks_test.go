@@ -0,0 +1,38 @@
+package pool
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+func TestPool_AddDirectAccountBlocks(t *testing.T) {
+	pl := &pool{log: log15.New("module", "pool")}
+	addr1, addr2 := common.MockAddress(1), common.MockAddress(2)
+	blocks := []*ledger.AccountBlock{
+		{AccountAddress: addr1, Height: 1, Hash: common.MockHash(1)},
+		{AccountAddress: addr2, Height: 1, Hash: common.MockHash(2)},
+		{AccountAddress: addr1, Height: 2, Hash: common.MockHash(3)},
+	}
+
+	verifyErr := errors.New("verify failed")
+	var verified []*ledger.AccountBlock
+	errs := pl.AddDirectAccountBlocks(blocks, func(block *ledger.AccountBlock) (*vm_db.VmAccountBlock, error) {
+		verified = append(verified, block)
+		if block.AccountAddress == addr1 {
+			return nil, verifyErr
+		}
+		return nil, nil
+	})
+
+	// the second block of addr1 is skipped after the first one failed
+	assert.Equal(t, blocks[:2], verified)
+	assert.Equal(t, verifyErr, errs[0])
+	assert.Error(t, errs[1])
+	assert.Equal(t, ErrPrevBlockFailed, errs[2])
+}
@@ -29,6 +29,9 @@ import (
 type Writer interface {
 	// for normal account
 	AddDirectAccountBlock(address types.Address, vmAccountBlock *vm_db.VmAccountBlock) error
+	// AddDirectAccountBlocks verifies the blocks by verify and inserts them in order as one batch, the result has
+	// an error for every block, and the blocks after a failed block of the same account fail with ErrPrevBlockFailed.
+	AddDirectAccountBlocks(blocks []*ledger.AccountBlock, verify func(block *ledger.AccountBlock) (*vm_db.VmAccountBlock, error)) []error
 
 	// for contract account
 	//AddDirectAccountBlocks(address types.Address, received *vm_db.VmAccountBlock, sendBlocks []*vm_db.VmAccountBlock) error
@@ -65,6 +68,10 @@ type BlockPool interface {
 	Reader
 	SnapshotProducerWriter
 	Debug
+	Inspector
+
+	// SetBlacklist replaces the blocks which are always rejected, e.g. the BlackBlockHashList of the node config
+	SetBlacklist(hashes []types.Hash)
 
 	Start()
 	Stop()
@@ -162,6 +169,12 @@ type pool struct {
 
 	hashBlacklist Blacklist
 	cs            consensus.Consensus
+
+	// the hashes in hashBlacklist which are set by SetBlacklist
+	fixedBlacklistMu sync.Mutex
+	fixedBlacklist   map[types.Hash]struct{}
+
+	pendingFeed pendingFeed
 }
 
 func (pl *pool) Snapshot() map[string]interface{} {
@@ -364,11 +377,15 @@ func (pl *pool) AddAccountBlock(address types.Address, block *ledger.AccountBloc
 		return
 	}
 	ac := pl.selfPendingAc(address)
+	existed := ac.existInPool(block.Hash)
 	ac.addBlock(newAccountPoolBlock(block, nil, pl.version, source))
 
 	ac.setCompactDirty(true)
 	pl.newAccBlockCond.Broadcast()
 	pl.worker.bus.newABlockEvent()
+	if !existed {
+		pl.pendingFeed.notify(block, source)
+	}
 }
 
 func (pl *pool) AddDirectAccountBlock(address types.Address, block *vm_db.VmAccountBlock) error {
@@ -377,6 +394,10 @@ func (pl *pool) AddDirectAccountBlock(address types.Address, block *vm_db.VmAcco
 	pl.RLockInsert()
 	defer pl.RUnLockInsert()
 
+	return pl.addDirectAccountBlock(address, block)
+}
+
+func (pl *pool) addDirectAccountBlock(address types.Address, block *vm_db.VmAccountBlock) error {
 	ac := pl.selfPendingAc(address)
 
 	err := ac.v.verifyAccountData(block.AccountBlock)
@@ -390,6 +411,7 @@ func (pl *pool) AddDirectAccountBlock(address types.Address, block *vm_db.VmAcco
 	if err != nil {
 		return err
 	}
+	pl.pendingFeed.notify(block.AccountBlock, types.Local)
 	ac.f.broadcastBlock(block.AccountBlock)
 	return nil
 
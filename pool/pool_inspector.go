@@ -0,0 +1,176 @@
+package pool
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// the reasons why an account block stays in the pool
+const (
+	// PendingReasonPrevBlock means the previous block is neither in the chain nor linked in the pool
+	PendingReasonPrevBlock = "waitingPrevBlock"
+	// PendingReasonSendBlock means the send block referred by the receive block isn't in the chain
+	PendingReasonSendBlock = "waitingSendBlock"
+	// PendingReasonInsert means the block is on the current chain of the pool and waits to be inserted
+	PendingReasonInsert = "waitingInsert"
+	// PendingReasonFork means the block is on a fork of the pool which isn't the current chain
+	PendingReasonFork = "forked"
+)
+
+// PendingAccountBlock is an account block in the pool which hasn't been inserted into the chain
+type PendingAccountBlock struct {
+	Block  *ledger.AccountBlock
+	Source types.BlockSource
+	Reason string
+	// Dependency is the hash of the missing block if the block waits on a dependency
+	Dependency *types.Hash
+}
+
+// PendingAccountBlockCallback is called when an account block enters the pool
+type PendingAccountBlockCallback func(block *ledger.AccountBlock, source types.BlockSource)
+
+// Inspector provides typed views of the account blocks in BlockPool
+type Inspector interface {
+	// PendingAccounts returns the addresses which have blocks in the pool
+	PendingAccounts() []types.Address
+	// PendingAccountBlocks returns the blocks of addr in the pool, sorted by height
+	PendingAccountBlocks(addr types.Address) []*PendingAccountBlock
+
+	SubscribePendingAccountBlock(fn PendingAccountBlockCallback) (subId int)
+	UnsubscribePendingAccountBlock(subId int)
+}
+
+type pendingFeed struct {
+	mu        sync.RWMutex
+	subs      map[int]PendingAccountBlockCallback
+	currentId int
+}
+
+func (pf *pendingFeed) subscribe(fn PendingAccountBlockCallback) int {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	if pf.subs == nil {
+		pf.subs = make(map[int]PendingAccountBlockCallback)
+	}
+	pf.currentId++
+	pf.subs[pf.currentId] = fn
+	return pf.currentId
+}
+
+func (pf *pendingFeed) unsubscribe(subId int) {
+	pf.mu.Lock()
+	defer pf.mu.Unlock()
+	delete(pf.subs, subId)
+}
+
+func (pf *pendingFeed) notify(block *ledger.AccountBlock, source types.BlockSource) {
+	pf.mu.RLock()
+	defer pf.mu.RUnlock()
+	for _, fn := range pf.subs {
+		fn(block, source)
+	}
+}
+
+func (pl *pool) SubscribePendingAccountBlock(fn PendingAccountBlockCallback) (subId int) {
+	return pl.pendingFeed.subscribe(fn)
+}
+
+func (pl *pool) UnsubscribePendingAccountBlock(subId int) {
+	pl.pendingFeed.unsubscribe(subId)
+}
+
+func (pl *pool) PendingAccounts() []types.Address {
+	var addrs []types.Address
+	pl.pendingAc.Range(func(k, v interface{}) bool {
+		if v.(*accountPool).hasPending() {
+			addrs = append(addrs, k.(types.Address))
+		}
+		return true
+	})
+	return addrs
+}
+
+func (pl *pool) PendingAccountBlocks(addr types.Address) []*PendingAccountBlock {
+	ac, ok := pl.pendingAc.Load(addr)
+	if !ok {
+		return nil
+	}
+	return ac.(*accountPool).pendingBlocks()
+}
+
+// hasPending returns true if there are blocks which haven't been inserted into the chain
+func (accP *accountPool) hasPending() bool {
+	accP.chainHeadMu.Lock()
+	defer accP.chainHeadMu.Unlock()
+
+	accP.chainTailMu.Lock()
+	defer accP.chainTailMu.Unlock()
+	return accP.blockpool.size() > 0 || len(accP.chainpool.snippetChains) > 0 || accP.chainpool.tree.Size() > 0
+}
+
+func (accP *accountPool) pendingBlocks() []*PendingAccountBlock {
+	accP.chainHeadMu.Lock()
+	defer accP.chainHeadMu.Unlock()
+
+	accP.chainTailMu.Lock()
+	defer accP.chainTailMu.Unlock()
+
+	accP.blockpool.pendingMu.Lock()
+	defer accP.blockpool.pendingMu.Unlock()
+
+	var result []*PendingAccountBlock
+	seen := make(map[types.Hash]bool)
+	add := func(k interface{}, reason string, dependency *types.Hash) {
+		b, ok := k.(*accountPoolBlock)
+		if !ok || seen[b.Hash()] {
+			return
+		}
+		seen[b.Hash()] = true
+		result = append(result, &PendingAccountBlock{Block: b.block, Source: b.Source(), Reason: reason, Dependency: dependency})
+	}
+
+	cp := accP.chainpool
+	diskHeight, _ := cp.tree.Root().HeadHH()
+	main := cp.tree.Main()
+	mainHeight, _ := main.HeadHH()
+	for h := diskHeight + 1; h <= mainHeight; h++ {
+		k := main.GetKnot(h, true)
+		b, ok := k.(*accountPoolBlock)
+		if !ok {
+			continue
+		}
+		if b.block.IsReceiveBlock() {
+			if send, err := accP.pool.bc.GetAccountBlockByHash(b.block.FromBlockHash); err == nil && send == nil {
+				fromHash := b.block.FromBlockHash
+				add(b, PendingReasonSendBlock, &fromHash)
+				continue
+			}
+		}
+		add(b, PendingReasonInsert, nil)
+	}
+	for _, branch := range cp.tree.Branches() {
+		tailHeight, _ := branch.TailHH()
+		headHeight, _ := branch.HeadHH()
+		for h := tailHeight + 1; h <= headHeight; h++ {
+			add(branch.GetKnot(h, false), PendingReasonFork, nil)
+		}
+	}
+	for _, snippet := range cp.snippetChains {
+		tailHash := snippet.tailHash
+		for _, k := range snippet.heightBlocks {
+			add(k, PendingReasonPrevBlock, &tailHash)
+		}
+	}
+	for _, k := range accP.blockpool.freeBlocks {
+		prevHash := k.PrevHash()
+		add(k, PendingReasonPrevBlock, &prevHash)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Block.Height < result[j].Block.Height
+	})
+	return result
+}
@@ -0,0 +1,76 @@
+package pool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/pool/tree"
+)
+
+func newInspectorTestBlock(prevHash types.Hash, height uint64, flag byte) *accountPoolBlock {
+	block := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, Height: height, PrevHash: prevHash}
+	block.Hash[0], block.Hash[1] = byte(height), flag
+	return newAccountPoolBlock(block, nil, &common.Version{}, types.RemoteBroadcast)
+}
+
+func TestAccountPool_PendingBlocks(t *testing.T) {
+	tr := tree.NewTree()
+	cp := &chainPool{poolID: "unittest", tree: tr, log: log15.New("module", "unittest")}
+	cp.snippetChains = make(map[string]*snippetChain)
+	cp.tree.Init(cp.poolID, tree.NewMockBranchRoot())
+
+	accP := &accountPool{}
+	accP.chainpool = cp
+	accP.blockpool = &blockPool{freeBlocks: make(map[types.Hash]commonBlock)}
+
+	// main: 1 <- 2, fork: 1 <- 2'
+	main := tr.Main()
+	_, rootHash := main.HeadHH()
+	b1 := newInspectorTestBlock(rootHash, 1, 0)
+	b2 := newInspectorTestBlock(b1.Hash(), 2, 0)
+	assert.NoError(t, tr.AddHead(main, b1))
+	assert.NoError(t, tr.AddHead(main, b2))
+	fork := tr.ForkBranch(main, 1, b1.Hash())
+	forked := newInspectorTestBlock(b1.Hash(), 2, 1)
+	assert.NoError(t, tr.AddHead(fork, forked))
+
+	// a snippet and a free block which can't be linked
+	snippetBlock := newInspectorTestBlock(types.Hash{5}, 5, 0)
+	cp.snippetChains["snippet"] = newSnippetChain(snippetBlock, "snippet")
+	free := newInspectorTestBlock(types.Hash{7}, 7, 0)
+	accP.blockpool.putBlock(free.Hash(), free)
+
+	blocks := accP.pendingBlocks()
+	assert.Equal(t, 5, len(blocks))
+	reasons := make(map[types.Hash]*PendingAccountBlock)
+	for i, b := range blocks {
+		if i > 0 {
+			assert.True(t, blocks[i-1].Block.Height <= b.Block.Height)
+		}
+		reasons[b.Block.Hash] = b
+	}
+	assert.Equal(t, PendingReasonInsert, reasons[b1.Hash()].Reason)
+	assert.Equal(t, PendingReasonInsert, reasons[b2.Hash()].Reason)
+	assert.Equal(t, PendingReasonFork, reasons[forked.Hash()].Reason)
+	assert.Equal(t, PendingReasonPrevBlock, reasons[snippetBlock.Hash()].Reason)
+	assert.Equal(t, types.Hash{5}, *reasons[snippetBlock.Hash()].Dependency)
+	assert.Equal(t, PendingReasonPrevBlock, reasons[free.Hash()].Reason)
+	assert.Equal(t, types.Hash{7}, *reasons[free.Hash()].Dependency)
+	assert.True(t, accP.hasPending())
+}
+
+func TestPendingFeed(t *testing.T) {
+	feed := &pendingFeed{}
+	var received []types.Hash
+	id := feed.subscribe(func(block *ledger.AccountBlock, source types.BlockSource) {
+		received = append(received, block.Hash)
+	})
+	feed.notify(&ledger.AccountBlock{Hash: types.Hash{1}}, types.Local)
+	feed.unsubscribe(id)
+	feed.notify(&ledger.AccountBlock{Hash: types.Hash{2}}, types.Local)
+	assert.Equal(t, []types.Hash{{1}}, received)
+}
@@ -23,6 +23,8 @@ const (
 var defaultTarget = new(big.Int).SetUint64(FullThreshold)
 var VMTestParamEnabled = false
 
+var ErrPowAborted = errors.New("pow is aborted")
+
 func Init(vMTestParamEnabled bool) {
 	VMTestParamEnabled = vMTestParamEnabled
 }
@@ -63,6 +65,41 @@ func GetPowNonce(difficulty *big.Int, dataHash types.Hash) ([]byte, error) {
 	return nil, errors.New("get pow nonce error")
 }
 
+// GetPowNonceWithAbort is like GetPowNonce, but it stops and returns ErrPowAborted when abort is closed.
+func GetPowNonceWithAbort(difficulty *big.Int, dataHash types.Hash, abort <-chan struct{}) ([]byte, error) {
+	target := defaultTarget
+	if !VMTestParamEnabled {
+		if difficulty == nil {
+			return nil, errors.New("difficulty can't be nil")
+		}
+		target = DifficultyToTarget(difficulty)
+	}
+	return GetPowNonceByTarget(target, dataHash.Bytes(), abort)
+}
+
+// GetPowNonceByTarget searches a nonce which makes Hash(nonce + data) >= target until it's found or abort is closed.
+func GetPowNonceByTarget(target *big.Int, data []byte, abort <-chan struct{}) ([]byte, error) {
+	if target == nil || target.BitLen() > 256 {
+		return nil, errors.New("target too long")
+	}
+	target256 := helper.LeftPadBytes(target.Bytes(), 32)
+	for i := 0; ; i++ {
+		// check abort every 10000 attempts
+		if i == 10000 {
+			select {
+			case <-abort:
+				return nil, ErrPowAborted
+			default:
+			}
+			i = 0
+		}
+		nonce := crypto.GetEntropyCSPRNG(8)
+		if QuickGreater(powHash256(nonce, data), target256) {
+			return nonce, nil
+		}
+	}
+}
+
 func powHash256(nonce []byte, data []byte) []byte {
 	hash, _ := blake2b.New256(nil)
 	hash.Write(nonce)
@@ -81,6 +118,13 @@ func CheckPowNonce(difficulty *big.Int, nonce []byte, data []byte) bool {
 			return false
 		}
 	}
+	return CheckPowNonceByTarget(target, nonce, data)
+}
+
+func CheckPowNonceByTarget(target *big.Int, nonce []byte, data []byte) bool {
+	if target == nil || target.BitLen() > 256 {
+		return false
+	}
 	out := powHash256(nonce, data)
 	return QuickGreater(out, helper.LeftPadBytes(target.Bytes(), 32))
 }
@@ -0,0 +1,475 @@
+package remote
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/metrics"
+	"github.com/vitelabs/go-vite/pow"
+)
+
+const ApiActionMetrics = "/api/metrics"
+
+// Codes of ResponseJson
+const (
+	CodeSuccess     = 0
+	CodeBadRequest  = 1
+	CodeFailed      = 2
+	CodeCanceled    = 3
+	CodeQueueFull   = 4
+	CodeRateLimited = 5
+)
+
+var (
+	ErrWorkCanceled   = errors.New("work is canceled")
+	ErrQueueFull      = errors.New("work queue is full")
+	ErrRateLimited    = errors.New("too many requests")
+	ErrServerStopped  = errors.New("pow server is stopped")
+	errThresholdInUse = errors.New("work of the hash is in progress with another threshold")
+)
+
+type ServerConfig struct {
+	ListenAddr string
+	Workers    int     // count of works calculated at the same time, default is the count of cpu
+	QueueSize  int     // max count of queued works
+	RateLimit  float64 // generate requests per second of a client, 0 means no limit
+	RateBurst  int
+}
+
+// Server serves the protocol of GenerateWork, CancelWork and VaildateWork. Works of the same
+// hash are merged, every worker calculates a work at a time.
+type Server struct {
+	cfg ServerConfig
+	log log15.Logger
+
+	mu       sync.Mutex
+	jobs     map[string]*powJob
+	queue    chan *powJob
+	running  int64
+	limiters *clientLimiters
+
+	registry         metrics.Registry
+	generateCounter  metrics.Counter
+	generatedCounter metrics.Counter
+	canceledCounter  metrics.Counter
+	failedCounter    metrics.Counter
+	rejectedCounter  metrics.Counter
+	workTimeCounter  metrics.Counter
+
+	httpServer *http.Server
+	stopCh     chan struct{}
+	wg         sync.WaitGroup
+}
+
+type powJob struct {
+	key      string
+	dataHash []byte
+	target   *big.Int
+
+	waiters int
+	abort   chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	nonce   []byte
+	err     error
+}
+
+func (job *powJob) finish(nonce []byte, err error) bool {
+	finished := false
+	job.once.Do(func() {
+		job.nonce, job.err = nonce, err
+		close(job.abort)
+		close(job.done)
+		finished = true
+	})
+	return finished
+}
+
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.RateBurst <= 0 {
+		cfg.RateBurst = 1
+	}
+	registry := metrics.NewRegistry()
+	return &Server{
+		cfg:              cfg,
+		log:              log15.New("module", "pow_server"),
+		jobs:             make(map[string]*powJob),
+		queue:            make(chan *powJob, cfg.QueueSize),
+		limiters:         newClientLimiters(cfg.RateLimit, cfg.RateBurst),
+		registry:         registry,
+		generateCounter:  metrics.NewRegisteredCounterForced("generate", registry),
+		generatedCounter: metrics.NewRegisteredCounterForced("generated", registry),
+		canceledCounter:  metrics.NewRegisteredCounterForced("canceled", registry),
+		failedCounter:    metrics.NewRegisteredCounterForced("failed", registry),
+		rejectedCounter:  metrics.NewRegisteredCounterForced("rejected", registry),
+		workTimeCounter:  metrics.NewRegisteredCounterForced("workTimeMs", registry),
+		stopCh:           make(chan struct{}),
+	}
+}
+
+// Start runs the workers and listens on cfg.ListenAddr.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	s.startWorkers()
+	s.httpServer = &http.Server{Handler: s.Handler()}
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			s.log.Error("pow server stopped", "err", err)
+		}
+	}()
+	s.log.Info("pow server started", "addr", listener.Addr(), "workers", s.cfg.Workers)
+	return nil
+}
+
+func (s *Server) startWorkers() {
+	for i := 0; i < s.cfg.Workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	s.wg.Add(1)
+	go s.limiters.cleanLoop(s.stopCh, &s.wg)
+}
+
+// Stop cancels all works and stops the workers.
+func (s *Server) Stop() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+	close(s.stopCh)
+	s.mu.Lock()
+	for key, job := range s.jobs {
+		job.finish(nil, ErrServerStopped)
+		delete(s.jobs, key)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(ApiActionGenerate, s.handleGenerate)
+	mux.HandleFunc(ApiActionCancel, s.handleCancel)
+	mux.HandleFunc(ApiActionValidate, s.handleValidate)
+	mux.HandleFunc(ApiActionMetrics, s.handleMetrics)
+	return mux
+}
+
+func (s *Server) work() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case job := <-s.queue:
+			select {
+			case <-job.done:
+				// canceled in the queue
+				continue
+			default:
+			}
+			s.mu.Lock()
+			s.running++
+			s.mu.Unlock()
+
+			start := time.Now()
+			nonce, err := pow.GetPowNonceByTarget(job.target, job.dataHash, job.abort)
+			if err == nil {
+				s.workTimeCounter.Inc(int64(time.Since(start) / time.Millisecond))
+				s.generatedCounter.Inc(1)
+			} else if err != pow.ErrPowAborted {
+				s.failedCounter.Inc(1)
+			}
+
+			s.mu.Lock()
+			s.running--
+			if s.jobs[job.key] == job {
+				delete(s.jobs, job.key)
+			}
+			s.mu.Unlock()
+			job.finish(nonce, err)
+		}
+	}
+}
+
+// generate queues a work or joins the queued one with the same hash, and waits for the result.
+func (s *Server) generate(req *http.Request, dataHash []byte, target *big.Int) ([]byte, error) {
+	key := hex.EncodeToString(dataHash)
+	s.mu.Lock()
+	job, ok := s.jobs[key]
+	if ok {
+		if job.target.Cmp(target) != 0 {
+			s.mu.Unlock()
+			return nil, errThresholdInUse
+		}
+	} else {
+		job = &powJob{
+			key:      key,
+			dataHash: dataHash,
+			target:   target,
+			abort:    make(chan struct{}),
+			done:     make(chan struct{}),
+		}
+		select {
+		case s.queue <- job:
+		default:
+			s.mu.Unlock()
+			return nil, ErrQueueFull
+		}
+		s.jobs[key] = job
+	}
+	job.waiters++
+	s.mu.Unlock()
+
+	select {
+	case <-job.done:
+		return job.nonce, job.err
+	case <-req.Context().Done():
+		// the work is canceled if no one is waiting for it
+		s.mu.Lock()
+		job.waiters--
+		if job.waiters == 0 && s.jobs[job.key] == job {
+			delete(s.jobs, job.key)
+			job.finish(nil, ErrWorkCanceled)
+		}
+		s.mu.Unlock()
+		return nil, req.Context().Err()
+	}
+}
+
+func (s *Server) cancel(dataHash []byte) bool {
+	key := hex.EncodeToString(dataHash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[key]
+	if !ok {
+		return false
+	}
+	delete(s.jobs, key)
+	return job.finish(nil, ErrWorkCanceled)
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, req *http.Request) {
+	s.generateCounter.Inc(1)
+	if !s.limiters.allow(clientKey(req)) {
+		s.rejectedCounter.Inc(1)
+		writeResponse(w, CodeRateLimited, nil, ErrRateLimited)
+		return
+	}
+	param := &workGenerate{}
+	if err := readRequest(req, param); err != nil {
+		writeResponse(w, CodeBadRequest, nil, err)
+		return
+	}
+	dataHash, target, err := parseHashAndThreshold(param.DataHash, param.Threshold)
+	if err != nil {
+		writeResponse(w, CodeBadRequest, nil, err)
+		return
+	}
+
+	nonce, err := s.generate(req, dataHash, target)
+	switch err {
+	case nil:
+		// the client converts work into the nonce in little endian
+		work := strconv.FormatUint(binary.LittleEndian.Uint64(nonce), 16)
+		writeResponse(w, CodeSuccess, &workGenerateResult{Work: work}, nil)
+	case ErrQueueFull:
+		s.rejectedCounter.Inc(1)
+		writeResponse(w, CodeQueueFull, nil, err)
+	case ErrWorkCanceled, ErrServerStopped, pow.ErrPowAborted:
+		writeResponse(w, CodeCanceled, nil, err)
+	case errThresholdInUse:
+		writeResponse(w, CodeBadRequest, nil, err)
+	default:
+		writeResponse(w, CodeFailed, nil, err)
+	}
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, req *http.Request) {
+	param := &workCancel{}
+	if err := readRequest(req, param); err != nil {
+		writeResponse(w, CodeBadRequest, nil, err)
+		return
+	}
+	dataHash, err := hex.DecodeString(param.DataHash)
+	if err != nil || len(dataHash) != 32 {
+		writeResponse(w, CodeBadRequest, nil, errors.New("invalid hash"))
+		return
+	}
+	if s.cancel(dataHash) {
+		s.canceledCounter.Inc(1)
+	}
+	writeResponse(w, CodeSuccess, &workCancelResult{}, nil)
+}
+
+func (s *Server) handleValidate(w http.ResponseWriter, req *http.Request) {
+	param := &workValidate{}
+	if err := readRequest(req, param); err != nil {
+		writeResponse(w, CodeBadRequest, nil, err)
+		return
+	}
+	dataHash, target, err := parseHashAndThreshold(param.DataHash, param.Threshold)
+	if err != nil {
+		writeResponse(w, CodeBadRequest, nil, err)
+		return
+	}
+	nonce, err := hex.DecodeString(param.Work)
+	if err != nil || len(nonce) != 8 {
+		writeResponse(w, CodeBadRequest, nil, errors.New("invalid work"))
+		return
+	}
+	result := &workValidateResult{Valid: "0"}
+	if pow.CheckPowNonceByTarget(target, nonce, dataHash) {
+		result.Valid = "1"
+	}
+	writeResponse(w, CodeSuccess, result, nil)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, req *http.Request) {
+	result := make(map[string]int64)
+	s.registry.Each(func(name string, i interface{}) {
+		if c, ok := i.(metrics.Counter); ok {
+			result[name] = c.Count()
+		}
+	})
+	s.mu.Lock()
+	result["jobs"] = int64(len(s.jobs))
+	result["running"] = s.running
+	s.mu.Unlock()
+	result["queue"] = int64(len(s.queue))
+	result["clients"] = int64(s.limiters.size())
+	writeResponse(w, CodeSuccess, result, nil)
+}
+
+func parseHashAndThreshold(hashStr, thresholdStr string) ([]byte, *big.Int, error) {
+	dataHash, err := hex.DecodeString(hashStr)
+	if err != nil || len(dataHash) != 32 {
+		return nil, nil, errors.New("invalid hash")
+	}
+	target, ok := new(big.Int).SetString(thresholdStr, 16)
+	if !ok || target.Sign() <= 0 || target.BitLen() > 256 {
+		return nil, nil, errors.New("invalid threshold")
+	}
+	return dataHash, target, nil
+}
+
+func readRequest(req *http.Request, param interface{}) error {
+	if req.Method != http.MethodPost {
+		return errors.New("only POST is supported")
+	}
+	defer req.Body.Close()
+	return json.NewDecoder(io.LimitReader(req.Body, 1024)).Decode(param)
+}
+
+func writeResponse(w http.ResponseWriter, code int, data interface{}, err error) {
+	resp := &ResponseJson{Code: code, Data: data, Msg: "ok"}
+	if err != nil {
+		resp.Error = err.Error()
+		resp.Msg = "error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func clientKey(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// clientLimiters is a token bucket per client.
+type clientLimiters struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newClientLimiters(rate float64, burst int) *clientLimiters {
+	return &clientLimiters{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+func (l *clientLimiters) allow(client string) bool {
+	if l.rate <= 0 {
+		return true
+	}
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (l *clientLimiters) size() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.buckets)
+}
+
+// cleanLoop drops the buckets which are full again.
+func (l *clientLimiters) cleanLoop(stopCh <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stopCh:
+			return
+		case now := <-ticker.C:
+			if l.rate <= 0 {
+				continue
+			}
+			l.mu.Lock()
+			for client, b := range l.buckets {
+				if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+					delete(l.buckets, client)
+				}
+			}
+			l.mu.Unlock()
+		}
+	}
+}
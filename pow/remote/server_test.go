@@ -0,0 +1,112 @@
+package remote
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/pow"
+)
+
+func newTestServer(cfg ServerConfig) (*Server, *httptest.Server) {
+	s := NewServer(cfg)
+	s.startWorkers()
+	return s, httptest.NewServer(s.Handler())
+}
+
+func TestServer_GenerateAndValidate(t *testing.T) {
+	s, ts := newTestServer(ServerConfig{Workers: 2})
+	defer s.Stop()
+	defer ts.Close()
+	InitRawUrl(ts.URL)
+
+	difficulty := big.NewInt(1000)
+	dataHash := types.DataHash([]byte("pow server"))
+	work, err := GenerateWork(dataHash.Bytes(), difficulty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonceBig, ok := new(big.Int).SetString(*work, 16)
+	if !ok {
+		t.Fatalf("wrong work %v", *work)
+	}
+	nonce := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nonce, nonceBig.Uint64())
+	if !pow.CheckPowNonce(difficulty, nonce, dataHash.Bytes()) {
+		t.Fatal("check nonce failed")
+	}
+
+	target := pow.DifficultyToTarget(difficulty)
+	if valid, err := VaildateWork(dataHash.Bytes(), target, nonce); err != nil || !valid {
+		t.Fatalf("validate work failed, valid %v, err %v", valid, err)
+	}
+	maxTarget := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	if valid, err := VaildateWork(dataHash.Bytes(), maxTarget, nonce); err != nil || valid {
+		t.Fatalf("work should be invalid, valid %v, err %v", valid, err)
+	}
+}
+
+func TestServer_Cancel(t *testing.T) {
+	s, ts := newTestServer(ServerConfig{Workers: 1})
+	defer s.Stop()
+	defer ts.Close()
+	InitRawUrl(ts.URL)
+
+	dataHash := types.DataHash([]byte("cancel"))
+	errCh := make(chan error, 1)
+	go func() {
+		// hardly possible to be found
+		_, err := GenerateWork(dataHash.Bytes(), new(big.Int).Lsh(big.NewInt(1), 60))
+		errCh <- err
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		_, ok := s.jobs[hex.EncodeToString(dataHash.Bytes())]
+		s.mu.Unlock()
+		if ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("work is not queued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := CancelWork(dataHash.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errCh:
+		if err == nil || err.Error() != ErrWorkCanceled.Error() {
+			t.Fatalf("expect %v, got %v", ErrWorkCanceled, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("work is not canceled")
+	}
+	if c := s.canceledCounter.Count(); c != 1 {
+		t.Fatalf("canceled counter is %d", c)
+	}
+}
+
+func TestServer_RateLimit(t *testing.T) {
+	s, ts := newTestServer(ServerConfig{Workers: 1, RateLimit: 0.001, RateBurst: 1})
+	defer s.Stop()
+	defer ts.Close()
+	InitRawUrl(ts.URL)
+
+	dataHash := types.DataHash([]byte("rate limit"))
+	if _, err := GenerateWork(dataHash.Bytes(), big.NewInt(1000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GenerateWork(dataHash.Bytes(), big.NewInt(1000)); err == nil || err.Error() != ErrRateLimited.Error() {
+		t.Fatalf("expect %v, got %v", ErrRateLimited, err)
+	}
+	if c := s.rejectedCounter.Count(); c != 1 {
+		t.Fatalf("rejected counter is %d", c)
+	}
+}
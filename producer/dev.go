@@ -0,0 +1,173 @@
+package producer
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// the periods searched for a free snapshot slot of the coinbase
+const devSearchPeriods = 10
+
+// SetInstamine makes the producer cut a snapshot block as soon as account blocks are inserted,
+// it should be called before Start, and is only for development networks.
+func (self *producer) SetInstamine(instamine bool) {
+	self.instamine = instamine
+}
+
+// SetDevNet marks the producer serves a local development network, it should be called before Start.
+func (self *producer) SetDevNet(devNet bool) {
+	self.devNet = devNet
+}
+
+// SetTime moves the clock used by MineSnapshot to t, the following snapshot blocks are mined at t or later.
+// It's only allowed in a development network.
+func (self *producer) SetTime(t time.Time) error {
+	if !self.devNet {
+		return errors.New("the time can only be set in a development network")
+	}
+	head := self.tools.chain.GetLatestSnapshotBlock()
+	if t.Before(*head.Timestamp) {
+		return errors.Errorf("time %s is before the latest snapshot block %d %s", t, head.Height, head.Timestamp)
+	}
+	atomic.StoreInt64(&self.timeOffset, int64(t.Sub(time.Now())))
+	return nil
+}
+
+func (self *producer) now() time.Time {
+	if !self.devNet {
+		return time.Now()
+	}
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&self.timeOffset)))
+}
+
+// MineSnapshot produces a snapshot block at once, it takes the first slot of the coinbase not earlier than now,
+// so the timestamp may be ahead of the wall clock by a slot. It's used by development networks.
+func (self *producer) MineSnapshot() (*ledger.SnapshotBlock, error) {
+	// 4: started
+	if self.GetStatus() != 4 {
+		return nil, errors.New("producer is not started")
+	}
+	reader, ok := self.cs.(consensus.Reader)
+	if !ok {
+		return nil, errors.New("consensus can't be read")
+	}
+
+	head := self.tools.chain.GetLatestSnapshotBlock()
+	now := self.now()
+	start := now
+	if head.Timestamp.After(start) {
+		start = *head.Timestamp
+	}
+	index, err := reader.VoteTimeToIndex(types.SNAPSHOT_GID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := index; i < index+devSearchPeriods; i++ {
+		events, _, err := reader.ReadByIndex(types.SNAPSHOT_GID, i)
+		if err != nil {
+			return nil, err
+		}
+		if e := selectSnapshotEvent(events, self.coinbase.Address, *head.Timestamp, now); e != nil {
+			return self.worker.mineSnapshot(e)
+		}
+	}
+	return nil, errors.Errorf("no snapshot slot for %s after %s", self.coinbase.Address, start)
+}
+
+// selectSnapshotEvent returns the earliest event of the address, which is after the head and not before now.
+func selectSnapshotEvent(events []*consensus.Event, addr types.Address, head time.Time, now time.Time) *consensus.Event {
+	var result *consensus.Event
+	for _, e := range events {
+		if e.Address != addr || !e.Timestamp.After(head) || e.Timestamp.Before(now.Truncate(time.Second)) {
+			continue
+		}
+		if result == nil || e.Timestamp.Before(result.Timestamp) {
+			result = e
+		}
+	}
+	return result
+}
+
+func (self *producer) startInstamine() {
+	self.instamineCh = make(chan struct{}, 1)
+	self.instamineStop = make(chan struct{})
+	self.instamineListener = &instamineListener{ch: self.instamineCh}
+	self.tools.chain.Register(self.instamineListener)
+
+	ch, stop := self.instamineCh, self.instamineStop
+	common.Go(func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ch:
+			}
+			if len(self.tools.chain.GetContentNeedSnapshot()) == 0 {
+				continue
+			}
+			if b, err := self.MineSnapshot(); err != nil {
+				mLog.Error("instamine fail.", "err", err)
+			} else {
+				mLog.Info("instamine snapshot block.", "height", b.Height, "hash", b.Hash)
+			}
+		}
+	})
+}
+
+func (self *producer) stopInstamine() {
+	if self.instamineListener == nil {
+		return
+	}
+	self.tools.chain.UnRegister(self.instamineListener)
+	close(self.instamineStop)
+	self.instamineListener = nil
+}
+
+// instamineListener notifies the instamine loop when account blocks are inserted into the chain
+type instamineListener struct {
+	ch chan<- struct{}
+}
+
+func (l *instamineListener) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	select {
+	case l.ch <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (l *instamineListener) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (l *instamineListener) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (l *instamineListener) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (l *instamineListener) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (l *instamineListener) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (l *instamineListener) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (l *instamineListener) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
@@ -0,0 +1,42 @@
+package producer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type devTestChain struct {
+	chain.Chain
+	head *ledger.SnapshotBlock
+}
+
+func (c *devTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.head
+}
+
+func TestProducer_SetTime(t *testing.T) {
+	now := time.Now()
+	p := &producer{tools: &tools{chain: &devTestChain{head: &ledger.SnapshotBlock{Height: 1, Timestamp: &now}}}}
+
+	future := now.Add(time.Hour)
+	if err := p.SetTime(future); err == nil {
+		t.Fatal("time should not be set out of a development network")
+	}
+	if p.now().After(future) || p.now().Before(now) {
+		t.Fatalf("clock is moved to %s out of a development network", p.now())
+	}
+
+	p.SetDevNet(true)
+	if err := p.SetTime(now.Add(-time.Hour)); err == nil {
+		t.Fatal("time before the latest snapshot block should be rejected")
+	}
+	if err := p.SetTime(future); err != nil {
+		t.Fatal(err)
+	}
+	if p.now().Before(future) {
+		t.Fatalf("clock is %s, should be moved to %s", p.now(), future)
+	}
+}
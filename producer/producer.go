@@ -3,6 +3,7 @@ package producer
 import (
 	"fmt"
 	"sync/atomic"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/chain"
@@ -34,6 +35,10 @@ type Producer interface {
 	Start() error
 	Stop() error
 	GetCoinBase() types.Address
+
+	// for development networks
+	MineSnapshot() (*ledger.SnapshotBlock, error)
+	SetTime(t time.Time) error
 }
 
 // Backend wraps all methods required for mining.
@@ -80,6 +85,13 @@ type producer struct {
 	accountFn            func(producerevent.AccountEvent)
 	syncState            net.SyncState
 	netSyncId            int
+
+	devNet            bool
+	instamine         bool
+	instamineCh       chan struct{}
+	instamineStop     chan struct{}
+	instamineListener *instamineListener
+	timeOffset        int64
 }
 
 // todo syncDone
@@ -153,6 +165,10 @@ func (self *producer) Start() error {
 		self.syncState = state
 	})
 	self.netSyncId = id
+
+	if self.instamine {
+		self.startInstamine()
+	}
 	wLog.Info("started.")
 	return nil
 }
@@ -166,6 +182,7 @@ func (self *producer) Stop() error {
 	snapshotId := self.coinbase.Address.String() + "_snapshot"
 	contractId := self.coinbase.Address.String() + "_contract"
 
+	self.stopInstamine()
 	self.cs.UnSubscribe(types.SNAPSHOT_GID, snapshotId)
 	self.cs.UnSubscribe(types.DELEGATE_GID, contractId)
 
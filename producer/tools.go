@@ -14,11 +14,13 @@ import (
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/wallet"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 type tools struct {
 	log       log15.Logger
 	wt        *wallet.Manager
+	signer    signer.Signer
 	pool      pool.SnapshotProducerWriter
 	chain     chain.Chain
 	sVerifier *verifier.SnapshotVerifier
@@ -57,16 +59,7 @@ func (self *tools) generateSnapshot(e *consensus.Event, coinbase *AddressContext
 	}
 
 	block.Hash = block.ComputeHash()
-	manager, err := self.wt.GetEntropyStoreManager(coinbase.EntryPath)
-	if err != nil {
-		return nil, err
-	}
-	_, key, err := manager.DeriveForIndexPath(coinbase.Index)
-	if err != nil {
-		return nil, err
-	}
-	signedData, pubkey, err := key.SignData(block.Hash.Bytes())
-
+	signedData, pubkey, err := self.signer.SignData(coinbase.Address, block.Hash.Bytes())
 	if err != nil {
 		return nil, err
 	}
@@ -83,7 +76,7 @@ func (self *tools) insertSnapshot(block *ledger.SnapshotBlock) error {
 
 func newChainRw(ch chain.Chain, sVerifier *verifier.SnapshotVerifier, wt *wallet.Manager, p pool.SnapshotProducerWriter) *tools {
 	log := log15.New("module", "tools")
-	return &tools{chain: ch, log: log, sVerifier: sVerifier, wt: wt, pool: p}
+	return &tools{chain: ch, log: log, sVerifier: sVerifier, wt: wt, signer: wt, pool: p}
 }
 
 func (self *tools) checkAddressLock(address types.Address, coinbase *AddressContext) error {
@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/vitelabs/go-vite/common"
 	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/monitor"
 )
@@ -84,29 +85,54 @@ func (self *worker) genAndInsert(e *consensus.Event) {
 	defer self.wg.Done()
 	self.mu.Lock()
 	defer self.mu.Unlock()
+	self.generateAndInsert(e)
+}
+
+// generateAndInsert must be called with self.mu locked
+func (self *worker) generateAndInsert(e *consensus.Event) (*ledger.SnapshotBlock, error) {
 	// lock pool
 	self.tools.pool.LockInsert()
 	// unlock pool
 	defer self.tools.pool.UnLockInsert()
 
+	// the slot may be taken by a snapshot block mined in advance, see mineSnapshot
+	if head := self.tools.chain.GetLatestSnapshotBlock(); !e.Timestamp.After(*head.Timestamp) {
+		wLog.Info("snapshot slot is taken.", "timestamp", e.Timestamp, "head", head.Height)
+		return nil, errors.Errorf("snapshot timestamp %s is not after the latest snapshot block %d", e.Timestamp, head.Height)
+	}
+
 	seed := self.randomSeed()
 
 	// generate snapshot block
 	b, err := self.tools.generateSnapshot(e, self.coinbase, seed, self.getSeedByHash)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[generate].", "err", err)
-		return
+		return nil, err
 	}
 
 	// insert snapshot block
 	err = self.tools.insertSnapshot(b)
 	if err != nil {
 		wLog.Error("produce snapshot block fail[insert].", "err", err)
-		return
+		return nil, err
 	}
 
 	// todo
 	self.storeSeedHash(seed, b.SeedHash)
+	return b, nil
+}
+
+// mineSnapshot produces a snapshot block at once with the consensus event of a future slot
+func (self *worker) mineSnapshot(e *consensus.Event) (*ledger.SnapshotBlock, error) {
+	self.wg.Add(1)
+	defer self.wg.Done()
+	if err := self.tools.checkAddressLock(e.Address, self.coinbase); err != nil {
+		return nil, err
+	}
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	return self.generateAndInsert(e)
 }
 
 func (self *worker) randomSeed() uint64 {
@@ -0,0 +1,56 @@
+package rpc
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+)
+
+// accessHandler serves the requests by the handler which checks the cors/vhosts of HTTP requests
+// or the origins of websocket handshakes, the handler is rebuilt when the rules change
+type accessHandler struct {
+	current atomic.Value // *handlerHolder
+
+	buildHTTP func(cors []string, vhosts []string) http.Handler
+	buildWS   func(origins []string) http.Handler
+}
+
+type handlerHolder struct {
+	http.Handler
+}
+
+func newHTTPAccessHandler(cors []string, vhosts []string, build func(cors []string, vhosts []string) http.Handler) *accessHandler {
+	h := &accessHandler{buildHTTP: build}
+	h.current.Store(&handlerHolder{build(cors, vhosts)})
+	return h
+}
+
+func newWSAccessHandler(origins []string, build func(origins []string) http.Handler) *accessHandler {
+	h := &accessHandler{buildWS: build}
+	h.current.Store(&handlerHolder{build(origins)})
+	return h
+}
+
+// ServeHTTP implements http.Handler
+func (h *accessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.current.Load().(*handlerHolder).ServeHTTP(w, r)
+}
+
+// SetHTTPAccess replaces the cors and vhosts of the HTTP endpoint served by srv, it applies to the new requests
+func (srv *Server) SetHTTPAccess(cors []string, vhosts []string) error {
+	if srv.access == nil || srv.access.buildHTTP == nil {
+		return errors.New("not an HTTP endpoint")
+	}
+	srv.access.current.Store(&handlerHolder{srv.access.buildHTTP(cors, vhosts)})
+	return nil
+}
+
+// SetWSOrigins replaces the allowed origins of the websocket endpoint served by srv, it applies to the new
+// connections and the connected websockets are kept
+func (srv *Server) SetWSOrigins(origins []string) error {
+	if srv.access == nil || srv.access.buildWS == nil {
+		return errors.New("not a websocket endpoint")
+	}
+	srv.access.current.Store(&handlerHolder{srv.access.buildWS(origins)})
+	return nil
+}
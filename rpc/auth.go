@@ -0,0 +1,452 @@
+package rpc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/vitelabs/go-vite/log15"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+	apiKeyQuery  = "apikey"
+
+	// idle clients without subscriptions are dropped after authClientIdle
+	authClientIdle     = 10 * time.Minute
+	authPruneInterval  = time.Minute
+	anonymousClientTag = "anonymous/"
+	keyClientTag       = "key/"
+
+	// the extra HTTP handlers are authorized as the methods of httpHandlerNamespace named by their paths,
+	// e.g. "http_graphql" for /graphql, so "http" in Allow allows all of them
+	httpHandlerNamespace = "http"
+)
+
+var (
+	errMissingCredentials = errors.New("missing api key")
+	errInvalidCredentials = errors.New("invalid api key")
+)
+
+// AuthConfig is the access control of the public HTTP and WebSocket endpoints.
+// A client sends its key in the "Authorization: Bearer <key>" header, the "X-API-Key" header,
+// or the "apikey" query parameter which is handy for browser WebSocket clients.
+// A HS256 JWT signed with JWTSecret is accepted in place of a key, its "sub" claim is the name of the key,
+// its "exp" claim is required, so a leaked token doesn't grant access forever.
+type AuthConfig struct {
+	Keys      []*APIKeyConfig `json:"Keys"`
+	JWTSecret string          `json:"JWTSecret"`
+	// the policy of requests without credentials, they are rejected if it's nil.
+	// The rate limits of anonymous requests are counted per remote ip.
+	Anonymous *APIKeyConfig `json:"Anonymous"`
+}
+
+// APIKeyConfig is the policy of an api key
+type APIKeyConfig struct {
+	Name string `json:"Name"`
+	// the secret sent by clients, a key without secret can only be used by JWT
+	Key string `json:"Key"`
+	// namespaces like "ledger" or methods like "ledger_getVmLogsByFilter", all methods are allowed if it's empty.
+	// The extra HTTP handlers are allowed by "http", or one by one like "http_graphql" and "http_metrics"
+	Allow []string `json:"Allow"`
+	// requests per second of the key, 0 means unlimited
+	RateLimit float64 `json:"RateLimit"`
+	// the bucket size of RateLimit, it's the ceil of RateLimit by default
+	RateBurst int `json:"RateBurst"`
+	// requests per second of namespaces or methods, they are counted besides RateLimit
+	MethodRateLimits map[string]float64 `json:"MethodRateLimits"`
+	// concurrent subscriptions of the key over all connections, 0 means unlimited
+	MaxSubscriptions int `json:"MaxSubscriptions"`
+	// the max size in bytes of a request, it can't exceed the default limit of the server
+	MaxRequestSize int64 `json:"MaxRequestSize"`
+}
+
+// Authenticator authenticates requests of the HTTP and WebSocket endpoints and enforces the policies of api keys
+type Authenticator struct {
+	keys      map[string]*authPolicy // by the sha256 of the secret
+	names     map[string]*authPolicy
+	anonymous *authPolicy
+	jwtSecret []byte
+
+	mu        sync.Mutex
+	clients   map[string]*authClient
+	lastPrune time.Time
+}
+
+type authPolicy struct {
+	cfg   *APIKeyConfig
+	allow map[string]bool // nil allows all
+}
+
+// authClient is the rate limit and subscription state of a key, or of a remote ip for anonymous requests
+type authClient struct {
+	id     string
+	policy *authPolicy
+
+	bucket   *tokenBucket
+	methodMu sync.Mutex
+	methods  map[string]*tokenBucket
+
+	subscriptions int32
+	lastSeen      int64
+}
+
+// authSession is the state of a connection, it releases the subscriptions of the connection when it's closed
+type authSession struct {
+	client        *authClient
+	subscriptions int32
+}
+
+type authSessionKey struct{}
+
+func NewAuthenticator(cfg *AuthConfig) (*Authenticator, error) {
+	if cfg == nil {
+		return nil, errors.New("auth config is nil")
+	}
+	a := &Authenticator{
+		keys:      make(map[string]*authPolicy),
+		names:     make(map[string]*authPolicy),
+		jwtSecret: []byte(cfg.JWTSecret),
+		clients:   make(map[string]*authClient),
+	}
+	for _, k := range cfg.Keys {
+		if k == nil || k.Name == "" {
+			return nil, errors.New("api key name is empty")
+		}
+		if _, ok := a.names[k.Name]; ok {
+			return nil, fmt.Errorf("duplicate api key name %s", k.Name)
+		}
+		p, err := newAuthPolicy(k)
+		if err != nil {
+			return nil, err
+		}
+		a.names[k.Name] = p
+		if k.Key == "" {
+			continue
+		}
+		h := hashAPIKey(k.Key)
+		if _, ok := a.keys[h]; ok {
+			return nil, fmt.Errorf("duplicate api key of %s", k.Name)
+		}
+		a.keys[h] = p
+	}
+	if cfg.Anonymous != nil {
+		p, err := newAuthPolicy(cfg.Anonymous)
+		if err != nil {
+			return nil, err
+		}
+		a.anonymous = p
+	}
+	return a, nil
+}
+
+func newAuthPolicy(cfg *APIKeyConfig) (*authPolicy, error) {
+	if cfg.RateLimit < 0 || cfg.RateBurst < 0 || cfg.MaxSubscriptions < 0 || cfg.MaxRequestSize < 0 {
+		return nil, fmt.Errorf("invalid limits of api key %s", cfg.Name)
+	}
+	for m, rate := range cfg.MethodRateLimits {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rate limit of %s for api key %s", m, cfg.Name)
+		}
+	}
+	p := &authPolicy{cfg: cfg}
+	if len(cfg.Allow) > 0 {
+		p.allow = make(map[string]bool, len(cfg.Allow))
+		for _, m := range cfg.Allow {
+			p.allow[m] = true
+		}
+	}
+	return p, nil
+}
+
+func hashAPIKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// Handler authenticates the requests before next, it returns next if a is nil
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && isHealthCheckRouter(r.URL) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, err := a.authenticate(r)
+		if err != nil {
+			log.Debug("rpc request rejected", "remote", r.RemoteAddr, "err", err)
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if limit := client.policy.cfg.MaxRequestSize; limit > 0 {
+			if r.ContentLength > limit {
+				http.Error(w, fmt.Sprintf("content length too large (%d>%d)", r.ContentLength, limit), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		ctx := context.WithValue(r.Context(), authSessionKey{}, &authSession{client: client})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authorizeHandler checks the allowlist and the rate limits of the session set by Handler before the extra handler
+// served on path, it returns next if a is nil
+func (a *Authenticator) authorizeHandler(path string, next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+	method := strings.Replace(strings.Trim(path, "/"), "/", "_", -1)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok := authSessionFromContext(r.Context())
+		if !ok {
+			http.Error(w, errMissingCredentials.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err := session.authorize(httpHandlerNamespace, method); err != nil {
+			code := http.StatusForbidden
+			if _, limited := err.(*limitExceededError); limited {
+				code = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), code)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *Authenticator) authenticate(r *http.Request) (*authClient, error) {
+	cred := r.Header.Get(apiKeyHeader)
+	if auth := r.Header.Get("Authorization"); cred == "" && auth != "" {
+		if !strings.HasPrefix(auth, "Bearer ") {
+			return nil, errInvalidCredentials
+		}
+		cred = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if cred == "" {
+		cred = r.URL.Query().Get(apiKeyQuery)
+	}
+
+	if cred == "" {
+		if a.anonymous == nil {
+			return nil, errMissingCredentials
+		}
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		return a.client(anonymousClientTag+host, a.anonymous), nil
+	}
+	if p, ok := a.keys[hashAPIKey(cred)]; ok {
+		return a.client(keyClientTag+p.cfg.Name, p), nil
+	}
+	if len(a.jwtSecret) > 0 && strings.Count(cred, ".") == 2 {
+		p, err := a.verifyJWT(cred, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		return a.client(keyClientTag+p.cfg.Name, p), nil
+	}
+	return nil, errInvalidCredentials
+}
+
+// verifyJWT checks the HS256 signature and the "exp" and "nbf" claims of token,
+// and returns the policy of the key named by the "sub" claim
+func (a *Authenticator) verifyJWT(token string, now time.Time) (*authPolicy, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errInvalidCredentials
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, errors.New("invalid jwt header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("invalid jwt signature")
+	}
+	mac := hmac.New(sha256.New, a.jwtSecret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return nil, errors.New("invalid jwt signature")
+	}
+
+	var claims struct {
+		Sub string `json:"sub"`
+		Exp *int64 `json:"exp"`
+		Nbf *int64 `json:"nbf"`
+	}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, errors.New("invalid jwt claims")
+	}
+	if claims.Exp == nil {
+		return nil, errors.New("jwt has no expiration")
+	}
+	if now.Unix() >= *claims.Exp {
+		return nil, errors.New("jwt is expired")
+	}
+	if claims.Nbf != nil && now.Unix() < *claims.Nbf {
+		return nil, errors.New("jwt is not valid yet")
+	}
+	p, ok := a.names[claims.Sub]
+	if !ok {
+		return nil, fmt.Errorf("unknown api key %s", claims.Sub)
+	}
+	return p, nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (a *Authenticator) client(id string, p *authPolicy) *authClient {
+	now := time.Now()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Sub(a.lastPrune) > authPruneInterval {
+		a.lastPrune = now
+		for k, c := range a.clients {
+			if atomic.LoadInt32(&c.subscriptions) == 0 && now.Sub(time.Unix(0, atomic.LoadInt64(&c.lastSeen))) > authClientIdle {
+				delete(a.clients, k)
+			}
+		}
+	}
+
+	c, ok := a.clients[id]
+	if !ok {
+		c = &authClient{id: id, policy: p}
+		if p.cfg.RateLimit > 0 {
+			c.bucket = newTokenBucket(p.cfg.RateLimit, p.cfg.RateBurst)
+		}
+		if len(p.cfg.MethodRateLimits) > 0 {
+			c.methods = make(map[string]*tokenBucket)
+		}
+		a.clients[id] = c
+	}
+	atomic.StoreInt64(&c.lastSeen, now.UnixNano())
+	return c
+}
+
+func authSessionFromContext(ctx context.Context) (*authSession, bool) {
+	s, ok := ctx.Value(authSessionKey{}).(*authSession)
+	return s, ok
+}
+
+// authorize checks the allowlist and the rate limits of the client for a method
+func (s *authSession) authorize(namespace, method string) Error {
+	c := s.client
+	name := namespace + serviceMethodSeparator + method
+	if c.policy.allow != nil && !c.policy.allow[namespace] && !c.policy.allow[name] {
+		return &unauthorizedError{fmt.Sprintf("method %s is not allowed", name)}
+	}
+
+	now := time.Now()
+	if c.bucket != nil && !c.bucket.take(now) {
+		return &limitExceededError{"rate limit exceeded"}
+	}
+	if c.methods == nil {
+		return nil
+	}
+	limited := name
+	rate, ok := c.policy.cfg.MethodRateLimits[name]
+	if !ok {
+		if rate, ok = c.policy.cfg.MethodRateLimits[namespace]; !ok {
+			return nil
+		}
+		limited = namespace
+	}
+	c.methodMu.Lock()
+	b, ok := c.methods[limited]
+	if !ok {
+		b = newTokenBucket(rate, 0)
+		c.methods[limited] = b
+	}
+	c.methodMu.Unlock()
+	if !b.take(now) {
+		return &limitExceededError{fmt.Sprintf("rate limit of %s exceeded", limited)}
+	}
+	return nil
+}
+
+// acquireSubscription reserves a subscription of the client, it fails if the client reaches MaxSubscriptions
+func (s *authSession) acquireSubscription() Error {
+	c := s.client
+	max := int32(c.policy.cfg.MaxSubscriptions)
+	for {
+		n := atomic.LoadInt32(&c.subscriptions)
+		if max > 0 && n >= max {
+			return &limitExceededError{fmt.Sprintf("too many subscriptions, the limit is %d", max)}
+		}
+		if atomic.CompareAndSwapInt32(&c.subscriptions, n, n+1) {
+			atomic.AddInt32(&s.subscriptions, 1)
+			return nil
+		}
+	}
+}
+
+func (s *authSession) releaseSubscription() {
+	if atomic.AddInt32(&s.subscriptions, -1) < 0 {
+		atomic.AddInt32(&s.subscriptions, 1)
+		return
+	}
+	atomic.AddInt32(&s.client.subscriptions, -1)
+}
+
+// close releases all subscriptions of the connection
+func (s *authSession) close() {
+	n := atomic.SwapInt32(&s.subscriptions, 0)
+	atomic.AddInt32(&s.client.subscriptions, -n)
+}
+
+// tokenBucket is a rate limiter which allows bursts of up to burst requests
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := float64(burst)
+	if b < 1 {
+		b = math.Max(1, math.Ceil(rate))
+	}
+	return &tokenBucket{rate: rate, burst: b, tokens: b}
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
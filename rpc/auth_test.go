@@ -0,0 +1,319 @@
+package rpc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAuthenticator(t *testing.T) *Authenticator {
+	a, err := NewAuthenticator(&AuthConfig{
+		Keys: []*APIKeyConfig{
+			{Name: "full", Key: "full-key"},
+			{Name: "limited", Key: "limited-key", Allow: []string{"calc_echo", "rpc"}, RateLimit: 1, RateBurst: 2},
+			{Name: "quota", Key: "quota-key", MethodRateLimits: map[string]float64{"calc_echo": 1, "rpc": 100}},
+			{Name: "jwt-only", MaxSubscriptions: 1},
+		},
+		JWTSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func authTestPost(t *testing.T, url, key, body string) (int, *jsonErrResponse) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("content-type", contentType)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+	var result jsonErrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, &result
+}
+
+func TestAuthenticatorHTTP(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("calc", new(Service)); err != nil {
+		t.Fatal(err)
+	}
+	hs := httptest.NewServer(newTestAuthenticator(t).Handler(server))
+	defer hs.Close()
+
+	echo := `{"jsonrpc":"2.0","id":1,"method":"calc_echo","params":["a",1,{"S":"b"}]}`
+	noArgs := `{"jsonrpc":"2.0","id":1,"method":"calc_noArgsRets","params":[]}`
+
+	if code, _ := authTestPost(t, hs.URL, "", echo); code != http.StatusUnauthorized {
+		t.Fatalf("request without key should be rejected, code %d", code)
+	}
+	if code, _ := authTestPost(t, hs.URL, "wrong-key", echo); code != http.StatusUnauthorized {
+		t.Fatalf("request with a wrong key should be rejected, code %d", code)
+	}
+	if _, resp := authTestPost(t, hs.URL, "full-key", noArgs); resp.Error.Code != 0 {
+		t.Fatalf("unexpected error %v", resp.Error)
+	}
+
+	// allowlist
+	if _, resp := authTestPost(t, hs.URL, "limited-key", noArgs); resp.Error.Code != -32003 {
+		t.Fatalf("calc_noArgsRets should not be allowed, got %v", resp.Error)
+	}
+	for i := 0; i < 2; i++ {
+		if _, resp := authTestPost(t, hs.URL, "limited-key", echo); resp.Error.Code != 0 {
+			t.Fatalf("unexpected error %v", resp.Error)
+		}
+	}
+	if _, resp := authTestPost(t, hs.URL, "limited-key", echo); resp.Error.Code != -32005 {
+		t.Fatalf("rate limit should be exceeded, got %v", resp.Error)
+	}
+	// the limits of other keys are not affected
+	if _, resp := authTestPost(t, hs.URL, "full-key", echo); resp.Error.Code != 0 {
+		t.Fatalf("unexpected error %v", resp.Error)
+	}
+
+	// method quota
+	if _, resp := authTestPost(t, hs.URL, "quota-key", echo); resp.Error.Code != 0 {
+		t.Fatalf("unexpected error %v", resp.Error)
+	}
+	if _, resp := authTestPost(t, hs.URL, "quota-key", echo); resp.Error.Code != -32005 {
+		t.Fatalf("quota of calc_echo should be exceeded, got %v", resp.Error)
+	}
+	if _, resp := authTestPost(t, hs.URL, "quota-key", noArgs); resp.Error.Code != 0 {
+		t.Fatalf("unexpected error %v", resp.Error)
+	}
+}
+
+// newHandlerTestServer serves /graphql and /metrics beside the RPC server like StartHTTPEndpoint
+func newHandlerTestServer(a *Authenticator) *httptest.Server {
+	mux := http.NewServeMux()
+	for _, path := range []string{"/graphql", "/metrics"} {
+		mux.Handle(path, a.authorizeHandler(path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	}
+	return httptest.NewServer(a.Handler(mux))
+}
+
+func authTestGet(t *testing.T, url, key string) int {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuthenticatorHandlers(t *testing.T) {
+	hs := newHandlerTestServer(newTestAuthenticator(t))
+	defer hs.Close()
+
+	if code := authTestGet(t, hs.URL+"/graphql", ""); code != http.StatusUnauthorized {
+		t.Fatalf("request without key should be rejected, code %d", code)
+	}
+	if code := authTestGet(t, hs.URL+"/graphql", "full-key"); code != http.StatusOK {
+		t.Fatalf("unexpected code %d", code)
+	}
+	// http_graphql is not in the allowlist
+	if code := authTestGet(t, hs.URL+"/graphql", "limited-key"); code != http.StatusForbidden {
+		t.Fatalf("/graphql should not be allowed, code %d", code)
+	}
+
+	a, err := NewAuthenticator(&AuthConfig{Keys: []*APIKeyConfig{{Name: "graphql", Key: "graphql-key", Allow: []string{"http_graphql"}, RateLimit: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hs2 := newHandlerTestServer(a)
+	defer hs2.Close()
+
+	if code := authTestGet(t, hs2.URL+"/metrics", "graphql-key"); code != http.StatusForbidden {
+		t.Fatalf("/metrics should not be allowed, code %d", code)
+	}
+	if code := authTestGet(t, hs2.URL+"/graphql", "graphql-key"); code != http.StatusOK {
+		t.Fatalf("unexpected code %d", code)
+	}
+	if code := authTestGet(t, hs2.URL+"/graphql", "graphql-key"); code != http.StatusTooManyRequests {
+		t.Fatalf("rate limit should be exceeded, code %d", code)
+	}
+}
+
+func makeTestJWT(secret string, alg string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticatorJWT(t *testing.T) {
+	a := newTestAuthenticator(t)
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid", makeTestJWT("secret", "HS256", map[string]interface{}{"sub": "jwt-only", "exp": now.Unix() + 60}), true},
+		{"no expiration", makeTestJWT("secret", "HS256", map[string]interface{}{"sub": "limited"}), false},
+		{"expired", makeTestJWT("secret", "HS256", map[string]interface{}{"sub": "jwt-only", "exp": now.Unix() - 1}), false},
+		{"not valid yet", makeTestJWT("secret", "HS256", map[string]interface{}{"sub": "jwt-only", "nbf": now.Unix() + 60}), false},
+		{"wrong secret", makeTestJWT("other", "HS256", map[string]interface{}{"sub": "jwt-only"}), false},
+		{"wrong alg", makeTestJWT("secret", "none", map[string]interface{}{"sub": "jwt-only"}), false},
+		{"unknown key", makeTestJWT("secret", "HS256", map[string]interface{}{"sub": "nobody"}), false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodPost, "http://url.com", nil)
+		req.Header.Set("Authorization", "Bearer "+test.token)
+		_, err := a.authenticate(req)
+		if (err == nil) != test.ok {
+			t.Errorf("%s: unexpected result %v", test.name, err)
+		}
+	}
+}
+
+func TestAuthenticatorAnonymous(t *testing.T) {
+	a, err := NewAuthenticator(&AuthConfig{
+		Keys:      []*APIKeyConfig{{Name: "key", Key: "key"}},
+		Anonymous: &APIKeyConfig{Name: "anonymous", RateLimit: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientOf := func(remote string, query string) *authClient {
+		req := httptest.NewRequest(http.MethodGet, "http://url.com/"+query, nil)
+		req.RemoteAddr = remote
+		c, err := a.authenticate(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	c1 := clientOf("10.0.0.1:1000", "")
+	if c1 != clientOf("10.0.0.1:2000", "") {
+		t.Fatalf("anonymous requests of an ip should share the limits")
+	}
+	if c1 == clientOf("10.0.0.2:1000", "") {
+		t.Fatalf("anonymous requests of different ips should not share the limits")
+	}
+	if c := clientOf("10.0.0.1:1000", "?apikey=key"); c.id != keyClientTag+"key" {
+		t.Fatalf("unexpected client %s", c.id)
+	}
+}
+
+func TestAuthSessionSubscriptions(t *testing.T) {
+	a := newTestAuthenticator(t)
+	client := a.client(keyClientTag+"jwt-only", a.names["jwt-only"])
+	s1 := &authSession{client: client}
+	s2 := &authSession{client: client}
+
+	if err := s1.acquireSubscription(); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.acquireSubscription(); err == nil {
+		t.Fatalf("subscriptions of the key should be limited")
+	}
+	s1.close()
+	if err := s2.acquireSubscription(); err != nil {
+		t.Fatal(err)
+	}
+	s2.releaseSubscription()
+	s2.releaseSubscription()
+	if client.subscriptions != 0 {
+		t.Fatalf("unexpected subscriptions %d", client.subscriptions)
+	}
+}
+
+func TestNotifierReleasesSubscriptions(t *testing.T) {
+	a := newTestAuthenticator(t)
+	client := a.client(keyClientTag+"jwt-only", a.names["jwt-only"])
+	session := &authSession{client: client}
+
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	go io.Copy(ioutil.Discard, c2)
+	notifier := newNotifier(NewJSONCodec(c1))
+	notifier.release = session.releaseSubscription
+
+	subscribe := func() ID {
+		if err := session.acquireSubscription(); err != nil {
+			t.Fatal(err)
+		}
+		sub := notifier.CreateSubscription()
+		notifier.activate(sub.ID, "calc")
+		return sub.ID
+	}
+
+	// the subscription ended by the server releases its slot
+	if err := notifier.Fail(subscribe(), errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+	if err := notifier.unsubscribe(subscribe()); err != nil {
+		t.Fatal(err)
+	}
+	subscribe()
+	if client.subscriptions != 1 {
+		t.Fatalf("unexpected subscriptions %d", client.subscriptions)
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	b := newTokenBucket(2, 0)
+	now := time.Now()
+	if !b.take(now) || !b.take(now) {
+		t.Fatalf("the burst should be 2")
+	}
+	if b.take(now) {
+		t.Fatalf("the bucket should be empty")
+	}
+	if !b.take(now.Add(500 * time.Millisecond)) {
+		t.Fatalf("the bucket should be refilled")
+	}
+	if b.take(now.Add(500 * time.Millisecond)) {
+		t.Fatalf("the bucket should be empty")
+	}
+}
+
+func TestNewAuthenticatorInvalid(t *testing.T) {
+	configs := []*AuthConfig{
+		{Keys: []*APIKeyConfig{{Key: "k"}}},
+		{Keys: []*APIKeyConfig{{Name: "a", Key: "k"}, {Name: "a", Key: "k2"}}},
+		{Keys: []*APIKeyConfig{{Name: "a", Key: "k"}, {Name: "b", Key: "k"}}},
+		{Keys: []*APIKeyConfig{{Name: "a", RateLimit: -1}}},
+		{Keys: []*APIKeyConfig{{Name: "a", MethodRateLimits: map[string]float64{"ledger": 0}}}},
+	}
+	for i, cfg := range configs {
+		if _, err := NewAuthenticator(cfg); err == nil {
+			t.Errorf("config %d should be invalid", i)
+		}
+	}
+}
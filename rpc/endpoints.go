@@ -18,6 +18,7 @@ package rpc
 
 import (
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
@@ -26,8 +27,9 @@ import (
 	log "github.com/vitelabs/go-vite/log15"
 )
 
-// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules
-func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, exposeAll bool) (net.Listener, *Server, error) {
+// StartHTTPEndpoint starts the HTTP RPC endpoint, configured with cors/vhosts/modules,
+// the extra handlers are served on their own paths beside the RPC server, all requests are authenticated by auth if it's not nil
+func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []string, vhosts []string, timeouts HTTPTimeouts, exposeAll bool, handlers map[string]http.Handler, auth *Authenticator) (net.Listener, *Server, error) {
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
 	for _, module := range modules {
@@ -52,13 +54,29 @@ func StartHTTPEndpoint(endpoint string, apis []API, modules []string, cors []str
 		return nil, nil, err
 	}
 
-	go NewHTTPServer(cors, vhosts, timeouts, handler).Serve(listener)
+	var httpHandler http.Handler = handler
+	if len(handlers) > 0 {
+		mux := http.NewServeMux()
+		for path, h := range handlers {
+			mux.Handle(path, auth.authorizeHandler(path, h))
+			log.Debug("HTTP handler registered", "path", path)
+		}
+		mux.Handle("/", handler)
+		httpHandler = mux
+	}
+
+	// the cors and vhosts can be changed by SetHTTPAccess
+	authHandler := auth.Handler(httpHandler)
+	handler.access = newHTTPAccessHandler(cors, vhosts, func(cors []string, vhosts []string) http.Handler {
+		return newVHostHandler(vhosts, newCorsHandler(authHandler, cors))
+	})
+	go newHTTPServer(timeouts, handler.access).Serve(listener)
 
 	return listener, handler, err
 }
 
-// StartWSEndpoint starts chain websocket endpoint
-func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool) (net.Listener, *Server, error) {
+// StartWSEndpoint starts chain websocket endpoint, the connections are authenticated by auth if it's not nil
+func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []string, exposeAll bool, auth *Authenticator) (net.Listener, *Server, error) {
 
 	// Generate the whitelist based on the allowed modules
 	whitelist := make(map[string]bool)
@@ -84,7 +102,11 @@ func StartWSEndpoint(endpoint string, apis []API, modules []string, wsOrigins []
 		return nil, nil, err
 	}
 
-	go NewWSServer(wsOrigins, handler).Serve(listener)
+	// the origins can be changed by SetWSOrigins
+	handler.access = newWSAccessHandler(wsOrigins, func(origins []string) http.Handler {
+		return auth.Handler(handler.WebsocketHandler(origins))
+	})
+	go (&http.Server{Handler: handler.access}).Serve(listener)
 
 	return listener, handler, err
 
@@ -78,3 +78,17 @@ type invalidMessageError struct{ message string }
 func (e *invalidMessageError) ErrorCode() int { return -32700 }
 
 func (e *invalidMessageError) Error() string { return e.message }
+
+// request is rejected by the api key policy
+type unauthorizedError struct{ message string }
+
+func (e *unauthorizedError) ErrorCode() int { return -32003 }
+
+func (e *unauthorizedError) Error() string { return e.message }
+
+// request exceeds the rate or subscription limits of the api key
+type limitExceededError struct{ message string }
+
+func (e *limitExceededError) ErrorCode() int { return -32005 }
+
+func (e *limitExceededError) Error() string { return e.message }
@@ -195,11 +195,14 @@ func (t *httpReadWriteNopCloser) Close() error {
 // NewHTTPServer creates chain new HTTP RPC server around an API provider.
 //
 // Deprecated: Server implements http.Handler
-func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv *Server) *http.Server {
+func NewHTTPServer(cors []string, vhosts []string, timeouts HTTPTimeouts, srv http.Handler) *http.Server {
 	// Wrap the CORS-handler within chain host-handler
 	handler := newCorsHandler(srv, cors)
 	handler = newVHostHandler(vhosts, handler)
+	return newHTTPServer(timeouts, handler)
+}
 
+func newHTTPServer(timeouts HTTPTimeouts, handler http.Handler) *http.Server {
 	// Make sure timeout values are meaningful
 	if timeouts.ReadTimeout < time.Second {
 		log.Warn("Sanitizing invalid HTTP read timeout", "provided", timeouts.ReadTimeout, "updated", DefaultHTTPTimeouts.ReadTimeout)
@@ -263,7 +266,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (srv *Server) healthRequest() *serverRequest {
 	method := "health"
 	service := "health"
-	svc, ok := srv.services[service]
+	svc, ok := srv.service(service)
 	if !ok {
 		return &serverRequest{id: 0, err: &methodNotFoundError{service, method, nil}}
 	}
@@ -327,7 +330,7 @@ func validateRequest(r *http.Request) (int, error) {
 	return 0, nil
 }
 
-func newCorsHandler(srv *Server, allowedOrigins []string) http.Handler {
+func newCorsHandler(srv http.Handler, allowedOrigins []string) http.Handler {
 	// disable CORS support if user has not specified chain custom CORS configuration
 	if len(allowedOrigins) == 0 {
 		return srv
@@ -52,3 +52,29 @@ func testHTTPErrorResponse(t *testing.T, method, contentType, body string, expec
 		t.Fatalf("response code should be %d not %d", expected, code)
 	}
 }
+
+func TestServerSetHTTPAccess(t *testing.T) {
+	srv := NewServer()
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	srv.access = newHTTPAccessHandler(nil, []string{"localhost"}, func(cors []string, vhosts []string) http.Handler {
+		return newVHostHandler(vhosts, newCorsHandler(ok, cors))
+	})
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		srv.access.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "http://vite.org", nil))
+		return w.Code
+	}
+	if code := serve(); code != http.StatusForbidden {
+		t.Fatalf("response code should be %d not %d", http.StatusForbidden, code)
+	}
+	if err := srv.SetHTTPAccess(nil, []string{"vite.org"}); err != nil {
+		t.Fatal(err)
+	}
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("response code should be %d not %d", http.StatusOK, code)
+	}
+	if err := srv.SetWSOrigins([]string{"*"}); err == nil {
+		t.Fatal("origins should not be set to an HTTP endpoint")
+	}
+}
@@ -68,6 +68,8 @@ type RPCService struct {
 // Modules returns the list of RPC services with their version number
 func (s *RPCService) Modules() map[string]string {
 	modules := make(map[string]string)
+	s.server.servicesMu.RLock()
+	defer s.server.servicesMu.RUnlock()
 	for name := range s.server.services {
 		modules[name] = "1.0"
 	}
@@ -78,6 +80,8 @@ func (s *RPCService) Modules() map[string]string {
 // match the criteria to be either chain RPC method or chain subscription an error is returned. Otherwise chain new service is
 // created and added to the service collection this server instance serves.
 func (s *Server) RegisterName(name string, rcvr interface{}) error {
+	s.servicesMu.Lock()
+	defer s.servicesMu.Unlock()
 	if s.services == nil {
 		s.services = make(serviceRegistry)
 	}
@@ -102,14 +106,23 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 		return fmt.Errorf("Service %T doesn't have any suitable methods/subscriptions to expose", rcvr)
 	}
 
-	// already chain previous service register under given name, merge methods/subscriptions
+	// already chain previous service register under given name, merge methods/subscriptions.
+	// the merged service replaces the previous one because the requests may be reading it
 	if regsvc, present := s.services[name]; present {
+		merged := &service{name: regsvc.name, typ: regsvc.typ, callbacks: make(callbacks, len(regsvc.callbacks)), subscriptions: make(map[string]*callback, len(regsvc.subscriptions))}
+		for k, m := range regsvc.callbacks {
+			merged.callbacks[k] = m
+		}
+		for k, m := range regsvc.subscriptions {
+			merged.subscriptions[k] = m
+		}
 		for _, m := range methods {
-			regsvc.callbacks[formatName(m.method.Name)] = m
+			merged.callbacks[formatName(m.method.Name)] = m
 		}
 		for _, s := range subscriptions {
-			regsvc.subscriptions[formatName(s.method.Name)] = s
+			merged.subscriptions[formatName(s.method.Name)] = s
 		}
+		s.services[name] = merged
 		return nil
 	}
 
@@ -120,6 +133,24 @@ func (s *Server) RegisterName(name string, rcvr interface{}) error {
 	return nil
 }
 
+// UnregisterName removes the service registered under name, the subscriptions which have been created
+// by the service are kept until they are unsubscribed or the connections are closed.
+func (s *Server) UnregisterName(name string) {
+	if name == MetadataApi {
+		return
+	}
+	s.servicesMu.Lock()
+	defer s.servicesMu.Unlock()
+	delete(s.services, name)
+}
+
+func (s *Server) service(name string) (*service, bool) {
+	s.servicesMu.RLock()
+	defer s.servicesMu.RUnlock()
+	svc, ok := s.services[name]
+	return svc, ok
+}
+
 // serveRequest will reads requests from the codec, calls the RPC callback and
 // writes the response to the given codec.
 //
@@ -149,7 +180,12 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 	// to send notification to clients. It is tied to the codec/connection. If the
 	// connection is closed the notifier will stop and cancels all active subscriptions.
 	if options&OptionSubscriptions == OptionSubscriptions {
-		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec))
+		notifier := newNotifier(codec)
+		// the subscription slots of the api key are released when the subscriptions are removed
+		if session, ok := authSessionFromContext(ctx); ok {
+			notifier.release = session.releaseSubscription
+		}
+		ctx = context.WithValue(ctx, notifierKey{}, notifier)
 	}
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
@@ -220,8 +256,12 @@ func (s *Server) serveRequest(ctx context.Context, codec ServerCodec, singleShot
 // response back using the given codec. It will block until the codec is closed or the server is
 // stopped. In either case the codec is closed.
 func (s *Server) ServeCodec(codec ServerCodec, options CodecOption) error {
+	return s.serveCodec(context.Background(), codec, options)
+}
+
+func (s *Server) serveCodec(ctx context.Context, codec ServerCodec, options CodecOption) error {
 	defer codec.Close()
-	return s.serveRequest(context.Background(), codec, false, options)
+	return s.serveRequest(ctx, codec, false, options)
 }
 
 // ServeSingleRequest reads and processes chain single RPC request from the given codec. It will not
@@ -265,6 +305,13 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 		return codec.CreateErrorResponse(&req.id, req.err), nil
 	}
 
+	session, authed := authSessionFromContext(ctx)
+	if authed && !req.isUnsubscribe {
+		if err := session.authorize(req.svcname, formatName(req.callb.method.Name)); err != nil {
+			return codec.CreateErrorResponse(&req.id, err), nil
+		}
+	}
+
 	if req.isUnsubscribe { // cancel subscription, first param must be the subscription id
 		if len(req.args) >= 1 && req.args[0].Kind() == reflect.String {
 			notifier, supported := NotifierFromContext(ctx)
@@ -283,8 +330,16 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	}
 
 	if req.callb.isSubscribe {
+		if authed {
+			if err := session.acquireSubscription(); err != nil {
+				return codec.CreateErrorResponse(&req.id, err), nil
+			}
+		}
 		subid, err := s.createSubscription(ctx, codec, req)
 		if err != nil {
+			if authed {
+				session.releaseSubscription()
+			}
 			return codec.CreateErrorResponse(&req.id, &callbackError{err.Error()}), nil
 		}
 
@@ -421,7 +476,7 @@ func (s *Server) readRequest(codec ServerCodec) ([]*serverRequest, bool, Error)
 			continue
 		}
 
-		if svc, ok = s.services[r.service]; !ok { // rpc method isn't available
+		if svc, ok = s.service(r.service); !ok { // rpc method isn't available
 			requests[i] = &serverRequest{id: r.id, err: &methodNotFoundError{r.service, r.method, nil}}
 			continue
 		}
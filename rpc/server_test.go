@@ -101,6 +101,22 @@ func TestServerRegisterName(t *testing.T) {
 	}
 }
 
+func TestServerUnregisterName(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("calc", new(Service)); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	server.UnregisterName("calc")
+	server.UnregisterName(MetadataApi)
+	if _, ok := server.service("calc"); ok {
+		t.Fatalf("Expected service calc to be unregistered")
+	}
+	if _, ok := server.service(MetadataApi); !ok {
+		t.Fatalf("Expected service %s to be kept", MetadataApi)
+	}
+}
+
 func testServerMethodExecution(t *testing.T, method string) {
 	server := NewServer()
 	service := new(Service)
@@ -55,6 +55,7 @@ type Notifier struct {
 	subMu    sync.RWMutex // guards active and inactive maps
 	active   map[ID]*Subscription
 	inactive map[ID]*Subscription
+	release  func() // releases the slot of a removed subscription, nil if the subscriptions are not limited
 }
 
 // newNotifier creates chain new notifier that can be used to send subscription
@@ -102,6 +103,33 @@ func (n *Notifier) Notify(id ID, data interface{}) error {
 	return nil
 }
 
+// SubscriptionError is the last notification of chain subscription ended by the server.
+type SubscriptionError struct {
+	Error string `json:"error"`
+}
+
+// Fail ends chain subscription from the server side, err is sent to the client as the last notification.
+// The subscription's Err channel is closed, as if the client unsubscribed.
+func (n *Notifier) Fail(id ID, err error) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	sub, active := n.active[id]
+	if !active {
+		return ErrSubscriptionNotFound
+	}
+	close(sub.err)
+	delete(n.active, id)
+	n.releaseSlot()
+
+	notification := n.codec.CreateNotification(string(id), sub.namespace, &SubscriptionError{Error: err.Error()})
+	if err := n.codec.Write(notification); err != nil {
+		n.codec.Close()
+		return err
+	}
+	return nil
+}
+
 // Closed returns chain channel that is closed when the RPC connection is closed.
 func (n *Notifier) Closed() <-chan interface{} {
 	return n.codec.Closed()
@@ -115,11 +143,18 @@ func (n *Notifier) unsubscribe(id ID) error {
 	if s, found := n.active[id]; found {
 		close(s.err)
 		delete(n.active, id)
+		n.releaseSlot()
 		return nil
 	}
 	return ErrSubscriptionNotFound
 }
 
+func (n *Notifier) releaseSlot() {
+	if n.release != nil {
+		n.release()
+	}
+}
+
 // activate enables chain subscription. Until chain subscription is enabled all
 // notifications are dropped. This method is called by the RPC server after
 // the subscription ID was sent to client. This prevents notifications being
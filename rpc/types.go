@@ -65,10 +65,14 @@ type subscriptions map[string]*callback  // collection of subscription callbacks
 
 // Server represents a RPC server
 type Server struct {
-	services serviceRegistry
-	run      int32
-	codecsMu sync.Mutex
-	codecs   mapset.Set
+	servicesMu sync.RWMutex
+	services   serviceRegistry
+	run        int32
+	codecsMu   sync.Mutex
+	codecs     mapset.Set
+
+	// access is the front handler of the HTTP or websocket endpoint, its rules can be changed while serving
+	access *accessHandler
 }
 
 // rpcRequest represents a raw incoming RPC request
@@ -62,13 +62,23 @@ func (srv *Server) WebsocketHandler(allowedOrigins []string) http.Handler {
 			// Create chain custom encode/decode pair to enforce payload size and number encoding
 			conn.MaxPayloadBytes = maxRequestContentLength
 
+			// the connection carries the api key session set by Authenticator.Handler
+			ctx := context.Background()
+			if session, ok := authSessionFromContext(conn.Request().Context()); ok {
+				ctx = context.WithValue(ctx, authSessionKey{}, session)
+				defer session.close()
+				if limit := session.client.policy.cfg.MaxRequestSize; limit > 0 && limit < maxRequestContentLength {
+					conn.MaxPayloadBytes = int(limit)
+				}
+			}
+
 			encoder := func(v interface{}) error {
 				return websocketJSONCodec.Send(conn, v)
 			}
 			decoder := func(v interface{}) error {
 				return websocketJSONCodec.Receive(conn, v)
 			}
-			srv.ServeCodec(NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
+			srv.serveCodec(ctx, NewCodec(conn, encoder, decoder), OptionMethodInvocation|OptionSubscriptions)
 		},
 	}
 }
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// archiveChain reads the vm state at a snapshot height, so that the vm can run against the historical state.
+// The reads by hash are kept as they are, the quota used lists are only kept for the latest snapshot blocks
+// and are read at the latest height.
+type archiveChain struct {
+	chain.Chain
+	snapshotHeight uint64
+}
+
+func newArchiveChain(c chain.Chain, snapshotHeight uint64) (*archiveChain, error) {
+	if err := c.CheckHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+	return &archiveChain{Chain: c, snapshotHeight: snapshotHeight}, nil
+}
+
+func (c *archiveChain) IsContractAccount(addr types.Address) (bool, error) {
+	if types.IsBuiltinContractAddrInUse(addr) {
+		return true, nil
+	}
+	meta, err := c.Chain.GetContractMetaInSnapshot(addr, c.snapshotHeight)
+	if err != nil {
+		return false, err
+	}
+	return meta != nil, nil
+}
+
+func (c *archiveChain) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	return c.Chain.GetBalanceAtHeight(addr, tokenId, c.snapshotHeight)
+}
+
+func (c *archiveChain) GetContractMeta(addr types.Address) (*ledger.ContractMeta, error) {
+	return c.Chain.GetContractMetaInSnapshot(addr, c.snapshotHeight)
+}
+
+func (c *archiveChain) GetContractCode(addr types.Address) ([]byte, error) {
+	meta, err := c.Chain.GetContractMetaInSnapshot(addr, c.snapshotHeight)
+	if err != nil || meta == nil {
+		return nil, err
+	}
+	return c.Chain.GetContractCode(addr)
+}
+
+func (c *archiveChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	return c.Chain.GetLatestAccountBlockAtHeight(addr, c.snapshotHeight)
+}
+
+func (c *archiveChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return nil
+}
+
+func (c *archiveChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	header, err := c.Chain.GetConfirmSnapshotHeaderByAbHash(abHash)
+	if err != nil || header == nil || header.Height > c.snapshotHeight {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (c *archiveChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	header, err := c.GetConfirmSnapshotHeaderByAbHash(blockHash)
+	if err != nil || header == nil {
+		return 0, err
+	}
+	return c.snapshotHeight + 1 - header.Height, nil
+}
+
+func (c *archiveChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	return abi.GetStakeBeneficialAmount(&archiveStorage{chain: c, addr: types.AddressQuota}, addr)
+}
+
+func (c *archiveChain) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	return c.Chain.GetValueAtHeight(addr, key, c.snapshotHeight)
+}
+
+func (c *archiveChain) GetStorageIterator(addr types.Address, prefix []byte) (interfaces.StorageIterator, error) {
+	return c.Chain.GetStorageIteratorAtHeight(addr, prefix, c.snapshotHeight)
+}
+
+// archiveStorage reads the storage of a contract at the snapshot height of the archive chain
+type archiveStorage struct {
+	chain *archiveChain
+	addr  types.Address
+}
+
+func (s *archiveStorage) GetValue(key []byte) ([]byte, error) {
+	return s.chain.GetValue(s.addr, key)
+}
+
+func (s *archiveStorage) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return s.chain.GetStorageIterator(s.addr, prefix)
+}
+
+func (s *archiveStorage) Address() *types.Address {
+	return &s.addr
+}
+
+func (l *LedgerApi) GetBalanceAtHeight(addr types.Address, tokenId types.TokenTypeId, snapshotHeight interface{}) (*string, error) {
+	height, err := parseHeight(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := l.chain.GetBalanceAtHeight(addr, tokenId, height)
+	if err != nil {
+		l.log.Error("GetBalanceAtHeight failed, error is "+err.Error(), "method", "GetBalanceAtHeight")
+		return nil, err
+	}
+	return bigIntToString(balance), nil
+}
+
+func (c *ContractApi) GetStorageAtHeight(addr types.Address, prefix string, snapshotHeight interface{}) (map[string]string, error) {
+	height, err := parseHeight(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	var prefixBytes []byte
+	if len(prefix) > 0 {
+		prefixBytes, err = hex.DecodeString(prefix)
+		if err != nil {
+			return nil, err
+		}
+	}
+	iter, err := c.chain.GetStorageIteratorAtHeight(addr, prefixBytes, height)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Release()
+	m := make(map[string]string)
+	for {
+		if !iter.Next() {
+			if iter.Error() != nil {
+				return nil, iter.Error()
+			}
+			return m, nil
+		}
+		if len(iter.Key()) > 0 && len(iter.Value()) > 0 {
+			m[hex.EncodeToString(iter.Key())] = hex.EncodeToString(iter.Value())
+		}
+	}
+}
+
+// CallOffChainMethodAtHeight runs the off-chain method against the contract state at the snapshot height,
+// param.Height and param.SnapshotHash are ignored.
+func (c *ContractApi) CallOffChainMethodAtHeight(param CallOffChainMethodParam, snapshotHeight interface{}) ([]byte, error) {
+	if param.Addr != nil {
+		param.SelfAddr = *param.Addr
+	}
+	height, err := parseHeight(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	archive, err := newArchiveChain(c.chain, height)
+	if err != nil {
+		return nil, err
+	}
+	snapshotHash, err := c.chain.GetSnapshotHashByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotHash == nil {
+		return nil, ErrSnapshotBlockNotExist
+	}
+
+	prevHash := &types.Hash{}
+	prevBlock, err := c.chain.GetLatestAccountBlockAtHeight(param.SelfAddr, height)
+	if err != nil {
+		return nil, err
+	}
+	if prevBlock != nil {
+		prevHash = &prevBlock.Hash
+	}
+
+	db, err := vm_db.NewVmDb(archive, &param.SelfAddr, snapshotHash, prevHash)
+	if err != nil {
+		return nil, err
+	}
+	codeBytes, err := param.codeBytes()
+	if err != nil {
+		return nil, err
+	}
+	return vm.NewVM(nil).OffChainReader(db, codeBytes, param.Data)
+}
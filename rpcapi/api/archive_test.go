@@ -0,0 +1,141 @@
+package api
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+// archiveTestChain keeps the state of two snapshot heights, 5 and 10, and retains the history from 3
+type archiveTestChain struct {
+	chain.Chain
+	contract types.Address
+	values   map[uint64][]byte
+	blocks   map[uint64]*ledger.AccountBlock
+}
+
+func (c *archiveTestChain) CheckHistoryHeight(snapshotHeight uint64) error {
+	if snapshotHeight < 3 || snapshotHeight > 10 {
+		return errors.New("out of retention")
+	}
+	return nil
+}
+
+func (c *archiveTestChain) historyHeight(snapshotHeight uint64) uint64 {
+	if snapshotHeight >= 10 {
+		return 10
+	}
+	return 5
+}
+
+func (c *archiveTestChain) GetValueAtHeight(addr types.Address, key []byte, snapshotHeight uint64) ([]byte, error) {
+	if snapshotHeight < 5 {
+		return nil, nil
+	}
+	return c.values[c.historyHeight(snapshotHeight)], nil
+}
+
+func (c *archiveTestChain) GetBalanceAtHeight(addr types.Address, tokenId types.TokenTypeId, snapshotHeight uint64) (*big.Int, error) {
+	return new(big.Int).SetUint64(snapshotHeight), nil
+}
+
+func (c *archiveTestChain) GetContractMetaInSnapshot(addr types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
+	if addr != c.contract || snapshotHeight < 5 {
+		return nil, nil
+	}
+	return &ledger.ContractMeta{}, nil
+}
+
+func (c *archiveTestChain) GetContractCode(addr types.Address) ([]byte, error) {
+	return []byte("code"), nil
+}
+
+func (c *archiveTestChain) GetLatestAccountBlockAtHeight(addr types.Address, snapshotHeight uint64) (*ledger.AccountBlock, error) {
+	return c.blocks[c.historyHeight(snapshotHeight)], nil
+}
+
+func (c *archiveTestChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	for height, block := range c.blocks {
+		if block.Hash == abHash {
+			return &ledger.SnapshotBlock{Height: height}, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestArchiveChain(t *testing.T) {
+	contract := types.Address{1}
+	stakeAmount, err := abi.ABIQuota.PackVariable(abi.VariableNameStakeBeneficial, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &archiveTestChain{
+		contract: contract,
+		values:   map[uint64][]byte{5: stakeAmount, 10: []byte("v10")},
+		blocks: map[uint64]*ledger.AccountBlock{
+			5:  {Height: 1, Hash: types.Hash{5}},
+			10: {Height: 2, Hash: types.Hash{10}},
+		},
+	}
+
+	for _, height := range []uint64{0, 2, 11} {
+		if _, err := newArchiveChain(c, height); err == nil {
+			t.Fatalf("height %d out of retention should be rejected", height)
+		}
+	}
+
+	archive, err := newArchiveChain(c, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if balance, err := archive.GetBalance(contract, ledger.ViteTokenId); err != nil || balance.Uint64() != 7 {
+		t.Fatalf("balance is %v, error: %v", balance, err)
+	}
+	if amount, err := archive.GetStakeBeneficialAmount(contract); err != nil || amount.Int64() != 7 {
+		t.Fatalf("stake amount is %v, error: %v", amount, err)
+	}
+	if ok, err := archive.IsContractAccount(contract); err != nil || !ok {
+		t.Fatalf("%s should be a contract at 7, error: %v", contract, err)
+	}
+	if code, err := archive.GetContractCode(contract); err != nil || string(code) != "code" {
+		t.Fatalf("code is %s, error: %v", code, err)
+	}
+	if block, err := archive.GetLatestAccountBlock(contract); err != nil || block.Height != 1 {
+		t.Fatalf("latest account block is %v, error: %v", block, err)
+	}
+	if blocks := archive.GetUnconfirmedBlocks(contract); len(blocks) != 0 {
+		t.Fatalf("there should be no unconfirmed blocks, got %d", len(blocks))
+	}
+
+	// the block confirmed after the height is not confirmed yet
+	if times, err := archive.GetConfirmedTimes(types.Hash{5}); err != nil || times != 3 {
+		t.Fatalf("confirmed times is %d, error: %v", times, err)
+	}
+	if times, err := archive.GetConfirmedTimes(types.Hash{10}); err != nil || times != 0 {
+		t.Fatalf("confirmed times is %d, error: %v", times, err)
+	}
+	if header, err := archive.GetConfirmSnapshotHeaderByAbHash(types.Hash{10}); err != nil || header != nil {
+		t.Fatalf("confirm header is %v, error: %v", header, err)
+	}
+
+	// the contract is not deployed at 4
+	archive, err = newArchiveChain(c, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := archive.IsContractAccount(contract); err != nil || ok {
+		t.Fatalf("%s should not be a contract at 4, error: %v", contract, err)
+	}
+	if code, err := archive.GetContractCode(contract); err != nil || code != nil {
+		t.Fatalf("code at 4 is %s, error: %v", code, err)
+	}
+	if ok, err := archive.IsContractAccount(types.AddressQuota); err != nil || !ok {
+		t.Fatalf("builtin contract should be a contract, error: %v", err)
+	}
+}
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrStrToBigInt                    = errors.New("convert to big.Int failed")
 	ErrPoWNotSupportedUnderCongestion = errors.New("PoW service not supported")
+	ErrSnapshotBlockNotExist          = errors.New("snapshot block doesn't exist")
 )
@@ -121,18 +121,20 @@ func (c *ContractApi) CallOffChainMethod(param CallOffChainMethodParam) ([]byte,
 	if err != nil {
 		return nil, err
 	}
-	var codeBytes []byte
+	codeBytes, err := param.codeBytes()
+	if err != nil {
+		return nil, err
+	}
+	return vm.NewVM(nil).OffChainReader(db, codeBytes, param.Data)
+}
+
+func (param CallOffChainMethodParam) codeBytes() ([]byte, error) {
 	if len(param.OffChainCode) > 0 {
-		codeBytes, err = hex.DecodeString(param.OffChainCode)
-		if err != nil {
-			return nil, err
-		}
+		return hex.DecodeString(param.OffChainCode)
 	} else if len(param.OffChainCodeBytes) > 0 {
-		codeBytes = param.OffChainCodeBytes
-	} else {
-		codeBytes = param.Code
+		return param.OffChainCodeBytes, nil
 	}
-	return vm.NewVM(nil).OffChainReader(db, codeBytes, param.Data)
+	return param.Code, nil
 }
 
 func (c *ContractApi) GetContractStorage(addr types.Address, prefix string) (map[string]string, error) {
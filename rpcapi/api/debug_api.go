@@ -5,12 +5,14 @@ import (
 	"errors"
 	"math/big"
 	"runtime/debug"
+	"strconv"
 	"time"
 
 	"github.com/vitelabs/go-vite/common/fork"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vite"
 )
 
@@ -240,3 +242,41 @@ func (api DebugApi) UpdateOnRoadInfo(addr types.Address, tkId types.TokenTypeId,
 func (api DebugApi) ClearOnRoadUnconfirmedCache(addr types.Address, hashList []*types.Hash) error {
 	return api.v.Chain().ClearOnRoadUnconfirmedCache(addr, hashList)
 }
+
+// SetLogLevel changes the log level of module at runtime, e.g. "net" or "pool/tree", the level is one of
+// debug, info, warn, error and crit. An empty level resets the module to the default log level.
+func (api DebugApi) SetLogLevel(module string, level string) error {
+	if module == "" {
+		return errors.New("module is empty")
+	}
+	if level == "" {
+		log15.ResetModuleLvl(module)
+		return nil
+	}
+	lvl, err := log15.LvlFromString(level)
+	if err != nil {
+		return err
+	}
+	log15.SetModuleLvl(module, lvl)
+	return nil
+}
+
+// GetLogLevels returns the log levels of the modules which are changed by SetLogLevel or the config
+func (api DebugApi) GetLogLevels() map[string]string {
+	lvls := log15.ModuleLvls()
+	result := make(map[string]string, len(lvls))
+	for module, lvl := range lvls {
+		result[module] = lvl.String()
+	}
+	return result
+}
+
+// GetStateCheckpoint computes the state manifest at the snapshot height, and returns the checkpoint like
+// "height-snapshotHash-stateRoot" for StateSyncCheckpoint. It iterates the whole state and takes a while.
+func (api DebugApi) GetStateCheckpoint(height uint64) (string, error) {
+	manifest, err := api.v.Chain().GetStateManifest(height)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(manifest.Block.Height, 10) + "-" + manifest.Block.Hash.String() + "-" + manifest.Root.String(), nil
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+var (
+	ErrTraceNotContractReceive = errors.New("only contract receive blocks can be traced")
+	ErrTraceStateUnavailable   = errors.New("state before the block has been pruned, enable ArchiveMode to trace old blocks")
+)
+
+type TraceCallParam struct {
+	SelfAddr types.Address      `json:"address"`
+	ToAddr   types.Address      `json:"toAddress"`
+	TokenId  *types.TokenTypeId `json:"tokenId"`
+	Amount   *string            `json:"amount"`
+	Data     []byte             `json:"data"`
+}
+
+// TraceAccountBlock re-executes a contract receive block upon the state it was generated with
+// and returns every opcode step in struct-logger format. Quota is calculated with the current
+// stake, so quota related failures may differ from the original execution.
+func (api DebugApi) TraceAccountBlock(hash types.Hash, cfg *vm.StructLoggerConfig) (*vm.ExecutionResult, error) {
+	c := api.v.Chain()
+	block, err := c.GetAccountBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New(fmt.Sprintf("account block %v doesn't exist", hash))
+	}
+	if !block.IsReceiveBlock() || !types.IsContractAddr(block.AccountAddress) {
+		return nil, ErrTraceNotContractReceive
+	}
+	sendBlock, err := c.GetAccountBlockByHash(block.FromBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if sendBlock == nil {
+		return nil, errors.New(fmt.Sprintf("send block %v doesn't exist", block.FromBlockHash))
+	}
+
+	// the state before the block is the state at the previous snapshot block plus
+	// the blocks of the account confirmed by the same snapshot block
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	redoHeight, baseHeight := latestHeight+1, latestHeight
+	confirmSb, err := c.GetConfirmSnapshotHeaderByAbHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if confirmSb != nil {
+		redoHeight, baseHeight = confirmSb.Height, confirmSb.Height-1
+	}
+	_, _, stateDB := c.DBs()
+	snapshotLog, ok, err := stateDB.Redo().QueryLog(redoHeight)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrTraceStateUnavailable
+	}
+	baseSb, err := c.GetSnapshotBlockByHeight(baseHeight)
+	if err != nil {
+		return nil, err
+	}
+	if baseSb == nil {
+		return nil, ErrSnapshotBlockNotExist
+	}
+
+	db, err := vm_db.NewVmDb(&archiveChain{Chain: c, snapshotHeight: baseHeight}, &block.AccountAddress, &baseSb.Hash, &block.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range snapshotLog[block.AccountAddress] {
+		if item.Height >= block.Height {
+			continue
+		}
+		for _, kv := range item.Storage {
+			if err := db.SetValue(kv[0], kv[1]); err != nil {
+				return nil, err
+			}
+		}
+		for tokenId, balance := range item.BalanceMap {
+			tokenId := tokenId
+			db.SetBalance(&tokenId, balance)
+		}
+	}
+
+	status, err := generator.NewReceiveVMGlobalStatus(c, baseSb, block.AccountAddress, sendBlock.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return api.trace(db, block, sendBlock, status, cfg)
+}
+
+// TraceCall simulates calling a contract upon the latest state and returns every opcode step in
+// struct-logger format. Nothing is written to the ledger.
+func (api DebugApi) TraceCall(param TraceCallParam, cfg *vm.StructLoggerConfig) (*vm.ExecutionResult, error) {
+	if !types.IsContractAddr(param.ToAddr) {
+		return nil, ErrTraceNotContractReceive
+	}
+	amount := big.NewInt(0)
+	if param.Amount != nil {
+		var err error
+		if amount, err = stringToBigInt(param.Amount); err != nil {
+			return nil, err
+		}
+	}
+	tokenId := ledger.ViteTokenId
+	if param.TokenId != nil {
+		tokenId = *param.TokenId
+	}
+	c := api.v.Chain()
+	latestSb := c.GetLatestSnapshotBlock()
+	prevBlock, err := c.GetLatestAccountBlock(param.ToAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	sendBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: param.SelfAddr,
+		ToAddress:      param.ToAddr,
+		Amount:         amount,
+		TokenId:        tokenId,
+		Fee:            big.NewInt(0),
+		Data:           param.Data,
+	}
+	sendBlock.Hash = sendBlock.ComputeHash()
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: param.ToAddr,
+		FromBlockHash:  sendBlock.Hash,
+		Height:         1,
+	}
+	if prevBlock != nil {
+		block.Height = prevBlock.Height + 1
+		block.PrevHash = prevBlock.Hash
+	}
+
+	db, err := vm_db.NewVmDb(c, &param.ToAddr, &latestSb.Hash, &block.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	return api.trace(db, block, sendBlock, generator.NewVMGlobalStatus(c, latestSb, sendBlock.Hash), cfg)
+}
+
+func (api DebugApi) trace(db vm_db.VmDb, block, sendBlock *ledger.AccountBlock, status *generator.VMGlobalStatus, cfg *vm.StructLoggerConfig) (result *vm.ExecutionResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = errors.New(fmt.Sprintf("trace account block panic, %v", r))
+		}
+	}()
+	tracer := vm.NewStructLogger(cfg)
+	v := vm.NewVM(util.NewVMConsensusReader(api.v.Consensus().SBPReader()))
+	v.SetTracer(tracer)
+	var runErr error
+	if status == nil {
+		_, _, runErr = v.RunV2(db, block, sendBlock, nil)
+	} else {
+		_, _, runErr = v.RunV2(db, block, sendBlock, status)
+	}
+	result = tracer.Result()
+	if runErr != nil && !result.Failed {
+		result.Failed = true
+		result.Error = runErr.Error()
+	}
+	return result, nil
+}
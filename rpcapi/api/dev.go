@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"time"
+
+	"github.com/vitelabs/go-vite/producer"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+// DevApi controls the snapshot chain of a local development network, see `gvite devnet`
+type DevApi struct {
+	producer producer.Producer
+}
+
+func NewDevApi(vite *vite.Vite) *DevApi {
+	return &DevApi{
+		producer: vite.Producer(),
+	}
+}
+
+func (d DevApi) String() string {
+	return "DevApi"
+}
+
+// MineSnapshot produces a snapshot block at once, which snapshots all unconfirmed account blocks
+func (d DevApi) MineSnapshot() (*SnapshotBlock, error) {
+	if d.producer == nil {
+		return nil, errors.New("producer is not enabled")
+	}
+	block, err := d.producer.MineSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return ledgerSnapshotBlockToRpcBlock(block)
+}
+
+// SetTime moves the clock of the snapshot chain to timestamp in seconds, then mines a snapshot block at the time.
+// The clock can't go back before the latest snapshot block.
+func (d DevApi) SetTime(timestamp int64) (*SnapshotBlock, error) {
+	if d.producer == nil {
+		return nil, errors.New("producer is not enabled")
+	}
+	if err := d.producer.SetTime(time.Unix(timestamp, 0)); err != nil {
+		return nil, err
+	}
+	return d.MineSnapshot()
+}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/plugins"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
@@ -12,6 +14,7 @@ import (
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
 	"github.com/vitelabs/go-vite/vm_db"
+	"math"
 	"math/big"
 	"strconv"
 )
@@ -350,6 +353,61 @@ func (f DexApi) GetOrdersForMarket(tradeToken, quoteToken types.TokenTypeId, sid
 	}
 }
 
+// GetTrades returns the latest trades of the market in [startTime, endTime), the newest first, endTime 0 means now
+func (f DexApi) GetTrades(tradeToken, quoteToken types.TokenTypeId, startTime, endTime int64, limit int) ([]*chain_plugins.DexTrade, error) {
+	dexMarket, marketId, err := f.getDexMarket(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	return dexMarket.GetTrades(marketId, startTime, dexQueryEndTime(endTime), limit)
+}
+
+// GetKlines returns the latest candles of the market in [startTime, endTime) in ascending order of time,
+// interval is one of 1m, 5m, 15m, 30m, 1h, 4h and 1d
+func (f DexApi) GetKlines(tradeToken, quoteToken types.TokenTypeId, interval string, startTime, endTime int64, limit int) ([]*chain_plugins.DexKline, error) {
+	dexMarket, marketId, err := f.getDexMarket(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	return dexMarket.GetKlines(marketId, interval, startTime, dexQueryEndTime(endTime), limit)
+}
+
+// GetDepth returns the aggregated price levels of the order book of the market
+func (f DexApi) GetDepth(tradeToken, quoteToken types.TokenTypeId, limit int) (*chain_plugins.DexDepth, error) {
+	dexMarket, marketId, err := f.getDexMarket(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	return dexMarket.GetDepth(marketId, limit)
+}
+
+func (f DexApi) getDexMarket(tradeToken, quoteToken types.TokenTypeId) (*chain_plugins.DexMarket, int32, error) {
+	plugins := f.chain.Plugins()
+	if plugins == nil {
+		return nil, 0, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	dexMarket, ok := plugins.GetPlugin("dexMarket").(*chain_plugins.DexMarket)
+	if !ok {
+		return nil, 0, errors.New("plugins-DexMarket's service not provided")
+	}
+	fundDb, err := getVmDb(f.chain, types.AddressDexFund)
+	if err != nil {
+		return nil, 0, err
+	}
+	marketInfo, ok := dex.GetMarketInfo(fundDb, tradeToken, quoteToken)
+	if !ok {
+		return nil, 0, dex.TradeMarketNotExistsErr
+	}
+	return dexMarket, marketInfo.MarketId, nil
+}
+
+func dexQueryEndTime(endTime int64) int64 {
+	if endTime <= 0 {
+		return math.MaxInt64
+	}
+	return endTime
+}
+
 func (f DexApi) GetVIPStakeInfoList(address types.Address, pageIndex int, pageSize int) (*apidex.StakeInfoList, error) {
 	db, err := getVmDb(f.chain, types.AddressDexFund)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"github.com/vitelabs/go-vite/chain"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/vm/contracts/dex/proto"
 	"math/big"
 )
 
@@ -427,6 +428,44 @@ func OrdersToRpc(orders []*dex.Order) []*RpcOrder {
 	}
 }
 
+type RpcOrderTx struct {
+	Id               string `json:"Id"`
+	MarketId         int32  `json:"MarketId"`
+	TakerSide        bool   `json:"TakerSide"`
+	TakerId          string `json:"TakerId"`
+	MakerId          string `json:"MakerId"`
+	Price            string `json:"Price"`
+	Quantity         string `json:"Quantity"`
+	Amount           string `json:"Amount"`
+	TakerFee         string `json:"TakerFee"`
+	MakerFee         string `json:"MakerFee"`
+	TakerOperatorFee string `json:"TakerOperatorFee"`
+	MakerOperatorFee string `json:"MakerOperatorFee"`
+	Timestamp        int64  `json:"Timestamp"`
+}
+
+func OrderTxToRpc(tx *dexproto.Transaction) *RpcOrderTx {
+	if tx == nil {
+		return nil
+	}
+	marketId, _, _, _, _ := dex.DeComposeOrderId(tx.TakerId)
+	return &RpcOrderTx{
+		Id:               hex.EncodeToString(tx.Id),
+		MarketId:         marketId,
+		TakerSide:        tx.TakerSide,
+		TakerId:          hex.EncodeToString(tx.TakerId),
+		MakerId:          hex.EncodeToString(tx.MakerId),
+		Price:            dex.BytesToPrice(tx.Price),
+		Quantity:         AmountBytesToString(tx.Quantity),
+		Amount:           AmountBytesToString(tx.Amount),
+		TakerFee:         AmountBytesToString(tx.TakerFee),
+		MakerFee:         AmountBytesToString(tx.MakerFee),
+		TakerOperatorFee: AmountBytesToString(tx.TakerOperatorFee),
+		MakerOperatorFee: AmountBytesToString(tx.MakerOperatorFee),
+		Timestamp:        tx.Timestamp,
+	}
+}
+
 type StakeInfoList struct {
 	StakeAmount string       `json:"totalStakeAmount"`
 	Count       int          `json:"totalStakeCount"`
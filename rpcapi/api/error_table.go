@@ -196,6 +196,16 @@ var (
 		Code:    -37013,
 	}
 
+	// -38001 ~ -38999 ledger_sendRawTransactions
+	ErrSendRawTxPrevBlockFailed = JsonRpc2Error{
+		Message: "a previous block of the account in the batch failed",
+		Code:    -38001,
+	}
+	ErrSendRawTxNotSent = JsonRpc2Error{
+		Message: "not sent because another block in the batch failed the check",
+		Code:    -38002,
+	}
+
 	concernedErrorMap map[string]JsonRpc2Error
 )
 
@@ -245,6 +255,9 @@ func init() {
 	concernedErrorMap[ErrDexTradeMarketInvalidTokenPair.Error()] = ErrDexTradeMarketInvalidTokenPair
 	concernedErrorMap[ErrDexFundUserNotExists.Error()] = ErrDexFundUserNotExists
 
+	concernedErrorMap[ErrSendRawTxPrevBlockFailed.Error()] = ErrSendRawTxPrevBlockFailed
+	concernedErrorMap[ErrSendRawTxNotSent.Error()] = ErrSendRawTxNotSent
+
 }
 
 func TryMakeConcernedError(err error) (newerr error, concerned bool) {
@@ -10,6 +10,7 @@ import (
 type AccountChainEvent struct {
 	BlockType     byte
 	FromBlockHash types.Hash
+	PrevHash      types.Hash
 	Hash          types.Hash
 	Height        uint64
 	Addr          types.Address
@@ -27,6 +28,7 @@ func NewAccountChainEvent(block *ledger.AccountBlock, logs []*ledger.VmLog) *Acc
 	ace := &AccountChainEvent{
 		BlockType:     block.BlockType,
 		FromBlockHash: block.FromBlockHash,
+		PrevHash:      block.PrevHash,
 		Hash:          block.Hash,
 		Height:        block.Height,
 		Addr:          block.AccountAddress,
@@ -0,0 +1,73 @@
+package filters
+
+import (
+	"context"
+	"errors"
+
+	"github.com/vitelabs/go-vite/consensus/sbpmonitor"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+var ErrSBPMonitorDisabled = errors.New("the SBP monitor isn't enabled, set \"SBPMonitorEnabled\" to true in node_config.json")
+
+// ConsensusApi provides the missed slots and the success rates of the SBPs tracked by the SBP monitor
+type ConsensusApi struct {
+	monitor *sbpmonitor.Monitor
+	log     log15.Logger
+}
+
+func NewConsensusApi(vite *vite.Vite) *ConsensusApi {
+	return &ConsensusApi{
+		monitor: vite.SBPMonitor(),
+		log:     log15.New("module", "rpc_api/consensus_api"),
+	}
+}
+
+func (c ConsensusApi) String() string {
+	return "ConsensusApi"
+}
+
+// GetProducerStats returns the success rates of the SBPs in the last rounds
+func (c ConsensusApi) GetProducerStats() ([]*sbpmonitor.ProducerStat, error) {
+	if c.monitor == nil {
+		return nil, ErrSBPMonitorDisabled
+	}
+	return c.monitor.ProducerStats(), nil
+}
+
+// SubscribeProducerEvents pushes the missed slots, the finished rounds and the producer changes of the snapshot chain
+func (c ConsensusApi) SubscribeProducerEvents(ctx context.Context) (*rpc.Subscription, error) {
+	if c.monitor == nil {
+		return nil, ErrSBPMonitorDisabled
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	eventCh := make(chan *sbpmonitor.ProducerEvent, 128)
+	id := c.monitor.SubscribeProducerEvents(func(e *sbpmonitor.ProducerEvent) {
+		select {
+		case eventCh <- e:
+		default:
+			c.log.Warn("the subscriber is too slow, drop the producer event", "id", rpcSub.ID, "type", e.Type)
+		}
+	})
+	go func() {
+		defer c.monitor.UnsubscribeProducerEvents(id)
+		for {
+			select {
+			case e := <-eventCh:
+				notifier.Notify(rpcSub.ID, e)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -0,0 +1,176 @@
+package filters
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/vm/contracts/dex/proto"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+var (
+	dexNewOrderTopic    = dex.NewOrderEvent{}.GetTopicId()
+	dexOrderUpdateTopic = dex.OrderUpdateEvent{}.GetTopicId()
+	dexTxTopic          = dex.TransactionEvent{}.GetTopicId()
+)
+
+// DexEvent is a decoded vm log of the dex trade contract. Order is set for new orders and order updates,
+// an order update only carries the fields in dex.OrderUpdateEvent and the owner if it's resolved. Tx is
+// set for trades.
+type DexEvent struct {
+	MarketId  int32
+	Order     *dex.Order
+	Tx        *dexproto.Transaction
+	BlockHash types.Hash
+	Removed   bool
+}
+
+// decodeDexEvents decodes the order and trade logs of an account block of the dex trade contract
+func decodeDexEvents(e *AccountChainEvent, removed bool) []*DexEvent {
+	if e.Addr != types.AddressDexTrade || len(e.Logs) == 0 {
+		return nil
+	}
+	var events []*DexEvent
+	for _, l := range e.Logs {
+		if len(l.Topics) == 0 {
+			continue
+		}
+		event := &DexEvent{BlockHash: e.Hash, Removed: removed}
+		switch l.Topics[0] {
+		case dexNewOrderTopic:
+			newOrder, ok := dex.NewOrderEvent{}.FromBytes(l.Data).(dex.NewOrderEvent)
+			if !ok || newOrder.Order == nil {
+				continue
+			}
+			event.Order = &dex.Order{Order: *newOrder.Order}
+		case dexOrderUpdateTopic:
+			update, ok := dex.OrderUpdateEvent{}.FromBytes(l.Data).(dex.OrderUpdateEvent)
+			if !ok {
+				continue
+			}
+			event.Order = orderUpdateToOrder(&update.OrderUpdateInfo)
+		case dexTxTopic:
+			tx, ok := dex.TransactionEvent{}.FromBytes(l.Data).(dex.TransactionEvent)
+			if !ok {
+				continue
+			}
+			event.Tx = &tx.Transaction
+		default:
+			continue
+		}
+
+		orderId := event.orderId()
+		marketId, side, price, timestamp, err := dex.DeComposeOrderId(orderId)
+		if err != nil {
+			continue
+		}
+		event.MarketId = marketId
+		if event.Order != nil && len(event.Order.Price) == 0 {
+			event.Order.MarketId = marketId
+			event.Order.Side = side
+			event.Order.Price = price
+			event.Order.Timestamp = timestamp
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func orderUpdateToOrder(info *dexproto.OrderUpdateInfo) *dex.Order {
+	order := &dex.Order{}
+	order.Id = info.Id
+	order.Status = info.Status
+	order.CancelReason = info.CancelReason
+	order.ExecutedQuantity = info.ExecutedQuantity
+	order.ExecutedAmount = info.ExecutedAmount
+	order.ExecutedBaseFee = info.ExecutedBaseFee
+	order.ExecutedOperatorFee = info.ExecutedOperatorFee
+	order.RefundToken = info.RefundToken
+	order.RefundQuantity = info.RefundQuantity
+	return order
+}
+
+func (e *DexEvent) orderId() []byte {
+	if e.Order != nil {
+		return e.Order.Id
+	}
+	return e.Tx.TakerId
+}
+
+func (es *EventSystem) handleDexEvent(filters map[FilterType]map[rpc.ID]*subscription, acEvent []*AccountChainEvent, removed bool) {
+	if len(filters[DexOrderBookSubscription]) == 0 && len(filters[DexTradesSubscription]) == 0 && len(filters[DexAccountOrdersSubscription]) == 0 {
+		return
+	}
+	var events []*DexEvent
+	for _, e := range acEvent {
+		blockEvents := decodeDexEvents(e, removed)
+		if len(blockEvents) > 0 && len(filters[DexAccountOrdersSubscription]) > 0 {
+			es.resolveDexOrderAddress(e, blockEvents, removed)
+		}
+		events = append(events, blockEvents...)
+	}
+	if len(events) == 0 {
+		return
+	}
+
+	for _, f := range filters[DexOrderBookSubscription] {
+		if matched := filterDexEvents(events, func(e *DexEvent) bool { return e.Order != nil && e.MarketId == f.marketId }); len(matched) > 0 {
+			f.dexEventCh <- matched
+		}
+	}
+	for _, f := range filters[DexTradesSubscription] {
+		if matched := filterDexEvents(events, func(e *DexEvent) bool { return e.Tx != nil && e.MarketId == f.marketId }); len(matched) > 0 {
+			f.dexEventCh <- matched
+		}
+	}
+	for _, f := range filters[DexAccountOrdersSubscription] {
+		addr := f.addr.Bytes()
+		if matched := filterDexEvents(events, func(e *DexEvent) bool {
+			return e.Order != nil && string(e.Order.Address) == string(addr)
+		}); len(matched) > 0 {
+			f.dexEventCh <- matched
+		}
+	}
+}
+
+// resolveDexOrderAddress sets the owners of the updated orders, which are read from the storage of the
+// dex trade contract before the block. The storage of a removed block is gone, the orders are read from
+// the latest storage which is rolled back to the state before the block.
+func (es *EventSystem) resolveDexOrderAddress(e *AccountChainEvent, events []*DexEvent, removed bool) {
+	c := es.vite.Chain()
+	prevHash := e.PrevHash
+	if removed {
+		latest, err := c.GetLatestAccountBlock(types.AddressDexTrade)
+		if err != nil || latest == nil {
+			return
+		}
+		prevHash = latest.Hash
+	}
+	var matcher *dex.Matcher
+	for _, event := range events {
+		if event.Order == nil || len(event.Order.Address) > 0 {
+			continue
+		}
+		if matcher == nil {
+			db, err := vm_db.NewVmDb(c, &types.AddressDexTrade, &c.GetLatestSnapshotBlock().Hash, &prevHash)
+			if err != nil {
+				es.log.Error("new vm db failed when resolving dex order address", "hash", e.Hash, "err", err)
+				return
+			}
+			matcher = dex.NewRawMatcher(db)
+		}
+		if order, err := matcher.GetOrderById(event.Order.Id); err == nil {
+			event.Order.Address = order.Address
+		}
+	}
+}
+
+func filterDexEvents(events []*DexEvent, match func(*DexEvent) bool) []*DexEvent {
+	var matched []*DexEvent
+	for _, e := range events {
+		if match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
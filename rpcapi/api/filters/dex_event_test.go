@@ -0,0 +1,85 @@
+package filters
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/vm/contracts/dex/proto"
+)
+
+func newDexTestLog(t *testing.T, topic types.Hash, msg proto.Message) *ledger.VmLog {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &ledger.VmLog{Topics: []types.Hash{topic}, Data: data}
+}
+
+func TestDecodeDexEvents(t *testing.T) {
+	// market 1, sell, price 1.5
+	makerId := make([]byte, dex.OrderIdBytesLength)
+	makerId[2], makerId[3] = 1, 1
+	copy(makerId[4:14], dex.PriceToBytes("1.5"))
+	// market 1, buy, price 1.5
+	takerId := make([]byte, dex.OrderIdBytesLength)
+	takerId[2] = 1
+	copy(takerId[4:14], dex.PriceToBytes("1.5"))
+	dex.BitwiseNotBytes(takerId[4:14])
+
+	addr := types.AddressDexTrade
+	e := &AccountChainEvent{
+		Addr: types.AddressDexTrade,
+		Logs: []*ledger.VmLog{
+			newDexTestLog(t, dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{
+				Id: takerId, Address: addr.Bytes(), MarketId: 1, Price: dex.PriceToBytes("1.5"), Status: dex.FullyExecuted, Quantity: big.NewInt(10).Bytes(),
+			}}),
+			newDexTestLog(t, dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{
+				Id: makerId, Status: dex.PartialExecuted, ExecutedQuantity: big.NewInt(10).Bytes(),
+			}),
+			newDexTestLog(t, dexTxTopic, &dexproto.Transaction{
+				TakerId: takerId, MakerId: makerId, Price: dex.PriceToBytes("1.5"), Quantity: big.NewInt(10).Bytes(),
+			}),
+			{Topics: []types.Hash{{1}}},
+		},
+	}
+
+	events := decodeDexEvents(e, false)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.MarketId != 1 {
+			t.Fatalf("unexpected market id %d", event.MarketId)
+		}
+	}
+	if events[0].Order == nil || string(events[0].Order.Address) != string(addr.Bytes()) {
+		t.Fatalf("unexpected new order %+v", events[0].Order)
+	}
+	update := events[1].Order
+	if update == nil || !update.Side || dex.BytesToPrice(update.Price) != "1.5" || update.Status != dex.PartialExecuted {
+		t.Fatalf("unexpected order update %+v", update)
+	}
+	if events[2].Tx == nil || events[2].Order != nil {
+		t.Fatalf("unexpected tx event %+v", events[2])
+	}
+
+	e.Addr = types.AddressDexFund
+	if events := decodeDexEvents(e, false); len(events) != 0 {
+		t.Fatalf("logs of other contracts should be ignored")
+	}
+}
+
+func TestSplitRemovedDexEvents(t *testing.T) {
+	events := []*DexEvent{{Removed: true}, {Removed: true}, {}, {Removed: true}}
+	groups := splitRemovedDexEvents(events)
+	if len(groups) != 3 || len(groups[0]) != 2 || len(groups[1]) != 1 || len(groups[2]) != 1 {
+		t.Fatalf("unexpected groups %v", groups)
+	}
+	if len(splitRemovedDexEvents(nil)) != 0 {
+		t.Fatalf("unexpected groups of no events")
+	}
+}
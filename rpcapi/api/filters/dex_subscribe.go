@@ -0,0 +1,274 @@
+package filters
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	apidex "github.com/vitelabs/go-vite/rpcapi/api/dex"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	defaultDexBookDepth = 50
+	maxDexBookDepth     = 500
+	dexRecentTradeCount = 50
+)
+
+// DexSubscribeApi pushes decoded orders and trades of the dex markets. Every message has a sequence
+// which is strictly increasing in a subscription, the first message is a snapshot.
+type DexSubscribeApi struct {
+	vite        *vite.Vite
+	chain       chain.Chain
+	log         log15.Logger
+	eventSystem *EventSystem
+}
+
+func NewDexSubscribeApi(vite *vite.Vite) *DexSubscribeApi {
+	if Es == nil {
+		panic("Set \"SubscribeEnabled\" to \"true\" in node_config.json")
+	}
+	return &DexSubscribeApi{
+		vite:        vite,
+		chain:       vite.Chain(),
+		log:         log15.New("module", "rpc_api/dex_subscribe_api"),
+		eventSystem: Es,
+	}
+}
+
+func (s DexSubscribeApi) String() string {
+	return "DexSubscribeApi"
+}
+
+// DexOrderBookMsg is a message of an order book subscription. A snapshot carries the best orders of both
+// sides and replaces the book of the client. An update carries the new orders and the updated orders of
+// the market, an updated order only has the changed fields and its remaining quantity is its quantity
+// minus ExecutedQuantity, it leaves the book unless its status is pending or partially executed.
+type DexOrderBookMsg struct {
+	Sequence   string             `json:"sequence"`
+	Snapshot   bool               `json:"snapshot"`
+	SellOrders []*apidex.RpcOrder `json:"sellOrders,omitempty"`
+	BuyOrders  []*apidex.RpcOrder `json:"buyOrders,omitempty"`
+	Orders     []*apidex.RpcOrder `json:"orders,omitempty"`
+}
+
+// DexTradesMsg is a message of a trade subscription. The snapshot carries the recent trades if the
+// dexMarket plugin is enabled, Removed is set for the trades of rolled back blocks.
+type DexTradesMsg struct {
+	Sequence     string                    `json:"sequence"`
+	Snapshot     bool                      `json:"snapshot"`
+	RecentTrades []*chain_plugins.DexTrade `json:"recentTrades,omitempty"`
+	Trades       []*apidex.RpcOrderTx      `json:"trades,omitempty"`
+	Removed      bool                      `json:"removed"`
+}
+
+// DexAccountOrdersMsg is a message of an account order subscription, Removed is set for the orders of
+// rolled back blocks. The trade contract keeps no index of orders by address, so the snapshot is empty and
+// only marks the start of the subscription.
+type DexAccountOrdersMsg struct {
+	Sequence string             `json:"sequence"`
+	Snapshot bool               `json:"snapshot"`
+	Orders   []*apidex.RpcOrder `json:"orders,omitempty"`
+	Removed  bool               `json:"removed"`
+}
+
+// SubscribeOrderBook pushes a snapshot of the order book of the market with at most depth orders of each
+// side, then the changes of the book. A new snapshot is pushed when blocks of the market are rolled back.
+func (s *DexSubscribeApi) SubscribeOrderBook(ctx context.Context, tradeToken, quoteToken types.TokenTypeId, depth int) (*rpc.Subscription, error) {
+	marketInfo, err := s.getMarketInfo(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	if depth <= 0 {
+		depth = defaultDexBookDepth
+	} else if depth > maxDexBookDepth {
+		depth = maxDexBookDepth
+	}
+	return s.subscribe(ctx, marketInfo.MarketId, types.Address{}, DexOrderBookSubscription, func(notify func(interface{}), events []*DexEvent) error {
+		if events == nil || events[0].Removed {
+			return s.notifyOrderBookSnapshot(notify, marketInfo, depth)
+		}
+		notify(&DexOrderBookMsg{Orders: dexEventsToRpcOrders(events)})
+		return nil
+	})
+}
+
+// SubscribeTrades pushes the trades of the market
+func (s *DexSubscribeApi) SubscribeTrades(ctx context.Context, tradeToken, quoteToken types.TokenTypeId) (*rpc.Subscription, error) {
+	marketInfo, err := s.getMarketInfo(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.subscribe(ctx, marketInfo.MarketId, types.Address{}, DexTradesSubscription, func(notify func(interface{}), events []*DexEvent) error {
+		if events == nil {
+			msg := &DexTradesMsg{Snapshot: true}
+			if dexMarket := s.dexMarketPlugin(); dexMarket != nil {
+				trades, err := dexMarket.GetTrades(marketInfo.MarketId, 0, math.MaxInt64, dexRecentTradeCount)
+				if err != nil {
+					return err
+				}
+				msg.RecentTrades = trades
+			}
+			notify(msg)
+			return nil
+		}
+		trades := make([]*apidex.RpcOrderTx, len(events))
+		for i, e := range events {
+			trades[i] = apidex.OrderTxToRpc(e.Tx)
+		}
+		notify(&DexTradesMsg{Trades: trades, Removed: events[0].Removed})
+		return nil
+	})
+}
+
+// SubscribeAccountOrders pushes the new orders and the order updates of the address in all markets
+func (s *DexSubscribeApi) SubscribeAccountOrders(ctx context.Context, address types.Address) (*rpc.Subscription, error) {
+	return s.subscribe(ctx, 0, address, DexAccountOrdersSubscription, func(notify func(interface{}), events []*DexEvent) error {
+		if events == nil {
+			notify(&DexAccountOrdersMsg{Snapshot: true})
+			return nil
+		}
+		notify(&DexAccountOrdersMsg{Orders: dexEventsToRpcOrders(events), Removed: events[0].Removed})
+		return nil
+	})
+}
+
+// subscribe installs the subscription before the snapshot is taken, so no change after the snapshot is
+// lost. handle is called with nil events for the snapshot, the events of a call are either all removed
+// or all inserted.
+func (s *DexSubscribeApi) subscribe(ctx context.Context, marketId int32, addr types.Address, ft FilterType,
+	handle func(notify func(interface{}), events []*DexEvent) error) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		eventsMsg := make(chan []*DexEvent, 128)
+		sub := s.eventSystem.SubscribeDexEvents(marketId, addr, eventsMsg, ft)
+
+		sequence := uint64(0)
+		notify := func(msg interface{}) {
+			sequence++
+			seq := api.Uint64ToString(sequence)
+			switch m := msg.(type) {
+			case *DexOrderBookMsg:
+				m.Sequence = seq
+			case *DexTradesMsg:
+				m.Sequence = seq
+			case *DexAccountOrdersMsg:
+				m.Sequence = seq
+			}
+			notifier.Notify(rpcSub.ID, msg)
+		}
+
+		if err := handle(notify, nil); err != nil {
+			s.log.Error("push dex snapshot failed", "type", ft, "err", err)
+		}
+		for {
+			select {
+			case events := <-eventsMsg:
+				for _, group := range splitRemovedDexEvents(events) {
+					if err := handle(notify, group); err != nil {
+						s.log.Error("push dex events failed", "type", ft, "err", err)
+					}
+				}
+			case <-rpcSub.Err():
+				sub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+func (s *DexSubscribeApi) notifyOrderBookSnapshot(notify func(interface{}), marketInfo *dex.MarketInfo, depth int) error {
+	db, err := s.latestVmDb(types.AddressDexTrade)
+	if err != nil {
+		return err
+	}
+	matcher := dex.NewMatcherWithMarketInfo(db, marketInfo)
+	sells, _, err := matcher.GetOrdersFromMarket(true, 0, depth)
+	if err != nil {
+		return err
+	}
+	buys, _, err := matcher.GetOrdersFromMarket(false, 0, depth)
+	if err != nil {
+		return err
+	}
+	notify(&DexOrderBookMsg{Snapshot: true, SellOrders: apidex.OrdersToRpc(sells), BuyOrders: apidex.OrdersToRpc(buys)})
+	return nil
+}
+
+func (s *DexSubscribeApi) getMarketInfo(tradeToken, quoteToken types.TokenTypeId) (*dex.MarketInfo, error) {
+	db, err := s.latestVmDb(types.AddressDexFund)
+	if err != nil {
+		return nil, err
+	}
+	marketInfo, ok := dex.GetMarketInfo(db, tradeToken, quoteToken)
+	if !ok {
+		return nil, dex.TradeMarketNotExistsErr
+	}
+	return marketInfo, nil
+}
+
+func (s *DexSubscribeApi) latestVmDb(addr types.Address) (vm_db.VmDb, error) {
+	prevHash := types.Hash{}
+	block, err := s.chain.GetLatestAccountBlock(addr)
+	if err != nil {
+		return nil, err
+	}
+	if block != nil {
+		prevHash = block.Hash
+	}
+	sb := s.chain.GetLatestSnapshotBlock()
+	if sb == nil {
+		return nil, errors.New("latest snapshot block is nil")
+	}
+	return vm_db.NewVmDb(s.chain, &addr, &sb.Hash, &prevHash)
+}
+
+func (s *DexSubscribeApi) dexMarketPlugin() *chain_plugins.DexMarket {
+	plugins := s.chain.Plugins()
+	if plugins == nil {
+		return nil
+	}
+	dexMarket, _ := plugins.GetPlugin("dexMarket").(*chain_plugins.DexMarket)
+	return dexMarket
+}
+
+// splitRemovedDexEvents splits events into groups of consecutive inserted or removed events
+func splitRemovedDexEvents(events []*DexEvent) [][]*DexEvent {
+	var groups [][]*DexEvent
+	start := 0
+	for i := 1; i <= len(events); i++ {
+		if i == len(events) || events[i].Removed != events[start].Removed {
+			groups = append(groups, events[start:i])
+			start = i
+		}
+	}
+	return groups
+}
+
+func dexEventsToRpcOrders(events []*DexEvent) []*apidex.RpcOrder {
+	orders := make([]*apidex.RpcOrder, len(events))
+	for i, e := range events {
+		orders[i] = apidex.OrderToRpc(e.Order)
+		if len(e.Order.Address) == 0 {
+			orders[i].Address = ""
+		}
+	}
+	return orders
+}
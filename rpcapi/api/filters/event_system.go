@@ -25,6 +25,10 @@ const (
 	OnroadBlocksSubscriptionV2
 	SnapshotBlocksSubscription
 	SnapshotBlocksSubscriptionV2
+	DexOrderBookSubscription
+	DexTradesSubscription
+	DexAccountOrdersSubscription
+	PendingAccountBlocksSubscription
 )
 
 type subscription struct {
@@ -40,17 +44,22 @@ type subscription struct {
 	accountBlockWithHeightCh chan []*AccountBlockWithHeight
 	logsCh                   chan []*Logs
 	onroadMsgCh              chan []*OnroadMsg
+	marketId                 int32
+	dexEventCh               chan []*DexEvent
+	pendingBlockCh           chan []*PendingAccountBlock
 }
 
 type EventSystem struct {
 	vite      *vite.Vite
 	chain     *ChainSubscribe
+	pool      *PoolSubscribe
 	install   chan *subscription        // install filter
 	uninstall chan *subscription        // remove filter
 	acCh      chan []*AccountChainEvent // Channel to receive new account chain event
 	acDelCh   chan []*AccountChainEvent // Channel to receive new account chain delete event when account chain fork
 	sbCh      chan []*SnapshotChainEvent
 	sbDelCh   chan []*SnapshotChainEvent
+	pendingCh chan []*PendingAccountBlock
 	stop      chan struct{}
 	log       log15.Logger
 }
@@ -60,6 +69,7 @@ const (
 	acDelChanSize = 10
 	sbChanSize    = 10
 	sbDelChanSize = 10
+	pendingChSize = 100
 	installSize   = 10
 	uninstallSize = 10
 )
@@ -71,6 +81,7 @@ func NewEventSystem(v *vite.Vite) *EventSystem {
 		acDelCh:   make(chan []*AccountChainEvent, acDelChanSize),
 		sbCh:      make(chan []*SnapshotChainEvent, sbChanSize),
 		sbDelCh:   make(chan []*SnapshotChainEvent, sbDelChanSize),
+		pendingCh: make(chan []*PendingAccountBlock, pendingChSize),
 		install:   make(chan *subscription, installSize),
 		uninstall: make(chan *subscription, uninstallSize),
 		stop:      make(chan struct{}),
@@ -81,18 +92,20 @@ func NewEventSystem(v *vite.Vite) *EventSystem {
 
 func (es *EventSystem) Start() {
 	es.chain = NewChainSubscribe(es.vite, es)
+	es.pool = NewPoolSubscribe(es.vite, es)
 	go es.eventLoop()
 }
 
 func (es *EventSystem) Stop() {
 	close(es.stop)
 	es.chain.Stop()
+	es.pool.Stop()
 }
 
 func (es *EventSystem) eventLoop() {
 	es.log.Info("start event loop")
 	index := make(map[FilterType]map[rpc.ID]*subscription)
-	for i := LogsSubscription; i <= SnapshotBlocksSubscriptionV2; i++ {
+	for i := LogsSubscription; i <= PendingAccountBlocksSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
 	}
 
@@ -106,6 +119,8 @@ func (es *EventSystem) eventLoop() {
 			es.handleSbEvent(index, sbEvent, false)
 		case sbDelEvent := <-es.sbDelCh:
 			es.handleSbEvent(index, sbDelEvent, true)
+		case pendingEvent := <-es.pendingCh:
+			es.handlePendingEvent(index, pendingEvent)
 		case i := <-es.install:
 			es.log.Info("install ", "id", i.id)
 			index[i.typ][i.id] = i
@@ -246,6 +261,8 @@ func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscrip
 			f.logsCh <- logs
 		}
 	}
+	// handle dex events
+	es.handleDexEvent(filters, acEvent, removed)
 }
 
 func appendOnroadMsg(onroadMsgs map[types.Address][]*OnroadMsg, toAddr types.Address, hash types.Hash, closed, removed bool) map[types.Address][]*OnroadMsg {
@@ -268,9 +285,9 @@ func filterLogs(e *AccountChainEvent, filter *api.FilterParam, removed bool) []*
 			return nil
 		}
 	}
-	for _, l := range e.Logs {
+	for i, l := range e.Logs {
 		if api.FilterLog(filter, l) {
-			logs = append(logs, &Logs{l, e.Hash, api.Uint64ToString(e.Height), &e.Addr, removed})
+			logs = append(logs, &Logs{Log: l, AccountBlockHash: e.Hash, AccountHeight: api.Uint64ToString(e.Height), Addr: &e.Addr, Removed: removed, index: i})
 		}
 	}
 	return logs
@@ -299,6 +316,8 @@ func (s *RpcSubscription) Unsubscribe() {
 			case <-s.sub.logsCh:
 			case <-s.sub.snapshotBlockCh:
 			case <-s.sub.onroadMsgCh:
+			case <-s.sub.dexEventCh:
+			case <-s.sub.pendingBlockCh:
 			}
 		}
 		<-s.Err()
@@ -317,6 +336,8 @@ func (es *EventSystem) SubscribeAccountBlocks(ch chan []*AccountBlock) *RpcSubsc
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
 	}
 	return es.subscribe(sub)
 }
@@ -334,6 +355,8 @@ func (es *EventSystem) SubscribeAccountBlocksByAddr(addr types.Address, ch chan
 		accountBlockWithHeightCh: ch,
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
 	}
 	return es.subscribe(sub)
 }
@@ -351,6 +374,8 @@ func (es *EventSystem) SubscribeOnroadBlocksByAddr(addr types.Address, ch chan [
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              ch,
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
 	}
 	return es.subscribe(sub)
 }
@@ -367,6 +392,8 @@ func (es *EventSystem) SubscribeSnapshotBlocks(ch chan []*SnapshotBlock, ft Filt
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
 	}
 	return es.subscribe(sub)
 }
@@ -384,6 +411,46 @@ func (es *EventSystem) SubscribeLogs(p *api.FilterParam, ch chan []*Logs, ft Fil
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   ch,
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
+	}
+	return es.subscribe(sub)
+}
+
+func (es *EventSystem) SubscribeDexEvents(marketId int32, addr types.Address, ch chan []*DexEvent, ft FilterType) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      ft,
+		addr:                     addr,
+		marketId:                 marketId,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               ch,
+		pendingBlockCh:           make(chan []*PendingAccountBlock),
+	}
+	return es.subscribe(sub)
+}
+
+func (es *EventSystem) SubscribePendingAccountBlocks(ch chan []*PendingAccountBlock) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      PendingAccountBlocksSubscription,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		dexEventCh:               make(chan []*DexEvent),
+		pendingBlockCh:           ch,
 	}
 	return es.subscribe(sub)
 }
@@ -0,0 +1,87 @@
+package filters
+
+import (
+	"sync/atomic"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+// PendingAccountBlock is an account block which just entered the pool, it may be not inserted into the
+// chain yet. Local is set for the blocks sent to this node through rpc.
+type PendingAccountBlock struct {
+	Hash           types.Hash         `json:"hash"`
+	Height         string             `json:"height"`
+	BlockType      byte               `json:"blockType"`
+	AccountAddress types.Address      `json:"accountAddress"`
+	ToAddress      *types.Address     `json:"toAddress,omitempty"`
+	FromBlockHash  *types.Hash        `json:"fromBlockHash,omitempty"`
+	TokenId        *types.TokenTypeId `json:"tokenId,omitempty"`
+	Amount         *string            `json:"amount,omitempty"`
+	Local          bool               `json:"local"`
+}
+
+func NewPendingAccountBlock(block *ledger.AccountBlock, source types.BlockSource) *PendingAccountBlock {
+	msg := &PendingAccountBlock{
+		Hash:           block.Hash,
+		Height:         api.Uint64ToString(block.Height),
+		BlockType:      block.BlockType,
+		AccountAddress: block.AccountAddress,
+		Local:          source == types.Local,
+	}
+	if block.IsSendBlock() {
+		toAddress := block.ToAddress
+		tokenId := block.TokenId
+		msg.ToAddress = &toAddress
+		msg.TokenId = &tokenId
+		if block.Amount != nil {
+			amount := block.Amount.String()
+			msg.Amount = &amount
+		}
+	} else {
+		fromBlockHash := block.FromBlockHash
+		msg.FromBlockHash = &fromBlockHash
+	}
+	return msg
+}
+
+// pendingDropLogInterval is how often the dropped pending blocks are logged
+const pendingDropLogInterval = 100
+
+type PoolSubscribe struct {
+	vite    *vite.Vite
+	es      *EventSystem
+	subId   int
+	dropped uint64
+}
+
+func NewPoolSubscribe(v *vite.Vite, e *EventSystem) *PoolSubscribe {
+	p := &PoolSubscribe{vite: v, es: e}
+	p.subId = v.Pool().SubscribePendingAccountBlock(p.newPendingAccountBlock)
+	return p
+}
+
+func (p *PoolSubscribe) Stop() {
+	p.vite.Pool().UnsubscribePendingAccountBlock(p.subId)
+}
+
+// newPendingAccountBlock is called by the pool when it accepts a block, so it never blocks.
+// The block is dropped if the event loop falls behind.
+func (p *PoolSubscribe) newPendingAccountBlock(block *ledger.AccountBlock, source types.BlockSource) {
+	select {
+	case p.es.pendingCh <- []*PendingAccountBlock{NewPendingAccountBlock(block, source)}:
+	default:
+		if n := atomic.AddUint64(&p.dropped, 1); n%pendingDropLogInterval == 1 {
+			p.es.log.Warn("pending account blocks are dropped, the event loop falls behind", "hash", block.Hash, "dropped", n)
+		}
+	}
+}
+
+func (es *EventSystem) handlePendingEvent(filters map[FilterType]map[rpc.ID]*subscription, msgs []*PendingAccountBlock) {
+	for _, f := range filters[PendingAccountBlocksSubscription] {
+		f.pendingBlockCh <- msgs
+	}
+}
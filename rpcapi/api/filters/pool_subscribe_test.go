@@ -0,0 +1,27 @@
+package filters
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+func TestPoolSubscribe_NewPendingAccountBlock(t *testing.T) {
+	es := &EventSystem{pendingCh: make(chan []*PendingAccountBlock, 1), stop: make(chan struct{}), log: log15.New("module", "test")}
+	p := &PoolSubscribe{es: es}
+
+	// the event loop isn't running, the blocks beyond the channel size are dropped without blocking the pool
+	for i := 0; i < 3; i++ {
+		p.newPendingAccountBlock(&ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, Hash: types.Hash{byte(i)}}, types.Local)
+	}
+	if n := atomic.LoadUint64(&p.dropped); n != 2 {
+		t.Fatalf("%d blocks are dropped, should be 2", n)
+	}
+	msgs := <-es.pendingCh
+	if len(msgs) != 1 || msgs[0].Hash != (types.Hash{0}) || !msgs[0].Local {
+		t.Fatalf("unexpected pending blocks %v", msgs)
+	}
+}
@@ -123,6 +123,8 @@ type Logs struct {
 	AccountHeight    string         `json:"accountHeight"`
 	Addr             *types.Address `json:"addr"`
 	Removed          bool           `json:"removed"`
+
+	index int // index of the log in the vm log list of the account block
 }
 type LogsV2 struct {
 	Log              *ledger.VmLog  `json:"vmlog"`
@@ -130,6 +132,8 @@ type LogsV2 struct {
 	AccountHeight    string         `json:"accountBlockHeight"`
 	Addr             *types.Address `json:"address"`
 	Removed          bool           `json:"removed"`
+	LogIndex         string         `json:"logIndex"`
+	Sequence         string         `json:"sequence,omitempty"` // strictly increasing in a subscription, not set for filters
 }
 
 // Deprecated: use subscribe_createSnapshotBlockFilter instead
@@ -443,7 +447,7 @@ func (s *SubscribeApi) getChangesByFilterId(id rpc.ID) (interface{}, error) {
 			f.logs = nil
 			result := make([]*LogsV2, len(logs))
 			for i, l := range logs {
-				result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+				result[i] = &LogsV2{Log: l.Log, AccountBlockHash: l.AccountBlockHash, AccountHeight: l.AccountHeight, Addr: l.Addr, Removed: l.Removed, LogIndex: api.Uint64ToString(uint64(l.index))}
 			}
 			return LogsMsgV2{result, id}, nil
 		case SnapshotBlocksSubscription:
@@ -542,6 +546,36 @@ func (s *SubscribeApi) createAccountBlockSubscription(ctx context.Context) (*rpc
 	return rpcSub, nil
 }
 
+// NewPendingAccountBlocks pushes the account blocks as soon as they enter the pool, before they are
+// inserted into the chain and confirmed by snapshot blocks. A block in the pool may never be inserted.
+func (s *SubscribeApi) NewPendingAccountBlocks(ctx context.Context) (*rpc.Subscription, error) {
+	s.log.Info("NewPendingAccountBlocks")
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		pendingBlockCh := make(chan []*PendingAccountBlock, 128)
+		pendingSub := s.eventSystem.SubscribePendingAccountBlocks(pendingBlockCh)
+		for {
+			select {
+			case msgs := <-pendingBlockCh:
+				notifier.Notify(rpcSub.ID, msgs)
+			case <-rpcSub.Err():
+				pendingSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				pendingSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Deprecated: use subscribe_createAccountBlockSubscriptionByAddress instead
 func (s *SubscribeApi) NewAccountBlocksByAddr(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
 	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscription)
@@ -630,12 +664,20 @@ func (s *SubscribeApi) createUnreceivedBlockSubscriptionByAddress(ctx context.Co
 
 // Deprevated: use subscribe_createVmLogSubscription instead
 func (s *SubscribeApi) NewLogs(ctx context.Context, param RpcFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscription)
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, nil, LogsSubscription)
 }
-func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param api.VmLogFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscriptionV2)
+
+// CreateVmlogSubscription pushes vm logs matching the filter. If fromSnapshotHeight or fromAccountBlockHash
+// is set, logs after the checkpoint are replayed from the ledger before live logs, so a client can resume
+// from the last block it handled without gaps or duplicates.
+func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param VmLogSubscriptionParam) (*rpc.Subscription, error) {
+	checkpoint, err := s.toVmLogCheckpoint(param)
+	if err != nil {
+		return nil, err
+	}
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, checkpoint, LogsSubscriptionV2)
 }
-func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, checkpoint *vmLogCheckpoint, ft FilterType) (*rpc.Subscription, error) {
 	s.log.Info("createVmLogSubscription")
 	p, err := api.ToFilterParam(rangeMap, topics)
 	if err != nil {
@@ -650,19 +692,67 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 
 	go func() {
 		logsMsg := make(chan []*Logs, 128)
+		// subscribe before replaying, live logs are held back until the replay is done
 		sub := s.eventSystem.SubscribeLogs(p, logsMsg, ft)
 
+		sequence := uint64(0)
+		notify := func(msg []*Logs) {
+			if ft == LogsSubscriptionV2 {
+				result := make([]*LogsV2, len(msg))
+				for i, l := range msg {
+					sequence++
+					result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed, api.Uint64ToString(uint64(l.index)), api.Uint64ToString(sequence)}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, msg)
+			}
+		}
+
+		fail := func(err error) {
+			s.log.Error("vm log subscription failed", "err", err)
+			sub.Unsubscribe()
+			notifier.Fail(rpcSub.ID, err)
+		}
+
+		var replayCh chan []*Logs
+		var pending heldLogs
+		var replayed map[types.Hash]struct{}
+		var replayErr error
+		stopCh := make(chan struct{})
+		defer close(stopCh)
+		if checkpoint != nil {
+			replayCh = make(chan []*Logs, 16)
+			go func() {
+				defer close(replayCh)
+				replayed, replayErr = replayVmLogs(s.vite.Chain(), p, checkpoint, replayCh, stopCh)
+			}()
+		}
+
 		for {
 			select {
-			case msg := <-logsMsg:
-				if ft == LogsSubscriptionV2 {
-					result := make([]*LogsV2, len(msg))
-					for i, l := range msg {
-						result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+			case msg, ok := <-replayCh:
+				if ok {
+					notify(msg)
+					continue
+				}
+				replayCh = nil
+				if replayErr != nil {
+					fail(replayErr)
+					return
+				}
+				for _, msg := range pending.msgs {
+					if msg = skipReplayedLogs(msg, replayed); len(msg) > 0 {
+						notify(msg)
 					}
-					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, msg)
+				}
+				pending = heldLogs{}
+			case msg := <-logsMsg:
+				if replayCh == nil {
+					notify(msg)
+				} else if err := pending.add(msg); err != nil {
+					fail(err)
+					return
 				}
 
 			case <-rpcSub.Err():
@@ -685,7 +775,7 @@ func (s *SubscribeApi) GetLogs(param RpcFilterParam) ([]*Logs, error) {
 	}
 	resultList := make([]*Logs, len(logs))
 	for i, l := range logs {
-		resultList[i] = &Logs{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, false}
+		resultList[i] = &Logs{Log: l.Log, AccountBlockHash: l.AccountBlockHash, AccountHeight: l.AccountHeight, Addr: l.Addr}
 	}
 	return resultList, nil
 }
@@ -0,0 +1,205 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+const (
+	// maxReplaySnapshotBlocks limits how far back a vm log subscription can be resumed
+	maxReplaySnapshotBlocks = 100000
+	replayBatchSize         = 100
+
+	// maxHeldLogs limits the live logs held back during a replay, the subscription fails when it's exceeded
+	maxHeldLogs = 10000
+)
+
+var (
+	errReplayStopped   = errors.New("replay is stopped")
+	errTooManyHeldLogs = errors.New(fmt.Sprintf("more than %d live logs are held back during the replay, resubscribe from a later checkpoint", maxHeldLogs))
+)
+
+// replayChain is the part of the chain read by the replay
+type replayChain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+	GetAllUnconfirmedBlocks() []*ledger.AccountBlock
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+}
+
+// VmLogSubscriptionParam is the filter of a vm log subscription with an optional checkpoint.
+// Empty topics in a position match any topic. At most one of FromSnapshotHeight and
+// FromAccountBlockHash should be set:
+//   - FromSnapshotHeight replays logs of account blocks confirmed by snapshot blocks from the height on
+//   - FromAccountBlockHash replays logs of account blocks after the block, in the ledger order
+type VmLogSubscriptionParam struct {
+	api.VmLogFilterParam
+	FromSnapshotHeight   string      `json:"fromSnapshotHeight"`
+	FromAccountBlockHash *types.Hash `json:"fromAccountBlockHash"`
+}
+
+type vmLogCheckpoint struct {
+	fromHeight uint64      // the first snapshot height to replay
+	afterHash  *types.Hash // account blocks before and including it are skipped
+}
+
+func (s *SubscribeApi) toVmLogCheckpoint(param VmLogSubscriptionParam) (*vmLogCheckpoint, error) {
+	c := s.vite.Chain()
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	if param.FromAccountBlockHash != nil {
+		if len(param.FromSnapshotHeight) > 0 {
+			return nil, errors.New("fromSnapshotHeight and fromAccountBlockHash can't be set at the same time")
+		}
+		block, err := c.GetAccountBlockByHash(*param.FromAccountBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			return nil, errors.New(fmt.Sprintf("account block %v doesn't exist", param.FromAccountBlockHash))
+		}
+		confirmSb, err := c.GetConfirmSnapshotHeaderByAbHash(block.Hash)
+		if err != nil {
+			return nil, err
+		}
+		// an unconfirmed block is after all snapshot blocks
+		fromHeight := latestHeight + 1
+		if confirmSb != nil {
+			fromHeight = confirmSb.Height
+		}
+		if latestHeight-fromHeight+1 > maxReplaySnapshotBlocks {
+			return nil, errors.New(fmt.Sprintf("checkpoint is too old, at most %d snapshot blocks can be replayed", maxReplaySnapshotBlocks))
+		}
+		return &vmLogCheckpoint{fromHeight: fromHeight, afterHash: &block.Hash}, nil
+	}
+	if len(param.FromSnapshotHeight) > 0 {
+		fromHeight, err := api.StringToUint64(param.FromSnapshotHeight)
+		if err != nil {
+			return nil, err
+		}
+		if fromHeight == 0 || fromHeight > latestHeight+1 {
+			return nil, errors.New(fmt.Sprintf("fromSnapshotHeight should be in [1, %d]", latestHeight+1))
+		}
+		if latestHeight-fromHeight+1 > maxReplaySnapshotBlocks {
+			return nil, errors.New(fmt.Sprintf("checkpoint is too old, at most %d snapshot blocks can be replayed", maxReplaySnapshotBlocks))
+		}
+		return &vmLogCheckpoint{fromHeight: fromHeight}, nil
+	}
+	return nil, nil
+}
+
+// replayVmLogs sends the matched logs after the checkpoint to logsCh in the ledger order, confirmed
+// blocks first and then unconfirmed blocks. It returns the hashes of the replayed account blocks,
+// so that the live logs received during the replay can be deduplicated.
+// It fails if the checkpoint block is rolled back before it's reached.
+func replayVmLogs(c replayChain, p *api.FilterParam, cp *vmLogCheckpoint, logsCh chan<- []*Logs, stopCh <-chan struct{}) (map[types.Hash]struct{}, error) {
+	replayed := make(map[types.Hash]struct{})
+	// the genesis account blocks have no vm logs and are not replayed
+	skipping := cp.afterHash != nil && cp.fromHeight >= 2
+
+	replayBlocks := func(blocks []*ledger.AccountBlock) error {
+		for _, block := range blocks {
+			if skipping {
+				if block.Hash == *cp.afterHash {
+					skipping = false
+				}
+				continue
+			}
+			if _, ok := replayed[block.Hash]; ok {
+				continue
+			}
+			replayed[block.Hash] = struct{}{}
+			if block.LogHash == nil {
+				continue
+			}
+			logList, err := c.GetVmLogList(block.LogHash)
+			if err != nil {
+				return err
+			}
+			logs := filterLogs(NewAccountChainEvent(block, logList), p, false)
+			if len(logs) == 0 {
+				continue
+			}
+			select {
+			case logsCh <- logs:
+			case <-stopCh:
+				return errReplayStopped
+			}
+		}
+		return nil
+	}
+
+	next := cp.fromHeight
+	if next < 2 {
+		// the genesis account blocks have no vm logs
+		next = 2
+	}
+	for {
+		latestHeight := c.GetLatestSnapshotBlock().Height
+		for ; next <= latestHeight; next += replayBatchSize {
+			end := next + replayBatchSize - 1
+			if end > latestHeight {
+				end = latestHeight
+			}
+			chunks, err := c.GetSubLedger(next-1, end)
+			if err != nil {
+				return replayed, err
+			}
+			for _, chunk := range chunks {
+				// the first chunk is the snapshot block of next-1
+				if chunk.SnapshotBlock != nil && chunk.SnapshotBlock.Height < next {
+					continue
+				}
+				if err := replayBlocks(chunk.AccountBlocks); err != nil {
+					return replayed, err
+				}
+			}
+		}
+		next = latestHeight + 1
+
+		// snapshot blocks inserted during the replay confirm blocks which are no longer unconfirmed
+		unconfirmedBlocks := c.GetAllUnconfirmedBlocks()
+		if c.GetLatestSnapshotBlock().Height == latestHeight {
+			if err := replayBlocks(unconfirmedBlocks); err != nil {
+				return replayed, err
+			}
+			if skipping {
+				return replayed, errors.New(fmt.Sprintf("checkpoint account block %s is rolled back", cp.afterHash))
+			}
+			return replayed, nil
+		}
+	}
+}
+
+// heldLogs keeps the live logs received during a replay
+type heldLogs struct {
+	msgs  [][]*Logs
+	count int
+}
+
+func (h *heldLogs) add(msg []*Logs) error {
+	if h.count+len(msg) > maxHeldLogs {
+		return errTooManyHeldLogs
+	}
+	h.msgs = append(h.msgs, msg)
+	h.count += len(msg)
+	return nil
+}
+
+// skipReplayedLogs drops the live logs of replayed account blocks, logs of rolled back blocks are kept.
+func skipReplayedLogs(logs []*Logs, replayed map[types.Hash]struct{}) []*Logs {
+	if len(replayed) == 0 {
+		return logs
+	}
+	var result []*Logs
+	for _, l := range logs {
+		if _, ok := replayed[l.AccountBlockHash]; ok && !l.Removed {
+			continue
+		}
+		result = append(result, l)
+	}
+	return result
+}
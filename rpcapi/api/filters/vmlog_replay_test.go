@@ -0,0 +1,102 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// replayTestChain confirms an account block with a vm log by each snapshot block from 2
+type replayTestChain struct {
+	chunks      []*ledger.SnapshotChunk
+	unconfirmed []*ledger.AccountBlock
+}
+
+func newReplayTestChain(latestHeight uint64) *replayTestChain {
+	c := &replayTestChain{}
+	for h := uint64(0); h <= latestHeight; h++ {
+		chunk := &ledger.SnapshotChunk{SnapshotBlock: &ledger.SnapshotBlock{Height: h}}
+		if h >= 2 {
+			logHash := types.Hash{byte(h)}
+			chunk.AccountBlocks = []*ledger.AccountBlock{{Height: h, Hash: types.Hash{byte(h)}, LogHash: &logHash}}
+		}
+		c.chunks = append(c.chunks, chunk)
+	}
+	return c
+}
+
+func (c *replayTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.chunks[len(c.chunks)-1].SnapshotBlock
+}
+
+func (c *replayTestChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	return c.chunks[startHeight : endHeight+1], nil
+}
+
+func (c *replayTestChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock {
+	return c.unconfirmed
+}
+
+func (c *replayTestChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return ledger.VmLogList{{Topics: []types.Hash{*logListHash}}}, nil
+}
+
+func collectReplayedLogs(t *testing.T, c replayChain, cp *vmLogCheckpoint) ([]*Logs, error) {
+	logsCh := make(chan []*Logs, 100)
+	_, err := replayVmLogs(c, &api.FilterParam{}, cp, logsCh, make(chan struct{}))
+	close(logsCh)
+	var logs []*Logs
+	for msg := range logsCh {
+		logs = append(logs, msg...)
+	}
+	return logs, err
+}
+
+func TestReplayVmLogs(t *testing.T) {
+	c := newReplayTestChain(5)
+	c.unconfirmed = []*ledger.AccountBlock{{Height: 6, Hash: types.Hash{6}, LogHash: &types.Hash{6}}}
+
+	logs, err := collectReplayedLogs(t, c, &vmLogCheckpoint{fromHeight: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 4 || logs[0].AccountBlockHash != (types.Hash{3}) || logs[3].AccountBlockHash != (types.Hash{6}) {
+		t.Fatalf("replayed %d logs, should be the logs of 3 to 6", len(logs))
+	}
+
+	// the blocks before and including the checkpoint block are skipped
+	logs, err = collectReplayedLogs(t, c, &vmLogCheckpoint{fromHeight: 3, afterHash: &types.Hash{3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 3 || logs[0].AccountBlockHash != (types.Hash{4}) {
+		t.Fatalf("replayed %d logs, should be the logs of 4 to 6", len(logs))
+	}
+
+	// the checkpoint block is rolled back
+	logs, err = collectReplayedLogs(t, c, &vmLogCheckpoint{fromHeight: 3, afterHash: &types.Hash{0xff}})
+	if err == nil {
+		t.Fatal("rolled back checkpoint should fail the replay")
+	}
+	if len(logs) != 0 {
+		t.Fatalf("no log should be replayed after a rolled back checkpoint, got %d", len(logs))
+	}
+}
+
+func TestHeldLogs(t *testing.T) {
+	var h heldLogs
+	msg := make([]*Logs, maxHeldLogs/2)
+	for i := 0; i < 2; i++ {
+		if err := h.add(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.add(make([]*Logs, 1)); err != errTooManyHeldLogs {
+		t.Fatalf("held logs beyond %d should be rejected, error: %v", maxHeldLogs, err)
+	}
+	if len(h.msgs) != 2 || h.count != maxHeldLogs {
+		t.Fatalf("%d messages with %d logs are held", len(h.msgs), h.count)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"math/big"
+	"reflect"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -28,7 +29,9 @@ func convert(params []string, arguments abi.Arguments) ([]interface{}, error) {
 
 func convertOne(param string, t abi.Type) (interface{}, error) {
 	typeString := t.String()
-	if strings.Contains(typeString, "[") {
+	if strings.Contains(typeString, "(") {
+		return convertToTuple(param, t)
+	} else if strings.Contains(typeString, "[") {
 		return convertToArray(param, t)
 	} else if typeString == "bool" {
 		return convertToBool(param)
@@ -52,6 +55,16 @@ func convertOne(param string, t abi.Type) (interface{}, error) {
 	return nil, errors.New("unknown type " + typeString)
 }
 
+// convertToTuple converts the json param to tuples, arrays of tuples or tuples contain arrays,
+// the component names are the keys of a json object, like: {"id":1,"owner":"vite_...","tags":["a"]}
+func convertToTuple(param string, t abi.Type) (interface{}, error) {
+	v := reflect.New(t.Type)
+	if err := json.Unmarshal([]byte(param), v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
 func convertToArray(param string, t abi.Type) (interface{}, error) {
 	if t.Elem.Elem != nil {
 		return nil, errors.New(t.String() + " type not supported")
@@ -97,3 +97,41 @@ func TestConvert(t *testing.T) {
 	}
 	fmt.Println(data)
 }
+
+func TestConvertTuple(t *testing.T) {
+	abiStr := `[{"type":"function","name":"place","inputs":[
+		{"name":"order","type":"tuple","components":[
+			{"name":"id","type":"uint64"},
+			{"name":"owner","type":"address"},
+			{"name":"amounts","type":"uint256[]"}
+		]},
+		{"name":"tags","type":"tuple[]","components":[{"name":"name","type":"string"}]}
+	]}]`
+	abiContract, err := abi.JSONToABIContract(strings.NewReader(abiStr))
+	if err != nil {
+		t.Fatalf("convert abi failed, %v", err)
+	}
+	params := []string{
+		`{"id":1,"owner":"vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a","amounts":[1,2]}`,
+		`[{"name":"a"},{"name":"b"}]`,
+	}
+	arguments, err := convert(params, abiContract.Methods["place"].Inputs)
+	if err != nil {
+		t.Fatalf("convert arguments failed, %v", err)
+	}
+	data, err := abiContract.PackMethod("place", arguments...)
+	if err != nil {
+		t.Fatalf("pack method failed, %v", err)
+	}
+	values, err := abiContract.DirectUnpackMethodInput("place", data)
+	if err != nil {
+		t.Fatalf("unpack method failed, %v", err)
+	}
+	if fmt.Sprint(values) != fmt.Sprint(arguments) {
+		t.Fatalf("wrong values %v, want %v", values, arguments)
+	}
+
+	if _, err := convert([]string{`{"id":"x"}`, `[]`}, abiContract.Methods["place"].Inputs); err == nil {
+		t.Fatal("convert invalid tuple should fail")
+	}
+}
@@ -244,6 +244,12 @@ func (block *AccountBlock) addExtraInfo(chain chain.Chain) error {
 	if err != nil {
 		return err
 	}
+	block.setConfirmed(latestSb, confirmedBlock)
+	return nil
+}
+
+// setConfirmed sets ConfirmedTimes & ConfirmedHash by the snapshot block which confirms the block
+func (block *AccountBlock) setConfirmed(latestSb, confirmedBlock *ledger.SnapshotBlock) {
 	if confirmedBlock != nil && latestSb != nil && confirmedBlock.Height <= latestSb.Height {
 		confirmedTimeStr := strconv.FormatUint(latestSb.Height-confirmedBlock.Height+1, 10)
 
@@ -255,7 +261,6 @@ func (block *AccountBlock) addExtraInfo(chain chain.Chain) error {
 
 		block.Timestamp = confirmedBlock.Timestamp.Unix()
 	}
-	return nil
 }
 
 func ledgerSnapshotBlockToRpcBlock(sb *ledger.SnapshotBlock) (*SnapshotBlock, error) {
@@ -277,6 +282,40 @@ func ledgerSnapshotBlockToRpcBlock(sb *ledger.SnapshotBlock) (*SnapshotBlock, er
 }
 
 func ledgerToRpcBlock(chain chain.Chain, lAb *ledger.AccountBlock) (*AccountBlock, error) {
+	rpcBlock := newRpcBlock(lAb)
+
+	// FromAddress & ToAddress of the receive block
+	if !lAb.IsSendBlock() {
+		sendBlock, err := chain.GetAccountBlockByHash(lAb.FromBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		rpcBlock.setSendBlock(sendBlock)
+	}
+
+	if err := rpcBlock.addExtraInfo(chain); err != nil {
+		return nil, err
+	}
+
+	// SendBlockList
+	if len(lAb.SendBlockList) > 0 {
+		subBlockList := make([]*AccountBlock, len(lAb.SendBlockList))
+		for k, v := range lAb.SendBlockList {
+			subRpcTx, err := ledgerToRpcBlock(chain, v)
+			if err != nil {
+				return nil, err
+			}
+			subBlockList[k] = subRpcTx
+		}
+		rpcBlock.SendBlockList = subBlockList
+		rpcBlock.TriggeredSendBlockList = subBlockList
+	}
+
+	return rpcBlock, nil
+}
+
+// newRpcBlock converts the fields of the account block which need not read the chain
+func newRpcBlock(lAb *ledger.AccountBlock) *AccountBlock {
 	rpcBlock := &AccountBlock{
 		BlockType:    lAb.BlockType,
 		Hash:         lAb.Hash,
@@ -327,26 +366,6 @@ func ledgerToRpcBlock(chain chain.Chain, lAb *ledger.AccountBlock) (*AccountBloc
 			fee = lAb.Fee.String()
 			rpcBlock.Fee = &fee
 		}
-	} else {
-		sendBlock, err := chain.GetAccountBlockByHash(lAb.FromBlockHash)
-		if err != nil {
-			return nil, err
-		}
-		if sendBlock != nil {
-			rpcBlock.FromAddress = sendBlock.AccountAddress
-			rpcBlock.ToAddress = sendBlock.ToAddress
-
-			rpcBlock.FromBlockHash = sendBlock.Hash
-			rpcBlock.TokenId = sendBlock.TokenId
-			if sendBlock.Amount != nil {
-				amount = sendBlock.Amount.String()
-				rpcBlock.Amount = &amount
-			}
-			if sendBlock.Fee != nil {
-				fee = sendBlock.Fee.String()
-				rpcBlock.Fee = &fee
-			}
-		}
 	}
 
 	// Difficulty & Nonce
@@ -356,25 +375,27 @@ func ledgerToRpcBlock(chain chain.Chain, lAb *ledger.AccountBlock) (*AccountBloc
 		rpcBlock.Difficulty = &difficulty
 	}
 
-	if err := rpcBlock.addExtraInfo(chain); err != nil {
-		return nil, err
-	}
+	return rpcBlock
+}
 
-	// SendBlockList
-	if len(lAb.SendBlockList) > 0 {
-		subBlockList := make([]*AccountBlock, len(lAb.SendBlockList))
-		for k, v := range lAb.SendBlockList {
-			subRpcTx, err := ledgerToRpcBlock(chain, v)
-			if err != nil {
-				return nil, err
-			}
-			subBlockList[k] = subRpcTx
-		}
-		rpcBlock.SendBlockList = subBlockList
-		rpcBlock.TriggeredSendBlockList = subBlockList
+// setSendBlock sets FromAddress & ToAddress of the receive block by the send block
+func (block *AccountBlock) setSendBlock(sendBlock *ledger.AccountBlock) {
+	if sendBlock == nil {
+		return
 	}
+	block.FromAddress = sendBlock.AccountAddress
+	block.ToAddress = sendBlock.ToAddress
 
-	return rpcBlock, nil
+	block.FromBlockHash = sendBlock.Hash
+	block.TokenId = sendBlock.TokenId
+	if sendBlock.Amount != nil {
+		amount := sendBlock.Amount.String()
+		block.Amount = &amount
+	}
+	if sendBlock.Fee != nil {
+		fee := sendBlock.Fee.String()
+		block.Fee = &fee
+	}
 }
 
 type RpcAccountInfo struct {
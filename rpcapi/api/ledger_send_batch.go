@@ -0,0 +1,144 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// the max count of the blocks of ledger_sendRawTransactions, the pool holds the insert lock for the whole batch
+const maxSendRawTransactionsCount = 256
+
+// the code of the errors which have no error code
+const defaultSendRawTxErrorCode = -32000
+
+// SendRawTxResult is the result of a block of ledger_sendRawTransactions, Code and Error are set if the block
+// isn't inserted
+type SendRawTxResult struct {
+	Hash    types.Hash `json:"hash"`
+	Success bool       `json:"success"`
+	Code    int        `json:"code,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// new api: ledger_sendRawTransactions
+// SendRawTransactions checks the blocks as a batch, the blocks of an account must follow each other and the latest
+// block of the account. If any block fails the check, no block is sent and the other blocks fail with -38002.
+// Otherwise the blocks are verified and inserted in order, and the blocks after a failed block of the same account
+// fail with -38001.
+func (l *LedgerApi) SendRawTransactions(blocks []*AccountBlock) ([]*SendRawTxResult, error) {
+	if len(blocks) == 0 {
+		return nil, errors.New("empty blocks")
+	}
+	if len(blocks) > maxSendRawTransactionsCount {
+		return nil, fmt.Errorf("too many blocks, the max count is %d", maxSendRawTransactionsCount)
+	}
+	latestSb := l.chain.GetLatestSnapshotBlock()
+	if latestSb == nil {
+		return nil, errors.New("failed to get latest snapshotBlock")
+	}
+	if err := checkSnapshotValid(latestSb); err != nil {
+		return nil, err
+	}
+
+	results := make([]*SendRawTxResult, len(blocks))
+	lbs := make([]*ledger.AccountBlock, len(blocks))
+	checked := true
+	prevBlocks := make(map[types.Address]*ledger.AccountBlock)
+	for i, block := range blocks {
+		results[i] = &SendRawTxResult{}
+		if block != nil {
+			results[i].Hash = block.Hash
+		}
+		lb, err := l.checkRawTransaction(block, prevBlocks)
+		if err != nil {
+			setSendRawTxError(results[i], err)
+			checked = false
+			continue
+		}
+		lbs[i] = lb
+	}
+	if !checked {
+		for _, result := range results {
+			if result.Code == 0 {
+				setSendRawTxError(result, ErrSendRawTxNotSent)
+			}
+		}
+		return results, nil
+	}
+
+	errs := l.vite.Pool().AddDirectAccountBlocks(lbs, func(block *ledger.AccountBlock) (*vm_db.VmAccountBlock, error) {
+		return l.vite.Verifier().VerifyRPCAccountBlock(block, l.chain.GetLatestSnapshotBlock())
+	})
+	for i, err := range errs {
+		if err != nil {
+			setSendRawTxError(results[i], err)
+		} else {
+			results[i].Success = true
+		}
+	}
+	return results, nil
+}
+
+// checkRawTransaction checks the block without the state of the chain, prevBlocks is the last checked
+// block of every account in the batch
+func (l *LedgerApi) checkRawTransaction(block *AccountBlock, prevBlocks map[types.Address]*ledger.AccountBlock) (*ledger.AccountBlock, error) {
+	if block == nil {
+		return nil, errors.New("empty block")
+	}
+	if !checkTxToAddressAvailable(block.ToAddress) {
+		return nil, errors.New("ToAddress is invalid")
+	}
+	lb, err := block.RpcToLedgerBlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTokenIdValid(l.chain, &lb.TokenId); err != nil {
+		return nil, err
+	}
+	if lb.ToAddress == types.AddressDexFund && !dex.VerifyNewOrderPriceForRpc(lb.Data) {
+		return nil, dex.InvalidOrderPriceErr
+	}
+
+	prev, ok := prevBlocks[lb.AccountAddress]
+	if !ok {
+		if prev, err = l.chain.GetLatestAccountBlock(lb.AccountAddress); err != nil {
+			return nil, err
+		}
+	}
+	prevHeight, prevHash := uint64(0), types.Hash{}
+	if prev != nil {
+		prevHeight, prevHash = prev.Height, prev.Hash
+	}
+	if lb.Height != prevHeight+1 || lb.PrevHash != prevHash {
+		return nil, ErrVerifyPrevBlock
+	}
+	// the next block follows this block even if this block fails, so it isn't reported as discontinuous
+	prevBlocks[lb.AccountAddress] = lb
+
+	if err := l.vite.Verifier().VerifyAccountBlockHash(lb); err != nil {
+		return nil, err
+	}
+	if err := l.vite.Verifier().VerifyAccountBlockSignature(lb); err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+func setSendRawTxError(result *SendRawTxResult, err error) {
+	if err == pool.ErrPrevBlockFailed {
+		err = ErrSendRawTxPrevBlockFailed
+	} else {
+		err, _ = TryMakeConcernedError(err)
+	}
+	result.Code = defaultSendRawTxErrorCode
+	if e, ok := err.(JsonRpc2Error); ok {
+		result.Code = e.Code
+	}
+	result.Error = err.Error()
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/light"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+// LightLedgerApi is the ledger api of the edge nodes, the blocks are requested from the light servers
+// and verified by the light client. The snapshot contents of the synced headers are nil,
+// the account blocks are returned only if they have been confirmed by the synced headers.
+type LightLedgerApi struct {
+	light *light.Client
+	log   log15.Logger
+}
+
+func NewLightLedgerApi(client *light.Client) *LightLedgerApi {
+	return &LightLedgerApi{
+		light: client,
+		log:   log15.New("module", "rpc_api/light_ledger_api"),
+	}
+}
+
+func (l LightLedgerApi) String() string {
+	return "LightLedgerApi"
+}
+
+// the receive block is completed by its send block, it's requested from the light servers too
+func (l *LightLedgerApi) accountBlockToRpcBlock(block *ledger.AccountBlock, confirmed *ledger.SnapshotBlock) (*AccountBlock, error) {
+	if block == nil {
+		return nil, nil
+	}
+
+	latest := l.light.LatestHeader()
+	rpcBlock := newRpcBlock(block)
+	if !block.IsSendBlock() && block.FromBlockHash != types.ZERO_HASH {
+		sendBlock, _, err := l.light.GetAccountBlockByHash(block.FromBlockHash)
+		if err != nil {
+			return nil, err
+		}
+		rpcBlock.setSendBlock(sendBlock)
+	}
+	rpcBlock.setConfirmed(latest, confirmed)
+
+	if len(block.SendBlockList) > 0 {
+		subBlockList := make([]*AccountBlock, len(block.SendBlockList))
+		for k, v := range block.SendBlockList {
+			subBlockList[k] = newRpcBlock(v)
+			subBlockList[k].setConfirmed(latest, confirmed)
+		}
+		rpcBlock.SendBlockList = subBlockList
+		rpcBlock.TriggeredSendBlockList = subBlockList
+	}
+
+	return rpcBlock, nil
+}
+
+func (l *LightLedgerApi) GetSnapshotChainHeight() string {
+	latest := l.light.LatestHeader()
+	if latest == nil {
+		return "0"
+	}
+	return strconv.FormatUint(latest.Height, 10)
+}
+
+func (l *LightLedgerApi) GetLatestSnapshotHash() *types.Hash {
+	latest := l.light.LatestHeader()
+	if latest == nil {
+		return nil
+	}
+	return &latest.Hash
+}
+
+// old api
+func (l *LightLedgerApi) GetLatestSnapshotChainHash() *types.Hash {
+	return l.GetLatestSnapshotHash()
+}
+
+// GetLatestSnapshotBlock returns the latest synced header, its snapshotData is nil
+func (l *LightLedgerApi) GetLatestSnapshotBlock() (*SnapshotBlock, error) {
+	return ledgerSnapshotBlockToRpcBlock(l.light.LatestHeader())
+}
+
+func (l *LightLedgerApi) GetSnapshotBlockByHeight(height interface{}) (*SnapshotBlock, error) {
+	heightUint64, err := parseHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := l.light.GetSnapshotBlockByHeight(heightUint64)
+	if err != nil {
+		l.log.Error("GetSnapshotBlockByHeight failed, error is "+err.Error(), "method", "GetSnapshotBlockByHeight")
+		return nil, err
+	}
+	return ledgerSnapshotBlockToRpcBlock(block)
+}
+
+func (l *LightLedgerApi) GetSnapshotBlockByHash(hash types.Hash) (*SnapshotBlock, error) {
+	block, err := l.light.GetSnapshotBlockByHash(hash)
+	if err != nil {
+		l.log.Error("GetSnapshotBlockByHash failed, error is "+err.Error(), "method", "GetSnapshotBlockByHash")
+		return nil, err
+	}
+	return ledgerSnapshotBlockToRpcBlock(block)
+}
+
+func (l *LightLedgerApi) GetAccountBlockByHash(blockHash types.Hash) (*AccountBlock, error) {
+	block, confirmed, err := l.light.GetAccountBlockByHash(blockHash)
+	if err != nil {
+		l.log.Error("GetAccountBlockByHash failed, error is "+err.Error(), "method", "GetAccountBlockByHash")
+		return nil, err
+	}
+	return l.accountBlockToRpcBlock(block, confirmed)
+}
+
+// old api
+func (l *LightLedgerApi) GetBlockByHash(blockHash types.Hash) (*AccountBlock, error) {
+	return l.GetAccountBlockByHash(blockHash)
+}
+
+func (l *LightLedgerApi) GetAccountBlockByHeight(addr types.Address, height interface{}) (*AccountBlock, error) {
+	heightUint64, err := parseHeight(height)
+	if err != nil {
+		return nil, err
+	}
+
+	block, confirmed, err := l.light.GetAccountBlockByHeight(addr, heightUint64)
+	if err != nil {
+		l.log.Error("GetAccountBlockByHeight failed, error is "+err.Error(), "method", "GetAccountBlockByHeight")
+		return nil, err
+	}
+	return l.accountBlockToRpcBlock(block, confirmed)
+}
+
+// old api
+func (l *LightLedgerApi) GetBlockByHeight(addr types.Address, height interface{}) (*AccountBlock, error) {
+	return l.GetAccountBlockByHeight(addr, height)
+}
+
+// GetLatestAccountBlock returns the latest confirmed account block
+func (l *LightLedgerApi) GetLatestAccountBlock(addr types.Address) (*AccountBlock, error) {
+	block, confirmed, err := l.light.GetLatestAccountBlock(addr)
+	if err != nil {
+		l.log.Error("GetLatestAccountBlock failed, error is "+err.Error(), "method", "GetLatestAccountBlock")
+		return nil, err
+	}
+	return l.accountBlockToRpcBlock(block, confirmed)
+}
+
+// old api
+func (l *LightLedgerApi) GetLatestBlock(addr types.Address) (*AccountBlock, error) {
+	return l.GetLatestAccountBlock(addr)
+}
+
+// LightApi reports the sync status of the light client
+type LightApi struct {
+	light *light.Client
+}
+
+func NewLightApi(client *light.Client) *LightApi {
+	return &LightApi{
+		light: client,
+	}
+}
+
+func (l LightApi) String() string {
+	return "LightApi"
+}
+
+func (l *LightApi) GetStatus() light.Status {
+	return l.light.Status()
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/go-errors/errors"
 	"github.com/vitelabs/go-vite/common/math"
 	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/onroad"
 	"github.com/vitelabs/go-vite/vite"
 )
 
@@ -105,3 +106,96 @@ func (pri PrivateOnroadApi) GetOnroadInfoInBatch(addrList []types.Address) ([]*R
 	}
 	return resultList, nil
 }
+
+// AutoReceiveFilter selects the unreceived blocks which are received automatically, all tokens are received
+// if TokenIds is empty, and PoW is true by default.
+type AutoReceiveFilter struct {
+	TokenIds  []types.TokenTypeId `json:"tokenIds"`
+	MinAmount *string             `json:"minAmount"`
+	PoW       *bool               `json:"pow"`
+}
+
+type AutoReceiveStatus struct {
+	Address  types.Address      `json:"address"`
+	Filter   *AutoReceiveFilter `json:"filter"`
+	Unlocked bool               `json:"unlocked"`
+
+	Received        string `json:"received"`
+	ReceivedByPoW   string `json:"receivedByPoW"`
+	Failed          string `json:"failed"`
+	LastReceiveTime int64  `json:"lastReceiveTime"`
+	LastError       string `json:"lastError,omitempty"`
+	LastErrorTime   int64  `json:"lastErrorTime"`
+}
+
+// StartAutoReceive enables the auto-receive of the address with the filter, the address must be unlocked
+// in the wallet to receive, and the enabled addresses are kept after restart.
+func (pri PrivateOnroadApi) StartAutoReceive(addr types.Address, filter *AutoReceiveFilter) error {
+	f := &onroad.AutoReceiveFilter{PoW: true}
+	if filter != nil {
+		for i := range filter.TokenIds {
+			if err := checkTokenIdValid(pri.ledgerApi.chain, &filter.TokenIds[i]); err != nil {
+				return err
+			}
+		}
+		f.TokenIds = filter.TokenIds
+		if filter.MinAmount != nil {
+			amount, err := stringToBigInt(filter.MinAmount)
+			if err != nil {
+				return err
+			}
+			if amount.Sign() < 0 {
+				return errors.New("minAmount can't be negative")
+			}
+			f.MinAmount = amount
+		}
+		if filter.PoW != nil {
+			f.PoW = *filter.PoW
+		}
+	}
+	return pri.ledgerApi.vite.OnRoad().AutoReceiver().Start(addr, f)
+}
+
+func (pri PrivateOnroadApi) StopAutoReceive(addr types.Address) error {
+	return pri.ledgerApi.vite.OnRoad().AutoReceiver().Stop(addr)
+}
+
+// ListWorkingAutoReceiveWorker returns the addresses which are enabled to receive automatically and unlocked.
+func (pri PrivateOnroadApi) ListWorkingAutoReceiveWorker() []types.Address {
+	addrs := pri.ledgerApi.vite.OnRoad().AutoReceiver().Addresses()
+	if addrs == nil {
+		return []types.Address{}
+	}
+	return addrs
+}
+
+// GetAutoReceiveStatus returns the filters and the receive statistics of the addresses which are enabled
+// to receive automatically.
+func (pri PrivateOnroadApi) GetAutoReceiveStatus() []*AutoReceiveStatus {
+	statuses := pri.ledgerApi.vite.OnRoad().AutoReceiver().Statuses()
+	result := make([]*AutoReceiveStatus, 0, len(statuses))
+	for _, s := range statuses {
+		pow := s.Filter.PoW
+		status := &AutoReceiveStatus{
+			Address: s.Address,
+			Filter: &AutoReceiveFilter{
+				TokenIds:  s.Filter.TokenIds,
+				MinAmount: bigIntToString(s.Filter.MinAmount),
+				PoW:       &pow,
+			},
+			Unlocked:      s.Unlocked,
+			Received:      Uint64ToString(s.Received),
+			ReceivedByPoW: Uint64ToString(s.ReceivedByPoW),
+			Failed:        Uint64ToString(s.Failed),
+			LastError:     s.LastError,
+		}
+		if s.LastReceiveTime != nil {
+			status.LastReceiveTime = s.LastReceiveTime.Unix()
+		}
+		if s.LastErrorTime != nil {
+			status.LastErrorTime = s.LastErrorTime.Unix()
+		}
+		result = append(result, status)
+	}
+	return result
+}
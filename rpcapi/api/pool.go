@@ -0,0 +1,134 @@
+package api
+
+import (
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/pool"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+// PendingReasonSnapshot means the block is in the chain and waits to be confirmed by a snapshot block
+const PendingReasonSnapshot = "waitingSnapshot"
+
+// PendingAccountBlock is an account block which hasn't been confirmed by a snapshot block. Reason is one of
+// waitingSnapshot, waitingInsert, waitingPrevBlock, waitingSendBlock and forked, Dependency is the hash of
+// the missing block for waitingPrevBlock and waitingSendBlock.
+type PendingAccountBlock struct {
+	*AccountBlock
+	Reason     string      `json:"reason"`
+	Dependency *types.Hash `json:"dependency,omitempty"`
+}
+
+// PoolApi lists the account blocks which haven't been confirmed, both the unconfirmed blocks in the chain
+// and the blocks in the pool which haven't been inserted into the chain
+type PoolApi struct {
+	chain chain.Chain
+	pool  pool.BlockPool
+}
+
+func NewPoolApi(vite *vite.Vite) *PoolApi {
+	return &PoolApi{
+		chain: vite.Chain(),
+		pool:  vite.Pool(),
+	}
+}
+
+func (p PoolApi) String() string {
+	return "PoolApi"
+}
+
+// GetPendingAccountBlocks returns the unconfirmed blocks of the address sorted by height
+func (p PoolApi) GetPendingAccountBlocks(addr types.Address) ([]*PendingAccountBlock, error) {
+	return p.pendingBlocksOf(addr, p.chain.GetUnconfirmedBlocks(addr))
+}
+
+// GetPendingAccountBlocksByGid returns the unconfirmed blocks of the contracts in the consensus group
+func (p PoolApi) GetPendingAccountBlocksByGid(gid types.Gid) ([]*PendingAccountBlock, error) {
+	unconfirmed := make(map[types.Address][]*ledger.AccountBlock)
+	var addrs []types.Address
+	for _, b := range p.chain.GetAllUnconfirmedBlocks() {
+		if _, ok := unconfirmed[b.AccountAddress]; !ok {
+			addrs = append(addrs, b.AccountAddress)
+		}
+		unconfirmed[b.AccountAddress] = append(unconfirmed[b.AccountAddress], b)
+	}
+	for _, addr := range p.pool.PendingAccounts() {
+		if _, ok := unconfirmed[addr]; !ok {
+			addrs = append(addrs, addr)
+			unconfirmed[addr] = nil
+		}
+	}
+
+	var result []*PendingAccountBlock
+	for _, addr := range addrs {
+		if !types.IsContractAddr(addr) {
+			continue
+		}
+		meta, err := p.chain.GetContractMeta(addr)
+		if err != nil {
+			return nil, err
+		}
+		if meta == nil || meta.Gid != gid {
+			continue
+		}
+		blocks, err := p.pendingBlocksOf(addr, unconfirmed[addr])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, blocks...)
+	}
+	return result, nil
+}
+
+// GetDependencyPendingAccountBlocks returns the blocks in the pool which wait on a missing previous block
+// or a missing send block
+func (p PoolApi) GetDependencyPendingAccountBlocks() ([]*PendingAccountBlock, error) {
+	var result []*PendingAccountBlock
+	for _, addr := range p.pool.PendingAccounts() {
+		for _, b := range p.pool.PendingAccountBlocks(addr) {
+			if b.Dependency == nil {
+				continue
+			}
+			block, err := p.toPendingBlock(b.Block, b.Reason, b.Dependency)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, block)
+		}
+	}
+	return result, nil
+}
+
+func (p PoolApi) pendingBlocksOf(addr types.Address, unconfirmed []*ledger.AccountBlock) ([]*PendingAccountBlock, error) {
+	result := make([]*PendingAccountBlock, 0, len(unconfirmed))
+	inChain := make(map[types.Hash]bool, len(unconfirmed))
+	for _, b := range unconfirmed {
+		block, err := p.toPendingBlock(b, PendingReasonSnapshot, nil)
+		if err != nil {
+			return nil, err
+		}
+		inChain[b.Hash] = true
+		result = append(result, block)
+	}
+	for _, b := range p.pool.PendingAccountBlocks(addr) {
+		// the block may be inserted after the unconfirmed blocks are read
+		if inChain[b.Block.Hash] {
+			continue
+		}
+		block, err := p.toPendingBlock(b.Block, b.Reason, b.Dependency)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, block)
+	}
+	return result, nil
+}
+
+func (p PoolApi) toPendingBlock(b *ledger.AccountBlock, reason string, dependency *types.Hash) (*PendingAccountBlock, error) {
+	rpcBlock, err := ledgerToRpcBlock(p.chain, b)
+	if err != nil {
+		return nil, err
+	}
+	return &PendingAccountBlock{AccountBlock: rpcBlock, Reason: reason, Dependency: dependency}, nil
+}
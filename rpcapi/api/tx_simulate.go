@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/contracts/dex"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+type SimulateStorageDiff struct {
+	Key      string `json:"key"`
+	OldValue string `json:"oldValue"`
+	NewValue string `json:"newValue"`
+}
+
+// SimulateResult is the result of a simulated transaction. The receive fields are only set when
+// the send block is sent to a contract, RevertReason is set when the contract receive fails.
+type SimulateResult struct {
+	SendBlock              *AccountBlock          `json:"sendBlock"`
+	ReceiveBlock           *AccountBlock          `json:"receiveBlock,omitempty"`
+	TriggeredSendBlockList []*AccountBlock        `json:"triggeredSendBlockList,omitempty"`
+	VmLogList              ledger.VmLogList       `json:"vmLogList,omitempty"`
+	StorageDiffs           []*SimulateStorageDiff `json:"storageDiffs,omitempty"`
+	QuotaUsed              string                 `json:"quotaUsed"`
+	ReceiveQuotaUsed       string                 `json:"receiveQuotaUsed"`
+	Success                bool                   `json:"success"`
+	RevertReason           string                 `json:"revertReason,omitempty"`
+}
+
+// simulateBackend is the part of vite used by Simulate
+type simulateBackend interface {
+	Chain() chain.Chain
+	Verifier() verifier.Verifier
+	Consensus() consensus.Consensus
+}
+
+// Simulate verifies a signed send block the same way as SendRawTx, then executes the contract
+// receive of it upon the latest state, assuming the send block is confirmed by the latest snapshot
+// block. Nothing is inserted into the pool or broadcast, so signing a block to preview it is safe.
+func (t Tx) Simulate(block *AccountBlock) (*SimulateResult, error) {
+	return simulateTx(t.vite, block)
+}
+
+func simulateTx(backend simulateBackend, block *AccountBlock) (*SimulateResult, error) {
+	if block == nil {
+		return nil, errors.New("empty block")
+	}
+	if !checkTxToAddressAvailable(block.ToAddress) {
+		return nil, errors.New("ToAddress is invalid")
+	}
+	lb, err := block.RpcToLedgerBlock()
+	if err != nil {
+		return nil, err
+	}
+	if !lb.IsSendBlock() {
+		return nil, errors.New("only send blocks can be simulated")
+	}
+	c := backend.Chain()
+	if err := checkTokenIdValid(c, &lb.TokenId); err != nil {
+		return nil, err
+	}
+	latestSb := c.GetLatestSnapshotBlock()
+	if latestSb == nil {
+		return nil, errors.New("failed to get latest snapshotBlock")
+	}
+	if err := checkSnapshotValid(latestSb); err != nil {
+		return nil, err
+	}
+	if lb.ToAddress == types.AddressDexFund && !dex.VerifyNewOrderPriceForRpc(lb.Data) {
+		return nil, dex.InvalidOrderPriceErr
+	}
+
+	sendResult, err := backend.Verifier().VerifyRPCAccountBlock(lb, latestSb)
+	if err != nil {
+		return nil, err
+	}
+	if sendResult == nil {
+		return nil, errors.New("generator gen an empty block")
+	}
+	sendBlock := sendResult.AccountBlock
+	result := &SimulateResult{
+		QuotaUsed:        strconv.FormatUint(sendBlock.QuotaUsed, 10),
+		ReceiveQuotaUsed: "0",
+		Success:          true,
+	}
+	if result.SendBlock, err = ledgerToRpcBlock(c, sendBlock); err != nil {
+		return nil, err
+	}
+	if !types.IsContractAddr(sendBlock.ToAddress) {
+		return result, nil
+	}
+
+	prevBlock, err := c.GetLatestAccountBlock(sendBlock.ToAddress)
+	if err != nil {
+		return nil, err
+	}
+	receiveBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: sendBlock.ToAddress,
+		FromBlockHash:  sendBlock.Hash,
+		Height:         1,
+	}
+	if prevBlock != nil {
+		receiveBlock.Height = prevBlock.Height + 1
+		receiveBlock.PrevHash = prevBlock.Hash
+	}
+	db, err := vm_db.NewVmDb(c, &receiveBlock.AccountAddress, &latestSb.Hash, &receiveBlock.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	if sendBlock.BlockType == ledger.BlockTypeSendCreate {
+		// the contract meta is only saved along with the send block
+		meta := sendResult.VmDb.GetUnsavedContractMeta()[sendBlock.ToAddress]
+		if meta == nil {
+			return nil, util.ErrContractNotExists
+		}
+		db.SetContractMeta(sendBlock.ToAddress, meta)
+	}
+
+	vmBlock, runErr := simulateReceive(backend.Consensus(), db, receiveBlock, sendBlock, generator.NewVMGlobalStatus(c, latestSb, sendBlock.Hash))
+	if runErr != nil {
+		result.Success = false
+		result.RevertReason = runErr.Error()
+	}
+	if vmBlock == nil {
+		return result, nil
+	}
+
+	vb := vmBlock.AccountBlock
+	for idx, v := range vb.SendBlockList {
+		v.Hash = v.ComputeSendHash(vb, uint8(idx))
+	}
+	vb.Hash = vb.ComputeHash()
+	if result.ReceiveBlock, err = ledgerToRpcBlock(c, vb); err != nil {
+		return nil, err
+	}
+	// the send block isn't on chain, so the fields taken from it are filled here
+	result.ReceiveBlock.FromAddress = sendBlock.AccountAddress
+	result.ReceiveBlock.ToAddress = sendBlock.ToAddress
+	result.ReceiveBlock.TokenId = sendBlock.TokenId
+	result.ReceiveBlock.TokenInfo = result.SendBlock.TokenInfo
+	result.ReceiveBlock.Amount = result.SendBlock.Amount
+	result.ReceiveBlock.Fee = result.SendBlock.Fee
+	result.TriggeredSendBlockList = result.ReceiveBlock.TriggeredSendBlockList
+	result.ReceiveQuotaUsed = strconv.FormatUint(vb.QuotaUsed, 10)
+
+	result.VmLogList = vmBlock.VmDb.GetLogList()
+	for _, kv := range vmBlock.VmDb.GetUnsavedStorage() {
+		oldValue, err := vmBlock.VmDb.GetOriginalValue(kv[0])
+		if err != nil {
+			return nil, err
+		}
+		result.StorageDiffs = append(result.StorageDiffs, &SimulateStorageDiff{
+			Key:      hex.EncodeToString(kv[0]),
+			OldValue: hex.EncodeToString(oldValue),
+			NewValue: hex.EncodeToString(kv[1]),
+		})
+	}
+	return result, nil
+}
+
+func simulateReceive(cs consensus.Consensus, db vm_db.VmDb, block, sendBlock *ledger.AccountBlock, status *generator.VMGlobalStatus) (vmBlock *vm_db.VmAccountBlock, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			vmBlock = nil
+			err = errors.New(fmt.Sprintf("simulate receive panic, %v", r))
+		}
+	}()
+	v := vm.NewVM(util.NewVMConsensusReader(cs.SBPReader()))
+	vmBlock, _, err = v.RunV2(db, block, sendBlock, status)
+	return vmBlock, err
+}
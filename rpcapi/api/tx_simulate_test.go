@@ -0,0 +1,185 @@
+package api
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/verifier"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+type simulateTestConsensus struct {
+	consensus.Consensus
+}
+
+func (cs *simulateTestConsensus) SBPReader() core.SBPStatReader {
+	return nil
+}
+
+type simulateTestBackend struct {
+	c  chain.Chain
+	v  verifier.Verifier
+	cs consensus.Consensus
+}
+
+func (b *simulateTestBackend) Chain() chain.Chain {
+	return b.c
+}
+
+func (b *simulateTestBackend) Verifier() verifier.Verifier {
+	return b.v
+}
+
+func (b *simulateTestBackend) Consensus() consensus.Consensus {
+	return b.cs
+}
+
+// newSimulateTestBackend starts a chain of the devnet genesis funding addr, the latest snapshot block
+// is produced just now so that all forks are active
+func newSimulateTestBackend(t *testing.T, dir string, addr types.Address, balance *big.Int) *simulateTestBackend {
+	genesis, err := config.MakeDevNetGenesis([]types.Address{addr}, balance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fork.SetForkPoints(genesis.ForkPoints)
+	vm.InitVMConfig(false, false, false, false, dir)
+
+	c := chain.NewChain(dir, &config.Chain{}, genesis)
+	if err := c.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := 8; i > 0; i-- {
+		latest := c.GetLatestSnapshotBlock()
+		timestamp := now.Add(-time.Duration(i) * time.Second)
+		sb := &ledger.SnapshotBlock{PrevHash: latest.Hash, Height: latest.Height + 1, Timestamp: &timestamp}
+		sb.Hash = sb.ComputeHash()
+		if _, err := c.InsertSnapshotBlock(sb); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cs := &simulateTestConsensus{}
+	return &simulateTestBackend{c: c, v: verifier.NewVerifier2(c, cs), cs: cs}
+}
+
+func newSimulateTestBlock(t *testing.T, c chain.Chain, key ed25519.PrivateKey, amount *big.Int, data []byte) *AccountBlock {
+	addr := types.PubkeyToAddress(key.PubByte())
+	prev, err := c.GetLatestAccountBlock(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: addr,
+		PublicKey:      key.PubByte(),
+		ToAddress:      types.AddressQuota,
+		Amount:         amount,
+		TokenId:        ledger.ViteTokenId,
+		Fee:            big.NewInt(0),
+		Data:           data,
+		Height:         1,
+	}
+	if prev != nil {
+		block.Height = prev.Height + 1
+		block.PrevHash = prev.Hash
+	}
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+
+	rpcBlock, err := ledgerToRpcBlock(c, block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rpcBlock
+}
+
+func TestSimulateTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simulate_tx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := types.PubkeyToAddress(pub)
+	balance := new(big.Int).Mul(big.NewInt(1e4), big.NewInt(1e18))
+	backend := newSimulateTestBackend(t, dir, addr, balance)
+	c := backend.c
+	defer c.Stop()
+
+	prevSender, err := c.GetLatestAccountBlock(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevQuota, err := c.GetLatestAccountBlock(types.AddressQuota)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a stake for quota succeeds and changes the storage of the quota contract
+	stakeData, err := abi.ABIQuota.PackMethod(abi.MethodNameStakeV3, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stakeAmount := new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	result, err := simulateTx(backend, newSimulateTestBlock(t, c, key, stakeAmount, stakeData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Success || result.ReceiveBlock == nil || len(result.StorageDiffs) == 0 {
+		t.Fatalf("stake should succeed with storage changes, revert reason: %s", result.RevertReason)
+	}
+
+	// canceling a stake which doesn't exist is reverted by the receive
+	cancelData, err := abi.ABIQuota.PackMethod(abi.MethodNameCancelStakeV3, types.Hash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = simulateTx(backend, newSimulateTestBlock(t, c, key, big.NewInt(0), cancelData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || result.RevertReason == "" {
+		t.Fatal("canceling an unknown stake should be reverted")
+	}
+
+	// the send block itself is invalid if the balance is insufficient
+	if _, err := simulateTx(backend, newSimulateTestBlock(t, c, key, new(big.Int).Add(balance, big.NewInt(1)), stakeData)); err == nil {
+		t.Fatal("send block with insufficient balance should be rejected")
+	}
+
+	// nothing is written by the simulations
+	if block, err := c.GetLatestAccountBlock(addr); err != nil || block.Hash != prevSender.Hash {
+		t.Fatalf("latest block of the sender is changed to %v, error: %v", block, err)
+	}
+	if block, err := c.GetLatestAccountBlock(types.AddressQuota); err != nil || block.Hash != prevQuota.Hash {
+		t.Fatalf("latest block of the quota contract is changed to %v, error: %v", block, err)
+	}
+	if b, err := c.GetBalance(addr, ledger.ViteTokenId); err != nil || b.Cmp(balance) != 0 {
+		t.Fatalf("balance of the sender is changed to %v, error: %v", b, err)
+	}
+	if blocks := c.GetAllUnconfirmedBlocks(); len(blocks) != 0 {
+		t.Fatalf("%d unconfirmed blocks are inserted", len(blocks))
+	}
+}
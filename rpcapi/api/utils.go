@@ -47,7 +47,7 @@ func InitLog(dir, lvl string) {
 	path := filepath.Join(dir, "rpclog", time.Now().Format("2006-01-02T15-04"))
 	filename := filepath.Join(path, "rpc.log")
 	log.SetHandler(
-		log15.LvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.LogfmtFormat())),
+		log15.ModuleLvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.FileFormat())),
 	)
 }
 
@@ -16,6 +16,7 @@ import (
 	"github.com/vitelabs/go-vite/vm/contracts/dex"
 	"github.com/vitelabs/go-vite/wallet"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
+	"github.com/vitelabs/go-vite/wallet/signer"
 )
 
 type HexSignedTuple struct {
@@ -60,6 +61,7 @@ type IsMayValidKeystoreFileResponse struct {
 func NewWalletApi(vite *vite.Vite) *WalletApi {
 	return &WalletApi{
 		wallet:    vite.WalletManager(),
+		signer:    vite.WalletManager(),
 		chain:     vite.Chain(),
 		pool:      vite.Pool(),
 		consensus: vite.Consensus(),
@@ -68,6 +70,7 @@ func NewWalletApi(vite *vite.Vite) *WalletApi {
 
 type WalletApi struct {
 	wallet    *wallet.Manager
+	signer    signer.Signer
 	chain     chain.Chain
 	pool      pool.Writer
 	consensus generator.Consensus
@@ -252,12 +255,7 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 	if err != nil {
 		return nil, err
 	}
-	_, key, _, e := m.wallet.GlobalFindAddr(addr)
-	if e != nil {
-		return nil, e
-	}
-
-	signedData, pubkey, err := key.SignData(msgbytes)
+	signedData, pubkey, err := m.signer.SignData(addr, msgbytes)
 	if err != nil {
 		return nil, err
 	}
@@ -272,6 +270,29 @@ func (m WalletApi) SignData(addr types.Address, hexMsg string) (*HexSignedTuple,
 }
 
 func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.Hash, error) {
+	return m.createTx(params, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+		if params.EntropystoreFile != nil {
+			manager, e := m.wallet.GetEntropyStoreManager(*params.EntropystoreFile)
+			if e != nil {
+				return nil, nil, e
+			}
+			return manager.SignDataWithPassphrase(addr, params.Passphrase, data)
+		}
+
+		_, key, _, e := m.wallet.GlobalFindAddrWithPassphrase(addr, params.Passphrase)
+		if e != nil {
+			return nil, nil, e
+		}
+		return key.SignData(data)
+	})
+}
+
+// CreateTx signs the block with an unlocked entropy file or the external signer, EntropystoreFile and Passphrase are ignored.
+func (m WalletApi) CreateTx(params CreateTransferTxParms) (*types.Hash, error) {
+	return m.createTx(params, m.signer.SignData)
+}
+
+func (m WalletApi) createTx(params CreateTransferTxParms, signFunc header.SignFunc) (*types.Hash, error) {
 	if !checkTxToAddressAvailable(params.ToAddr) {
 		return nil, errors.New("ToAddress is invalid")
 	}
@@ -312,21 +333,7 @@ func (m WalletApi) CreateTxWithPassphrase(params CreateTransferTxParms) (*types.
 	if e != nil {
 		return nil, e
 	}
-	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, func(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
-		if params.EntropystoreFile != nil {
-			manager, e := m.wallet.GetEntropyStoreManager(*params.EntropystoreFile)
-			if e != nil {
-				return nil, nil, e
-			}
-			return manager.SignDataWithPassphrase(addr, params.Passphrase, data)
-		}
-
-		_, key, _, e := m.wallet.GlobalFindAddrWithPassphrase(addr, params.Passphrase)
-		if e != nil {
-			return nil, nil, e
-		}
-		return key.SignData(data)
-	})
+	result, e := g.GenerateWithMessage(msg, &msg.AccountAddress, signFunc)
 
 	if e != nil {
 		return nil, e
@@ -125,6 +125,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewDexApi(vite),
 			Public:    true,
 		}
+	case "dexsubscribe":
+		return rpc.API{
+			Namespace: "dex",
+			Version:   "1.0",
+			Service:   filters.NewDexSubscribeApi(vite),
+			Public:    true,
+		}
 	case "private_dex":
 		return rpc.API{
 			Namespace: "dex",
@@ -154,6 +161,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewTestApi(api.NewWalletApi(vite)),
 			Public:    true,
 		}
+	case "pool":
+		return rpc.API{
+			Namespace: "pool",
+			Version:   "1.0",
+			Service:   api.NewPoolApi(vite),
+			Public:    true,
+		}
 	case "debug":
 		return rpc.API{
 			Namespace: "debug",
@@ -189,6 +203,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewStatsApi(vite),
 			Public:    true,
 		}
+	case "consensus":
+		return rpc.API{
+			Namespace: "consensus",
+			Version:   "1.0",
+			Service:   filters.NewConsensusApi(vite),
+			Public:    true,
+		}
 	case "util":
 		return rpc.API{
 			Namespace: "util",
@@ -210,6 +231,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewLedgerDebugApi(vite),
 			Public:    false,
 		}
+	case "dev":
+		return rpc.API{
+			Namespace: "dev",
+			Version:   "1.0",
+			Service:   api.NewDevApi(vite),
+			Public:    false,
+		}
 	default:
 		return rpc.API{Namespace: apiModule}
 	}
@@ -226,11 +254,16 @@ func MergeApis(apis ...[]rpc.API) []rpc.API {
 	resultMap := make(map[string]rpc.API)
 	for _, apiArr := range apis {
 		for _, r := range apiArr {
-			_, ok := resultMap[r.Namespace]
+			key := r.Namespace
+			// dexsubscribe shares the dex namespace, its methods are merged with dex by the rpc server
+			if _, ok := r.Service.(*filters.DexSubscribeApi); ok {
+				key = "dexsubscribe"
+			}
+			_, ok := resultMap[key]
 			if ok {
 				continue
 			}
-			resultMap[r.Namespace] = r
+			resultMap[key] = r
 		}
 	}
 
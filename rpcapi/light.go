@@ -0,0 +1,33 @@
+package rpcapi
+
+import (
+	"github.com/vitelabs/go-vite/light"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+// GetLightApi returns the api of the edge nodes, the service is nil if the module isn't supported by the light client
+func GetLightApi(client *light.Client, apiModule string) rpc.API {
+	switch apiModule {
+	case "ledger":
+		return rpc.API{
+			Namespace: "ledger",
+			Version:   "1.0",
+			Service:   api.NewLightLedgerApi(client),
+			Public:    true,
+		}
+	case "light":
+		return rpc.API{
+			Namespace: "light",
+			Version:   "1.0",
+			Service:   api.NewLightApi(client),
+			Public:    true,
+		}
+	default:
+		return rpc.API{Namespace: apiModule}
+	}
+}
+
+func GetLightPublicApis(client *light.Client) []rpc.API {
+	return []rpc.API{GetLightApi(client, "ledger"), GetLightApi(client, "light")}
+}
@@ -3,6 +3,7 @@ package vite
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/config"
 	"github.com/vitelabs/go-vite/consensus"
+	"github.com/vitelabs/go-vite/consensus/sbpmonitor"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
 	"github.com/vitelabs/go-vite/onroad"
@@ -38,6 +40,7 @@ type Vite struct {
 	pool          pool.BlockPool
 	consensus     consensus.Consensus
 	onRoad        *onroad.Manager
+	sbpMonitor    *sbpmonitor.Monitor
 }
 
 func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err error) {
@@ -53,6 +56,11 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 		err = walletManager.MatchAddress(cfg.EntropyStorePath, *coinbase, index)
 
 		if err != nil {
+			if walletManager.HasAddress(*coinbase) {
+				// the net proves the node is the producer by signing every handshake with the mine key,
+				// which can't be taken from the external signer
+				err = fmt.Errorf("coinbase %v is kept by the external signer, a producer needs it in the entropy store", coinbase)
+			}
 			log.Error(fmt.Sprintf("coinBase is not child of entropyStore, coinBase is : %v", cfg.Producer.Coinbase), "err", err)
 			return nil, err
 		}
@@ -113,11 +121,24 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	}
 
 	if addressContext != nil {
-		vite.producer = producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		p := producer.NewProducer(chain, net, addressContext, cs, verifier.GetSnapshotVerifier(), walletManager, pl)
+		p.SetInstamine(cfg.Producer.Instamine)
+		p.SetDevNet(cfg.Producer.DevNet)
+		vite.producer = p
+	}
+
+	if cfg.SBPMonitor != nil && cfg.SBPMonitor.MonitorSBP {
+		vite.sbpMonitor, err = sbpmonitor.New(cfg.SBPMonitor, cs, chain)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// onroad
 	or := onroad.NewManager(net, pl, vite.producer, vite.consensus, walletManager)
+	if err = or.LoadAutoReceive(filepath.Join(cfg.DataDir, "onroad", "autoreceive.json")); err != nil {
+		return nil, err
+	}
 
 	// set onroad
 	vite.onRoad = or
@@ -155,6 +176,9 @@ func (v *Vite) Start() (err error) {
 	v.pool.Init(v.net, v.walletManager, v.verifier.GetSnapshotVerifier(), v.verifier, v.consensus)
 
 	v.consensus.Start()
+	if v.sbpMonitor != nil {
+		v.sbpMonitor.Start()
+	}
 
 	err = v.net.Start()
 	if err != nil {
@@ -183,6 +207,9 @@ func (v *Vite) Stop() (err error) {
 			return err
 		}
 	}
+	if v.sbpMonitor != nil {
+		v.sbpMonitor.Stop()
+	}
 	v.consensus.Stop()
 	v.chain.Stop()
 	v.onRoad.Stop()
@@ -217,6 +244,11 @@ func (v *Vite) OnRoad() *onroad.Manager {
 	return v.onRoad
 }
 
+// SBPMonitor returns nil if the monitor isn't enabled
+func (v *Vite) SBPMonitor() *sbpmonitor.Monitor {
+	return v.sbpMonitor
+}
+
 func (v *Vite) Config() *config.Config {
 	return v.config
 }
@@ -0,0 +1,238 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: vitepb/light.proto
+
+package vitepb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetRoundProducers struct {
+	Index                uint64   `protobuf:"varint,1,opt,name=Index,proto3" json:"Index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRoundProducers) Reset()         { *m = GetRoundProducers{} }
+func (m *GetRoundProducers) String() string { return proto.CompactTextString(m) }
+func (*GetRoundProducers) ProtoMessage()    {}
+func (*GetRoundProducers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1cef86a66614d972, []int{0}
+}
+
+func (m *GetRoundProducers) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRoundProducers.Unmarshal(m, b)
+}
+func (m *GetRoundProducers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRoundProducers.Marshal(b, m, deterministic)
+}
+func (m *GetRoundProducers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRoundProducers.Merge(m, src)
+}
+func (m *GetRoundProducers) XXX_Size() int {
+	return xxx_messageInfo_GetRoundProducers.Size(m)
+}
+func (m *GetRoundProducers) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRoundProducers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRoundProducers proto.InternalMessageInfo
+
+func (m *GetRoundProducers) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type RoundProducers struct {
+	Index                uint64   `protobuf:"varint,1,opt,name=Index,proto3" json:"Index,omitempty"`
+	Producers            [][]byte `protobuf:"bytes,2,rep,name=Producers,proto3" json:"Producers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RoundProducers) Reset()         { *m = RoundProducers{} }
+func (m *RoundProducers) String() string { return proto.CompactTextString(m) }
+func (*RoundProducers) ProtoMessage()    {}
+func (*RoundProducers) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1cef86a66614d972, []int{1}
+}
+
+func (m *RoundProducers) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RoundProducers.Unmarshal(m, b)
+}
+func (m *RoundProducers) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RoundProducers.Marshal(b, m, deterministic)
+}
+func (m *RoundProducers) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RoundProducers.Merge(m, src)
+}
+func (m *RoundProducers) XXX_Size() int {
+	return xxx_messageInfo_RoundProducers.Size(m)
+}
+func (m *RoundProducers) XXX_DiscardUnknown() {
+	xxx_messageInfo_RoundProducers.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RoundProducers proto.InternalMessageInfo
+
+func (m *RoundProducers) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *RoundProducers) GetProducers() [][]byte {
+	if m != nil {
+		return m.Producers
+	}
+	return nil
+}
+
+type GetAccountBlockProof struct {
+	Hash                 []byte   `protobuf:"bytes,1,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	Address              []byte   `protobuf:"bytes,2,opt,name=Address,proto3" json:"Address,omitempty"`
+	Height               uint64   `protobuf:"varint,3,opt,name=Height,proto3" json:"Height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAccountBlockProof) Reset()         { *m = GetAccountBlockProof{} }
+func (m *GetAccountBlockProof) String() string { return proto.CompactTextString(m) }
+func (*GetAccountBlockProof) ProtoMessage()    {}
+func (*GetAccountBlockProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1cef86a66614d972, []int{2}
+}
+
+func (m *GetAccountBlockProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAccountBlockProof.Unmarshal(m, b)
+}
+func (m *GetAccountBlockProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAccountBlockProof.Marshal(b, m, deterministic)
+}
+func (m *GetAccountBlockProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAccountBlockProof.Merge(m, src)
+}
+func (m *GetAccountBlockProof) XXX_Size() int {
+	return xxx_messageInfo_GetAccountBlockProof.Size(m)
+}
+func (m *GetAccountBlockProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAccountBlockProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAccountBlockProof proto.InternalMessageInfo
+
+func (m *GetAccountBlockProof) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+func (m *GetAccountBlockProof) GetAddress() []byte {
+	if m != nil {
+		return m.Address
+	}
+	return nil
+}
+
+func (m *GetAccountBlockProof) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type AccountBlockProof struct {
+	Blocks               []*AccountBlock `protobuf:"bytes,1,rep,name=Blocks,proto3" json:"Blocks,omitempty"`
+	Snapshot             *SnapshotBlock  `protobuf:"bytes,2,opt,name=Snapshot,proto3" json:"Snapshot,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *AccountBlockProof) Reset()         { *m = AccountBlockProof{} }
+func (m *AccountBlockProof) String() string { return proto.CompactTextString(m) }
+func (*AccountBlockProof) ProtoMessage()    {}
+func (*AccountBlockProof) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1cef86a66614d972, []int{3}
+}
+
+func (m *AccountBlockProof) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountBlockProof.Unmarshal(m, b)
+}
+func (m *AccountBlockProof) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountBlockProof.Marshal(b, m, deterministic)
+}
+func (m *AccountBlockProof) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountBlockProof.Merge(m, src)
+}
+func (m *AccountBlockProof) XXX_Size() int {
+	return xxx_messageInfo_AccountBlockProof.Size(m)
+}
+func (m *AccountBlockProof) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountBlockProof.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountBlockProof proto.InternalMessageInfo
+
+func (m *AccountBlockProof) GetBlocks() []*AccountBlock {
+	if m != nil {
+		return m.Blocks
+	}
+	return nil
+}
+
+func (m *AccountBlockProof) GetSnapshot() *SnapshotBlock {
+	if m != nil {
+		return m.Snapshot
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetRoundProducers)(nil), "vitepb.GetRoundProducers")
+	proto.RegisterType((*RoundProducers)(nil), "vitepb.RoundProducers")
+	proto.RegisterType((*GetAccountBlockProof)(nil), "vitepb.GetAccountBlockProof")
+	proto.RegisterType((*AccountBlockProof)(nil), "vitepb.AccountBlockProof")
+}
+
+func init() { proto.RegisterFile("vitepb/light.proto", fileDescriptor_1cef86a66614d972) }
+
+var fileDescriptor_1cef86a66614d972 = []byte{
+	// 251 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x90, 0x51, 0x4b, 0xc3, 0x30,
+	0x14, 0x85, 0xa9, 0x9d, 0x55, 0xef, 0x86, 0xb0, 0x4b, 0x95, 0x50, 0x7d, 0x28, 0x7d, 0xaa, 0x20,
+	0x15, 0xe7, 0x2f, 0x98, 0x08, 0x9b, 0x6f, 0x23, 0xbe, 0x0a, 0xd2, 0x36, 0xd1, 0x0e, 0x47, 0x6f,
+	0x49, 0x52, 0xf1, 0xe7, 0x4b, 0x93, 0x74, 0x2a, 0xbe, 0xf8, 0x96, 0x73, 0xee, 0x97, 0x73, 0x2f,
+	0x07, 0xf0, 0x63, 0x6b, 0x64, 0x57, 0xdd, 0xec, 0xb6, 0x6f, 0x8d, 0x29, 0x3a, 0x45, 0x86, 0x30,
+	0x72, 0x5e, 0x92, 0xf8, 0x59, 0x59, 0xd7, 0xd4, 0xb7, 0xe6, 0xa5, 0xda, 0x51, 0xfd, 0xee, 0x98,
+	0xe4, 0xc2, 0xcf, 0x74, 0x5b, 0x76, 0xba, 0xa1, 0x5f, 0xc3, 0xec, 0x0a, 0xe6, 0x2b, 0x69, 0x38,
+	0xf5, 0xad, 0xd8, 0x28, 0x12, 0x7d, 0x2d, 0x95, 0xc6, 0x18, 0x0e, 0x1f, 0x5b, 0x21, 0x3f, 0x59,
+	0x90, 0x06, 0xf9, 0x84, 0x3b, 0x91, 0x3d, 0xc0, 0xe9, 0x7f, 0x38, 0xbc, 0x84, 0x93, 0x3d, 0xc2,
+	0x0e, 0xd2, 0x30, 0x9f, 0xf1, 0x6f, 0x23, 0x7b, 0x86, 0x78, 0x25, 0xcd, 0xd2, 0xdd, 0x79, 0x3f,
+	0x5c, 0xb2, 0x51, 0x44, 0xaf, 0x88, 0x30, 0x59, 0x97, 0xba, 0xb1, 0x51, 0x33, 0x6e, 0xdf, 0xc8,
+	0xe0, 0x68, 0x29, 0x84, 0x92, 0x7a, 0xc8, 0x19, 0xec, 0x51, 0xe2, 0x39, 0x44, 0x6b, 0x39, 0xf4,
+	0xc0, 0x42, 0xbb, 0xda, 0xab, 0xcc, 0xc0, 0xfc, 0x6f, 0xf4, 0x35, 0x44, 0x56, 0x69, 0x16, 0xa4,
+	0x61, 0x3e, 0x5d, 0xc4, 0x85, 0x6b, 0xa4, 0xf8, 0x89, 0x72, 0xcf, 0xe0, 0x2d, 0x1c, 0x3f, 0xf9,
+	0xa6, 0xec, 0xd6, 0xe9, 0xe2, 0x6c, 0xe4, 0x47, 0xdf, 0x7d, 0xd8, 0x63, 0x55, 0x64, 0xbb, 0xbc,
+	0xfb, 0x1a, 0x00, 0xba, 0x0b, 0x09, 0xda, 0xa2, 0x01, 0x00, 0x00,
+}
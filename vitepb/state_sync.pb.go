@@ -0,0 +1,354 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: vitepb/state_sync.proto
+
+package vitepb
+
+import (
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetStateManifest struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=Height,proto3" json:"Height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStateManifest) Reset()         { *m = GetStateManifest{} }
+func (m *GetStateManifest) String() string { return proto.CompactTextString(m) }
+func (*GetStateManifest) ProtoMessage()    {}
+func (*GetStateManifest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{0}
+}
+
+func (m *GetStateManifest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStateManifest.Unmarshal(m, b)
+}
+func (m *GetStateManifest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStateManifest.Marshal(b, m, deterministic)
+}
+func (m *GetStateManifest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStateManifest.Merge(m, src)
+}
+func (m *GetStateManifest) XXX_Size() int {
+	return xxx_messageInfo_GetStateManifest.Size(m)
+}
+func (m *GetStateManifest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStateManifest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStateManifest proto.InternalMessageInfo
+
+func (m *GetStateManifest) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type StateChunkInfo struct {
+	StartKey             []byte   `protobuf:"bytes,1,opt,name=StartKey,proto3" json:"StartKey,omitempty"`
+	Count                uint64   `protobuf:"varint,2,opt,name=Count,proto3" json:"Count,omitempty"`
+	Hash                 []byte   `protobuf:"bytes,3,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateChunkInfo) Reset()         { *m = StateChunkInfo{} }
+func (m *StateChunkInfo) String() string { return proto.CompactTextString(m) }
+func (*StateChunkInfo) ProtoMessage()    {}
+func (*StateChunkInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{1}
+}
+
+func (m *StateChunkInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateChunkInfo.Unmarshal(m, b)
+}
+func (m *StateChunkInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateChunkInfo.Marshal(b, m, deterministic)
+}
+func (m *StateChunkInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateChunkInfo.Merge(m, src)
+}
+func (m *StateChunkInfo) XXX_Size() int {
+	return xxx_messageInfo_StateChunkInfo.Size(m)
+}
+func (m *StateChunkInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateChunkInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateChunkInfo proto.InternalMessageInfo
+
+func (m *StateChunkInfo) GetStartKey() []byte {
+	if m != nil {
+		return m.StartKey
+	}
+	return nil
+}
+
+func (m *StateChunkInfo) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *StateChunkInfo) GetHash() []byte {
+	if m != nil {
+		return m.Hash
+	}
+	return nil
+}
+
+type StateManifest struct {
+	Block                *SnapshotBlock    `protobuf:"bytes,1,opt,name=Block,proto3" json:"Block,omitempty"`
+	Root                 []byte            `protobuf:"bytes,2,opt,name=Root,proto3" json:"Root,omitempty"`
+	Chunks               []*StateChunkInfo `protobuf:"bytes,3,rep,name=Chunks,proto3" json:"Chunks,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *StateManifest) Reset()         { *m = StateManifest{} }
+func (m *StateManifest) String() string { return proto.CompactTextString(m) }
+func (*StateManifest) ProtoMessage()    {}
+func (*StateManifest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{2}
+}
+
+func (m *StateManifest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateManifest.Unmarshal(m, b)
+}
+func (m *StateManifest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateManifest.Marshal(b, m, deterministic)
+}
+func (m *StateManifest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateManifest.Merge(m, src)
+}
+func (m *StateManifest) XXX_Size() int {
+	return xxx_messageInfo_StateManifest.Size(m)
+}
+func (m *StateManifest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateManifest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateManifest proto.InternalMessageInfo
+
+func (m *StateManifest) GetBlock() *SnapshotBlock {
+	if m != nil {
+		return m.Block
+	}
+	return nil
+}
+
+func (m *StateManifest) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *StateManifest) GetChunks() []*StateChunkInfo {
+	if m != nil {
+		return m.Chunks
+	}
+	return nil
+}
+
+type GetStateChunk struct {
+	Height               uint64   `protobuf:"varint,1,opt,name=Height,proto3" json:"Height,omitempty"`
+	Root                 []byte   `protobuf:"bytes,2,opt,name=Root,proto3" json:"Root,omitempty"`
+	Index                uint64   `protobuf:"varint,3,opt,name=Index,proto3" json:"Index,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStateChunk) Reset()         { *m = GetStateChunk{} }
+func (m *GetStateChunk) String() string { return proto.CompactTextString(m) }
+func (*GetStateChunk) ProtoMessage()    {}
+func (*GetStateChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{3}
+}
+
+func (m *GetStateChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStateChunk.Unmarshal(m, b)
+}
+func (m *GetStateChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStateChunk.Marshal(b, m, deterministic)
+}
+func (m *GetStateChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStateChunk.Merge(m, src)
+}
+func (m *GetStateChunk) XXX_Size() int {
+	return xxx_messageInfo_GetStateChunk.Size(m)
+}
+func (m *GetStateChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStateChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStateChunk proto.InternalMessageInfo
+
+func (m *GetStateChunk) GetHeight() uint64 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+func (m *GetStateChunk) GetRoot() []byte {
+	if m != nil {
+		return m.Root
+	}
+	return nil
+}
+
+func (m *GetStateChunk) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+type StateEntry struct {
+	Key                  []byte   `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value                []byte   `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StateEntry) Reset()         { *m = StateEntry{} }
+func (m *StateEntry) String() string { return proto.CompactTextString(m) }
+func (*StateEntry) ProtoMessage()    {}
+func (*StateEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{4}
+}
+
+func (m *StateEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateEntry.Unmarshal(m, b)
+}
+func (m *StateEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateEntry.Marshal(b, m, deterministic)
+}
+func (m *StateEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateEntry.Merge(m, src)
+}
+func (m *StateEntry) XXX_Size() int {
+	return xxx_messageInfo_StateEntry.Size(m)
+}
+func (m *StateEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateEntry proto.InternalMessageInfo
+
+func (m *StateEntry) GetKey() []byte {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (m *StateEntry) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type StateChunk struct {
+	Index                uint64        `protobuf:"varint,1,opt,name=Index,proto3" json:"Index,omitempty"`
+	Entries              []*StateEntry `protobuf:"bytes,2,rep,name=Entries,proto3" json:"Entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *StateChunk) Reset()         { *m = StateChunk{} }
+func (m *StateChunk) String() string { return proto.CompactTextString(m) }
+func (*StateChunk) ProtoMessage()    {}
+func (*StateChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_4792ed07cc51145d, []int{5}
+}
+
+func (m *StateChunk) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StateChunk.Unmarshal(m, b)
+}
+func (m *StateChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StateChunk.Marshal(b, m, deterministic)
+}
+func (m *StateChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StateChunk.Merge(m, src)
+}
+func (m *StateChunk) XXX_Size() int {
+	return xxx_messageInfo_StateChunk.Size(m)
+}
+func (m *StateChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_StateChunk.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StateChunk proto.InternalMessageInfo
+
+func (m *StateChunk) GetIndex() uint64 {
+	if m != nil {
+		return m.Index
+	}
+	return 0
+}
+
+func (m *StateChunk) GetEntries() []*StateEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetStateManifest)(nil), "vitepb.GetStateManifest")
+	proto.RegisterType((*StateChunkInfo)(nil), "vitepb.StateChunkInfo")
+	proto.RegisterType((*StateManifest)(nil), "vitepb.StateManifest")
+	proto.RegisterType((*GetStateChunk)(nil), "vitepb.GetStateChunk")
+	proto.RegisterType((*StateEntry)(nil), "vitepb.StateEntry")
+	proto.RegisterType((*StateChunk)(nil), "vitepb.StateChunk")
+}
+
+func init() { proto.RegisterFile("vitepb/state_sync.proto", fileDescriptor_4792ed07cc51145d) }
+
+var fileDescriptor_4792ed07cc51145d = []byte{
+	// 311 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0x51, 0x4b, 0x02, 0x41,
+	0x14, 0x85, 0x59, 0x57, 0xb7, 0xb8, 0x6a, 0xc8, 0x60, 0xb6, 0xd8, 0x8b, 0xcc, 0x93, 0x54, 0x6c,
+	0x60, 0xfd, 0x82, 0x24, 0x52, 0x22, 0xa8, 0x11, 0x7c, 0x95, 0xd1, 0xc6, 0x76, 0x51, 0x66, 0xc4,
+	0xb9, 0x46, 0xfb, 0xd6, 0x4f, 0x8f, 0xbd, 0xb3, 0xbb, 0xb9, 0x50, 0x6f, 0x73, 0x76, 0xce, 0x7e,
+	0xf7, 0x9e, 0x33, 0x70, 0xf1, 0x99, 0xa0, 0xda, 0x2d, 0x6f, 0x2d, 0x4a, 0x54, 0x0b, 0x9b, 0xea,
+	0x55, 0xb4, 0xdb, 0x1b, 0x34, 0x2c, 0x70, 0x17, 0xfd, 0xcb, 0xc2, 0xa0, 0xe5, 0xce, 0xc6, 0x06,
+	0x17, 0xcb, 0xad, 0x59, 0x6d, 0x9c, 0x89, 0x5f, 0x41, 0xe7, 0x49, 0xe1, 0x2c, 0xfb, 0xf7, 0x45,
+	0xea, 0x64, 0xad, 0x2c, 0xb2, 0x1e, 0x04, 0x13, 0x95, 0x7c, 0xc4, 0x18, 0x7a, 0x03, 0x6f, 0x58,
+	0x17, 0xb9, 0xe2, 0x73, 0x38, 0x23, 0xe3, 0x38, 0x3e, 0xe8, 0xcd, 0x54, 0xaf, 0x0d, 0xeb, 0xc3,
+	0xe9, 0x0c, 0xe5, 0x1e, 0x9f, 0x55, 0x4a, 0xde, 0x96, 0x28, 0x35, 0xeb, 0x42, 0x63, 0x6c, 0x0e,
+	0x1a, 0xc3, 0x1a, 0x41, 0x9c, 0x60, 0x0c, 0xea, 0x13, 0x69, 0xe3, 0xd0, 0x27, 0x37, 0x9d, 0xf9,
+	0xb7, 0x07, 0xed, 0xea, 0x06, 0xd7, 0xd0, 0x78, 0xc8, 0x96, 0x24, 0x68, 0x73, 0x74, 0x1e, 0xb9,
+	0x08, 0xd1, 0x2c, 0x8f, 0x40, 0x97, 0xc2, 0x79, 0x32, 0xa4, 0x30, 0xc6, 0xcd, 0x69, 0x09, 0x3a,
+	0xb3, 0x08, 0x02, 0xda, 0xd2, 0x86, 0xfe, 0xc0, 0x1f, 0x36, 0x47, 0xbd, 0x92, 0x50, 0x09, 0x20,
+	0x72, 0x17, 0x7f, 0x83, 0x76, 0x51, 0x03, 0x7d, 0xf9, 0xaf, 0x83, 0x3f, 0x87, 0x75, 0xa1, 0x31,
+	0xd5, 0xef, 0xea, 0x8b, 0x42, 0xd5, 0x85, 0x13, 0xfc, 0x1e, 0x80, 0x78, 0x8f, 0x1a, 0xf7, 0x29,
+	0xeb, 0x80, 0xff, 0x5b, 0x92, 0x9f, 0xf7, 0x33, 0x97, 0xdb, 0x83, 0xca, 0x51, 0x4e, 0xf0, 0x57,
+	0x80, 0xa3, 0x2d, 0x4a, 0xb2, 0x77, 0x44, 0x66, 0x37, 0x70, 0x92, 0x41, 0x13, 0x65, 0xc3, 0x1a,
+	0xa5, 0x63, 0x95, 0x74, 0x34, 0x50, 0x14, 0x96, 0x65, 0x40, 0x0f, 0x7d, 0xf7, 0x33, 0x00, 0x0b,
+	0xaf, 0xec, 0x3a, 0x28, 0x02, 0x00, 0x00,
+}
@@ -2,7 +2,6 @@ package abi
 
 import (
 	"encoding/json"
-	"github.com/vitelabs/go-vite/common/helper"
 	"reflect"
 	"strings"
 )
@@ -17,19 +16,24 @@ type Argument struct {
 
 type Arguments []Argument
 
+// ArgumentMarshaling is the json form of an argument, Components are the fields of a tuple argument
+type ArgumentMarshaling struct {
+	Name         string
+	Type         string
+	InternalType string
+	Components   []ArgumentMarshaling
+	Indexed      bool
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface
 func (argument *Argument) UnmarshalJSON(data []byte) error {
-	var extarg struct {
-		Name    string
-		Type    string
-		Indexed bool
-	}
+	var extarg ArgumentMarshaling
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
 		return errArgumentJsonErr(err)
 	}
 
-	argument.Type, err = NewType(extarg.Type)
+	argument.Type, err = NewTypeWithComponents(extarg.Type, extarg.InternalType, extarg.Components)
 	if err != nil {
 		return err
 	}
@@ -82,7 +86,7 @@ func (arguments Arguments) unpackTuple(v interface{}, marshalledValues []interfa
 	var abi2struct map[string]string
 	if kind == reflect.Struct {
 		var err error
-		abi2struct, err = mapAbiToStructFields(arguments, value)
+		abi2struct, err = mapAbiToStructFields(arguments.names(), value)
 		if err != nil {
 			return err
 		}
@@ -130,13 +134,17 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 	var abi2struct map[string]string
 	if kind == reflect.Struct {
 		var err error
-		if abi2struct, err = mapAbiToStructFields(arguments, elem); err != nil {
+		if abi2struct, err = mapAbiToStructFields(arguments.names(), elem); err != nil {
 			return err
 		}
 		arg := arguments[0]
 		if structField, ok := abi2struct[arg.Name]; ok {
 			return set(elem.FieldByName(structField), reflectValue, arg)
 		}
+		// a single tuple can be unpacked into the struct itself
+		if arg.Type.T == TupleTy {
+			return set(elem, reflectValue, arg)
+		}
 		return nil
 	}
 
@@ -144,19 +152,12 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 
 }
 
-// Computes the full size of an array;
-// i.e. counting nested arrays, which count towards size for unpacking.
-func getArraySize(arr *Type) int {
-	size := arr.Size
-	// Arrays can be nested, with each element being the same size
-	arr = arr.Elem
-	for arr.T == ArrayTy {
-		// Keep multiplying by elem.Size while the elem is an array.
-		size *= arr.Size
-		arr = arr.Elem
+func (arguments Arguments) names() []string {
+	names := make([]string, len(arguments))
+	for i, arg := range arguments {
+		names[i] = arg.Name
 	}
-	// Now we have the full array size, including its children.
-	return size
+	return names
 }
 
 // UnpackValues can be used to unpack ABI-encoded hexdata according to the ABI-specification,
@@ -164,25 +165,15 @@ func getArraySize(arr *Type) int {
 // values. An atomic argument will be a list with one element.
 func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
 	retval := make([]interface{}, 0, len(arguments))
-	virtualArgs := 0
-	for index, arg := range arguments {
-		marshalledValue, err := toGoType((index+virtualArgs)*helper.WordSize, arg.Type, data)
-		if arg.Type.T == ArrayTy {
-			// If we have a static array, like [3]uint256, these are coded as
-			// just like uint256,uint256,uint256.
-			// This means that we need to add two 'virtual' arguments when
-			// we count the index from now on.
-			//
-			// Array values nested multiple levels deep are also encoded inline:
-			// [2][3]uint256: uint256,uint256,uint256,uint256,uint256,uint256
-			//
-			// Calculate the full array size to get the correct offset for the next argument.
-			// Decrement it by 1, as the normal index increment is still applied.
-			virtualArgs += getArraySize(&arg.Type) - 1
-		}
+	// static arrays and tuples are encoded inline, like [3]uint256 is encoded as uint256,uint256,uint256,
+	// so the offset of the next argument is counted by the size of each argument.
+	offset := 0
+	for _, arg := range arguments {
+		marshalledValue, err := toGoType(offset, arg.Type, data)
 		if err != nil {
 			return nil, err
 		}
+		offset += getTypeSize(arg.Type)
 		retval = append(retval, marshalledValue)
 	}
 	return retval, nil
@@ -208,11 +199,7 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 	// input offset is the bytes offset for packed output
 	inputOffset := 0
 	for _, abiArg := range abiArgs {
-		if abiArg.Type.T == ArrayTy {
-			inputOffset += helper.WordSize * abiArg.Type.Size
-		} else {
-			inputOffset += helper.WordSize
-		}
+		inputOffset += getTypeSize(abiArg.Type)
 	}
 	var ret []byte
 	for i, a := range args {
@@ -222,8 +209,8 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		// check for a slice type (string, bytes, slice)
-		if input.Type.requiresLengthPrefix() {
+		// check for a dynamic type (string, bytes, slice, or arrays and tuples contain them)
+		if isDynamicType(input.Type) {
 			// calculate the offset
 			offset := inputOffset + len(variableInput)
 			// set the offset
@@ -22,6 +22,8 @@ var (
 	errPureUnderscoredOutput       = errors.New("abi: purely underscored output cannot unpack to struct")
 	errInvalidlFixedBytesType      = errors.New("abi: invalid type in call to make fixed byte array")
 	errInvalidlArrayType           = errors.New("abi: invalid type in array/slice unpacking stage")
+	errEmptyTupleComponents        = errors.New("abi: tuple components should not be empty")
+	errAssignNilValue              = errors.New("abi: cannot assign nil value")
 )
 
 // parse json errors
@@ -59,6 +61,9 @@ func errParsingVariableSize(err error) error {
 func errUnsupportedArgType(t string) error {
 	return fmt.Errorf("abi: unsupported arg type: %s", t)
 }
+func errInvalidComponentName(name string) error {
+	return fmt.Errorf("abi: invalid tuple component name '%s'", name)
+}
 func errUnknownType(t Type) error {
 	return fmt.Errorf("abi: unknown type %v", t.T)
 }
@@ -85,6 +90,9 @@ func errInsufficientElementSize(minLen int, v reflect.Value) error {
 func errUnmarshalTypeFailed(src, dst reflect.Value) error {
 	return fmt.Errorf("abi: cannot unmarshal %v in to %v", src.Type(), dst.Type())
 }
+func errTooManyTopicQueries(queries int, indexed Arguments) error {
+	return fmt.Errorf("abi: topic query count mismatch: %d for %d indexed inputs", queries, len(indexed))
+}
 func errInvalidTuple(typ reflect.Type) error {
 	return fmt.Errorf("abi: cannot unmarshal tuple into %v", typ)
 }
@@ -103,6 +111,9 @@ func errMultipleVariable(abiFieldName string) error {
 func errMultipleOutput(structFieldName string) error {
 	return fmt.Errorf("abi: multiple outputs mapping to the same struct field '%s'", structFieldName)
 }
+func errTupleFieldNotFound(name string) error {
+	return fmt.Errorf("abi: field for tuple component '%s' not found in the given struct", name)
+}
 func errNegativeInputSize(size int) error {
 	return fmt.Errorf("cannot marshal input to array, size is negative (%d)", size)
 }
@@ -149,8 +160,11 @@ func sliceTypeCheck(t Type, val reflect.Value) error {
 		if val.Len() > 0 {
 			return sliceTypeCheck(*t.Elem, val.Index(0))
 		}
-	} else if t.Elem.T == ArrayTy {
+	} else if t.Elem.T == ArrayTy && val.Len() > 0 {
 		return sliceTypeCheck(*t.Elem, val.Index(0))
+	} else if t.Elem.T == TupleTy {
+		// tuple elements may be structs or pointers, each of them is checked when packed
+		return nil
 	}
 
 	if elemKind := val.Type().Elem().Kind(); (elemKind != reflect.Slice && elemKind != t.Elem.Kind) ||
@@ -53,15 +53,11 @@ func (e Event) Pack(args ...interface{}) ([]types.Hash, []byte, error) {
 	topicIndex := 1
 	for i := 0; i < len(args); i++ {
 		if e.Inputs[i].Indexed {
-			topic, err := e.Inputs[i].Type.pack(reflect.ValueOf(args[i]))
+			topic, err := makeTopic(e.Inputs[i].Type, args[i])
 			if err != nil {
 				return nil, nil, err
 			}
-			if len(topic) <= types.HashSize {
-				topics[topicIndex], _ = types.BytesToHash(helper.LeftPadBytes(topic, types.HashSize))
-			} else {
-				topics[topicIndex] = types.DataHash(topic)
-			}
+			topics[topicIndex] = topic
 			topicIndex = topicIndex + 1
 		} else {
 			nonIndexedArgList = append(nonIndexedArgList, args[i])
@@ -78,6 +74,39 @@ func (e Event) Pack(args ...interface{}) ([]types.Hash, []byte, error) {
 	}
 }
 
+// MakeTopics converts the query values of the indexed inputs into the topics of a vm log filter,
+// the first topic is the event id, values in a query are ORed, an empty query matches any value.
+func (e Event) MakeTopics(query ...[]interface{}) ([][]types.Hash, error) {
+	if len(query) > len(e.IndexedInputs) {
+		return nil, errTooManyTopicQueries(len(query), e.IndexedInputs)
+	}
+	topics := make([][]types.Hash, len(query)+1)
+	topics[0] = []types.Hash{e.Id()}
+	for i, values := range query {
+		for _, v := range values {
+			topic, err := makeTopic(e.IndexedInputs[i].Type, v)
+			if err != nil {
+				return nil, err
+			}
+			topics[i+1] = append(topics[i+1], topic)
+		}
+	}
+	return topics, nil
+}
+
+// makeTopic packs an indexed value, the value is hashed if it's longer than a word,
+// indexed tuples are always hashed like the dynamic types
+func makeTopic(t Type, v interface{}) (types.Hash, error) {
+	topic, err := t.pack(reflect.ValueOf(v))
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if len(topic) <= types.HashSize && t.T != TupleTy {
+		return types.BytesToHash(helper.LeftPadBytes(topic, types.HashSize))
+	}
+	return types.DataHash(topic), nil
+}
+
 func (e Event) DirectUnPack(topics []types.Hash, data []byte) ([]interface{}, error) {
 	nonIndexedParams, err := e.NonIndexedInputs.DirectUnpack(data)
 	if err != nil {
@@ -88,7 +117,7 @@ func (e Event) DirectUnPack(topics []types.Hash, data []byte) ([]interface{}, er
 	args := make([]interface{}, 0)
 	for _, arg := range e.Inputs {
 		if arg.Indexed {
-			if arg.Type.T == ArrayTy || arg.Type.T == StringTy || arg.Type.T == SliceTy || arg.Type.T == BytesTy {
+			if arg.Type.T == ArrayTy || arg.Type.T == StringTy || arg.Type.T == SliceTy || arg.Type.T == BytesTy || arg.Type.T == TupleTy {
 				args = append(args, topics[index])
 			} else {
 				arg, err := toGoType(0, arg.Type, topics[index].Bytes())
@@ -373,3 +373,29 @@ func TestDirectUnpackEvent(t *testing.T) {
 		t.Fatalf("unpack event failed, got %v", result)
 	}
 }
+
+func TestEventMakeTopics(t *testing.T) {
+	definition := `[{"name": "test", "type": "event", "inputs": [{"indexed": true, "name":"value1", "type":"uint8"},{"indexed": true, "name":"value2", "type":"string"},{"indexed": false, "name":"value3", "type":"uint8"}]}]`
+	abi, err := JSONToABIContract(strings.NewReader(definition))
+	require.NoError(t, err)
+	topics, _, err := abi.PackEvent("test", uint8(1), "abc", uint8(2))
+	require.NoError(t, err)
+
+	e := abi.Events["test"]
+	query, err := e.MakeTopics(nil, []interface{}{"abc", "abd"})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(query))
+	require.Equal(t, []types.Hash{topics[0]}, query[0])
+	require.Equal(t, 0, len(query[1]))
+	require.Equal(t, 2, len(query[2]))
+	require.Equal(t, topics[2], query[2][0])
+
+	query, err = e.MakeTopics([]interface{}{uint8(1)})
+	require.NoError(t, err)
+	require.Equal(t, []types.Hash{topics[1]}, query[1])
+
+	_, err = e.MakeTopics(nil, nil, nil)
+	require.Error(t, err)
+	_, err = e.MakeTopics([]interface{}{"abc"})
+	require.Error(t, err)
+}
@@ -433,3 +433,93 @@ func TestPackNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestPackNestedDynamic(t *testing.T) {
+	// the example of the contract ABI specification: g(uint256[][],string[]) with ([[1, 2], [3]], ["one", "two", "three"])
+	uint256SliceSlice, _ := NewType("uint256[][]")
+	stringSlice, _ := NewType("string[]")
+	args := Arguments{{Name: "a", Type: uint256SliceSlice}, {Name: "b", Type: stringSlice}}
+
+	output := helper.HexToBytes("" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"0000000000000000000000000000000000000000000000000000000000000140" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000040" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"0000000000000000000000000000000000000000000000000000000000000060" +
+		"00000000000000000000000000000000000000000000000000000000000000a0" +
+		"00000000000000000000000000000000000000000000000000000000000000e0" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"6f6e650000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000003" +
+		"74776f0000000000000000000000000000000000000000000000000000000000" +
+		"0000000000000000000000000000000000000000000000000000000000000005" +
+		"7468726565000000000000000000000000000000000000000000000000000000")
+
+	a := [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3)}}
+	b := []string{"one", "two", "three"}
+	packed, err := args.Pack(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, output) {
+		t.Fatalf("pack nested dynamic failed, got %x", packed)
+	}
+
+	values, err := args.UnpackValues(packed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []interface{}{a, b}) {
+		t.Fatalf("unpack nested dynamic failed, got %v", values)
+	}
+}
+
+func TestPackTuple(t *testing.T) {
+	typ, err := NewTypeWithComponents("tuple", "", []ArgumentMarshaling{
+		{Name: "amount", Type: "uint256"},
+		{Name: "memo", Type: "string"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	uint8Type, _ := NewType("uint8")
+	args := Arguments{{Name: "order", Type: typ}, {Name: "flag", Type: uint8Type}}
+
+	output := helper.HexToBytes("" +
+		"0000000000000000000000000000000000000000000000000000000000000040" + // offset of the tuple
+		"0000000000000000000000000000000000000000000000000000000000000007" + // flag
+		"0000000000000000000000000000000000000000000000000000000000000001" + // amount
+		"0000000000000000000000000000000000000000000000000000000000000040" + // offset of memo in the tuple
+		"0000000000000000000000000000000000000000000000000000000000000004" +
+		"7669746500000000000000000000000000000000000000000000000000000000")
+
+	// fields are paired by abi tags and names, the order of the fields does not matter
+	order := struct {
+		Text   string `abi:"memo"`
+		Amount *big.Int
+	}{"vite", big.NewInt(1)}
+	for _, v := range []interface{}{order, &order} {
+		packed, err := args.Pack(v, uint8(7))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packed, output) {
+			t.Fatalf("pack tuple failed, got %x", packed)
+		}
+	}
+
+	missing := struct{ Amount *big.Int }{big.NewInt(1)}
+	if _, err := args.Pack(missing, uint8(7)); err == nil {
+		t.Fatal("pack tuple with missing field should fail")
+	}
+	if _, err := args.Pack(big.NewInt(1), uint8(7)); err == nil {
+		t.Fatal("pack tuple with non struct should fail")
+	}
+}
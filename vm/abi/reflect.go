@@ -63,13 +63,87 @@ func set(dst, src reflect.Value, output Argument) error {
 	case dstType.Kind() == reflect.Interface:
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dstType.Elem()))
+		}
 		return set(dst.Elem(), src, output)
+	case srcType.Kind() == reflect.Struct && dstType.Kind() == reflect.Struct:
+		return setStruct(dst, src, output)
+	case srcType.Kind() == reflect.Slice && dstType.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case srcType.Kind() == reflect.Array && dstType.Kind() == reflect.Array && srcType.Len() == dstType.Len():
+		for i := 0; i < src.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
 	default:
 		return errUnmarshalTypeFailed(src, dst)
 	}
 	return nil
 }
 
+// Assign sets the unpacked value src to the value dst points to,
+// a tuple can be assigned to any struct with the matched field names, such as the generated bindings.
+func Assign(dst interface{}, src interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errInvalidStruct(dst)
+	}
+	if src == nil {
+		return errAssignNilValue
+	}
+	return set(value.Elem(), reflect.ValueOf(src), Argument{})
+}
+
+// setStruct assigns the unpacked tuple src to the go struct dst,
+// the fields are paired by the `abi:""` tags or the capitalised component names like arguments.
+func setStruct(dst, src reflect.Value, output Argument) error {
+	srcType := src.Type()
+	names := make([]string, srcType.NumField())
+	for i := range names {
+		names[i] = srcType.Field(i).Tag.Get("json")
+	}
+
+	abi2struct, err := mapAbiToStructFields(names, dst)
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		if structField, ok := abi2struct[name]; ok {
+			if err := set(dst.FieldByName(structField), src.Field(i), output); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tupleFields returns the values of the tuple components from the go struct value,
+// the fields are paired by the `abi:""` tags or the capitalised component names.
+func tupleFields(t Type, value reflect.Value) ([]reflect.Value, error) {
+	abi2struct, err := mapAbiToStructFields(t.TupleRawNames, value)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]reflect.Value, len(t.TupleRawNames))
+	for i, name := range t.TupleRawNames {
+		structField, ok := abi2struct[name]
+		if !ok {
+			return nil, errTupleFieldNotFound(name)
+		}
+		fields[i] = value.FieldByName(structField)
+	}
+	return fields, nil
+}
+
 // requireAssignable assures that `dest` is a pointer and it's not an interface.
 func requireAssignable(dst, src reflect.Value) error {
 	if dst.Kind() != reflect.Ptr && dst.Kind() != reflect.Interface {
@@ -100,7 +174,7 @@ func requireUnpackKind(v reflect.Value, t reflect.Type, k reflect.Kind,
 // second round: for each argument field that has not been already linked,
 //   find what variable is expected to be mapped into, if it exists and has not been
 //   used, pair them.
-func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]string, error) {
+func mapAbiToStructFields(argNames []string, value reflect.Value) (map[string]string, error) {
 
 	typ := value.Type()
 
@@ -130,14 +204,14 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 
 		// check which argument field matches with the abi tag.
 		found := false
-		for _, abiField := range args {
-			if abiField.Name == tagName {
-				if abi2struct[abiField.Name] != "" {
+		for _, abiFieldName := range argNames {
+			if abiFieldName == tagName {
+				if abi2struct[abiFieldName] != "" {
 					return nil, errTagAlreadyMapped(structFieldName)
 				}
 				// pair them
-				abi2struct[abiField.Name] = structFieldName
-				struct2abi[structFieldName] = abiField.Name
+				abi2struct[abiFieldName] = structFieldName
+				struct2abi[structFieldName] = abiFieldName
 				found = true
 			}
 		}
@@ -150,9 +224,8 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 	}
 
 	// second round ~~~
-	for _, arg := range args {
+	for _, abiFieldName := range argNames {
 
-		abiFieldName := arg.Name
 		structFieldName := capitalise(abiFieldName)
 
 		if structFieldName == "" {
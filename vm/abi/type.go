@@ -1,6 +1,7 @@
 package abi
 
 import (
+	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"reflect"
 	"regexp"
@@ -21,6 +22,7 @@ const (
 	TokenIdTy
 	FixedBytesTy
 	BytesTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -33,15 +35,29 @@ type Type struct {
 	T    byte // Our own type checking
 
 	stringKind string // holds the unparsed string for deriving signatures
+
+	// Tuple relative fields, Type of the tuple is a struct with the capitalised component names as fields
+	TupleRawName  string   // struct name defined in the source code, may be empty
+	TupleElems    []*Type  // types of the tuple components
+	TupleRawNames []string // raw names of the tuple components
 }
 
 var (
 	// typeRegex parses the abi sub types
 	typeRegex = regexp.MustCompile("([a-zA-Z]+)([0-9]+)?")
+	// componentNameRegex checks the tuple component names can be go struct field names
+	componentNameRegex = regexp.MustCompile("^_*[a-zA-Z][a-zA-Z0-9_]*$")
 )
 
 // NewType creates a new reflection type of abi type given in t.
 func NewType(t string) (typ Type, err error) {
+	return NewTypeWithComponents(t, "", nil)
+}
+
+// NewTypeWithComponents creates a new reflection type of abi type given in t,
+// components are required by tuple types like "tuple" and "tuple[2][]",
+// internalType is the source code type like "struct Order[]", it's used to name the tuple.
+func NewTypeWithComponents(t string, internalType string, components []ArgumentMarshaling) (typ Type, err error) {
 	if t == "uint" || t == "int" {
 		// this should fail because it means that there's something wrong with
 		// the abi type (the compiler should always format it to the size...always)
@@ -49,7 +65,7 @@ func NewType(t string) (typ Type, err error) {
 	}
 
 	// check that array brackets are equal if they exist
-	if strings.Count(t, "[") != strings.Count(t, "]") || strings.Count(t, "[") > 1 {
+	if strings.Count(t, "[") != strings.Count(t, "]") {
 		return Type{}, errUnsupportedArgType(t)
 	}
 
@@ -59,13 +75,19 @@ func NewType(t string) (typ Type, err error) {
 	// recursively create the type
 	if strings.Count(t, "[") != 0 {
 		i := strings.LastIndex(t, "[")
+		subInternalType := internalType
+		if j := strings.LastIndex(internalType, "["); j != -1 {
+			subInternalType = internalType[:j]
+		}
 		// recursively embed the type
-		embeddedType, err := NewType(t[:i])
+		embeddedType, err := NewTypeWithComponents(t[:i], subInternalType, components)
 		if err != nil {
 			return Type{}, err
 		}
 		// grab the last cell and create a type from there
 		sliced := t[i:]
+		// tuple types are written as their components in signatures
+		typ.stringKind = embeddedType.stringKind + sliced
 		// grab the slice size with regexp
 		re := regexp.MustCompile("[0-9]+")
 		intz := re.FindAllString(sliced, -1)
@@ -95,8 +117,16 @@ func NewType(t string) (typ Type, err error) {
 		}
 		return typ, err
 	}
+	if t == "tuple" {
+		return newTupleType(internalType, components)
+	}
+
 	// parse the type and size of the abi-type.
-	parsedType := typeRegex.FindAllStringSubmatch(t, -1)[0]
+	matches := typeRegex.FindAllStringSubmatch(t, -1)
+	if len(matches) == 0 {
+		return Type{}, errUnsupportedArgType(t)
+	}
+	parsedType := matches[0]
 	// varSize is the size of the variable
 	var varSize int
 	if len(parsedType[2]) > 0 {
@@ -160,6 +190,62 @@ func NewType(t string) (typ Type, err error) {
 	return
 }
 
+// newTupleType creates the tuple type with its components,
+// the signature of a tuple is its component types in parentheses, like "(uint256,string[])"
+func newTupleType(internalType string, components []ArgumentMarshaling) (typ Type, err error) {
+	if len(components) == 0 {
+		return Type{}, errEmptyTupleComponents
+	}
+
+	var (
+		fields      = make([]reflect.StructField, 0, len(components))
+		elems       = make([]*Type, 0, len(components))
+		names       = make([]string, 0, len(components))
+		signatures  = make([]string, 0, len(components))
+		fieldExists = make(map[string]bool, len(components))
+	)
+	for _, c := range components {
+		elem, err := NewTypeWithComponents(c.Type, c.InternalType, c.Components)
+		if err != nil {
+			return Type{}, err
+		}
+
+		if !componentNameRegex.MatchString(c.Name) {
+			return Type{}, errInvalidComponentName(c.Name)
+		}
+		fieldName := capitalise(c.Name)
+		if fieldExists[fieldName] {
+			return Type{}, errMultipleOutput(fieldName)
+		}
+		fieldExists[fieldName] = true
+
+		fields = append(fields, reflect.StructField{
+			Name: fieldName,
+			Type: elem.Type,
+			Tag:  reflect.StructTag(`json:"` + c.Name + `"`),
+		})
+		elems = append(elems, &elem)
+		names = append(names, c.Name)
+		signatures = append(signatures, elem.stringKind)
+	}
+
+	typ.T = TupleTy
+	typ.Kind = reflect.Struct
+	typ.Type = reflect.StructOf(fields)
+	typ.TupleElems = elems
+	typ.TupleRawNames = names
+	typ.stringKind = "(" + strings.Join(signatures, ",") + ")"
+
+	// internal type of solidity++ struct is like "struct Exchange.Order",
+	// it is converted to "ExchangeOrder" as a go type name
+	const structPrefix = "struct "
+	if strings.HasPrefix(internalType, structPrefix) {
+		typ.TupleRawName = strings.Replace(internalType[len(structPrefix):], ".", "", -1)
+	}
+
+	return typ, nil
+}
+
 // String implements Stringer
 func (t Type) String() (out string) {
 	return t.stringKind
@@ -173,21 +259,70 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if t.T == SliceTy || t.T == ArrayTy {
-		var packed []byte
+	switch t.T {
+	case SliceTy, ArrayTy:
+		var ret, tail []byte
+		if t.T == SliceTy {
+			length, err := packNum(reflect.ValueOf(v.Len()))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, length...)
+		}
 
+		// dynamic elements are placed after the offsets of all elements
+		dynamic := isDynamicType(*t.Elem)
+		offset := 0
+		if dynamic {
+			offset = getTypeSize(*t.Elem) * v.Len()
+		}
 		for i := 0; i < v.Len(); i++ {
 			val, err := t.Elem.pack(v.Index(i))
 			if err != nil {
 				return nil, err
 			}
-			packed = append(packed, val...)
+			if !dynamic {
+				ret = append(ret, val...)
+				continue
+			}
+			packedOffset, err := packNum(reflect.ValueOf(offset))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, packedOffset...)
+			tail = append(tail, val...)
+			offset += len(val)
 		}
-		if t.T == SliceTy {
-			return packBytesSlice(packed, v.Len())
-		} else if t.T == ArrayTy {
-			return packed, nil
+		return append(ret, tail...), nil
+	case TupleTy:
+		fields, err := tupleFields(t, v)
+		if err != nil {
+			return nil, err
+		}
+
+		offset := 0
+		for _, elem := range t.TupleElems {
+			offset += getTypeSize(*elem)
+		}
+		var ret, tail []byte
+		for i, elem := range t.TupleElems {
+			val, err := elem.pack(fields[i])
+			if err != nil {
+				return nil, err
+			}
+			if !isDynamicType(*elem) {
+				ret = append(ret, val...)
+				continue
+			}
+			packedOffset, err := packNum(reflect.ValueOf(offset))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, packedOffset...)
+			tail = append(tail, val...)
+			offset += len(val)
 		}
+		return append(ret, tail...), nil
 	}
 	return packElement(t, v)
 }
@@ -197,3 +332,40 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 func (t Type) requiresLengthPrefix() bool {
 	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy
 }
+
+// isDynamicType returns whether the type is encoded at the tail with an offset,
+// like string, bytes, slices, and arrays or tuples contain any of them.
+func isDynamicType(t Type) bool {
+	switch t.T {
+	case StringTy, BytesTy, SliceTy:
+		return true
+	case ArrayTy:
+		return isDynamicType(*t.Elem)
+	case TupleTy:
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getTypeSize returns the size that the type occupies in the head of the encoding,
+// static arrays and tuples are encoded inline, others occupy one word.
+func getTypeSize(t Type) int {
+	if isDynamicType(t) {
+		return helper.WordSize
+	}
+	switch t.T {
+	case ArrayTy:
+		return t.Size * getTypeSize(*t.Elem)
+	case TupleTy:
+		size := 0
+		for _, elem := range t.TupleElems {
+			size += getTypeSize(*elem)
+		}
+		return size
+	}
+	return helper.WordSize
+}
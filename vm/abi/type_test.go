@@ -260,3 +260,79 @@ func TestTypeCheck(t *testing.T) {
 		}
 	}
 }
+
+func TestNewTupleType(t *testing.T) {
+	components := []ArgumentMarshaling{
+		{Name: "id", Type: "uint64"},
+		{Name: "owner", Type: "address"},
+		{Name: "items", Type: "tuple[]", InternalType: "struct Shop.Item[]", Components: []ArgumentMarshaling{
+			{Name: "name", Type: "string"},
+			{Name: "amounts", Type: "uint256[2]"},
+		}},
+		{Name: "_tags", Type: "string[][]"},
+	}
+	typ, err := NewTypeWithComponents("tuple[3]", "struct Shop.Order[3]", components)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typ.T != ArrayTy || typ.Size != 3 || typ.Elem.T != TupleTy {
+		t.Fatalf("wrong tuple array type: %v", typeWithoutStringer(typ))
+	}
+	if want := "(uint64,address,(string,uint256[2])[],string[][])[3]"; typ.String() != want {
+		t.Fatalf("wrong signature: %s, want %s", typ.String(), want)
+	}
+
+	tuple := typ.Elem
+	if tuple.TupleRawName != "ShopOrder" || tuple.TupleElems[2].Elem.TupleRawName != "ShopItem" {
+		t.Fatalf("wrong tuple names: %s %s", tuple.TupleRawName, tuple.TupleElems[2].Elem.TupleRawName)
+	}
+	if !reflect.DeepEqual(tuple.TupleRawNames, []string{"id", "owner", "items", "_tags"}) {
+		t.Fatalf("wrong component names: %v", tuple.TupleRawNames)
+	}
+
+	want := reflect.TypeOf(struct {
+		Id    uint64        `json:"id"`
+		Owner types.Address `json:"owner"`
+		Items []struct {
+			Name    string      `json:"name"`
+			Amounts [2]*big.Int `json:"amounts"`
+		} `json:"items"`
+		Tags [][]string `json:"_tags"`
+	}{})
+	if tuple.Type != want {
+		t.Fatalf("wrong tuple go type: %v, want %v", tuple.Type, want)
+	}
+	if typ.Type != reflect.ArrayOf(3, want) {
+		t.Fatalf("wrong tuple array go type: %v", typ.Type)
+	}
+
+	for _, test := range []struct {
+		typ        string
+		components []ArgumentMarshaling
+	}{
+		{"tuple", nil},
+		{"tuple", []ArgumentMarshaling{{Name: "", Type: "uint8"}}},
+		{"tuple", []ArgumentMarshaling{{Name: "a$", Type: "uint8"}}},
+		{"tuple", []ArgumentMarshaling{{Name: "a", Type: "uint8"}, {Name: "A", Type: "uint8"}}},
+		{"tuple", []ArgumentMarshaling{{Name: "a", Type: "uint"}}},
+		{"tuple[", []ArgumentMarshaling{{Name: "a", Type: "uint8"}}},
+	} {
+		if _, err := NewTypeWithComponents(test.typ, "", test.components); err == nil {
+			t.Errorf("%s %v should be invalid", test.typ, test.components)
+		}
+	}
+}
+
+func TestNestedArrayType(t *testing.T) {
+	typ, err := NewType("uint8[2][]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ.T != SliceTy || typ.Elem.T != ArrayTy || typ.Elem.Size != 2 || typ.Type != reflect.TypeOf([][2]uint8{}) {
+		t.Fatalf("wrong nested array type: %v", typeWithoutStringer(typ))
+	}
+	if typ.String() != "uint8[2][]" {
+		t.Fatalf("wrong signature: %s", typ.String())
+	}
+}
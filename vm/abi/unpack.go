@@ -63,23 +63,12 @@ func readFixedBytes(t Type, word []byte) (interface{}, error) {
 
 }
 
-func getFullElemSize(elem *Type) int {
-	//all other should be counted as 32 (slices have pointers to respective elements)
-	size := helper.WordSize
-	//arrays wrap it, each element being the same size
-	for elem.T == ArrayTy {
-		size *= elem.Size
-		elem = elem.Elem
-	}
-	return size
-}
-
 // iteratively unpack elements
 func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error) {
 	if size < 0 {
 		return nil, errNegativeInputSize(size)
 	}
-	if start+helper.WordSize*size > len(output) {
+	if start+getTypeSize(*t.Elem)*size > len(output) {
 		return nil, errArrayOffsetOverflow(output, start, size)
 	}
 
@@ -96,12 +85,9 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 		return nil, errInvalidlArrayType
 	}
 
-	// Arrays have packed elements, resulting in longer unpack steps.
-	// Slices have just 32 bytes per element (pointing to the contents).
-	elemSize := helper.WordSize
-	if t.T == ArrayTy {
-		elemSize = getFullElemSize(t.Elem)
-	}
+	// Static arrays and tuples elements are packed inline, resulting in longer unpack steps.
+	// Others have just 32 bytes per element (the value or the offset of the contents).
+	elemSize := getTypeSize(*t.Elem)
 
 	for i, j := start, 0; j < size; i, j = i+elemSize, j+1 {
 
@@ -118,6 +104,22 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 	return refSlice.Interface(), nil
 }
 
+// forTupleUnpack unpacks the components of a tuple into the struct of the tuple type,
+// output starts with the head of the tuple.
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.Type).Elem()
+	offset := 0
+	for i, elem := range t.TupleElems {
+		marshalledValue, err := toGoType(offset, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		offset += getTypeSize(*elem)
+		retval.Field(i).Set(reflect.ValueOf(marshalledValue))
+	}
+	return retval.Interface(), nil
+}
+
 // toGoType parses the output bytes and recursively assigns the value of these bytes
 // into a go type with accordance with the ABI spec.
 func toGoType(index int, t Type, output []byte) (interface{}, error) {
@@ -143,9 +145,26 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 
 	switch t.T {
 	case SliceTy:
-		return forEachUnpack(t, output, begin, end)
+		// offsets of dynamic elements are relative to the start of the elements
+		return forEachUnpack(t, output[begin:], 0, end)
 	case ArrayTy:
-		return forEachUnpack(t, output, index, t.Size)
+		if isDynamicType(*t.Elem) {
+			begin, err = offsetPointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forEachUnpack(t, output[begin:], 0, t.Size)
+		}
+		return forEachUnpack(t, output[index:], 0, t.Size)
+	case TupleTy:
+		if isDynamicType(t) {
+			begin, err = offsetPointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
 	case StringTy: // variable arrays are written at the end of the return bytes
 		return string(output[begin : begin+end]), nil
 	case IntTy, UintTy:
@@ -170,6 +189,20 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	}
 }
 
+// interprets a 32 byte slice as the offset of a dynamic array or tuple which has no length prefix.
+func offsetPointsTo(index int, output []byte) (start int, err error) {
+	offset := new(big.Int).SetBytes(output[index : index+helper.WordSize])
+	outputLength := big.NewInt(int64(len(output)))
+
+	if offset.Cmp(outputLength) > 0 {
+		return 0, errBigSliceOffsetOverflow(offset, outputLength)
+	}
+	if offset.BitLen() > 63 {
+		return 0, errBigOffsetOverflow(offset)
+	}
+	return int(offset.Uint64()), nil
+}
+
 // interprets a 32 byte slice as an offset and then determines which indice to look to decode the type.
 func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err error) {
 	intpool := util.PoolOfIntPools.Get()
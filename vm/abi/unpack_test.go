@@ -729,3 +729,167 @@ func TestOOMMaliciousInput(t *testing.T) {
 		}
 	}
 }
+
+const tupleJsonData = `
+[
+	{"type":"function","name":"placeOrders","inputs":[
+		{"name":"orders","type":"tuple[]","internalType":"struct Shop.Order[]","components":[
+			{"name":"id","type":"uint64"},
+			{"name":"owner","type":"address"},
+			{"name":"items","type":"tuple[]","components":[
+				{"name":"name","type":"string"},
+				{"name":"amounts","type":"uint256[2]"}
+			]}
+		]},
+		{"name":"memo","type":"string"}
+	]},
+	{"type":"offchain","name":"getOrder","inputs":[{"name":"id","type":"uint64"}],"outputs":[
+		{"name":"order","type":"tuple","components":[
+			{"name":"id","type":"uint64"},
+			{"name":"owner","type":"address"},
+			{"name":"items","type":"tuple[]","components":[
+				{"name":"name","type":"string"},
+				{"name":"amounts","type":"uint256[2]"}
+			]}
+		]},
+		{"name":"exists","type":"bool"}
+	]},
+	{"type":"event","name":"OrderPlaced","inputs":[
+		{"name":"order","type":"tuple","indexed":true,"components":[{"name":"id","type":"uint64"}]},
+		{"name":"item","type":"tuple","components":[{"name":"name","type":"string"},{"name":"amounts","type":"uint256[2]"}]}
+	]}
+]`
+
+type testItem struct {
+	Name    string
+	Amounts [2]*big.Int
+}
+
+type testOrder struct {
+	Id    uint64
+	Owner types.Address
+	Goods []*testItem `abi:"items"`
+}
+
+func TestTupleABI(t *testing.T) {
+	abi, err := JSONToABIContract(strings.NewReader(tupleJsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	method := abi.Methods["placeOrders"]
+	if sig := method.Sig(); sig != "placeOrders((uint64,address,(string,uint256[2])[])[],string)" {
+		t.Fatalf("wrong method signature: %s", sig)
+	}
+
+	owner, _ := types.HexToAddress("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	orders := []testOrder{
+		{Id: 1, Owner: owner, Goods: []*testItem{
+			{"apple", [2]*big.Int{big.NewInt(1), big.NewInt(2)}},
+			{"banana", [2]*big.Int{big.NewInt(3), big.NewInt(4)}},
+		}},
+		{Id: 2, Owner: owner},
+	}
+	data, err := abi.PackMethod("placeOrders", orders, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// direct unpack returns the tuples as anonymous structs, which can be packed again
+	values, err := abi.DirectUnpackMethodInput("placeOrders", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values[1] != "hello" {
+		t.Fatalf("wrong values: %v", values)
+	}
+	data2, err := abi.PackMethod("placeOrders", values...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, data2) {
+		t.Fatal("repack unpacked tuples failed")
+	}
+
+	// unpack to go structs
+	var args struct {
+		Orders []testOrder
+		Memo   string
+	}
+	if err := abi.UnpackMethod(&args, "placeOrders", data); err != nil {
+		t.Fatal(err)
+	}
+	if args.Memo != "hello" || len(args.Orders) != 2 || args.Orders[1].Goods == nil || len(args.Orders[1].Goods) != 0 {
+		t.Fatalf("wrong unpacked args: %+v", args)
+	}
+	if args.Orders[0].Id != 1 || args.Orders[0].Owner != owner || args.Orders[0].Goods[1].Name != "banana" ||
+		args.Orders[0].Goods[1].Amounts[1].Cmp(big.NewInt(4)) != 0 {
+		t.Fatalf("wrong unpacked order: %+v", args.Orders[0])
+	}
+
+	// offchain output
+	output, err := abi.OffChains["getOrder"].Outputs.Pack(orders[0], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := abi.DirectUnpackOffchainOutput("getOrder", output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outputs) != 2 || outputs[1] != true {
+		t.Fatalf("wrong offchain outputs: %v", outputs)
+	}
+	var order testOrder
+	if err := Assign(&order, outputs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if order.Id != 1 || len(order.Goods) != 2 || order.Goods[0].Name != "apple" {
+		t.Fatalf("wrong offchain order: %+v", order)
+	}
+	if err := Assign(order, outputs[0]); err == nil {
+		t.Fatal("assign to non-pointer should fail")
+	}
+	var exists string
+	if err := Assign(&exists, outputs[1]); err == nil {
+		t.Fatal("assign bool to string should fail")
+	}
+
+	// event
+	item := testItem{"apple", [2]*big.Int{big.NewInt(1), big.NewInt(2)}}
+	topics, eventData, err := abi.PackEvent("OrderPlaced", struct{ Id uint64 }{1}, item)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 2 || topics[1] != types.DataHash(helper.LeftPadBytes([]byte{1}, helper.WordSize)) {
+		t.Fatalf("indexed tuple should be hashed: %v", topics)
+	}
+	name, params, err := abi.DirectUnpackEvent(topics, eventData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "event OrderPlaced((uint64) indexed order, (string,uint256[2]) item)" || params[0] != topics[1] {
+		t.Fatalf("wrong event: %s %v", name, params)
+	}
+	var item2 testItem
+	if err := abi.UnpackEvent(&item2, "OrderPlaced", eventData); err != nil {
+		t.Fatal(err)
+	}
+	if item2.Name != "apple" || item2.Amounts[0].Cmp(big.NewInt(1)) != 0 {
+		t.Fatalf("wrong event item: %+v", item2)
+	}
+}
+
+func TestUnpackTupleMaliciousOffset(t *testing.T) {
+	typ, _ := NewTypeWithComponents("tuple", "", []ArgumentMarshaling{{Name: "name", Type: "string"}})
+	args := Arguments{{Name: "a", Type: typ}}
+
+	for _, enc := range []string{
+		"0000000000000000000000000000000000000000000000000000000000000040",
+		"00000000000000000000000000000000000000000000000000000000ffffffff",
+		"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	} {
+		if _, err := args.UnpackValues(helper.HexToBytes(enc)); err == nil {
+			t.Errorf("unpack malicious offset %s should fail", enc)
+		}
+	}
+}
@@ -61,5 +61,11 @@ func (c *contract) run(vm *VM) (ret []byte, err error) {
 		c.intPool = nil
 	}()
 
-	return vm.i.runLoop(vm, c)
+	if vm.tracer == nil {
+		return vm.i.runLoop(vm, c)
+	}
+	vm.tracer.CaptureStart(c.codeAddr, c.data, c.quotaLeft)
+	ret, err = vm.i.runLoop(vm, c)
+	vm.tracer.CaptureEnd(ret, c.quotaLeft, err)
+	return ret, err
 }
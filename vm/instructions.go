@@ -602,6 +602,9 @@ func opSLoad(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte
 	loc := stack.peek()
 	locHash, _ := types.BigToHash(loc)
 	val := util.GetValue(c.db, locHash.Bytes())
+	if vm.tracer != nil {
+		vm.tracer.CaptureStorage(c.codeAddr, locHash.Bytes(), val, false)
+	}
 	loc.SetBytes(val)
 	return nil, nil
 }
@@ -610,6 +613,9 @@ func opSStore(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byt
 	loc, val := stack.pop(), stack.pop()
 	locHash, _ := types.BigToHash(loc)
 	util.SetValue(c.db, locHash.Bytes(), val.Bytes())
+	if vm.tracer != nil {
+		vm.tracer.CaptureStorage(c.codeAddr, locHash.Bytes(), val.Bytes(), true)
+	}
 
 	c.intPool.Put(loc, val)
 	return nil, nil
@@ -58,10 +58,12 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 
 		if !operation.valid {
 			nodeConfig.log.Error("invalid opcode", "op", int(op))
+			vm.captureFault(currentPc, op, util.ErrInvalidOpCode)
 			return nil, util.ErrInvalidOpCode
 		}
 
 		if err := operation.validateStack(st); err != nil {
+			vm.captureFault(currentPc, op, err)
 			return nil, err
 		}
 
@@ -69,19 +71,26 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 		if operation.memorySize != nil {
 			memSize, overflow := helper.BigUint64(operation.memorySize(st))
 			if overflow {
+				vm.captureFault(currentPc, op, util.ErrMemSizeOverflow)
 				return nil, util.ErrMemSizeOverflow
 			}
 			if memorySize, overflow = helper.SafeMul(helper.ToWordSize(memSize), helper.WordSize); overflow {
+				vm.captureFault(currentPc, op, util.ErrMemSizeOverflow)
 				return nil, util.ErrMemSizeOverflow
 			}
 		}
 
 		cost, flag, err = operation.gasCost(vm, c, st, mem, memorySize)
 		if err != nil {
+			vm.captureFault(currentPc, op, err)
 			return nil, err
 		}
+		if vm.tracer != nil {
+			vm.tracer.CaptureState(currentPc, opCodeToString[op], c.quotaLeft, cost, mem.store, st.data)
+		}
 		c.quotaLeft, err = util.UseQuotaWithFlag(c.quotaLeft, cost, flag)
 		if err != nil {
+			vm.captureFault(currentPc, op, err)
 			return nil, err
 		}
 
@@ -120,6 +129,7 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 
 		switch {
 		case err != nil:
+			vm.captureFault(currentPc, op, err)
 			return nil, err
 		case operation.halts:
 			return res, nil
@@ -131,3 +141,9 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 	}
 	panic(util.ErrExecutionCanceled)
 }
+
+func (vm *VM) captureFault(pc uint64, op opCode, err error) {
+	if vm.tracer != nil {
+		vm.tracer.CaptureFault(pc, opCodeToString[op], err)
+	}
+}
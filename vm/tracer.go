@@ -0,0 +1,259 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// Tracer collects execution details of contract code. A tracer is attached
+// to a VM instance by SetTracer and is called synchronously from the
+// interpreter loop, so implementations should be cheap and must not modify
+// the passed in memory or stack.
+type Tracer interface {
+	// CaptureStart is called before contract code starts to run.
+	CaptureStart(addr types.Address, input []byte, quota uint64)
+	// CaptureState is called before each opcode is executed.
+	CaptureState(pc uint64, op string, quota, cost uint64, memory []byte, stack []*big.Int)
+	// CaptureStorage is called when an opcode reads or writes contract storage.
+	CaptureStorage(addr types.Address, key, value []byte, write bool)
+	// CaptureFault is called when an opcode fails.
+	CaptureFault(pc uint64, op string, err error)
+	// CaptureEnd is called after contract code returns.
+	CaptureEnd(output []byte, quotaLeft uint64, err error)
+}
+
+// SetTracer attaches a tracer to vm, pass nil to detach.
+func (vm *VM) SetTracer(t Tracer) {
+	vm.tracer = t
+}
+
+// StructLoggerConfig holds options of a StructLogger.
+type StructLoggerConfig struct {
+	DisableStack   bool `json:"disableStack"`
+	DisableStorage bool `json:"disableStorage"`
+	EnableMemory   bool `json:"enableMemory"`
+	// Limit is the max number of steps to record, 0 means no limit.
+	Limit int `json:"limit"`
+}
+
+// StructLog is a single opcode step recorded by a StructLogger. Stack,
+// memory and storage are captured before the opcode is executed, MemoryDelta
+// holds the words changed since the previous step.
+type StructLog struct {
+	Pc            uint64            `json:"pc"`
+	Op            string            `json:"op"`
+	Gas           uint64            `json:"gas"`
+	GasCost       uint64            `json:"gasCost"`
+	Depth         int               `json:"depth"`
+	Stack         []string          `json:"stack,omitempty"`
+	Memory        []string          `json:"memory,omitempty"`
+	MemorySize    int               `json:"memSize"`
+	MemoryDelta   map[string]string `json:"memoryDelta,omitempty"`
+	Storage       map[string]string `json:"storage,omitempty"`
+	StorageAccess []StorageAccess   `json:"storageAccess,omitempty"`
+	Error         string            `json:"error,omitempty"`
+}
+
+// StorageAccess is a storage read or write performed by a step.
+type StorageAccess struct {
+	Address types.Address `json:"address"`
+	Key     string        `json:"key"`
+	Value   string        `json:"value"`
+	Write   bool          `json:"write"`
+}
+
+// ExecutionResult is the trace result in struct-logger format.
+type ExecutionResult struct {
+	Address     types.Address `json:"address"`
+	Gas         uint64        `json:"gas"`
+	Failed      bool          `json:"failed"`
+	Error       string        `json:"error,omitempty"`
+	ReturnValue string        `json:"returnValue"`
+	StructLogs  []StructLog   `json:"structLogs"`
+}
+
+// StructLogger is a Tracer which records every opcode step, the stack,
+// memory changes, storage access and quota used.
+type StructLogger struct {
+	cfg StructLoggerConfig
+
+	addr       types.Address
+	quotaStart uint64
+	depth      int
+	logs       []StructLog
+	lastMemory []byte
+	storage    map[types.Address]map[string]string
+
+	output    []byte
+	quotaUsed uint64
+	err       error
+}
+
+// NewStructLogger returns a StructLogger, cfg may be nil.
+func NewStructLogger(cfg *StructLoggerConfig) *StructLogger {
+	l := &StructLogger{storage: make(map[types.Address]map[string]string)}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+// CaptureStart implements Tracer.
+func (l *StructLogger) CaptureStart(addr types.Address, input []byte, quota uint64) {
+	if l.depth == 0 {
+		l.addr = addr
+		l.quotaStart = quota
+		l.lastMemory = nil
+	}
+	l.depth++
+}
+
+// CaptureState implements Tracer.
+func (l *StructLogger) CaptureState(pc uint64, op string, quota, cost uint64, memory []byte, stack []*big.Int) {
+	if l.cfg.Limit > 0 && len(l.logs) >= l.cfg.Limit {
+		return
+	}
+	log := StructLog{
+		Pc:         pc,
+		Op:         op,
+		Gas:        quota,
+		GasCost:    cost,
+		Depth:      l.depth,
+		MemorySize: len(memory),
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = make([]string, len(stack))
+		for i, v := range stack {
+			log.Stack[i] = hex.EncodeToString(helper.LeftPadBytes(v.Bytes(), helper.WordSize))
+		}
+	}
+	if l.cfg.EnableMemory {
+		log.Memory = make([]string, 0, len(memory)/helper.WordSize)
+		for i := 0; i+helper.WordSize <= len(memory); i += helper.WordSize {
+			log.Memory = append(log.Memory, hex.EncodeToString(memory[i:i+helper.WordSize]))
+		}
+	}
+	log.MemoryDelta = memoryDelta(l.lastMemory, memory)
+	l.lastMemory = append(l.lastMemory[:0], memory...)
+	if !l.cfg.DisableStorage {
+		if s, ok := l.storage[l.addr]; ok && len(s) > 0 {
+			log.Storage = make(map[string]string, len(s))
+			for k, v := range s {
+				log.Storage[k] = v
+			}
+		}
+	}
+	l.logs = append(l.logs, log)
+}
+
+// CaptureStorage implements Tracer. The access is attached to the latest step.
+func (l *StructLogger) CaptureStorage(addr types.Address, key, value []byte, write bool) {
+	if l.cfg.DisableStorage {
+		return
+	}
+	k, v := hex.EncodeToString(key), hex.EncodeToString(helper.LeftPadBytes(value, helper.WordSize))
+	s, ok := l.storage[addr]
+	if !ok {
+		s = make(map[string]string)
+		l.storage[addr] = s
+	}
+	s[k] = v
+	if len(l.logs) > 0 {
+		last := &l.logs[len(l.logs)-1]
+		last.StorageAccess = append(last.StorageAccess, StorageAccess{Address: addr, Key: k, Value: v, Write: write})
+	}
+}
+
+// CaptureFault implements Tracer.
+func (l *StructLogger) CaptureFault(pc uint64, op string, err error) {
+	if len(l.logs) > 0 {
+		last := &l.logs[len(l.logs)-1]
+		if last.Pc == pc && last.Op == op {
+			last.Error = err.Error()
+			return
+		}
+	}
+	if l.cfg.Limit > 0 && len(l.logs) >= l.cfg.Limit {
+		return
+	}
+	l.logs = append(l.logs, StructLog{Pc: pc, Op: op, Depth: l.depth, Error: err.Error()})
+}
+
+// CaptureEnd implements Tracer.
+func (l *StructLogger) CaptureEnd(output []byte, quotaLeft uint64, err error) {
+	l.depth--
+	if l.depth > 0 {
+		return
+	}
+	l.output = output
+	l.err = err
+	if l.quotaStart > quotaLeft {
+		l.quotaUsed = l.quotaStart - quotaLeft
+	}
+}
+
+// StructLogs returns the recorded steps.
+func (l *StructLogger) StructLogs() []StructLog {
+	return l.logs
+}
+
+// Error returns the error of the outermost execution.
+func (l *StructLogger) Error() error {
+	return l.err
+}
+
+// Output returns the return data of the outermost execution.
+func (l *StructLogger) Output() []byte {
+	return l.output
+}
+
+// Result returns the trace in struct-logger format.
+func (l *StructLogger) Result() *ExecutionResult {
+	result := &ExecutionResult{
+		Address:     l.addr,
+		Gas:         l.quotaUsed,
+		Failed:      l.err != nil,
+		ReturnValue: hex.EncodeToString(l.output),
+		StructLogs:  l.logs,
+	}
+	if result.StructLogs == nil {
+		result.StructLogs = []StructLog{}
+	}
+	if l.err != nil {
+		result.Error = l.err.Error()
+	}
+	return result
+}
+
+// memoryDelta returns the words changed from prev to cur, keyed by offset.
+func memoryDelta(prev, cur []byte) map[string]string {
+	var delta map[string]string
+	for i := 0; i+helper.WordSize <= len(cur); i += helper.WordSize {
+		word := cur[i : i+helper.WordSize]
+		if i+helper.WordSize <= len(prev) && bytes.Equal(prev[i:i+helper.WordSize], word) {
+			continue
+		}
+		if i+helper.WordSize > len(prev) && isZeroWord(word) {
+			continue
+		}
+		if delta == nil {
+			delta = make(map[string]string)
+		}
+		delta[strconv.Itoa(i)] = hex.EncodeToString(word)
+	}
+	return delta
+}
+
+func isZeroWord(word []byte) bool {
+	for _, b := range word {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package vm
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+func TestStructLogger(t *testing.T) {
+	// sstore(0, 1+2); mstore(0, sload(0)); return(0, 32)
+	code := []byte{
+		byte(PUSH1), 1, byte(PUSH1), 2, byte(ADD), byte(PUSH1), 0, byte(SSTORE),
+		byte(PUSH1), 0, byte(SLOAD), byte(PUSH1), 0, byte(MSTORE),
+		byte(PUSH1), 32, byte(PUSH1), 0, byte(RETURN)}
+	addr := types.Address{1}
+	sbTime := time.Now()
+	db := newMemoryDatabase(addr, &ledger.SnapshotBlock{Height: 1, Timestamp: &sbTime})
+
+	vm := NewVM(nil)
+	vm.i = newInterpreter(1, false)
+	vm.gasTable = util.QuotaTableByHeight(1)
+	tracer := NewStructLogger(&StructLoggerConfig{EnableMemory: true})
+	vm.SetTracer(tracer)
+
+	sendBlock := &ledger.AccountBlock{
+		AccountAddress: types.Address{2},
+		ToAddress:      addr,
+		BlockType:      ledger.BlockTypeSendCall,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+	receiveBlock := &ledger.AccountBlock{AccountAddress: addr, BlockType: ledger.BlockTypeReceive}
+	c := newContract(receiveBlock, db, sendBlock, nil, 1000000)
+	c.setCallCode(addr, code)
+	ret, err := c.run(vm)
+	if err != nil {
+		t.Fatalf("run failed, %v", err)
+	}
+
+	result := tracer.Result()
+	if result.Failed || result.ReturnValue != "0000000000000000000000000000000000000000000000000000000000000003" {
+		t.Fatalf("unexpected result, failed %v, return %v", result.Failed, result.ReturnValue)
+	}
+	if result.Gas != 1000000-c.quotaLeft {
+		t.Fatalf("quota used not match, expected %v, got %v", 1000000-c.quotaLeft, result.Gas)
+	}
+	if len(result.StructLogs) != 12 {
+		t.Fatalf("step count not match, expected 12, got %v", len(result.StructLogs))
+	}
+	sstore, sload := result.StructLogs[4], result.StructLogs[6]
+	if sstore.Op != "SSTORE" || len(sstore.StorageAccess) != 1 || !sstore.StorageAccess[0].Write {
+		t.Fatalf("sstore not traced, %v", sstore)
+	}
+	if sload.Op != "SLOAD" || len(sload.StorageAccess) != 1 || sload.StorageAccess[0].Write ||
+		sload.StorageAccess[0].Value != sstore.StorageAccess[0].Value {
+		t.Fatalf("sload not traced, %v", sload)
+	}
+	// memory is captured before execution, so the MSTORE change shows up in the next step
+	afterMStore := result.StructLogs[9]
+	if result.StructLogs[8].Op != "MSTORE" || afterMStore.MemoryDelta["0"] != result.ReturnValue || len(afterMStore.Memory) != 1 {
+		t.Fatalf("memory not traced, %v", afterMStore)
+	}
+	if _, err := json.Marshal(result); err != nil {
+		t.Fatalf("marshal result failed, %v", err)
+	}
+	if len(ret) != 32 {
+		t.Fatalf("unexpected return data %v", ret)
+	}
+}
+
+func TestStructLoggerFault(t *testing.T) {
+	vm := NewVM(nil)
+	vm.i = newInterpreter(1, false)
+	vm.gasTable = util.QuotaTableByHeight(1)
+	tracer := NewStructLogger(nil)
+	vm.SetTracer(tracer)
+
+	code := []byte{byte(PUSH1), 32, byte(JUMP)}
+	c := newContract(&ledger.AccountBlock{BlockType: ledger.BlockTypeReceive}, newNoDatabase(), &ledger.AccountBlock{}, nil, 1000000)
+	c.setCallCode(types.Address{}, code)
+	if _, err := c.run(vm); err == nil {
+		t.Fatalf("expected jump error")
+	}
+	result := tracer.Result()
+	if !result.Failed || len(result.StructLogs) != 2 || result.StructLogs[1].Error == "" {
+		t.Fatalf("fault not traced, %v", result)
+	}
+}
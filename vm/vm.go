@@ -129,11 +129,11 @@ func initLog(dir, lvl string) {
 	path := filepath.Join(dir, "vmlog", time.Now().Format("2006-01-02T15-04"))
 	filename := filepath.Join(path, "vm.log")
 	nodeConfig.log.SetHandler(
-		log15.LvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.LogfmtFormat())),
+		log15.ModuleLvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(filename), log15.FileFormat())),
 	)
 	interpreterFileName := filepath.Join(path, "interpreter.log")
 	nodeConfig.interpreterLog.SetHandler(
-		log15.LvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(interpreterFileName), log15.LogfmtFormat())),
+		log15.ModuleLvlFilterHandler(logLevel, log15.StreamHandler(common.MakeDefaultLogger(interpreterFileName), log15.FileFormat())),
 	)
 }
 
@@ -159,6 +159,8 @@ type VM struct {
 	// latest snapshot block height, used for fork check
 	latestSnapshotHeight uint64
 	gasTable             *util.QuotaTable
+	// tracer records execution steps when set, used for debugging only
+	tracer Tracer
 }
 
 // NewVM is a constructor of VM. This method is called before running an
@@ -13,6 +13,7 @@ import (
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/wallet/entropystore"
 	"github.com/vitelabs/go-vite/wallet/hd-bip/derivation"
+	"github.com/vitelabs/go-vite/wallet/signer"
 	"github.com/vitelabs/go-vite/wallet/walleterrors"
 )
 
@@ -22,6 +23,7 @@ type Manager struct {
 	entropyStoreManager map[string]*entropystore.Manager // key is the entropyStore`s abs path
 	unlockChangedLis    map[int]func(event entropystore.UnlockEvent)
 	mutex               sync.Mutex
+	externalSigner      signer.Signer
 
 	log log15.Logger
 }
@@ -83,6 +85,31 @@ func (m Manager) GlobalCheckAddrUnlock(targetAdr types.Address) bool {
 	return err == nil
 }
 
+// SetExternalSigner sets the signer of the addresses which are not kept by local entropy files.
+func (m *Manager) SetExternalSigner(s signer.Signer) {
+	m.externalSigner = s
+}
+
+// HasAddress implements signer.Signer, the address is kept by an unlocked entropy file or the external signer.
+func (m Manager) HasAddress(addr types.Address) bool {
+	if m.GlobalCheckAddrUnlock(addr) {
+		return true
+	}
+	return m.externalSigner != nil && m.externalSigner.HasAddress(addr)
+}
+
+// SignData implements signer.Signer, unlocked entropy files are searched before the external signer.
+func (m Manager) SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	_, key, _, err := m.GlobalFindAddr(addr)
+	if err == nil {
+		return key.SignData(data)
+	}
+	if err == walleterrors.ErrAddressNotFound && m.externalSigner != nil {
+		return m.externalSigner.SignData(addr, data)
+	}
+	return nil, nil, err
+}
+
 func (m *Manager) RefreshCache() {
 	for filename, _ := range m.entropyStoreManager {
 		_, e := os.Stat(filename)
@@ -275,7 +302,7 @@ func (m *Manager) Stop() {
 	m.entropyStoreManager = nil
 }
 
-func (m Manager) AddLockEventListener(lis func(event entropystore.UnlockEvent)) int {
+func (m *Manager) AddLockEventListener(lis func(event entropystore.UnlockEvent)) int {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -284,7 +311,7 @@ func (m Manager) AddLockEventListener(lis func(event entropystore.UnlockEvent))
 	return m.unlockChangedIndex
 }
 
-func (m Manager) RemoveUnlockChangeChannel(id int) {
+func (m *Manager) RemoveUnlockChangeChannel(id int) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 	delete(m.unlockChangedLis, id)
@@ -0,0 +1,130 @@
+package remotesigner
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/rpc"
+)
+
+// The external signer serves the JSON-RPC methods below, over a local socket or http:
+//
+//	signer_accounts() []types.Address
+//	signer_signData(address types.Address, hexData string) SignDataResult
+const (
+	MethodAccounts = "signer_accounts"
+	MethodSignData = "signer_signData"
+)
+
+var (
+	callTimeout = 10 * time.Second
+	// accountsTTL is how long the accounts of the external signer are cached for HasAddress
+	accountsTTL = 30 * time.Second
+)
+
+type SignDataResult struct {
+	Signature string `json:"signature"` // hex
+	PublicKey string `json:"publicKey"` // hex
+}
+
+// Signer delegates signing to an external signer, the signature is verified before it's returned.
+type Signer struct {
+	endpoint string
+	client   *rpc.Client
+	log      log15.Logger
+
+	accountsMu sync.Mutex
+	accounts   map[types.Address]struct{}
+	refreshed  time.Time
+}
+
+// New dials the external signer at endpoint, which is the path of an ipc socket or a http url.
+func New(endpoint string) (*Signer, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial the external signer %s: %v", endpoint, err)
+	}
+	return NewWithClient(endpoint, client), nil
+}
+
+func NewWithClient(endpoint string, client *rpc.Client) *Signer {
+	return &Signer{
+		endpoint: endpoint,
+		client:   client,
+		log:      log15.New("module", "remote_signer", "endpoint", endpoint),
+	}
+}
+
+// Accounts returns the addresses kept by the external signer, and refreshes the cached accounts.
+func (s *Signer) Accounts() ([]types.Address, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	var addrs []types.Address
+	if err := s.client.CallContext(ctx, &addrs, MethodAccounts); err != nil {
+		return nil, err
+	}
+
+	accounts := make(map[types.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		accounts[addr] = struct{}{}
+	}
+	s.accountsMu.Lock()
+	s.accounts = accounts
+	s.refreshed = time.Now()
+	s.accountsMu.Unlock()
+	return addrs, nil
+}
+
+// HasAddress looks up the cached accounts, which are refreshed after accountsTTL. The stale accounts are
+// used if the external signer can't be reached.
+func (s *Signer) HasAddress(addr types.Address) bool {
+	s.accountsMu.Lock()
+	expired := time.Since(s.refreshed) > accountsTTL
+	s.accountsMu.Unlock()
+
+	if expired {
+		if _, err := s.Accounts(); err != nil {
+			s.log.Error("failed to get accounts", "err", err)
+		}
+	}
+
+	s.accountsMu.Lock()
+	defer s.accountsMu.Unlock()
+	_, ok := s.accounts[addr]
+	return ok
+}
+
+func (s *Signer) SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	result := &SignDataResult{}
+	if err = s.client.CallContext(ctx, result, MethodSignData, addr, hex.EncodeToString(data)); err != nil {
+		return nil, nil, err
+	}
+	if signedData, err = hex.DecodeString(result.Signature); err != nil {
+		return nil, nil, err
+	}
+	if pubkey, err = hex.DecodeString(result.PublicKey); err != nil {
+		return nil, nil, err
+	}
+	if len(pubkey) != ed25519.PublicKeySize || types.PubkeyToAddress(pubkey) != addr {
+		return nil, nil, fmt.Errorf("public key returned by the external signer doesn't match %v", addr)
+	}
+	if !ed25519.Verify(pubkey, data, signedData) {
+		return nil, nil, errors.New("signature returned by the external signer is invalid")
+	}
+	return signedData, pubkey, nil
+}
+
+func (s *Signer) Close() {
+	s.client.Close()
+}
@@ -0,0 +1,147 @@
+package remotesigner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/wallet/signer"
+)
+
+// MockSigner is a local external signer keeping keys in memory.
+type MockSigner struct {
+	keys          map[types.Address]ed25519.PrivateKey
+	wrong         bool // sign with a wrong key
+	accountsCalls int
+}
+
+func newMockSigner(t *testing.T, n int) *MockSigner {
+	m := &MockSigner{keys: make(map[types.Address]ed25519.PrivateKey)}
+	for i := 0; i < n; i++ {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.keys[types.PubkeyToAddress(priv.PubByte())] = priv
+	}
+	return m
+}
+
+func (m *MockSigner) Accounts() []types.Address {
+	m.accountsCalls++
+	var addrs []types.Address
+	for addr := range m.keys {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+func (m *MockSigner) SignData(addr types.Address, hexData string) (*SignDataResult, error) {
+	priv, ok := m.keys[addr]
+	if !ok {
+		return nil, errors.New("address not found")
+	}
+	data, err := hex.DecodeString(hexData)
+	if err != nil {
+		return nil, err
+	}
+	if m.wrong {
+		data = append(data, 0)
+	}
+	return &SignDataResult{
+		Signature: hex.EncodeToString(ed25519.Sign(priv, data)),
+		PublicKey: hex.EncodeToString(priv.PubByte()),
+	}, nil
+}
+
+func newTestRemoteSigner(t *testing.T, m *MockSigner) *Signer {
+	server := rpc.NewServer()
+	if err := server.RegisterName("signer", m); err != nil {
+		t.Fatal(err)
+	}
+	return NewWithClient("inproc", rpc.DialInProc(server))
+}
+
+func TestRemoteSigner(t *testing.T) {
+	m := newMockSigner(t, 2)
+	s := newTestRemoteSigner(t, m)
+	defer s.Close()
+
+	addrs, err := s.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 2 {
+		t.Fatalf("expect 2 accounts, got %d", len(addrs))
+	}
+
+	var _ signer.Signer = s
+	data := []byte("block hash")
+	for _, addr := range addrs {
+		if !s.HasAddress(addr) {
+			t.Fatalf("%v should be found", addr)
+		}
+		signedData, pubkey, err := s.SignData(addr, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if types.PubkeyToAddress(pubkey) != addr || !ed25519.Verify(pubkey, data, signedData) {
+			t.Fatal("invalid signature")
+		}
+	}
+
+	unknown := types.Address{1}
+	if s.HasAddress(unknown) {
+		t.Fatal("unknown address should not be found")
+	}
+	if _, _, err := s.SignData(unknown, data); err == nil {
+		t.Fatal("sign with unknown address should fail")
+	}
+
+	m.wrong = true
+	if _, _, err := s.SignData(addrs[0], data); err == nil {
+		t.Fatal("wrong signature should be rejected")
+	}
+}
+
+func TestRemoteSigner_AccountsCache(t *testing.T) {
+	m := newMockSigner(t, 1)
+	s := newTestRemoteSigner(t, m)
+	defer s.Close()
+
+	var addr types.Address
+	for a := range m.keys {
+		addr = a
+	}
+	for i := 0; i < 3; i++ {
+		if !s.HasAddress(addr) {
+			t.Fatalf("%v should be found", addr)
+		}
+	}
+	if m.accountsCalls != 1 {
+		t.Fatalf("accounts should be cached, called %d times", m.accountsCalls)
+	}
+
+	// a new account is found after the cache expires
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newAddr := types.PubkeyToAddress(priv.PubByte())
+	m.keys[newAddr] = priv
+	if s.HasAddress(newAddr) {
+		t.Fatalf("%v should not be found before the cache expires", newAddr)
+	}
+	s.refreshed = s.refreshed.Add(-accountsTTL - time.Second)
+	if !s.HasAddress(newAddr) {
+		t.Fatalf("%v should be found after the cache expires", newAddr)
+	}
+	if m.accountsCalls != 2 {
+		t.Fatalf("accounts should be refreshed once, called %d times", m.accountsCalls)
+	}
+}
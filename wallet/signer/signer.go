@@ -0,0 +1,14 @@
+package signer
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// Signer signs data with the key of an address. The keys may be kept in local entropy
+// files, or by an external process such as a hardware wallet or an HSM bridge.
+type Signer interface {
+	// HasAddress reports whether the data of the address can be signed now
+	HasAddress(addr types.Address) bool
+	// SignData returns the ed25519 signature of data and the public key of the address
+	SignData(addr types.Address, data []byte) (signedData, pubkey []byte, err error)
+}